@@ -0,0 +1,70 @@
+package cfgstore
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-dt/appinfo"
+)
+
+// AppDirTypes is implemented by an appinfo.AppInfo that also names the DirTypes
+// its app's config is layered from, in order of precedence, e.g. by embedding
+// appinfo.AppInfo:
+//
+//	type AppInfo struct {
+//		appinfo.AppInfo
+//	}
+//
+//	func (AppInfo) DirTypes() []cfgstore.DirType {
+//		return []cfgstore.DirType{cfgstore.AppConfigDirType, cfgstore.ProjectConfigDirType}
+//	}
+type AppDirTypes interface {
+	DirTypes() []DirType
+}
+
+// NewConfigStoresFromAppInfo returns the ConfigStores for the app described by
+// info, using its ConfigSlug and ConfigFile, its Version and AppSlug for the
+// {version} and {app} placeholders, see ConfigStoreArgs.AppInfo, and its
+// DirTypes if it is an AppDirTypes, so an app describes itself once. opts
+// configure each store as for NewConfigStore, e.g. WithCodec.
+func NewConfigStoresFromAppInfo(info appinfo.AppInfo, opts ...ConfigStoreOption) *ConfigStores {
+	args := ConfigStoresArgs{
+		DirTypes: appDirTypes(info),
+		ConfigStoreArgs: ConfigStoreArgs{
+			ConfigSlug:  info.ConfigSlug(),
+			RelFilepath: info.ConfigFile(),
+			AppInfo:     info,
+		},
+	}
+	for _, opt := range opts {
+		opt.applyToConfigStoreArgs(&args.ConfigStoreArgs)
+	}
+	return NewConfigStores(args)
+}
+
+// LoadConfigForApp loads and merges the config of the app described by info
+// from the stores NewConfigStoresFromAppInfo returns for info and storeOpts, as
+// LoadConfig does, passing opts to Normalize:
+//
+//	cfg, err := cfgstore.LoadConfigForApp[MyConfig](myapp.AppInfo, nil, cfgstore.WithCodec(yamlCodec{}))
+func LoadConfigForApp[RC any, PRC RootConfigPtr[RC]](info appinfo.AppInfo, opts Options, storeOpts ...ConfigStoreOption) (prc PRC, err error) {
+	return LoadConfigForAppContext[RC, PRC](context.Background(), info, opts, storeOpts...)
+}
+
+// LoadConfigForAppContext is LoadConfigForApp with a context, see
+// LoadConfigStoresContext.
+func LoadConfigForAppContext[RC any, PRC RootConfigPtr[RC]](ctx context.Context, info appinfo.AppInfo, opts Options, storeOpts ...ConfigStoreOption) (prc PRC, err error) {
+	stores := NewConfigStoresFromAppInfo(info, storeOpts...)
+	return LoadConfigStoresContext[RC, PRC](ctx, stores, RootConfigArgs{
+		DirTypes: stores.DirTypes,
+		Options:  opts,
+	})
+}
+
+// appDirTypes returns info's DirTypes if it is an AppDirTypes, and otherwise
+// nil for the defaults.
+func appDirTypes(info appinfo.AppInfo) (dirTypes []DirType) {
+	if adt, ok := info.(AppDirTypes); ok {
+		dirTypes = adt.DirTypes()
+	}
+	return dirTypes
+}
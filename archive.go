@@ -0,0 +1,341 @@
+package cfgstore
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json/jsontext"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// RedactedValue replaces the values ExportArchive redacts.
+const RedactedValue = "REDACTED"
+
+// DefaultMaxArchiveBytes is the default limit on the total size of the files
+// ImportArchive extracts.
+const DefaultMaxArchiveBytes = 64 << 20
+
+type ExportArchiveOptions struct {
+	// RedactKeys lists the object member names, matched case-insensitively,
+	// whose values are replaced with RedactedValue in the JSON files archived,
+	// e.g. "token" and "password". Files that are not valid JSON are archived
+	// unchanged.
+	RedactKeys []string
+}
+
+type ImportArchiveOptions struct {
+	// Overwrite replaces files that already exist rather than failing with
+	// ErrConfigAlreadyExists.
+	Overwrite bool
+
+	// MaxBytes limits the total size of the files extracted. Defaults to
+	// DefaultMaxArchiveBytes.
+	MaxBytes int64
+}
+
+// archiveFile is a file or directory read from an archive by ImportArchive.
+type archiveFile struct {
+	relFilepath dt.RelFilepath
+	mode        fs.FileMode
+	isDir       bool
+	data        []byte
+}
+
+// ExportArchive writes the files in the store's config directory, including its
+// subdirectories, to w as a tar.gz archive that ImportArchive can restore, e.g.
+// to back up a user's setup or move it to another machine. Lock and temporary
+// files are not archived. A config directory that does not exist yet produces
+// an empty archive.
+func ExportArchive(store ConfigStore, w io.Writer, opts ...ExportArchiveOptions) (err error) {
+	var dir dt.DirPath
+
+	if len(opts) == 0 {
+		opts = []ExportArchiveOptions{{}}
+	}
+	fSys := store.FileSystem()
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	dir, err = store.ConfigDir()
+	if err != nil {
+		goto end
+	}
+	err = fs.WalkDir(fSys.DirFS(dir), ".", func(p string, d fs.DirEntry, err error) error {
+		var info fs.FileInfo
+		var data []byte
+
+		if p == "." {
+			if errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if err != nil {
+			goto end
+		}
+		if !d.IsDir() && isSidecarFile(p) {
+			goto end
+		}
+		info, err = d.Info()
+		if err != nil {
+			goto end
+		}
+		if d.IsDir() {
+			err = tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     p + "/",
+				Mode:     int64(info.Mode().Perm()),
+				ModTime:  info.ModTime(),
+			})
+			goto end
+		}
+		if !info.Mode().IsRegular() {
+			goto end
+		}
+		data, err = fSys.ReadFile(dt.FilepathJoin(dir, p))
+		if err != nil {
+			goto end
+		}
+		data = redactDocument(data, opts[0].RedactKeys)
+		err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     p,
+			Mode:     int64(info.Mode().Perm()),
+			Size:     int64(len(data)),
+			ModTime:  info.ModTime(),
+		})
+		if err != nil {
+			goto end
+		}
+		_, err = tw.Write(data)
+	end:
+		return err
+	})
+	err = CombineErrs([]error{err, tw.Close(), gw.Close()})
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToExportArchive, "config_dir", dir, err))
+	}
+	return err
+}
+
+// ImportArchive extracts a tar.gz archive written by ExportArchive into the
+// store's config directory and returns the files written. Every entry is read
+// and checked before anything is written: entries that are not regular files or
+// directories, or whose paths are absolute or lead outside the config
+// directory, or that are lock or temporary files, fail with
+// ErrUnsafeArchiveEntry, and archives larger than opts.MaxBytes fail with
+// ErrArchiveTooLarge. Files keep their archived permissions, less group and
+// other write access, if the store's FileSystem is a FileModeWriter, except the
+// store's own file, which is saved with the store's Save while holding its lock
+// so concurrent updates and save hooks see it like any other save.
+func ImportArchive(store ConfigStore, r io.Reader, opts ...ImportArchiveOptions) (files []dt.RelFilepath, err error) {
+	var dir dt.DirPath
+	var storeFP dt.Filepath
+	var entries []archiveFile
+	var errs []error
+
+	if len(opts) == 0 {
+		opts = []ImportArchiveOptions{{}}
+	}
+	if opts[0].MaxBytes <= 0 {
+		opts[0].MaxBytes = DefaultMaxArchiveBytes
+	}
+	fSys := store.FileSystem()
+	dir, err = store.ConfigDir()
+	if err != nil {
+		goto end
+	}
+	storeFP, err = store.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	entries, err = readArchive(r, opts[0].MaxBytes)
+	if err != nil {
+		goto end
+	}
+	if !opts[0].Overwrite {
+		for _, entry := range entries {
+			fp := dt.FilepathJoin(dir, entry.relFilepath)
+			_, statErr := fSys.Stat(fp)
+			if !entry.isDir && statErr == nil {
+				errs = append(errs, NewErr(ErrConfigAlreadyExists, "filepath", fp))
+			}
+		}
+		err = CombineErrs(errs)
+		if err != nil {
+			goto end
+		}
+	}
+	err = fSys.MkdirAll(dir)
+	if err != nil {
+		goto end
+	}
+	for _, entry := range entries {
+		fp := dt.FilepathJoin(dir, entry.relFilepath)
+		if entry.isDir {
+			err = fSys.MkdirAll(dt.DirPath(fp))
+			if err != nil {
+				goto end
+			}
+			continue
+		}
+		if fp == storeFP {
+			err = saveLocked(store, fp, entry.data)
+			if err != nil {
+				goto end
+			}
+			files = append(files, entry.relFilepath)
+			continue
+		}
+		err = fSys.MkdirAll(fp.Dir())
+		if err != nil {
+			goto end
+		}
+		err = WriteFileMode(fSys, fp, entry.data, entry.mode)
+		if err != nil {
+			goto end
+		}
+		files = append(files, entry.relFilepath)
+	}
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToImportArchive, "config_dir", dir, err))
+	}
+	return files, err
+}
+
+// saveLocked saves data to the store's own file, fp, while holding its lock.
+func saveLocked(store ConfigStore, fp dt.Filepath, data []byte) (err error) {
+	var unlock func()
+
+	unlock, err = store.FileSystem().Lock(fp, DefaultLockTimeout)
+	if err != nil {
+		goto end
+	}
+	defer unlock()
+	err = store.Save(data)
+end:
+	return err
+}
+
+// readArchive reads every entry of the tar.gz archive in r, failing on unsafe
+// entries, including lock and temporary files, and once more than maxBytes of
+// file content has been read.
+func readArchive(r io.Reader, maxBytes int64) (entries []archiveFile, err error) {
+	var gr *gzip.Reader
+	var hdr *tar.Header
+	var data []byte
+
+	gr, err = gzip.NewReader(r)
+	if err != nil {
+		goto end
+	}
+	defer func() { LogOnError(gr.Close()) }()
+	for tr := tar.NewReader(gr); ; {
+		hdr, err = tr.Next()
+		if errors.Is(err, io.EOF) {
+			err = nil
+			break
+		}
+		if err != nil {
+			goto end
+		}
+		name := strings.TrimSuffix(hdr.Name, "/")
+		if !isLocalArchivePath(name) {
+			err = NewErr(ErrUnsafeArchiveEntry, "name", hdr.Name)
+			goto end
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			entries = append(entries, archiveFile{
+				relFilepath: dt.RelFilepath(filepath.FromSlash(name)),
+				isDir:       true,
+			})
+			continue
+		case tar.TypeReg:
+			if isSidecarFile(name) {
+				err = NewErr(ErrUnsafeArchiveEntry, "name", hdr.Name)
+				goto end
+			}
+		default:
+			err = NewErr(ErrUnsafeArchiveEntry, "name", hdr.Name, "type", string(hdr.Typeflag))
+			goto end
+		}
+		if hdr.Size > maxBytes {
+			err = NewErr(ErrArchiveTooLarge, "max_bytes", maxBytes)
+			goto end
+		}
+		data, err = io.ReadAll(io.LimitReader(tr, hdr.Size))
+		if err != nil {
+			goto end
+		}
+		maxBytes -= int64(len(data))
+		entries = append(entries, archiveFile{
+			relFilepath: dt.RelFilepath(filepath.FromSlash(name)),
+			mode:        fs.FileMode(hdr.Mode).Perm() &^ 0o022,
+			data:        data,
+		})
+	}
+end:
+	return entries, err
+}
+
+// isLocalArchivePath reports whether name, a slash-separated path from an
+// archive, stays within the directory it is extracted to on every platform.
+func isLocalArchivePath(name string) bool {
+	switch {
+	case name == "" || name == ".":
+		return false
+	case !fs.ValidPath(name), strings.Contains(name, `\`):
+		return false
+	}
+	return filepath.IsLocal(filepath.FromSlash(name))
+}
+
+// redactDocument returns data, if it is a JSON document, with the values of the
+// members named in keys replaced with RedactedValue.
+func redactDocument(data []byte, keys []string) []byte {
+	var node any
+	var redacted []byte
+	var err error
+
+	if len(keys) == 0 {
+		goto end
+	}
+	node, err = parseDocument(data)
+	if err != nil || node == nil {
+		goto end
+	}
+	redactNode(node, keys)
+	redacted, err = encodeDocument(node)
+	if err != nil {
+		goto end
+	}
+	data = append(redacted, '\n')
+end:
+	return data
+}
+
+func redactNode(node any, keys []string) {
+	switch n := node.(type) {
+	case *jsonObject:
+		for i, m := range n.members {
+			isKey := func(key string) bool { return strings.EqualFold(key, m.name) }
+			if slices.ContainsFunc(keys, isKey) && !isNullNode(m.value) {
+				n.members[i].value = jsontext.Value(`"` + RedactedValue + `"`)
+				continue
+			}
+			redactNode(m.value, keys)
+		}
+	case *jsonArray:
+		for _, item := range n.items {
+			redactNode(item, keys)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package cfgstore
+
+import (
+	"slices"
+)
+
+// AppendValue marshals value as JSON and appends it to the array at path in the
+// store's file, creating the array, and the file, if they do not exist.
+func AppendValue(store ConfigStore, path string, value any) (err error) {
+	var node any
+
+	node, err = valueToNode(value)
+	if err != nil {
+		goto end
+	}
+	err = updateArray(store, path, func(arr *jsonArray) error {
+		arr.items = append(arr.items, node)
+		return nil
+	})
+end:
+	if err != nil {
+		err = WithErr(err, "path", path)
+	}
+	return err
+}
+
+// InsertValueAt marshals value as JSON and inserts it into the array at path
+// before the element at index, which may equal the array's length to append.
+func InsertValueAt(store ConfigStore, path string, index int, value any) (err error) {
+	var node any
+
+	node, err = valueToNode(value)
+	if err != nil {
+		goto end
+	}
+	err = updateArray(store, path, func(arr *jsonArray) (err error) {
+		if index < 0 || index > len(arr.items) {
+			err = NewErr(ErrArrayIndexOutOfRange, "index", index, "length", len(arr.items))
+			goto end
+		}
+		arr.items = slices.Insert(arr.items, index, node)
+	end:
+		return err
+	})
+end:
+	if err != nil {
+		err = WithErr(err, "path", path)
+	}
+	return err
+}
+
+// RemoveValueAt removes the element at index from the array at path in the
+// store's file, shifting the elements after it.
+func RemoveValueAt(store ConfigStore, path string, index int) (err error) {
+	err = updateArray(store, path, func(arr *jsonArray) (err error) {
+		if index < 0 || index >= len(arr.items) {
+			err = NewErr(ErrArrayIndexOutOfRange, "index", index, "length", len(arr.items))
+			goto end
+		}
+		arr.items = slices.Delete(arr.items, index, index+1)
+	end:
+		return err
+	})
+	if err != nil {
+		err = WithErr(err, "path", path)
+	}
+	return err
+}
+
+// updateArray loads the store's file, calls fn with the array at path, creating
+// it if it does not exist, and saves the file if fn succeeds, under the file's
+// lock. See updateDocument.
+func updateArray(store ConfigStore, path string, fn func(arr *jsonArray) error) (err error) {
+	var segments []string
+
+	segments, err = splitValuePath(path)
+	if err != nil {
+		goto end
+	}
+	err = updateDocument(store, func(doc any) (_ any, _ bool, err error) {
+		var arr *jsonArray
+
+		node, ok := lookupNode(doc, segments)
+		if !ok {
+			arr = &jsonArray{}
+			doc, err = setNode(doc, segments, arr)
+			if err != nil {
+				goto end
+			}
+		} else {
+			arr, ok = node.(*jsonArray)
+			if !ok {
+				err = NewErr(ErrValueTypeMismatch, "expected", "array")
+				goto end
+			}
+		}
+		err = fn(arr)
+	end:
+		return doc, err == nil, err
+	})
+end:
+	return err
+}
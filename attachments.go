@@ -0,0 +1,168 @@
+package cfgstore
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// DefaultMaxFileBytes is the default limit on the size of the files SaveFile
+// writes and LoadFile reads.
+const DefaultMaxFileBytes = 16 << 20
+
+type FileOptions struct {
+	// MaxBytes limits the size of the file. Defaults to DefaultMaxFileBytes.
+	MaxBytes int64
+
+	// Mode, if set, is the permissions SaveFile leaves the file with, e.g.
+	// 0600 for a private key, if the store's FileSystem is a FileModeWriter.
+	// Otherwise new files are created 0644 and replaced files keep theirs.
+	Mode fs.FileMode
+}
+
+// SaveFile writes data to the file at rel in the store's config dir, e.g. a
+// certificate or downloaded asset the config refers to, creating its
+// directories, and emits a SaveEventKind Event. The file is replaced
+// atomically, as the store's file is by Save, but without hooks or a Codec. rel
+// must name a file within the config dir other than the store's own file, or
+// SaveFile fails with ErrInvalidAttachmentPath, and data larger than
+// opts.MaxBytes fails with ErrAttachmentTooLarge.
+func SaveFile(store ConfigStore, rel dt.RelFilepath, data []byte, opts ...FileOptions) (err error) {
+	var fp dt.Filepath
+
+	if len(opts) == 0 {
+		opts = []FileOptions{{}}
+	}
+	fp, err = attachmentFilepath(store, rel)
+	if err != nil {
+		goto end
+	}
+	if int64(len(data)) > maxFileBytes(opts[0]) {
+		err = NewErr(ErrAttachmentTooLarge, "max_bytes", maxFileBytes(opts[0]), "size", len(data))
+		goto end
+	}
+	err = store.FileSystem().MkdirAll(fp.Dir())
+	if err != nil {
+		goto end
+	}
+	if opts[0].Mode != 0 {
+		err = WriteFileMode(store.FileSystem(), fp, data, opts[0].Mode)
+		goto end
+	}
+	err = store.FileSystem().WriteFile(fp, data)
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToSaveFile, "filepath", fp, err))
+	}
+	emitFileEvent(store, SaveEventKind, fp, err)
+	return err
+}
+
+// LoadFile returns the content of the file at rel in the store's config dir,
+// see SaveFile. A file that does not exist fails with an error matching
+// ErrFileDoesNotExist and fs.ErrNotExist, and one larger than opts.MaxBytes
+// with ErrAttachmentTooLarge.
+func LoadFile(store ConfigStore, rel dt.RelFilepath, opts ...FileOptions) (data []byte, err error) {
+	var fp dt.Filepath
+	var info fs.FileInfo
+
+	if len(opts) == 0 {
+		opts = []FileOptions{{}}
+	}
+	fp, err = attachmentFilepath(store, rel)
+	if err != nil {
+		goto end
+	}
+	info, err = store.FileSystem().Stat(fp)
+	if err == nil && info.Size() > maxFileBytes(opts[0]) {
+		err = NewErr(ErrAttachmentTooLarge, "max_bytes", maxFileBytes(opts[0]), "size", info.Size())
+		goto end
+	}
+	if err == nil {
+		data, err = store.FileSystem().ReadFile(fp)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		err = NewErr(ErrFileDoesNotExist, err)
+	}
+	if err != nil {
+		goto end
+	}
+	// The file may have grown since it was stat'd
+	if int64(len(data)) > maxFileBytes(opts[0]) {
+		data = nil
+		err = NewErr(ErrAttachmentTooLarge, "max_bytes", maxFileBytes(opts[0]))
+	}
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToLoadFile, "filepath", fp, err))
+	}
+	return data, err
+}
+
+// DeleteFile removes the file at rel in the store's config dir, see SaveFile,
+// and emits a DeleteEventKind Event. A file that does not exist is not an error.
+func DeleteFile(store ConfigStore, rel dt.RelFilepath) (err error) {
+	var fp dt.Filepath
+
+	fp, err = attachmentFilepath(store, rel)
+	if err != nil {
+		goto end
+	}
+	err = store.FileSystem().Remove(fp)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToDeleteFile, "filepath", fp, err))
+	}
+	emitFileEvent(store, DeleteEventKind, fp, err)
+	return err
+}
+
+// emitFileEvent emits an Event of kind for the file at fp in the store's config
+// dir, as Purge does for the dir, unless fp was not resolved.
+func emitFileEvent(store ConfigStore, kind EventKind, fp dt.Filepath, err error) {
+	if !hasEventListeners() || fp == "" {
+		return
+	}
+	emitEvent(Event{
+		Kind:     kind,
+		Store:    store,
+		DirType:  store.DirType(),
+		Filepath: fp,
+		Err:      err,
+	})
+}
+
+// attachmentFilepath returns the path of rel in the store's config dir, failing
+// with ErrInvalidAttachmentPath if rel leads outside it or names the store's
+// own file or a lock or temporary file.
+func attachmentFilepath(store ConfigStore, rel dt.RelFilepath) (fp dt.Filepath, err error) {
+	var dir dt.DirPath
+
+	if !isLocalRelFilepath(string(rel)) || isSidecarFile(filepath.ToSlash(string(rel))) {
+		err = NewErr(ErrInvalidAttachmentPath, "rel_filepath", rel)
+		goto end
+	}
+	if filepath.Clean(string(rel)) == filepath.Clean(string(store.GetRelFilepath())) {
+		err = NewErr(ErrInvalidAttachmentPath, "rel_filepath", rel, "reason", "is the store's file")
+		goto end
+	}
+	dir, err = store.ConfigDir()
+	if err != nil {
+		goto end
+	}
+	fp = dt.FilepathJoin(dir, rel)
+end:
+	return fp, err
+}
+
+func maxFileBytes(opts FileOptions) int64 {
+	if opts.MaxBytes <= 0 {
+		return DefaultMaxFileBytes
+	}
+	return opts.MaxBytes
+}
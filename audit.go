@@ -0,0 +1,324 @@
+package cfgstore
+
+import (
+	"bytes"
+	"encoding/json/v2"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// DefaultAuditLogFile is the name of the audit log NewAuditLog writes to in the
+// logs directory of the state dir when AuditLogArgs.Filepath is empty.
+const DefaultAuditLogFile dt.Filename = "audit.jsonl"
+
+// AuditAction is the kind of change an AuditRecord records.
+type AuditAction string
+
+const (
+	CreateAuditAction AuditAction = "create" // A store's file was written for the first time
+	SaveAuditAction   AuditAction = "save"   // An existing file was overwritten
+	DeleteAuditAction AuditAction = "delete" // A store's file or directory was removed
+)
+
+// AuditChangeOp is how the value at an AuditChange's Path changed.
+type AuditChangeOp string
+
+const (
+	AddedAuditChangeOp   AuditChangeOp = "added"
+	ChangedAuditChangeOp AuditChangeOp = "changed"
+	RemovedAuditChangeOp AuditChangeOp = "removed"
+)
+
+// AuditChange records that the value at a dotted path was added, changed or
+// removed. Values are not recorded so secrets do not leak into the audit log.
+type AuditChange struct {
+	Path string        `json:"path"`
+	Op   AuditChangeOp `json:"op"`
+}
+
+// AuditRecord is one line of an audit log.
+type AuditRecord struct {
+	Time     time.Time   `json:"time"`
+	User     string      `json:"user"`
+	Action   AuditAction `json:"action"`
+	DirType  string      `json:"dir_type"`
+	Filepath dt.Filepath `json:"filepath"`
+
+	// Changes lists the scalars, empty objects and empty arrays whose values
+	// differ, in document order. It is empty if the file is not JSON.
+	Changes []AuditChange `json:"changes,omitempty"`
+}
+
+type AuditLogArgs struct {
+	// Filepath is the JSONL file records are appended to. Defaults to
+	// logs/audit.jsonl in the state dir for ConfigSlug, see StateDir.
+	Filepath dt.Filepath
+
+	// ConfigSlug locates the default Filepath.
+	ConfigSlug dt.PathSegment
+
+	// DirsProvider locates the default Filepath. Defaults to
+	// DefaultDirsProvider().
+	DirsProvider *DirsProvider
+
+	// User is recorded as who made each change. Defaults to the current OS
+	// user's username.
+	User string
+}
+
+// AuditLog appends an AuditRecord to a JSONL file for every create, save and
+// delete of the stores it is attached to, for compliance-minded deployments:
+//
+//	audit, err := cfgstore.NewAuditLog(cfgstore.AuditLogArgs{ConfigSlug: "myapp"})
+//	err = audit.Attach(store)
+//	defer cfgstore.AddEventListener(audit.HandleEvent)()
+//
+// Attach records saves of the store's file; HandleEvent records deletes, and
+// saves of the other files in its config dir, reported as Events.
+type AuditLog struct {
+	filepath dt.Filepath
+	user     string
+	mutex    sync.Mutex
+}
+
+// NewAuditLog returns an AuditLog that appends to args.Filepath.
+func NewAuditLog(args AuditLogArgs) (al *AuditLog, err error) {
+	var dir dt.DirPath
+
+	fp := args.Filepath
+	if fp == "" {
+		dir, err = StateDir(args.ConfigSlug, args.DirsProvider)
+		if err != nil {
+			err = NewErr(ErrFailedToOpenAuditLog, err)
+			goto end
+		}
+		fp = dt.FilepathJoin3(dir, "logs", DefaultAuditLogFile)
+	}
+	al = &AuditLog{
+		filepath: fp,
+		user:     args.User,
+	}
+	if al.user == "" {
+		al.user = currentUsername()
+	}
+end:
+	return al, err
+}
+
+// Filepath returns the path of the audit log file.
+func (al *AuditLog) Filepath() dt.Filepath {
+	return al.filepath
+}
+
+// Attach registers hooks on cs that record each successful save, as a create if
+// the file did not exist before. The content before the save is passed to the
+// post-save hook in SaveHookArgs.State. A record that cannot be written is
+// logged rather than failing the save, which has already happened. Attach
+// fails with ErrHooksNotSupported if cs is not a HookRegistrar.
+func (al *AuditLog) Attach(cs ConfigStore) (err error) {
+	hr, ok := cs.(HookRegistrar)
+	if !ok {
+		return NewErr(ErrHooksNotSupported, "config_slug", cs.ConfigSlug())
+	}
+	hr.AddPreSaveHook(func(args *SaveHookArgs) error {
+		data, err := args.Store.FileSystem().ReadFile(args.Filepath)
+		if err != nil {
+			data = nil
+		}
+		if args.State == nil {
+			args.State = make(map[any]any)
+		}
+		args.State[al] = data
+		return nil
+	})
+	hr.AddPostSaveHook(func(args *SaveHookArgs) error {
+		before, _ := args.State[al].([]byte)
+		action := SaveAuditAction
+		if before == nil {
+			action = CreateAuditAction
+		}
+		LogOnError(al.Write(AuditRecord{
+			Action:   action,
+			DirType:  args.Store.DirType().Slug(),
+			Filepath: args.Filepath,
+			Changes:  AuditChanges(before, args.Data),
+		}))
+		return nil
+	})
+	return nil
+}
+
+// HandleEvent is an EventListener that records successful DeleteEventKind
+// Events, and SaveEventKind Events for files in a store's config dir other than
+// its own, e.g. those written by SaveFile. Saves of a store's own file are
+// recorded by Attach. Pass it to AddEventListener.
+func (al *AuditLog) HandleEvent(ev Event) {
+	var action AuditAction
+
+	if ev.Err != nil {
+		return
+	}
+	switch {
+	case ev.Kind == DeleteEventKind:
+		action = DeleteAuditAction
+	case ev.Kind == SaveEventKind && isAttachmentEvent(ev):
+		action = SaveAuditAction
+	default:
+		return
+	}
+	LogOnError(al.Write(AuditRecord{
+		Time:     ev.Time,
+		Action:   action,
+		DirType:  ev.DirType.Slug(),
+		Filepath: ev.Filepath,
+	}))
+}
+
+// isAttachmentEvent reports whether ev is for a file other than its store's.
+func isAttachmentEvent(ev Event) bool {
+	if ev.Store == nil {
+		return false
+	}
+	fp, err := ev.Store.GetFilepath()
+	return err == nil && fp != ev.Filepath
+}
+
+// Write appends record to the audit log, filling in Time and User if they are
+// empty.
+func (al *AuditLog) Write(record AuditRecord) (err error) {
+	var data []byte
+	var f *os.File
+
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	if record.User == "" {
+		record.User = al.user
+	}
+	data, err = json.Marshal(record)
+	if err != nil {
+		goto end
+	}
+	data = append(data, '\n')
+
+	al.mutex.Lock()
+	defer al.mutex.Unlock()
+	err = al.filepath.Dir().MkdirAll(0755)
+	if err != nil {
+		goto end
+	}
+	f, err = os.OpenFile(string(al.filepath), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		goto end
+	}
+	_, err = f.Write(data)
+	err = CombineErrs([]error{err, f.Close()})
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToWriteAuditLog, "audit_log", al.filepath, err)
+	}
+	return err
+}
+
+// AuditChanges returns the key-level differences between the JSON documents
+// before and after. Either may be nil for a file that does not exist. Content
+// that is not JSON has no changes.
+func AuditChanges(before, after []byte) (changes []AuditChange) {
+	var beforeLeaves, afterLeaves []auditLeaf
+	var beforeIndex, afterIndex map[string]int
+	var err error
+
+	beforeLeaves, err = documentLeaves(before)
+	if err != nil {
+		goto end
+	}
+	afterLeaves, err = documentLeaves(after)
+	if err != nil {
+		goto end
+	}
+	beforeIndex = leafIndex(beforeLeaves)
+	afterIndex = leafIndex(afterLeaves)
+	for _, a := range afterLeaves {
+		i, found := beforeIndex[a.path]
+		switch {
+		case !found:
+			changes = append(changes, AuditChange{Path: a.path, Op: AddedAuditChangeOp})
+		case !bytes.Equal(a.value, beforeLeaves[i].value):
+			changes = append(changes, AuditChange{Path: a.path, Op: ChangedAuditChangeOp})
+		}
+	}
+	for _, b := range beforeLeaves {
+		_, found := afterIndex[b.path]
+		if !found {
+			changes = append(changes, AuditChange{Path: b.path, Op: RemovedAuditChangeOp})
+		}
+	}
+end:
+	return changes
+}
+
+type auditLeaf struct {
+	path  string
+	value []byte
+}
+
+// documentLeaves returns the dotted path and encoded value of every scalar,
+// empty object and empty array in data, in document order.
+func documentLeaves(data []byte) (leaves []auditLeaf, err error) {
+	var doc any
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		goto end
+	}
+	doc, err = parseDocument(data)
+	if err != nil {
+		goto end
+	}
+	err = walkNode(doc, nil, func(segments []string, node any) (err error) {
+		var value []byte
+
+		if !isScalarNode(node) && !isEmptyNode(node) {
+			goto end
+		}
+		value, err = encodeDocument(node)
+		if err != nil {
+			goto end
+		}
+		leaves = append(leaves, auditLeaf{
+			path:  strings.Join(segments, "."),
+			value: value,
+		})
+	end:
+		return err
+	})
+end:
+	return leaves, err
+}
+
+// leafIndex maps the path of each of leaves to its index.
+func leafIndex(leaves []auditLeaf) map[string]int {
+	index := make(map[string]int, len(leaves))
+	for i, leaf := range leaves {
+		index[leaf.path] = i
+	}
+	return index
+}
+
+func currentUsername() (username string) {
+	u, err := user.Current()
+	if err == nil {
+		username = u.Username
+	}
+	if username == "" {
+		username = os.Getenv("USER")
+	}
+	if username == "" {
+		username = os.Getenv("USERNAME")
+	}
+	return username
+}
@@ -6,9 +6,17 @@ import (
 	"github.com/mikeschinkel/go-dt"
 )
 
+// DefaultProjectCacheSubdir is the directory within the project config dir that
+// GetProjectCacheDir returns by default.
+const DefaultProjectCacheSubdir dt.PathSegment = "cache"
+
 // CacheOptions provides optional configuration for cache directory functions
 type CacheOptions struct {
 	DirsProvider *DirsProvider
+
+	// ProjectCacheSubdir is the directory within the project config dir that
+	// GetProjectCacheDir returns. Defaults to DefaultProjectCacheSubdir.
+	ProjectCacheSubdir dt.PathSegment
 }
 
 // GetSharedCacheDir returns the shared cache directory for the given slug.
@@ -33,18 +41,40 @@ func GetAppCacheDir(slug, appName dt.PathSegment, opts ...CacheOptions) (dt.DirP
 	return getCacheDir(slug, appName, opts...)
 }
 
+// GetProjectCacheDir returns a per-project cache directory within the project
+// config dir, for build-tool style caches that belong to a single project:
+//   - All platforms: <projectDir>/.{slug}/cache/
+//
+// Example: GetProjectCacheDir("xmlui") → ./.xmlui/cache/ when run in the project
+// root. Set CacheOptions.ProjectCacheSubdir to use a directory other than cache.
+func GetProjectCacheDir(slug dt.PathSegment, opts ...CacheOptions) (dir dt.DirPath, err error) {
+	var dp *DirsProvider
+
+	if len(opts) == 0 {
+		opts = []CacheOptions{{}}
+	}
+	dp = opts[0].DirsProvider
+	subdir := opts[0].ProjectCacheSubdir
+	if subdir == "" {
+		subdir = DefaultProjectCacheSubdir
+	}
+	dir, err = ProjectConfigDir(slug, dp)
+	if err != nil {
+		goto end
+	}
+	dir = dt.DirPathJoin(dir, subdir)
+end:
+	return dir, err
+}
+
 // getCacheDir is the internal implementation for cache directory resolution
 func getCacheDir(slug, appName dt.PathSegment, opts ...CacheOptions) (dt.DirPath, error) {
-	var dp *DirsProvider
-	if len(opts) > 0 && opts[0].DirsProvider != nil {
-		dp = opts[0].DirsProvider
-	} else {
-		dp = &DirsProvider{
-			UserCacheDirFunc: dt.UserCacheDir,
-		}
+	cacheDirFunc := DirFunc(dt.UserCacheDir)
+	if len(opts) > 0 && opts[0].DirsProvider != nil && opts[0].DirsProvider.UserCacheDirFunc != nil {
+		cacheDirFunc = opts[0].DirsProvider.UserCacheDirFunc
 	}
 
-	cacheDir, err := dp.UserCacheDirFunc()
+	cacheDir, err := cacheDirFunc()
 	if err != nil {
 		return "", NewErr(ErrFailedGettingUserCacheDir, err)
 	}
@@ -0,0 +1,164 @@
+package cfgstore
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// PruneReason is why PruneCache removed a file.
+type PruneReason int
+
+const (
+	UnspecifiedPruneReason PruneReason = iota
+	ExpiredPruneReason                 // The file was older than maxAge
+	OversizePruneReason                // The cache exceeded maxBytes
+)
+
+func (r PruneReason) Slug() string {
+	switch r {
+	case ExpiredPruneReason:
+		return "expired"
+	case OversizePruneReason:
+		return "oversize"
+	case UnspecifiedPruneReason:
+		return "unspecified"
+	default:
+	}
+	return "invalid"
+}
+
+// PrunedFile is a file PruneCache removed.
+type PrunedFile struct {
+	Filepath dt.Filepath
+	Size     int64
+	ModTime  time.Time
+	Reason   PruneReason
+}
+
+// PruneResult reports what PruneCache removed.
+type PruneResult struct {
+	// Removed lists the files removed, expired files first, then the least
+	// recently modified.
+	Removed []PrunedFile
+
+	// RemovedBytes is the total size of Removed.
+	RemovedBytes int64
+
+	// RemainingBytes is the total size of the files left in the cache dir.
+	RemainingBytes int64
+}
+
+// PruneCache evicts files from the shared cache dir for slug, see
+// GetSharedCacheDir, including those in app-specific cache dirs below it. Files
+// last modified more than maxAge ago are removed first, then the least recently
+// modified files until the remaining files total no more than maxBytes. A zero
+// maxAge or maxBytes disables that limit. Directories left empty are removed.
+// The lock files of CacheStore.Lock and files being saved are neither counted
+// nor removed.
+//
+// Files are ordered by modification time rather than access time because many
+// file systems are mounted with access times disabled, so a CacheStore that
+// should count as recently used must be saved again.
+func PruneCache(slug dt.PathSegment, maxAge time.Duration, maxBytes int64, opts ...CacheOptions) (result PruneResult, err error) {
+	var dir dt.DirPath
+	var files []PrunedFile
+	var dirs []string
+	var errs []error
+
+	fSys := cacheFileSystem(opts)
+	dir, err = GetSharedCacheDir(slug, opts...)
+	if err != nil {
+		goto end
+	}
+	err = fs.WalkDir(fSys.DirFS(dir), ".", func(p string, d fs.DirEntry, err error) error {
+		var info fs.FileInfo
+
+		if p == "." && errors.Is(err, fs.ErrNotExist) {
+			return fs.SkipAll
+		}
+		if err != nil {
+			goto end
+		}
+		if d.IsDir() {
+			if p != "." {
+				dirs = append(dirs, p)
+			}
+			goto end
+		}
+		if isSidecarFile(p) {
+			goto end
+		}
+		info, err = d.Info()
+		if err != nil {
+			goto end
+		}
+		files = append(files, PrunedFile{
+			Filepath: dt.FilepathJoin(dir, p),
+			Size:     info.Size(),
+			ModTime:  info.ModTime(),
+		})
+	end:
+		return err
+	})
+	if err != nil {
+		goto end
+	}
+
+	// Oldest first, so both limits evict from the front
+	slices.SortFunc(files, func(a, b PrunedFile) int {
+		return a.ModTime.Compare(b.ModTime)
+	})
+	for _, f := range files {
+		result.RemainingBytes += f.Size
+	}
+	for _, f := range files {
+		switch {
+		case maxAge > 0 && time.Since(f.ModTime) > maxAge:
+			f.Reason = ExpiredPruneReason
+		case maxBytes > 0 && result.RemainingBytes > maxBytes:
+			f.Reason = OversizePruneReason
+		default:
+			continue
+		}
+		removeErr := fSys.Remove(f.Filepath)
+		if removeErr != nil {
+			errs = append(errs, removeErr)
+			continue
+		}
+		result.Removed = append(result.Removed, f)
+		result.RemovedBytes += f.Size
+		result.RemainingBytes -= f.Size
+	}
+	slices.SortStableFunc(result.Removed, func(a, b PrunedFile) int {
+		return cmp.Compare(a.Reason, b.Reason)
+	})
+
+	removeEmptyDirs(fSys, dir, dirs)
+	err = CombineErrs(errs)
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToPruneCache, "cache_dir", dir, err)
+	}
+	return result, err
+}
+
+// isSidecarFile reports whether name is a lock sidecar, which another process
+// could otherwise lock a new copy of if it were pruned, or a temporary file
+// being written by a concurrent Save.
+func isSidecarFile(name string) bool {
+	base := path.Base(name)
+	switch {
+	case strings.HasSuffix(base, lockFileExt):
+		return true
+	case strings.HasPrefix(base, ".") && strings.HasSuffix(base, ".tmp"):
+		return true
+	}
+	return false
+}
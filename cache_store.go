@@ -0,0 +1,311 @@
+package cfgstore
+
+import (
+	jsonv2 "encoding/json/v2"
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// CacheStore provides file operations for a single file in a cache directory, as
+// returned by GetSharedCacheDir or GetAppCacheDir, with the same ergonomics as a
+// ConfigStore. Writes are atomic. Unlike a ConfigStore, a CacheStore has no
+// hooks and emits no Events because cached files can always be regenerated.
+//
+// Lock and LoadOrCreate coordinate concurrent writers of the same entry, e.g.
+// parallel invocations from make -j or a CI matrix, so an expensive entry is
+// only generated once.
+type CacheStore interface {
+	Load() ([]byte, error)
+	Save([]byte) error
+	LoadOrCreate(create func() ([]byte, error), opts ...UpdateOptions) ([]byte, error)
+	Lock(timeout time.Duration) (unlock func(), err error)
+	LoadJSON(data any, opts ...jsonv2.Options) error
+	SaveJSON(data any) error
+	Exists() bool
+	Delete() error
+	GetFilepath() (dt.Filepath, error)
+	GetRelFilepath() dt.RelFilepath
+	CacheDir() (dt.DirPath, error)
+	Usage(opts ...UsageOptions) (Usage, error)
+	SubStore(dt.RelFilepath) CacheStore
+	FileSystem() FileSystem
+	CacheStore()
+}
+
+var _ CacheStore = (*cacheStore)(nil)
+
+type cacheStore struct {
+	slug         dt.PathSegment
+	appName      dt.PathSegment
+	version      dt.PathSegment
+	project      bool
+	relFilepath  dt.RelFilepath
+	dirsProvider *DirsProvider
+	cacheDir     dt.DirPath
+}
+
+type CacheStoreArgs struct {
+	// Slug is the single path segment used for ~/.cache/<slug>.
+	Slug dt.PathSegment
+
+	// AppName, if set, places the store in the app-specific cache dir returned
+	// by GetAppCacheDir, ~/.cache/<slug>/<appName>, instead of the shared one.
+	AppName dt.PathSegment
+
+	// Version, if set with AppName, places the store in the version namespace
+	// returned by GetVersionedCacheDir, ~/.cache/<slug>/<appName>/<version>.
+	Version dt.PathSegment
+
+	// Project places the store in the per-project cache dir returned by
+	// GetProjectCacheDir, <projectDir>/.<slug>/cache, ignoring AppName and
+	// Version.
+	Project bool
+
+	// RelFilepath is the file in the cache dir, which may include parent
+	// directories, e.g. "repos/index.json".
+	RelFilepath dt.RelFilepath
+
+	// DirsProvider is intended only to be used by test code.
+	DirsProvider *DirsProvider
+}
+
+// NewCacheStore returns a CacheStore for args.RelFilepath in the cache dir for
+// args.Slug and, optionally, args.AppName.
+func NewCacheStore(args CacheStoreArgs) CacheStore {
+	if args.DirsProvider == nil {
+		args.DirsProvider = DefaultDirsProvider()
+	}
+	return &cacheStore{
+		slug:         args.Slug,
+		appName:      args.AppName,
+		version:      args.Version,
+		project:      args.Project,
+		relFilepath:  args.RelFilepath,
+		dirsProvider: args.DirsProvider,
+	}
+}
+
+func (cs *cacheStore) CacheStore() {}
+
+// CacheDir returns the cache dir the store's file is relative to.
+func (cs *cacheStore) CacheDir() (dir dt.DirPath, err error) {
+	var opts CacheOptions
+
+	if cs.cacheDir != "" {
+		goto end
+	}
+	opts.DirsProvider = cs.dirsProvider
+	if cs.project {
+		cs.cacheDir, err = GetProjectCacheDir(cs.slug, opts)
+		goto end
+	}
+	if cs.appName != "" && cs.version != "" {
+		cs.cacheDir, err = GetVersionedCacheDir(cs.slug, cs.appName, cs.version, opts)
+		goto end
+	}
+	cs.cacheDir, err = getCacheDir(cs.slug, cs.appName, opts)
+end:
+	return cs.cacheDir, err
+}
+
+func (cs *cacheStore) GetRelFilepath() dt.RelFilepath {
+	return cs.relFilepath
+}
+
+func (cs *cacheStore) GetFilepath() (fp dt.Filepath, err error) {
+	var dir dt.DirPath
+
+	dir, err = cs.CacheDir()
+	if err != nil {
+		goto end
+	}
+	if !cs.relFilepath.ValidPath() {
+		err = NewErr(
+			dt.ErrInvalid,
+			dt.ErrInvalidForOpen,
+			"filepath", cs.relFilepath,
+		)
+		goto end
+	}
+	fp = dt.FilepathJoin(dir, cs.relFilepath)
+end:
+	return fp, err
+}
+
+// FileSystem returns the FileSystem of the store's DirsProvider, or
+// OSFileSystem() if it has none.
+func (cs *cacheStore) FileSystem() FileSystem {
+	if cs.dirsProvider.FileSystem == nil {
+		return OSFileSystem()
+	}
+	return cs.dirsProvider.FileSystem
+}
+
+// SubStore returns a CacheStore for relFilepath in the same cache dir.
+func (cs *cacheStore) SubStore(relFilepath dt.RelFilepath) CacheStore {
+	sub := *cs
+	sub.relFilepath = relFilepath
+	return &sub
+}
+
+// Load returns the content of the store's file. A file that does not exist
+// fails with an error matching ErrFileDoesNotExist and fs.ErrNotExist.
+func (cs *cacheStore) Load() (data []byte, err error) {
+	var fp dt.Filepath
+
+	fp, err = cs.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	data, err = cs.FileSystem().ReadFile(fp)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = NewErr(ErrFileDoesNotExist, err)
+	}
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToReadCacheFile, "filepath", fp, err))
+	}
+	return data, err
+}
+
+func (cs *cacheStore) LoadJSON(data any, opts ...jsonv2.Options) (err error) {
+	var raw []byte
+
+	raw, err = cs.Load()
+	if err != nil {
+		goto end
+	}
+	err = jsonv2.Unmarshal(raw, data, opts...)
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToUnmarshalCacheFile, err))
+	}
+end:
+	return err
+}
+
+// Save atomically replaces the store's file with data, creating its directory
+// if needed.
+func (cs *cacheStore) Save(data []byte) (err error) {
+	var fp dt.Filepath
+
+	fSys := cs.FileSystem()
+	fp, err = cs.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	err = fSys.MkdirAll(fp.Dir())
+	if err != nil {
+		goto end
+	}
+	err = fSys.WriteFile(fp, data)
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToWriteCacheFile, "filepath", fp, err))
+	}
+	return err
+}
+
+func (cs *cacheStore) SaveJSON(data any) (err error) {
+	var raw []byte
+
+	raw, err = marshalIndented(data)
+	if err != nil {
+		err = NewErr(ErrFailedToWriteCacheFile, ErrFailedToMarshalValue, err)
+		goto end
+	}
+	err = cs.Save(raw)
+end:
+	return err
+}
+
+func (cs *cacheStore) Exists() (exists bool) {
+	fp, err := cs.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	_, err = cs.FileSystem().Stat(fp)
+	exists = err == nil
+end:
+	return exists
+}
+
+// Delete removes the store's file. Deleting a file that does not exist is not
+// an error.
+func (cs *cacheStore) Delete() (err error) {
+	var fp dt.Filepath
+
+	fp, err = cs.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	err = cs.FileSystem().Remove(fp)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToDeleteCacheFile, "filepath", fp, err))
+	}
+	return err
+}
+
+// Lock acquires an exclusive lock on the store's entry that is honored by other
+// goroutines and processes, waiting up to timeout for it, or DefaultLockTimeout
+// if timeout is zero. Call unlock to release it. The lock is held on a sidecar
+// <entry>.lock file, which PruneCache leaves alone.
+func (cs *cacheStore) Lock(timeout time.Duration) (unlock func(), err error) {
+	var fp dt.Filepath
+
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+	fp, err = cs.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	unlock, err = cs.FileSystem().Lock(fp, timeout)
+end:
+	return unlock, withErrorKind(err)
+}
+
+// LoadOrCreate returns the content of the store's file. If the file does not
+// exist it calls create and saves what it returns, while holding the entry's
+// lock so that concurrent callers wait for the first one instead of generating
+// the entry too. If create returns an error nothing is saved and the error is
+// returned.
+func (cs *cacheStore) LoadOrCreate(create func() ([]byte, error), opts ...UpdateOptions) (data []byte, err error) {
+	var unlock func()
+
+	if len(opts) == 0 {
+		opts = []UpdateOptions{{}}
+	}
+	data, err = cs.Load()
+	if !errors.Is(err, fs.ErrNotExist) {
+		goto end
+	}
+	unlock, err = cs.Lock(opts[0].LockTimeout)
+	if err != nil {
+		goto end
+	}
+	defer unlock()
+
+	// Another process may have created the entry while we waited for the lock
+	data, err = cs.Load()
+	if !errors.Is(err, fs.ErrNotExist) {
+		goto end
+	}
+	data, err = create()
+	if err != nil {
+		err = NewErr(ErrCacheCreateFuncFailed, err)
+		goto end
+	}
+	err = cs.Save(data)
+end:
+	if err != nil {
+		data = nil
+	}
+	return data, err
+}
@@ -0,0 +1,239 @@
+package cfgstore
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// GetVersionedCacheDir returns a cache directory namespaced by version within
+// the app-specific cache dir, so that artifacts cached by one version of an app
+// are never read by another:
+//   - macOS: ~/Library/Caches/{slug}/{appName}/{version}/
+//   - Linux: ~/.cache/{slug}/{appName}/{version}/
+//   - Windows: %LOCALAPPDATA%\{slug}\{appName}\{version}\
+//
+// Example: GetVersionedCacheDir("xmlui", "cli", "v1.4.0") → ~/.cache/xmlui/cli/v1.4.0/
+// on Linux. See RemoveStaleCacheVersions.
+func GetVersionedCacheDir(slug, appName, version dt.PathSegment, opts ...CacheOptions) (dir dt.DirPath, err error) {
+	dir, err = getCacheDir(slug, appName, opts...)
+	if err != nil {
+		goto end
+	}
+	dir = dt.DirPathJoin(dir, version)
+end:
+	return dir, err
+}
+
+// RemoveStaleCacheVersions removes the version namespaces created by
+// GetVersionedCacheDir for versions of appName older than current, e.g. on
+// startup after an upgrade, and returns the directories removed. Versions are
+// compared as semantic versions with an optional "v" prefix and at least a
+// MAJOR.MINOR; namespaces that do not parse as one, such as "1" or "2024", and
+// those newer than current, are left in place. appName is required, failing
+// with ErrMissingCacheAppName, so the scan never covers the cache dir shared by
+// every app of slug.
+func RemoveStaleCacheVersions(slug, appName, current dt.PathSegment, opts ...CacheOptions) (removed []dt.DirPath, err error) {
+	var appDir dt.DirPath
+	var entries []fs.DirEntry
+	var errs []error
+	var cur cacheVersion
+	var ok bool
+
+	fSys := cacheFileSystem(opts)
+	if appName == "" {
+		err = NewErr(ErrMissingCacheAppName)
+		goto end
+	}
+	cur, ok = parseCacheVersion(string(current))
+	if !ok {
+		err = NewErr(ErrInvalidCacheVersion, "version", current)
+		goto end
+	}
+	appDir, err = getCacheDir(slug, appName, opts...)
+	if err != nil {
+		goto end
+	}
+	entries, err = fs.ReadDir(fSys.DirFS(appDir), ".")
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+		goto end
+	}
+	if err != nil {
+		goto end
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		v, ok := parseCacheVersion(entry.Name())
+		if !ok || v.compare(cur) >= 0 {
+			continue
+		}
+		dir := dt.DirPathJoin(appDir, entry.Name())
+		removeErr := removeTree(fSys, dir)
+		if removeErr != nil {
+			errs = append(errs, removeErr)
+			continue
+		}
+		removed = append(removed, dir)
+	}
+	err = CombineErrs(errs)
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToRemoveStaleCache, "app_cache_dir", appDir, err)
+	}
+	return removed, err
+}
+
+// cacheVersion is a parsed semantic version.
+type cacheVersion struct {
+	parts      [3]int
+	prerelease string
+}
+
+// parseCacheVersion parses s as MAJOR.MINOR[.PATCH][-PRERELEASE][+BUILD] with
+// an optional "v" prefix. A bare MAJOR is not accepted, as directories named
+// with a number, e.g. a year, are too likely not to be versions.
+func parseCacheVersion(s string) (v cacheVersion, ok bool) {
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, v.prerelease, _ = strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		goto end
+	}
+	for i, part := range parts {
+		// Atoi would also accept a sign, e.g. "+1" or "-0"
+		if !isDigits(part) {
+			goto end
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			goto end
+		}
+		v.parts[i] = n
+	}
+	ok = true
+end:
+	return v, ok
+}
+
+// isDigits reports whether s is one or more ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// compare returns -1, 0 or +1 as v is older than, the same as or newer than w. A
+// prerelease is older than its release; prereleases compare as comparePrerelease
+// does.
+func (v cacheVersion) compare(w cacheVersion) int {
+	c := slices.Compare(v.parts[:], w.parts[:])
+	switch {
+	case c != 0:
+		return c
+	case v.prerelease == w.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case w.prerelease == "":
+		return -1
+	}
+	return comparePrerelease(v.prerelease, w.prerelease)
+}
+
+// comparePrerelease compares the prereleases a and b as semver §11.4 requires:
+// their dot-separated identifiers are compared in turn, numeric identifiers
+// numerically and before alphanumeric ones, which compare lexically, and if
+// all of the shorter's are equal the one with more identifiers is newer, e.g.
+// alpha < alpha.1 < alpha.beta < beta < rc.2 < rc.10.
+func comparePrerelease(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := range min(len(as), len(bs)) {
+		c := compareIdentifier(as[i], bs[i])
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// compareIdentifier compares the prerelease identifiers a and b, see
+// comparePrerelease.
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isDigits(a), isDigits(b)
+	switch {
+	case aNum && bNum:
+		// Compare by length first so that long numbers cannot overflow
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		return cmp.Or(cmp.Compare(len(a), len(b)), cmp.Compare(a, b))
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return cmp.Compare(a, b)
+}
+
+// cacheFileSystem returns the FileSystem of the DirsProvider in opts, if any.
+func cacheFileSystem(opts []CacheOptions) FileSystem {
+	if len(opts) > 0 && opts[0].DirsProvider != nil && opts[0].DirsProvider.FileSystem != nil {
+		return opts[0].DirsProvider.FileSystem
+	}
+	return OSFileSystem()
+}
+
+// removeTree removes dir and everything below it using fSys.
+func removeTree(fSys FileSystem, dir dt.DirPath) (err error) {
+	var files []dt.Filepath
+	var dirs []string
+
+	err = fs.WalkDir(fSys.DirFS(dir), ".", func(p string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+		case d.IsDir():
+			dirs = append(dirs, p)
+		default:
+			files = append(files, dt.FilepathJoin(dir, p))
+		}
+		return err
+	})
+	if err != nil {
+		goto end
+	}
+	for _, fp := range files {
+		err = fSys.Remove(fp)
+		if err != nil {
+			goto end
+		}
+	}
+	removeEmptyDirs(fSys, dir, dirs)
+end:
+	return err
+}
+
+// removeEmptyDirs tries to remove each of dirs, relative to root, deepest first
+// so parents are empty by the time they are tried. Removing a directory that is
+// not empty fails harmlessly.
+func removeEmptyDirs(fSys FileSystem, root dt.DirPath, dirs []string) {
+	slices.SortFunc(dirs, func(a, b string) int {
+		return cmp.Compare(strings.Count(b, "/"), strings.Count(a, "/"))
+	})
+	for _, d := range dirs {
+		_ = fSys.Remove(dt.FilepathJoin(root, d))
+	}
+}
@@ -0,0 +1,95 @@
+package cfgstore
+
+import (
+	jsonv2 "encoding/json/v2"
+	"errors"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// CachedConfig is an opt-in cache of a store's file unmarshaled into an *RC, for
+// hot paths that would otherwise re-read and re-parse the file on every access.
+// Each Load revalidates the cached value by comparing the file's modification
+// time and size with those it was loaded at, so changes made by other processes
+// are picked up without a Watcher. The *RC returned by Load must be treated as
+// read-only, as for Live. It is safe for concurrent use.
+type CachedConfig[RC any] struct {
+	store   ConfigStore
+	opts    []jsonv2.Options
+	mutex   sync.Mutex
+	rc      *RC
+	modTime time.Time
+	size    int64
+}
+
+// NewCachedConfig returns a CachedConfig for store that unmarshals with opts.
+// If store is a HookRegistrar it registers a post-save hook on it so that saves
+// through store are seen even if they leave the file's modification time and
+// size unchanged.
+func NewCachedConfig[RC any](store ConfigStore, opts ...jsonv2.Options) *CachedConfig[RC] {
+	cc := &CachedConfig[RC]{
+		store: store,
+		opts:  opts,
+	}
+	if hr, ok := store.(HookRegistrar); ok {
+		hr.AddPostSaveHook(func(*SaveHookArgs) error {
+			cc.Invalidate()
+			return nil
+		})
+	}
+	return cc
+}
+
+// Load returns the cached *RC if the store's file is unchanged since it was
+// loaded, otherwise it loads the file with LoadJSON, running the store's load
+// hooks, and caches the result. If loading fails the cache is left empty. A
+// file that does not exist fails with an error matching ErrFileDoesNotExist.
+func (cc *CachedConfig[RC]) Load() (rc *RC, err error) {
+	var fp dt.Filepath
+	var info fs.FileInfo
+
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+	fp, err = cc.store.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	// Stat before loading so a change made while loading causes a reload
+	info, err = cc.store.FileSystem().Stat(fp)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = NewErr(ErrFileDoesNotExist, err)
+	}
+	if err != nil {
+		cc.rc = nil
+		err = NewErr(ErrFailedToReadConfigFile, "filepath", fp, err)
+		goto end
+	}
+	if cc.rc != nil && info.ModTime().Equal(cc.modTime) && info.Size() == cc.size {
+		observeCacheLookup(true)
+		rc = cc.rc
+		goto end
+	}
+	observeCacheLookup(false)
+	cc.rc = nil
+	rc = new(RC)
+	err = cc.store.LoadJSON(rc, cc.opts...)
+	if err != nil {
+		rc = nil
+		goto end
+	}
+	cc.rc = rc
+	cc.modTime = info.ModTime()
+	cc.size = info.Size()
+end:
+	return rc, err
+}
+
+// Invalidate discards the cached *RC so the next Load reads the file.
+func (cc *CachedConfig[RC]) Invalidate() {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+	cc.rc = nil
+}
@@ -0,0 +1,24 @@
+module github.com/mikeschinkel/go-cfgstore/cfgotel
+
+go 1.25.3
+
+require (
+	github.com/mikeschinkel/go-cfgstore v0.4.0
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/mikeschinkel/go-cliutil v0.3.0 // indirect
+	github.com/mikeschinkel/go-dt v0.3.3 // indirect
+	github.com/mikeschinkel/go-dt/appinfo v0.2.1 // indirect
+	github.com/mikeschinkel/go-dt/dtx v0.2.1 // indirect
+	github.com/mikeschinkel/go-logutil v0.2.1 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.46.0 // indirect
+)
+
+replace github.com/mikeschinkel/go-cfgstore => ..
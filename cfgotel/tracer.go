@@ -0,0 +1,84 @@
+// Package cfgotel records the spans of go-cfgstore's config IO with
+// OpenTelemetry:
+//
+//	cfgstore.SetTracer(cfgotel.New(tracerProvider))
+//
+// Spans are named after the cfgstore.*SpanName constants, e.g.
+// "cfgstore.load", and carry the slug of the DirType of the store, the path of
+// its file and, for loads of every layer and merges, the layers' DirTypes.
+// Failed spans record their error and have an Error status.
+package cfgotel
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName names the OpenTelemetry tracer the spans are started
+// with.
+const InstrumentationName = "github.com/mikeschinkel/go-cfgstore"
+
+// Attribute keys of the spans.
+const (
+	DirTypeKey  = attribute.Key("cfgstore.dir_type")
+	FilepathKey = attribute.Key("cfgstore.filepath")
+	DirTypesKey = attribute.Key("cfgstore.dir_types")
+)
+
+var _ cfgstore.Tracer = (*Tracer)(nil)
+
+// Tracer implements cfgstore.Tracer with an OpenTelemetry tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New returns a Tracer that starts spans with a tracer from tp, or from the
+// global TracerProvider, as set with otel.SetTracerProvider, if tp is nil.
+func New(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracer{
+		tracer: tp.Tracer(InstrumentationName),
+	}
+}
+
+// Start starts an internal span named args.Name as a child of the span in ctx,
+// if any.
+func (t *Tracer) Start(ctx context.Context, args cfgstore.SpanArgs) (context.Context, cfgstore.Span) {
+	var attrs []attribute.KeyValue
+
+	if args.DirType != cfgstore.UnspecifiedConfigDirType {
+		attrs = append(attrs, DirTypeKey.String(args.DirType.Slug()))
+	}
+	if args.Filepath != "" {
+		attrs = append(attrs, FilepathKey.String(string(args.Filepath)))
+	}
+	if len(args.DirTypes) > 0 {
+		slugs := make([]string, len(args.DirTypes))
+		for i, dirType := range args.DirTypes {
+			slugs[i] = dirType.Slug()
+		}
+		attrs = append(attrs, DirTypesKey.StringSlice(slugs))
+	}
+	ctx, span := t.tracer.Start(ctx, args.Name, trace.WithAttributes(attrs...))
+	return ctx, otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+// End records err, if it is not nil, and ends the span.
+func (s otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,26 @@
+module github.com/mikeschinkel/go-cfgstore/cfgprom
+
+go 1.25.3
+
+require (
+	github.com/mikeschinkel/go-cfgstore v0.4.0
+	github.com/prometheus/client_golang v1.24.1
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/mikeschinkel/go-cliutil v0.3.0 // indirect
+	github.com/mikeschinkel/go-dt v0.3.3 // indirect
+	github.com/mikeschinkel/go-dt/appinfo v0.2.1 // indirect
+	github.com/mikeschinkel/go-dt/dtx v0.2.1 // indirect
+	github.com/mikeschinkel/go-logutil v0.2.1 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.2 // indirect
+	github.com/prometheus/common v0.70.1 // indirect
+	github.com/prometheus/procfs v0.21.1 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+)
+
+replace github.com/mikeschinkel/go-cfgstore => ..
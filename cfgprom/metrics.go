@@ -0,0 +1,140 @@
+// Package cfgprom exports the measurements of go-cfgstore's config IO as
+// Prometheus metrics:
+//
+//	m, err := cfgprom.New()
+//	if err != nil {
+//		return err
+//	}
+//	cfgstore.SetMetrics(m)
+//
+// Operations are labeled with their kind, e.g. "load" or "save", and the slug
+// of the DirType of the store they were performed on, and errors with their
+// cfgstore.ErrorKind.
+package cfgprom
+
+import (
+	"errors"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultNamespace prefixes the names of the metrics unless Options.Namespace
+// is set.
+const DefaultNamespace = "cfgstore"
+
+var _ cfgstore.Metrics = (*Metrics)(nil)
+
+type Options struct {
+	// Namespace prefixes the names of the metrics. Defaults to
+	// DefaultNamespace.
+	Namespace string
+
+	// Registerer the metrics are registered with. Defaults to
+	// prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+
+	// DurationBuckets and SizeBuckets are the buckets of the operation
+	// duration, in seconds, and file size, in bytes, histograms. Default to
+	// prometheus.DefBuckets and powers of 4 from 256 bytes to 16 MiB.
+	DurationBuckets []float64
+	SizeBuckets     []float64
+}
+
+// Metrics implements cfgstore.Metrics with Prometheus collectors.
+type Metrics struct {
+	operations   *prometheus.CounterVec
+	errors       *prometheus.CounterVec
+	cacheLookups *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	fileSize     *prometheus.HistogramVec
+}
+
+// New returns Metrics whose collectors are registered with opts.Registerer,
+// failing if any of them cannot be, e.g. as they already are.
+func New(opts ...Options) (m *Metrics, err error) {
+	var errs []error
+
+	if len(opts) == 0 {
+		opts = []Options{{}}
+	}
+	o := opts[0]
+	if o.Namespace == "" {
+		o.Namespace = DefaultNamespace
+	}
+	if o.Registerer == nil {
+		o.Registerer = prometheus.DefaultRegisterer
+	}
+	if o.DurationBuckets == nil {
+		o.DurationBuckets = prometheus.DefBuckets
+	}
+	if o.SizeBuckets == nil {
+		o.SizeBuckets = prometheus.ExponentialBuckets(256, 4, 9)
+	}
+	m = &Metrics{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.Namespace,
+			Name:      "operations_total",
+			Help:      "Config file operations, by kind and dir type.",
+		}, []string{"op", "dir_type"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.Namespace,
+			Name:      "errors_total",
+			Help:      "Failed config file operations, by kind and error kind.",
+		}, []string{"op", "error_kind"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.Namespace,
+			Name:      "cache_lookups_total",
+			Help:      "CachedConfig loads, by whether they were served from the cache.",
+		}, []string{"result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.Namespace,
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of config file operations, by kind and dir type.",
+			Buckets:   o.DurationBuckets,
+		}, []string{"op", "dir_type"}),
+		fileSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.Namespace,
+			Name:      "file_size_bytes",
+			Help:      "Size of the config files read and written, by kind.",
+			Buckets:   o.SizeBuckets,
+		}, []string{"op"}),
+	}
+	for _, c := range m.collectors() {
+		errs = append(errs, o.Registerer.Register(c))
+	}
+	err = errors.Join(errs...)
+	if err != nil {
+		m = nil
+	}
+	return m, err
+}
+
+// ObserveOperation counts op, and its error if it failed, and observes its
+// duration and, if it is known, the size of the file.
+func (m *Metrics) ObserveOperation(op cfgstore.OperationMetric) {
+	kind := op.Kind.Slug()
+	dirType := op.DirType.Slug()
+	m.operations.WithLabelValues(kind, dirType).Inc()
+	m.duration.WithLabelValues(kind, dirType).Observe(op.Duration.Seconds())
+	if op.Err != nil {
+		m.errors.WithLabelValues(kind, cfgstore.ErrorKindOf(op.Err).Slug()).Inc()
+		return
+	}
+	if op.Size > 0 {
+		m.fileSize.WithLabelValues(kind).Observe(float64(op.Size))
+	}
+}
+
+// ObserveCacheLookup counts a CachedConfig lookup as a "hit" or "miss".
+func (m *Metrics) ObserveCacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.operations, m.errors, m.cacheLookups, m.duration, m.fileSize}
+}
@@ -0,0 +1,461 @@
+// Package cli implements the cfgstore command, a reference consumer of
+// go-cfgstore that reads and writes the config files of any app using it.
+package cli
+
+import (
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+const (
+	ExitOK    = 0 // The command succeeded
+	ExitError = 1 // The command failed, e.g. a value was not found or lint failed
+	ExitUsage = 2 // The command line was invalid
+)
+
+const usage = `usage: cfgstore <command> [flags] [args]
+
+Commands:
+  path                print the config file's path
+  get <path>          print the value at path, e.g. server.port
+  set <path> <value>  set the value at path, parsing value as JSON if it is
+                      valid JSON and as a string otherwise
+  unset <path>        remove the value at path
+  list [prefix]       list the paths of the values beginning with prefix
+  diff <dir-type>     list the values added (+), removed (-) or changed (~)
+                      in the <dir-type> layer relative to the -dir-type layer
+  edit                edit the config file in $VISUAL or $EDITOR, saving the
+                      edits only if they are valid JSON
+  lint                check the config file is valid JSON
+  doctor              report the location and health of each config layer
+
+Flags:
+`
+
+type Args struct {
+	// Args are the command-line arguments, without the program name.
+	Args []string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// LookupEnv reads VISUAL and EDITOR. Defaults to os.LookupEnv.
+	LookupEnv func(string) (string, bool)
+
+	// DirsProvider is intended only to be used by test code.
+	DirsProvider *cfgstore.DirsProvider
+}
+
+type runner struct {
+	Args
+	flags       *flag.FlagSet
+	slug        string
+	dirTypeSlug string
+	file        string
+	configDir   string
+	asString    bool
+	prune       bool
+
+	// dirType is -dir-type parsed by run.
+	dirType cfgstore.DirType
+	store   cfgstore.ConfigStore
+}
+
+// Run runs the command in args.Args and returns the process exit code.
+func Run(args Args) (code int) {
+	var cmd string
+	var err error
+
+	if args.LookupEnv == nil {
+		args.LookupEnv = os.LookupEnv
+	}
+	r := &runner{Args: args}
+	r.flags = flag.NewFlagSet("cfgstore", flag.ContinueOnError)
+	r.flags.SetOutput(args.Stderr)
+	r.flags.Usage = func() {
+		_, _ = fmt.Fprint(args.Stderr, usage)
+		r.flags.PrintDefaults()
+	}
+	r.flags.StringVar(&r.slug, "slug", "", "config slug, e.g. myapp (required)")
+	r.flags.StringVar(&r.dirTypeSlug, "dir-type", cfgstore.CLIConfigDirType.Slug(), "config layer: cli, app or project")
+	r.flags.StringVar(&r.file, "file", "config.json", "config file, relative to the config dir")
+	r.flags.StringVar(&r.configDir, "config-dir", "", "use this config dir instead of the layer's")
+	r.flags.BoolVar(&r.asString, "string", false, "set: store value as a string even if it is valid JSON")
+	r.flags.BoolVar(&r.prune, "prune", false, "unset: remove objects and arrays left empty")
+
+	if len(args.Args) == 0 {
+		r.flags.Usage()
+		code = ExitUsage
+		goto end
+	}
+	if args.Args[0] == "help" || args.Args[0] == "-h" || args.Args[0] == "--help" {
+		r.flags.Usage()
+		goto end
+	}
+	cmd = args.Args[0]
+	err = r.flags.Parse(args.Args[1:])
+	if err != nil {
+		code = ExitUsage
+		goto end
+	}
+	err = r.run(cmd, r.flags.Args())
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrUnknownCommand),
+		errors.Is(err, ErrMissingSlug),
+		errors.Is(err, ErrWrongArgCount),
+		errors.Is(err, cfgstore.ErrInvalidConfigDirType):
+		code = ExitUsage
+	default:
+		code = ExitError
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(args.Stderr, "cfgstore %s: %v\n", cmd, err)
+	}
+end:
+	return code
+}
+
+func (r *runner) run(cmd string, args []string) (err error) {
+	if r.slug == "" {
+		err = cfgstore.NewErr(ErrMissingSlug)
+		goto end
+	}
+	r.dirType, err = cfgstore.ParseDirType(r.dirTypeSlug)
+	if err != nil {
+		goto end
+	}
+	r.store = r.newStore(r.dirType)
+	if r.configDir != "" {
+		r.store.SetConfigDir(dt.DirPath(r.configDir))
+	}
+	switch cmd {
+	case "path":
+		err = r.path(args)
+	case "get":
+		err = r.get(args)
+	case "set":
+		err = r.set(args)
+	case "unset":
+		err = r.unset(args)
+	case "list":
+		err = r.list(args)
+	case "diff":
+		err = r.diff(args)
+	case "edit":
+		err = r.edit(args)
+	case "lint":
+		err = r.lint(args)
+	case "doctor":
+		err = r.doctor(args)
+	default:
+		err = cfgstore.NewErr(ErrUnknownCommand, "command", cmd)
+	}
+end:
+	return err
+}
+
+func (r *runner) newStore(dirType cfgstore.DirType) cfgstore.ConfigStore {
+	opts := []cfgstore.ConfigStoreOption{
+		cfgstore.WithSlug(dt.PathSegment(r.slug)),
+		cfgstore.WithRelFilepath(dt.RelFilepath(r.file)),
+	}
+	if r.DirsProvider != nil {
+		opts = append(opts, cfgstore.WithDirsProvider(r.DirsProvider))
+	}
+	return cfgstore.NewConfigStore(dirType, opts...)
+}
+
+func (r *runner) path(args []string) (err error) {
+	var fp dt.Filepath
+
+	err = argCount(args, 0, 0)
+	if err != nil {
+		goto end
+	}
+	fp, err = r.store.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	_, err = fmt.Fprintln(r.Stdout, fp)
+end:
+	return err
+}
+
+// get prints strings as-is so that they can be used in scripts without
+// unquoting, and other values as JSON.
+func (r *runner) get(args []string) (err error) {
+	var value any
+	var found bool
+	var data []byte
+
+	err = argCount(args, 1, 1)
+	if err != nil {
+		goto end
+	}
+	value, found, err = cfgstore.GetValue(r.store, args[0])
+	if err != nil {
+		goto end
+	}
+	if !found {
+		err = cfgstore.NewErr(ErrValueNotFound, "path", args[0])
+		goto end
+	}
+	if s, ok := value.(string); ok {
+		_, err = fmt.Fprintln(r.Stdout, s)
+		goto end
+	}
+	data, err = jsonv2.Marshal(value, jsonv2.Deterministic(true), jsontext.WithIndent("  "))
+	if err != nil {
+		goto end
+	}
+	_, err = fmt.Fprintln(r.Stdout, string(data))
+end:
+	return err
+}
+
+func (r *runner) set(args []string) (err error) {
+	var value any
+
+	err = argCount(args, 2, 2)
+	if err != nil {
+		goto end
+	}
+	value = args[1]
+	if raw := jsontext.Value(args[1]); !r.asString && raw.IsValid() {
+		value = raw
+	}
+	err = cfgstore.SetValue(r.store, args[0], value)
+end:
+	return err
+}
+
+func (r *runner) unset(args []string) (err error) {
+	var found bool
+
+	err = argCount(args, 1, 1)
+	if err != nil {
+		goto end
+	}
+	found, err = cfgstore.UnsetValue(r.store, args[0], cfgstore.UnsetValueOptions{PruneEmpty: r.prune})
+	if err != nil {
+		goto end
+	}
+	if !found {
+		err = cfgstore.NewErr(ErrValueNotFound, "path", args[0])
+	}
+end:
+	return err
+}
+
+func (r *runner) list(args []string) (err error) {
+	var keys []string
+	var prefix string
+
+	err = argCount(args, 0, 1)
+	if err != nil {
+		goto end
+	}
+	if len(args) == 1 {
+		prefix = args[0]
+	}
+	keys, err = cfgstore.Keys(r.store, prefix)
+	if err != nil {
+		goto end
+	}
+	for _, key := range keys {
+		_, err = fmt.Fprintln(r.Stdout, key)
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+// diff prints the differences between the -dir-type layer and the layer named
+// in args, one per line, see cfgstore.Diff.
+func (r *runner) diff(args []string) (err error) {
+	var dirType cfgstore.DirType
+	var changes []cfgstore.Change
+
+	err = argCount(args, 1, 1)
+	if err != nil {
+		goto end
+	}
+	dirType, err = cfgstore.ParseDirType(args[0])
+	if err != nil {
+		goto end
+	}
+	changes, err = cfgstore.Diff(r.store, r.newStore(dirType))
+	if err != nil {
+		goto end
+	}
+	for _, change := range changes {
+		switch change.Kind {
+		case cfgstore.AddedChangeKind:
+			_, err = fmt.Fprintf(r.Stdout, "+ %s: %s\n", change.Path, compactJSON(change.New))
+		case cfgstore.RemovedChangeKind:
+			_, err = fmt.Fprintf(r.Stdout, "- %s: %s\n", change.Path, compactJSON(change.Old))
+		default:
+			_, err = fmt.Fprintf(r.Stdout, "~ %s: %s -> %s\n", change.Path, compactJSON(change.Old), compactJSON(change.New))
+		}
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+// compactJSON returns value as single-line JSON.
+func compactJSON(value any) string {
+	data, err := jsonv2.Marshal(value, jsonv2.Deterministic(true))
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// edit opens the config file in the user's editor, saving the edits only if
+// they are valid JSON, see cfgstore.EditInteractive.
+func (r *runner) edit(args []string) (err error) {
+	var changed bool
+
+	err = argCount(args, 0, 0)
+	if err != nil {
+		goto end
+	}
+	changed, err = cfgstore.EditInteractive(r.store, nil, cfgstore.EditOptions{
+		LookupEnv: r.LookupEnv,
+		Stdin:     r.Stdin,
+		Stdout:    r.Stdout,
+		Stderr:    r.Stderr,
+	})
+	if err != nil {
+		goto end
+	}
+	if !changed {
+		_, err = fmt.Fprintln(r.Stdout, "unchanged")
+	}
+end:
+	return err
+}
+
+func (r *runner) lint(args []string) (err error) {
+	err = argCount(args, 0, 0)
+	if err != nil {
+		goto end
+	}
+	err = lintStore(r.store)
+	if err != nil {
+		goto end
+	}
+	_, err = fmt.Fprintln(r.Stdout, "ok")
+end:
+	return err
+}
+
+// lintStore returns an error if the store's file cannot be read or is not a
+// valid JSON document, including one with duplicate object member names.
+func lintStore(store cfgstore.ConfigStore) (err error) {
+	var data []byte
+	var value any
+
+	data, err = store.Load()
+	if err != nil {
+		goto end
+	}
+	err = jsonv2.Unmarshal(data, &value)
+	if err != nil {
+		err = cfgstore.NewErr(ErrInvalidConfig, err)
+	}
+end:
+	return err
+}
+
+// doctor reports the file of each config layer and whether it is missing,
+// healthy or broken, followed by the environment, see cfgstore.Diagnose. Only
+// problems found fail the command.
+func (r *runner) doctor(args []string) (err error) {
+	var d cfgstore.Diagnosis
+	var tw *tabwriter.Writer
+
+	err = argCount(args, 0, 0)
+	if err != nil {
+		goto end
+	}
+	d = cfgstore.Diagnose(r.newStores(), cfgstore.DiagnoseOptions{LookupEnv: r.LookupEnv})
+	tw = tabwriter.NewWriter(r.Stdout, 0, 4, 2, ' ', 0)
+	for _, layer := range d.Layers {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", layer.DirType.Slug(), layer.Filepath, layerStatus(layer))
+	}
+	_, _ = fmt.Fprintf(tw, "\nos\t%s\n", d.Environment.GOOS)
+	if d.Environment.Sandbox != "" {
+		_, _ = fmt.Fprintf(tw, "sandbox\t%s\n", d.Environment.Sandbox)
+	}
+	for _, v := range d.Environment.Vars {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\n", v.Name, v.Value)
+	}
+	err = tw.Flush()
+	if err != nil {
+		goto end
+	}
+	err = d.Err()
+end:
+	return err
+}
+
+// newStores returns the stores of every config layer, with -config-dir applied
+// to the -dir-type layer.
+func (r *runner) newStores() *cfgstore.ConfigStores {
+	stores := &cfgstore.ConfigStores{
+		DirTypes: []cfgstore.DirType{
+			cfgstore.AppConfigDirType,
+			cfgstore.CLIConfigDirType,
+			cfgstore.ProjectConfigDirType,
+		},
+		StoreMap: cfgstore.ConfigStoreMap{},
+	}
+	for _, dirType := range stores.DirTypes {
+		store := r.newStore(dirType)
+		if r.configDir != "" && dirType == r.dirType {
+			store.SetConfigDir(dt.DirPath(r.configDir))
+		}
+		stores.StoreMap[dirType] = store
+	}
+	return stores
+}
+
+func layerStatus(layer cfgstore.LayerDiagnosis) (status string) {
+	switch {
+	case len(layer.Findings) > 0:
+		status = "error: " + layer.Findings[0].Error()
+	case !layer.Exists:
+		status = "missing"
+	default:
+		status = fmt.Sprintf("ok (%d bytes, %s)", layer.Size, layer.Mode)
+	}
+	if !layer.Writable {
+		status += ", read-only"
+	}
+	return status
+}
+
+func argCount(args []string, minimum, maximum int) (err error) {
+	if len(args) < minimum || len(args) > maximum {
+		err = cfgstore.NewErr(ErrWrongArgCount, "args", strings.Join(args, " "))
+	}
+	return err
+}
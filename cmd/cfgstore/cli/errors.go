@@ -0,0 +1,13 @@
+package cli
+
+import (
+	"errors"
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrMissingSlug    = errors.New("missing -slug")
+	ErrWrongArgCount  = errors.New("wrong number of arguments")
+	ErrValueNotFound  = errors.New("value not found")
+	ErrInvalidConfig  = errors.New("invalid config")
+)
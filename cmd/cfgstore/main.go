@@ -0,0 +1,24 @@
+// Command cfgstore reads and writes the config files of any app that uses
+// go-cfgstore, for debugging, scripting and support:
+//
+//	cfgstore get -slug myapp server.port
+//	cfgstore set -slug myapp -dir-type project server.port 8080
+//	cfgstore doctor -slug myapp
+//
+// Run `cfgstore help` for all commands.
+package main
+
+import (
+	"os"
+
+	"github.com/mikeschinkel/go-cfgstore/cmd/cfgstore/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(cli.Args{
+		Args:   os.Args[1:],
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}))
+}
@@ -0,0 +1,36 @@
+package cfgstore
+
+import (
+	jsonv2 "encoding/json/v2"
+)
+
+// Codec encodes and decodes values for a store created with a Codec, see
+// ConfigStoreArgs.Codec, so an app can keep its config in a format other than
+// JSON, e.g. YAML, by wrapping the library of its choice:
+//
+//	type yamlCodec struct{}
+//
+//	func (yamlCodec) Marshal(v any) ([]byte, error)      { return yaml.Marshal(v) }
+//	func (yamlCodec) Unmarshal(data []byte, v any) error { return yaml.Unmarshal(data, v) }
+//
+// The codec is used by LoadJSON and SaveJSON and their variants, which then
+// ignore any jsonv2.Options. LoadJSONStream, LoadJSONMapped and SaveJSONStream
+// fall back to them. A Watcher, and so Subscribe and Live, decodes the files it
+// watches with it too, so Unmarshal must also decode into an *any, producing
+// map[string]any and []any for subscribed paths to be found. Path-based access
+// such as GetValue and SetValue, and MergeJSON, always read and write JSON.
+// Unmarshal must not retain data.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// unmarshalStore decodes data, the content of store's file, into v with
+// store's Codec if it has one, and otherwise as JSON.
+func unmarshalStore(store ConfigStore, data []byte, v any) error {
+	cs, ok := store.(*configStore)
+	if ok && cs.codec != nil {
+		return cs.codec.Unmarshal(data, v)
+	}
+	return jsonv2.Unmarshal(data, v)
+}
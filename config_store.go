@@ -1,13 +1,18 @@
 package cfgstore
 
 import (
+	"bytes"
+	"context"
 	"encoding/json/jsontext"
 	jsonv2 "encoding/json/v2"
+	"errors"
 	"io/fs"
-	"os"
 	"runtime"
+	"text/template"
+	"time"
 
 	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-dt/appinfo"
 	"github.com/mikeschinkel/go-dt/dtx"
 )
 
@@ -18,18 +23,37 @@ const DefaultConfigDirType = CLIConfigDirType
 
 const DotConfigPathSegment dt.PathSegment = ".config"
 
-// ConfigStore provides file operations for Gmail APIConfig
-type ConfigStore interface {
+// ConfigReader is the read side of a ConfigStore. Read-only backends, e.g. one
+// over an embed.FS or an HTTP endpoint, and consumers that only read config can
+// accept or implement ConfigReader instead of a ConfigStore whose writes always
+// fail.
+type ConfigReader interface {
 	Load() ([]byte, error)
-	Save([]byte) error
 	LoadJSON(data any, opts ...jsonv2.Options) error
-	SaveJSON(data any) error
 	Exists() bool
 	GetFilepath() (dt.Filepath, error)
+	ConfigDir() (dt.DirPath, error)
+}
+
+// ConfigWriter is the write side of a ConfigStore.
+type ConfigWriter interface {
+	Save([]byte) error
+	SaveJSON(data any) error
+}
+
+// ConfigStore provides file operations for Gmail APIConfig
+//
+// It is kept small so that other implementations and mocks stay easy to write.
+// Other features are package-level functions taking a ConfigStore, e.g.
+// SetValue and LoadContext, or optional interfaces a ConfigStore may implement,
+// e.g. HookRegistrar and TenantConfigStore, as FileSystem's are.
+type ConfigStore interface {
+	ConfigReader
+	ConfigWriter
+	FileSystem() FileSystem
 	GetRelFilepath() dt.RelFilepath
 	SetRelFilepath(dt.RelFilepath)
 	SetConfigDir(dt.DirPath)
-	ConfigDir() (dt.DirPath, error)
 	EnsureDirs(subdirs []dt.PathSegment) error
 	WithDirType(DirType) ConfigStore
 	DirType() DirType
@@ -37,18 +61,71 @@ type ConfigStore interface {
 	ConfigSlug() dt.PathSegment
 }
 
-var _ ConfigStore = (*configStore)(nil)
+// SubStorer is implemented by a ConfigStore that returns stores for other files
+// in its config dir. See SubStore.
+type SubStorer interface {
+	SubStore(dt.RelFilepath) ConfigStore
+}
+
+// StoreCopier is implemented by a ConfigStore that returns copies of itself for
+// another file or config dir.
+type StoreCopier interface {
+	WithRelFilepath(dt.RelFilepath) ConfigStore
+	WithConfigSlug(dt.PathSegment) ConfigStore
+}
+
+var (
+	_ ConfigStore        = (*configStore)(nil)
+	_ SubStorer          = (*configStore)(nil)
+	_ StoreCopier        = (*configStore)(nil)
+	_ ContextConfigStore = (*configStore)(nil)
+	_ JSONStreamer       = (*configStore)(nil)
+	_ Invalidator        = (*configStore)(nil)
+	_ Purger             = (*configStore)(nil)
+	_ HookRegistrar      = (*configStore)(nil)
+	_ TenantConfigStore  = (*configStore)(nil)
+)
 
 type configStore struct {
 	configSlug dt.PathSegment
-	// parentDir is <projectDir> ot Getwd() for ProjectConfig,
+	// dir memoizes the config dir, <projectDir> ot Getwd() for ProjectConfig,
 	// or ~/.config for CLIConfigStore
-	// or UserConfigDir() for StdConfig
-	configDir    dt.DirPath
+	// or UserConfigDir() for StdConfig,
+	// and the fs.FS over it.
+	dir          *configDirCache
 	relFilepath  dt.RelFilepath
 	dirType      DirType
 	dirsProvider *DirsProvider
-	fs           fs.FS
+	hooks        storeHooks
+
+	// filepath memoizes GetFilepath until configDir or relFilepath changes.
+	filepath *filepathCache
+
+	// stat caches the result of Exists if ConfigStoreArgs.CacheStat was set.
+	stat *statCache
+
+	codec    Codec
+	fileMode fs.FileMode
+	scaffold *template.Template
+
+	// tenant namespaces configDir, see WithTenant.
+	tenant dt.PathSegment
+
+	// placeholders are expanded in rawRelFilepath, the RelFilepath as given, to
+	// give relFilepath by expandRelFilepath, which records in relFilepathErr why
+	// they could not be.
+	placeholders   map[string]string
+	rawRelFilepath dt.RelFilepath
+	relFilepathErr error
+
+	// legacyFilepaths are read, see ConfigStoreArgs.LegacyFilepaths, when the
+	// store's file does not exist.
+	legacyFilepaths []dt.Filepath
+
+	// writeExample and exampleComments are ConfigStoreArgs.WriteExample and
+	// ExampleComments.
+	writeExample    bool
+	exampleComments map[string]string
 }
 
 type ConfigStoreArgs struct {
@@ -63,6 +140,59 @@ type ConfigStoreArgs struct {
 	// DirsProvider is typically never used for production code. It is intended only
 	// to be used for test code in conjunction with go-the fsfix package
 	DirsProvider *DirsProvider
+
+	// CacheStat makes Exists stat the store's file only once, until the store
+	// saves it or Invalidate is called, trading strict freshness for speed in
+	// short-lived CLI runs that check it many times. Stores returned by
+	// SubStore and WithDirType cache separately.
+	CacheStat bool
+
+	// Codec, if set, replaces JSON as the format LoadJSON and SaveJSON decode
+	// and encode, e.g. to store YAML. See Codec.
+	Codec Codec
+
+	// FileMode, if set, is the permissions every save leaves the store's file
+	// with, e.g. 0600 for a file holding secrets. Otherwise new files are
+	// created 0644 and replaced files keep their permissions.
+	FileMode fs.FileMode
+
+	// Scaffold, if set, renders the file created for a config that does not
+	// exist yet, rather than marshaling the normalized RootConfig, so that the
+	// first file a user opens can explain itself. See ScaffoldData.
+	Scaffold *template.Template
+
+	// Tenant, if set, namespaces the config dir for one of several accounts or
+	// organizations, e.g. ~/.config/<slug>/<tenant>. See ConfigStore.WithTenant.
+	Tenant dt.PathSegment
+
+	// Placeholders are the values of the {name} placeholders in RelFilepath,
+	// e.g. "config-{profile}.json" with {"profile": "work"}, which are expanded
+	// when the store's file is resolved. {goos} and {goarch} are built in, see
+	// GOOSPlaceholder, as are {version} and {app} with an AppInfo, and Options
+	// passed to LoadConfigStores can add more, see PlaceholderOptions. Values
+	// must be single path segments.
+	Placeholders map[string]string
+
+	// AppInfo, if set, describes the app the store is for, supplying the
+	// values of the {version} and {app} placeholders. See VersionPlaceholder.
+	AppInfo appinfo.AppInfo
+
+	// LegacyFilepaths are files, e.g. "~/.myapprc" or "~/.myapp/config", that
+	// loads read, in order, when the store's file does not exist, easing the
+	// move from a tool's old locations. They are never written: a save creates
+	// the store's file, after which they are no longer read. See Relocate to
+	// move one instead. A leading "~/" is the user's home dir.
+	LegacyFilepaths []dt.Filepath
+
+	// WriteExample makes the store write an example of its config beside its
+	// file, e.g. config.example.json, with the RootConfig's defaults, when it
+	// creates the config and whenever it is loaded through LoadConfigStores or
+	// EnsureConfig and the defaults have changed, giving users a reference that
+	// is never read. ExampleComments documents the settings in it by their
+	// paths, as for GetValue, e.g. {"server.port": "Port to listen on"}. See
+	// ExampleFilepath.
+	WriteExample    bool
+	ExampleComments map[string]string
 }
 
 func NewCLIConfigStore(configSlug dt.PathSegment, configFile dt.RelFilepath) ConfigStore {
@@ -72,6 +202,13 @@ func NewCLIConfigStore(configSlug dt.PathSegment, configFile dt.RelFilepath) Con
 	})
 }
 
+func NewAppConfigStore(configSlug dt.PathSegment, configFile dt.RelFilepath) ConfigStore {
+	return NewConfigStore(AppConfigDirType, ConfigStoreArgs{
+		ConfigSlug:  configSlug,
+		RelFilepath: configFile,
+	})
+}
+
 func NewProjectConfigStore(configSlug dt.PathSegment, configFile dt.RelFilepath) ConfigStore {
 	return NewConfigStore(ProjectConfigDirType, ConfigStoreArgs{
 		ConfigSlug:  configSlug,
@@ -89,20 +226,35 @@ func ProjectConfigStoreExists(projectDir dt.DirPath, configSlug dt.PathSegment,
 	return exists
 }
 
+type InitConfigOptions struct {
+	// Fields are asked for with Prompter, see PromptConfig, before the new
+	// config is normalized and saved.
+	Fields []PromptField
+
+	// Prompter asks the user for each of Fields. If nil each field's Default
+	// is used.
+	Prompter Prompter
+}
+
 // InitProjectConfig initializes a project config.
 // Returns the initialized config and an error (ErrConfigAlreadyExists if config already exists).
+// Pass InitConfigOptions to walk the user through setting the config's values.
 func InitProjectConfig[RC any, PRC RootConfigPtr[RC]](
-		configSlug dt.PathSegment,
-		configFile dt.RelFilepath,
-		opts Options,
+	configSlug dt.PathSegment,
+	configFile dt.RelFilepath,
+	opts Options,
+	initOpts ...InitConfigOptions,
 ) (prc PRC, err error) {
 	var cs *configStore
 
+	if len(initOpts) == 0 {
+		initOpts = []InitConfigOptions{{}}
+	}
 	store := NewProjectConfigStore(configSlug, configFile)
 	cs = store.(*configStore)
 	prc = PRC(new(RC))
 
-	err = cs.initConfig(prc, ProjectConfigDirType, opts)
+	err = cs.initConfig(prc, ProjectConfigDirType, opts, initOpts[0])
 	if err != nil {
 		goto end
 	}
@@ -111,10 +263,67 @@ end:
 	return prc, err
 }
 
+// EnsureConfig loads the config of store into a new RC, normalizing it, and
+// if the file does not exist or has no settings creates it from the
+// normalized zero value instead. created reports whether the file was created.
+// This is what LoadConfig does for each non-project layer:
+//
+//	cfg, created, err := cfgstore.EnsureConfig[MyConfig](store, nil)
+//	if created {
+//	    fmt.Println("created a default config; edit it and run again")
+//	}
+func EnsureConfig[RC any, PRC RootConfigPtr[RC]](store ConfigStore, opts Options) (prc PRC, created bool, err error) {
+	return EnsureConfigContext[RC, PRC](context.Background(), store, opts)
+}
+
+// EnsureConfigContext is EnsureConfig with a context that is passed to the load
+// and save.
+func EnsureConfigContext[RC any, PRC RootConfigPtr[RC]](ctx context.Context, store ConfigStore, opts Options) (prc PRC, created bool, err error) {
+	var fp dt.Filepath
+	var cs *configStore
+	var ok bool
+
+	prc = PRC(new(RC))
+	fp, err = store.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	cs, ok = store.(*configStore)
+	if ok {
+		created, err = cs.ensureConfig(ctx, prc, cs.dirType, opts)
+		goto end
+	}
+	// Other ConfigStores, e.g. test doubles, are ensured through the interface
+	if store.Exists() {
+		err = LoadJSONContext(ctx, store, prc)
+		if err != nil {
+			goto end
+		}
+	}
+	created = dtx.IsZero(prc)
+	err = prc.Normalize(NormalizeArgs{
+		DirType:    store.DirType(),
+		SourceFile: fp,
+		Options:    opts,
+	})
+	if err != nil || !created {
+		goto end
+	}
+	err = SaveJSONContext(ctx, store, prc)
+end:
+	if err != nil {
+		created = false
+		err = withErrorKind(NewErr(ErrFailedToEnsureConfig, "filepath", fp, err))
+	}
+	return prc, created, err
+}
+
 func DefaultDirsProvider() *DirsProvider {
 	dp := &DirsProvider{
 		UserHomeDirFunc:   dt.UserHomeDir,
 		UserConfigDirFunc: dt.UserConfigDir,
+		UserCacheDirFunc:  dt.UserCacheDir,
+		UserStateDirFunc:  UserStateDir,
 		GetwdFunc:         dt.Getwd,
 		ProjectDirFunc: func() (dt.DirPath, error) {
 			return dt.Getwd()
@@ -123,19 +332,46 @@ func DefaultDirsProvider() *DirsProvider {
 	dp.CLIConfigDirFunc = dp.CLIConfigDirType
 	return dp
 }
-func NewConfigStore(dirType DirType, args ConfigStoreArgs) ConfigStore {
+
+// NewConfigStore returns a ConfigStore for dirType configured by opts, which
+// may be a ConfigStoreArgs, functional options such as WithSlug and
+// WithRelFilepath, or both:
+//
+//	store := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+//		cfgstore.WithSlug("myapp"),
+//		cfgstore.WithRelFilepath("config.json"),
+//		cfgstore.WithFileMode(0600),
+//	)
+func NewConfigStore(dirType DirType, opts ...ConfigStoreOption) ConfigStore {
+	var args ConfigStoreArgs
+
 	if dirType == UnspecifiedConfigDirType {
 		panic("NewConfigStore: DirType is Unspecified")
 	}
+	for _, opt := range opts {
+		opt.applyToConfigStoreArgs(&args)
+	}
 	if args.DirsProvider == nil {
 		args.DirsProvider = DefaultDirsProvider()
 	}
-	return &configStore{
-		dirType:      dirType,
-		configSlug:   args.ConfigSlug,
-		relFilepath:  args.RelFilepath,
-		dirsProvider: args.DirsProvider,
-	}
+	cs := &configStore{
+		dirType:         dirType,
+		configSlug:      args.ConfigSlug,
+		dirsProvider:    args.DirsProvider,
+		stat:            newStatCache(args.CacheStat),
+		codec:           args.Codec,
+		fileMode:        args.FileMode,
+		scaffold:        args.Scaffold,
+		tenant:          args.Tenant,
+		dir:             &configDirCache{},
+		filepath:        &filepathCache{},
+		placeholders:    newPlaceholders(args.AppInfo, args.Placeholders),
+		legacyFilepaths: args.LegacyFilepaths,
+		writeExample:    args.WriteExample,
+		exampleComments: args.ExampleComments,
+	}
+	cs.setRelFilepath(args.RelFilepath)
+	return cs
 }
 
 // CLIConfigDirType returns the absolute of either ~/.config/ or XDG_CONFIG_HOME on Linux
@@ -162,12 +398,29 @@ end:
 }
 
 func (cs *configStore) ConfigDir() (dir dt.DirPath, err error) {
-	if cs.configDir != "" {
+	cs.dir.mu.Lock()
+	defer cs.dir.mu.Unlock()
+	return cs.configDirLocked()
+}
+
+// configDirLocked is ConfigDir for callers holding cs.dir.mu.
+func (cs *configStore) configDirLocked() (dir dt.DirPath, err error) {
+	if cs.dir.path != "" {
+		goto end
+	}
+	if cs.tenant != "" {
+		err = validateTenant(cs.tenant)
+		if err != nil {
+			goto end
+		}
+	}
+	dir, err = ConfigDir(cs.dirType, cs.configSlug, cs.dirsProvider)
+	if err != nil {
 		goto end
 	}
-	cs.configDir, err = ConfigDir(cs.dirType, cs.configSlug, cs.dirsProvider)
+	cs.dir.path = joinTenant(dir, cs.tenant)
 end:
-	return cs.configDir, err
+	return cs.dir.path, err
 }
 
 func (cs *configStore) ConfigStore() {}
@@ -176,21 +429,34 @@ func (cs *configStore) ConfigPath() dt.PathSegment {
 	return cs.configSlug
 }
 
+// SetRelFilepath sets the store's file to rf, expanding its placeholders. See
+// ConfigStoreArgs.Placeholders.
 func (cs *configStore) SetRelFilepath(rf dt.RelFilepath) {
-	cs.relFilepath = rf
+	cs.setRelFilepath(rf)
+	cs.Invalidate()
 }
 
+// GetRelFilepath returns the store's file with its placeholders expanded.
 func (cs *configStore) GetRelFilepath() dt.RelFilepath {
 	return cs.relFilepath
 }
 
-func (cs *configStore) GetFilepath() (fp dt.Filepath, err error) {
+func (cs *configStore) GetFilepath() (dt.Filepath, error) {
+	return cs.filepath.resolve(cs.resolveFilepath)
+}
+
+// resolveFilepath joins the store's config dir and file. GetFilepath memoizes it.
+func (cs *configStore) resolveFilepath() (fp dt.Filepath, err error) {
 	var dir dt.DirPath
 
 	dir, err = cs.ConfigDir()
 	if err != nil {
 		goto end
 	}
+	if cs.relFilepathErr != nil {
+		err = cs.relFilepathErr
+		goto end
+	}
 
 	if !cs.relFilepath.ValidPath() {
 		err = NewErr(
@@ -208,52 +474,194 @@ end:
 }
 
 func (cs *configStore) Save(data []byte) (err error) {
-	var file *os.File
-	var fullPath dt.Filepath
+	return cs.SaveContext(context.Background(), data)
+}
 
-	fullPath, err = cs.ensureFilepath()
+// SaveContext is Save with a context that is checked before the file is written
+// and passed to the store's FileSystem if it is a ContextFileSystem.
+func (cs *configStore) SaveContext(ctx context.Context, data []byte) (err error) {
+	return cs.save(ctx, &SaveHookArgs{Data: data}, nil)
+}
+
+func (cs *configStore) SaveJSON(data any) (err error) {
+	return cs.SaveJSONContext(context.Background(), data)
+}
+
+// SaveJSONContext is SaveJSON with a context, as for SaveContext.
+func (cs *configStore) SaveJSONContext(ctx context.Context, data any) (err error) {
+	var buf *bytes.Buffer
+
+	if cs.codec != nil {
+		err = cs.save(ctx, &SaveHookArgs{Value: data}, cs.codec.Marshal)
+		goto end
+	}
+	if len(cs.hooks.postSave) > 0 {
+		err = cs.save(ctx, &SaveHookArgs{Value: data}, marshalIndented)
+		goto end
+	}
+	// Without post-save hooks nothing can keep a reference to the marshaled
+	// content once it is written, so it can be marshaled into a pooled buffer
+	buf = writeBufferPool.Get().(*bytes.Buffer)
+	defer releaseWriteBuffer(buf)
+	err = cs.save(ctx, &SaveHookArgs{Value: data}, func(value any) ([]byte, error) {
+		return marshalIndentedTo(buf, value)
+	})
+end:
+	return err
+}
+
+// marshalIndented is the marshal func used by SaveJSON.
+func marshalIndented(value any) ([]byte, error) {
+	// Use JSON v2 with pretty printing via jsontext.WithIndent
+	return jsonv2.Marshal(value, jsontext.WithIndent("  "))
+}
+
+// marshalIndentedTo marshals value as marshalIndented does, into buf, and
+// returns buf's content.
+func marshalIndentedTo(buf *bytes.Buffer, value any) (data []byte, err error) {
+	err = jsonv2.MarshalWrite(buf, value, jsontext.WithIndent("  "))
+	if err == nil {
+		data = buf.Bytes()
+	}
+	return data, err
+}
+
+// save runs the pre-save hooks, marshals args.Value with marshal unless it is
+// nil, writes args.Data to the store's file and then runs the post-save hooks.
+func (cs *configStore) save(ctx context.Context, args *SaveHookArgs, marshal func(any) ([]byte, error)) (err error) {
+	start := time.Now()
+	ctx, span := cs.startSpan(ctx, SaveSpanName)
+	args.Store = cs
+	args.Context = ctx
+	args.Filepath, err = cs.GetFilepath()
 	if err != nil {
 		goto end
 	}
 
-	file, err = dt.CreateFile(fullPath)
+	err = runSaveHooks(cs.hooks.preSave, args)
+	if err != nil {
+		err = NewErr(ErrPreSaveHookFailed, err)
+		goto end
+	}
+
+	if marshal != nil {
+		args.Data, err = marshal(args.Value)
+		if err != nil {
+			goto end
+		}
+	}
+
+	err = cs.writeFile(ctx, args.Filepath, args.Data)
 	if err != nil {
 		goto end
 	}
-	defer CloseOrLog(file)
+	cs.stat.set(true)
 
-	_, err = file.Write(data)
+	err = runSaveHooks(cs.hooks.postSave, args)
+	if err != nil {
+		err = NewErr(ErrPostSaveHookFailed, err)
+		goto end
+	}
 
 end:
+	err = withErrorKind(err)
+	cs.recordOperation(SaveEventKind, start, len(args.Data), err)
+	span.End(err)
 	return err
 }
 
-func (cs *configStore) SaveJSON(data any) (err error) {
-	var jsonData []byte
+func (cs *configStore) writeFile(ctx context.Context, fp dt.Filepath, data []byte) (err error) {
+	fSys := cs.FileSystem()
 
-	// Use JSON v2 with pretty printing via jsontext.WithIndent
-	jsonData, err = jsonv2.Marshal(data, jsontext.WithIndent("  "))
+	err = contextErr(ctx)
 	if err != nil {
 		goto end
 	}
-
-	err = cs.Save(jsonData)
+	// This is needed in case filepath contains a subdirectory, e.g. tokens/token-bill@microsoft.com.json
+	err = fSys.MkdirAll(fp.Dir())
+	if err != nil {
+		goto end
+	}
+	if cs.fileMode != 0 {
+		err = WriteFileMode(fSys, fp, data, cs.fileMode)
+		goto end
+	}
+	err = writeFileContext(ctx, fSys, fp, data)
 
 end:
 	return err
 }
 
 func (cs *configStore) Load() (data []byte, err error) {
+	return cs.LoadContext(context.Background())
+}
+
+// LoadContext is Load with a context that is checked before the file is read
+// and passed to the store's FileSystem if it is a ContextFileSystem. A call
+// waiting for a concurrent load of the same file to finish gives up when ctx is
+// done.
+func (cs *configStore) LoadContext(ctx context.Context) (data []byte, err error) {
+	var args *LoadHookArgs
+
+	start := time.Now()
+	ctx, span := cs.startSpan(ctx, LoadSpanName)
+	args, err = cs.sharedLoad(ctx, false)
+	if err != nil {
+		goto end
+	}
+
+	err = runLoadHooks(cs.hooks.postLoad, args)
+	if err != nil {
+		err = NewErr(ErrPostLoadHookFailed, err)
+		goto end
+	}
+	data = args.Data
+
+end:
+	err = withErrorKind(err)
+	cs.recordOperation(LoadEventKind, start, len(data), err)
+	span.End(err)
+	return data, err
+}
+
+// load reads the store's file and runs the pre-load hooks on its content. If
+// pooled, the content is read into a buffer from readBufferPool that the caller
+// must release once nothing refers to it. A ContextFileSystem is read through
+// ReadFileContext, which is never pooled.
+func (cs *configStore) load(ctx context.Context, pooled bool) (args *LoadHookArgs, err error) {
 	var fSys fs.FS
+	var data []byte
+	var fp dt.Filepath
 
+	err = contextErr(ctx)
+	if err != nil {
+		goto end
+	}
+	fp, err = cs.GetFilepath()
+	if err != nil {
+		goto end
+	}
 	fSys, err = cs.getFS()
 	if err != nil {
 		err = WithErr(ErrFailedToGetConfigFileSystem, err)
 		goto end
 	}
 
-	data, err = cs.relFilepath.ReadFile(fSys)
-	if NoSuchFileOrDirectory(err) {
+	if cfs, ok := cs.FileSystem().(ContextFileSystem); ok {
+		data, err = readFileContext(ctx, cfs, fp)
+	} else if pooled {
+		data, err = readPooledFile(fSys, string(cs.relFilepath))
+	} else {
+		data, err = cs.relFilepath.ReadFile(fSys)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		legacyFp, found := cs.legacyFilepath()
+		if found {
+			fp = legacyFp
+			data, err = cs.FileSystem().ReadFile(fp)
+		}
+	}
+	if errors.Is(err, fs.ErrNotExist) {
 		err = NewErr(ErrFileDoesNotExist, err)
 	}
 	if err != nil {
@@ -261,39 +669,101 @@ func (cs *configStore) Load() (data []byte, err error) {
 		goto end
 	}
 
+	args = &LoadHookArgs{
+		Store:     cs,
+		Filepath:  fp,
+		Context:   ctx,
+		Data:      data,
+		ForUpdate: isForUpdate(ctx),
+	}
+
+	err = runLoadHooks(cs.hooks.preLoad, args)
+	if err != nil {
+		err = NewErr(ErrPreLoadHookFailed, err)
+		goto end
+	}
+
 end:
-	return data, err
+	return args, err
 }
 
 func (cs *configStore) LoadJSON(data any, opts ...jsonv2.Options) (err error) {
-	var jsonData []byte
-	jsonData, err = cs.Load()
+	return cs.LoadJSONContext(context.Background(), data, opts...)
+}
+
+// LoadJSONContext is LoadJSON with a context, as for LoadContext.
+func (cs *configStore) LoadJSONContext(ctx context.Context, data any, opts ...jsonv2.Options) (err error) {
+	_, err = cs.loadJSON(ctx, data, false, opts...)
+	return err
+}
+
+// loadJSON loads the store's file into data and, if keep, also returns the
+// content data was unmarshaled from, e.g. to parse it as a document without
+// reading the file again.
+func (cs *configStore) loadJSON(ctx context.Context, data any, keep bool, opts ...jsonv2.Options) (raw []byte, err error) {
+	var args *LoadHookArgs
+	var size int
+
+	start := time.Now()
+	ctx, span := cs.startSpan(ctx, LoadSpanName)
+	// Without hooks nothing can keep a reference to the file's content once it
+	// is unmarshaled, so it can be read into a pooled buffer
+	pooled := !keep && len(cs.hooks.preLoad) == 0 && len(cs.hooks.postLoad) == 0
+	args, err = cs.sharedLoad(ctx, pooled)
 	if err != nil {
 		err = NewErr(ErrFailedToReadConfigFile, err)
 		goto end
 	}
+	size = len(args.Data)
 
-	// Use JSON v2 with any provided options (including custom unmarshalers)
-	err = jsonv2.Unmarshal(jsonData, data, opts...)
+	if cs.codec != nil {
+		err = cs.codec.Unmarshal(args.Data, data)
+	} else {
+		// Use JSON v2 with any provided options (including custom unmarshalers)
+		err = jsonv2.Unmarshal(args.Data, data, opts...)
+	}
+	if pooled {
+		releaseReadBuffer(args.Data)
+		args.Data = nil
+	}
 	if err != nil {
 		err = NewErr(ErrFailedToUnmarshalConfigFile, err)
 		goto end
 	}
+	if keep {
+		raw = args.Data
+	}
+
+	args.Value = data
+	err = runLoadHooks(cs.hooks.postLoad, args)
+	if err != nil {
+		err = NewErr(ErrPostLoadHookFailed, err)
+		goto end
+	}
 
 end:
 	if err != nil {
-		err = WithErr(err, ErrFailedToLoadJSON)
+		err = withErrorKind(WithErr(err, ErrFailedToLoadJSON))
 	}
-	return err
+	cs.recordOperation(LoadEventKind, start, size, err)
+	span.End(err)
+	return raw, err
 }
 
+// Exists stats the store's resolved filepath directly, rather than through its
+// fs.FS, because CLIs call it in hot loops.
 func (cs *configStore) Exists() (exists bool) {
-	fSys, err := cs.getFS()
+	exists, ok := cs.stat.get()
+	if ok {
+		return exists
+	}
+	fp, err := cs.GetFilepath()
 	if err != nil {
 		goto end
 	}
-	_, err = cs.relFilepath.Stat(fSys)
+	_, err = cs.FileSystem().Stat(fp)
 	exists = err == nil
+	cs.stat.set(exists)
 
 end:
 	return exists
@@ -301,20 +771,32 @@ end:
 
 // SetConfigDir allows overriding config dir for unit testing.
 func (cs *configStore) SetConfigDir(dir dt.DirPath) {
-	cs.configDir = dir
-	cs.fs = dt.DirFS(dir)
+	cs.dir.set(dir, cs.FileSystem().DirFS(dir))
+	cs.Invalidate()
 }
 
-// EnsureDirs creates the specified subdirectories under this ConfigStore's config directory.
-// This is a convenience method that delegates to EnsureConfigDirs function.
+// EnsureDirs creates the specified subdirectories under this ConfigStore's config
+// directory using the store's FileSystem.
 func (cs *configStore) EnsureDirs(subdirs []dt.PathSegment) (err error) {
 	var configDir dt.DirPath
+	var errs []error
 
 	configDir, err = cs.ConfigDir()
 	if err != nil {
 		goto end
 	}
-	err = EnsureConfigDirs(configDir, subdirs)
+	for _, dir := range subdirs {
+		dirPath := dt.DirPathJoin(configDir, dir)
+		err = cs.FileSystem().MkdirAll(dirPath)
+		if err != nil {
+			errs = append(errs, dt.NewErr(
+				dt.ErrFailedToMakeDirectory,
+				err,
+				"dir", dirPath,
+			))
+		}
+	}
+	err = dt.CombineErrs(errs)
 
 end:
 	return err
@@ -323,9 +805,67 @@ end:
 func (cs *configStore) WithDirType(dt DirType) ConfigStore {
 	store := *cs
 	store.dirType = dt
+	store.dir = cs.dir.clone()
+	store.filepath = &filepathCache{}
+	store.stat = newStatCache(cs.stat != nil)
+	return &store
+}
+
+// WithRelFilepath returns a copy of the store for the file rf, e.g. the same
+// slug with a different file, leaving cs unchanged. Unlike SubStore, hooks are
+// copied. LegacyFilepaths, being for cs's file, are not.
+func (cs *configStore) WithRelFilepath(rf dt.RelFilepath) ConfigStore {
+	store := *cs
+	store.legacyFilepaths = nil
+	store.dir = cs.dir.clone()
+	store.filepath = &filepathCache{}
+	store.setRelFilepath(rf)
+	store.stat = newStatCache(cs.stat != nil)
+	return &store
+}
+
+// WithConfigSlug returns a copy of the store for slug's config dir, leaving cs
+// unchanged. A config dir set with SetConfigDir is not copied. Hooks are copied.
+func (cs *configStore) WithConfigSlug(slug dt.PathSegment) ConfigStore {
+	store := *cs
+	store.configSlug = slug
+	store.dir = &configDirCache{}
+	store.filepath = &filepathCache{}
+	store.stat = newStatCache(cs.stat != nil)
 	return &store
 }
 
+// SubStore returns a ConfigStore for another file, e.g. "tokens/alice.json", in
+// the same config directory as cs, sharing its DirsProvider and DirType. Hooks
+// registered on cs are not copied.
+func (cs *configStore) SubStore(rf dt.RelFilepath) ConfigStore {
+	sub := &configStore{
+		configSlug:   cs.configSlug,
+		dir:          cs.dir.clone(),
+		dirType:      cs.dirType,
+		dirsProvider: cs.dirsProvider,
+		filepath:     &filepathCache{},
+		stat:         newStatCache(cs.stat != nil),
+		codec:        cs.codec,
+		fileMode:     cs.fileMode,
+		tenant:       cs.tenant,
+		placeholders: cs.placeholders,
+	}
+	sub.setRelFilepath(rf)
+	return sub
+}
+
+// SubStore returns a ConfigStore for the file rf in store's config directory.
+// If store is not a SubStorer it is a copy of store, see WithDirType, for rf.
+func SubStore(store ConfigStore, rf dt.RelFilepath) ConfigStore {
+	if ss, ok := store.(SubStorer); ok {
+		return ss.SubStore(rf)
+	}
+	sub := store.WithDirType(store.DirType())
+	sub.SetRelFilepath(rf)
+	return sub
+}
+
 func (cs *configStore) DirType() DirType {
 	return cs.dirType
 }
@@ -334,8 +874,10 @@ func (cs *configStore) ConfigSlug() dt.PathSegment {
 	return cs.configSlug
 }
 
-func (cs *configStore) ensureConfig(rc RootConfig, dirType DirType, opts Options) (err error) {
-	err = cs.loadConfigIfExists(rc, dirType, opts)
+// ensureConfig loads the store's config into rc, creating it if it does not
+// exist or has no settings, in which case created is true.
+func (cs *configStore) ensureConfig(ctx context.Context, rc RootConfig, dirType DirType, opts Options) (created bool, err error) {
+	err = cs.loadConfigIfExists(ctx, rc, dirType, opts)
 	if err != nil {
 		// A real error occurred, bail out
 		goto end
@@ -343,17 +885,25 @@ func (cs *configStore) ensureConfig(rc RootConfig, dirType DirType, opts Options
 
 	if rc == nil || dtx.IsZero(rc) {
 		// Config not loaded, need to create config
-		err = cs.createConfig(rc, dirType, opts)
-		goto end
+		err = cs.createConfig(ctx, rc, dirType, opts)
+		created = err == nil
+		if err != nil {
+			goto end
+		}
+	}
+	if cs.writeExample {
+		err = cs.saveExample(rc, dirType, opts)
 	}
 
 end:
-	return err
+	return created, err
 }
 
-func (cs *configStore) createConfig(rc RootConfig, dirType DirType, opts Options) (err error) {
+func (cs *configStore) createConfig(ctx context.Context, rc RootConfig, dirType DirType, opts Options) (err error) {
 	var fp dt.Filepath
 
+	start := time.Now()
+	ctx, span := cs.startSpan(ctx, CreateSpanName)
 	fp, err = cs.GetFilepath()
 	if err != nil {
 		goto end
@@ -366,36 +916,62 @@ func (cs *configStore) createConfig(rc RootConfig, dirType DirType, opts Options
 	if err != nil {
 		goto end
 	}
-	err = cs.SaveJSON(rc)
+	if cs.scaffold != nil {
+		err = cs.saveScaffold(ctx, rc, fp)
+		goto end
+	}
+	err = cs.SaveJSONContext(ctx, rc)
 	if err != nil {
 		goto end
 	}
 end:
+	cs.recordOperation(CreateEventKind, start, 0, err)
+	span.End(err)
 	return err
 }
 
 // InitConfig initializes a new config, returning an error if it already exists
-func (cs *configStore) initConfig(rc RootConfig, dirType DirType, opts Options) (err error) {
+func (cs *configStore) initConfig(rc RootConfig, dirType DirType, opts Options, initOpts InitConfigOptions) (err error) {
 	if cs.Exists() {
 		err = ErrConfigAlreadyExists
 		goto end
 	}
-	err = cs.createConfig(rc, dirType, opts)
+	if len(initOpts.Fields) > 0 {
+		err = PromptConfig(rc, initOpts.Fields, initOpts.Prompter)
+		if err != nil {
+			goto end
+		}
+	}
+	err = cs.createConfig(context.Background(), rc, dirType, opts)
+	if err != nil {
+		goto end
+	}
+	if cs.writeExample {
+		err = cs.saveExample(rc, dirType, opts)
+	}
 end:
 	return err
 }
 
-func (cs *configStore) loadConfigIfExists(rc RootConfig, dirType DirType, opts Options) (err error) {
+func (cs *configStore) loadConfigIfExists(ctx context.Context, rc RootConfig, dirType DirType, opts Options) (err error) {
 	var fp dt.Filepath
+	var found bool
+
 	if !cs.Exists() {
-		goto end
+		// Fall back to the first legacy file that exists, if any
+		fp, found = cs.legacyFilepath()
+		if !found {
+			goto end
+		}
 	}
 
-	err = cs.LoadJSON(rc)
+	err = cs.LoadJSONContext(ctx, rc)
 	if err != nil {
 		goto end
 	}
-	fp, err = cs.GetFilepath()
+	if fp == "" {
+		fp, err = cs.GetFilepath()
+	}
 	if err != nil {
 		goto end
 	}
@@ -419,31 +995,19 @@ end:
 func (cs *configStore) getFS() (_ fs.FS, err error) {
 	var dir dt.DirPath
 
-	if cs.fs != nil {
+	cs.dir.mu.Lock()
+	defer cs.dir.mu.Unlock()
+	if cs.dir.fs != nil {
 		goto end
 	}
 
-	dir, err = cs.ConfigDir()
+	dir, err = cs.configDirLocked()
 	if err != nil {
 		goto end
 	}
 
-	cs.fs = dt.DirFS(dir)
+	cs.dir.fs = cs.FileSystem().DirFS(dir)
 
 end:
-	return cs.fs, err
-}
-
-func (cs *configStore) ensureFilepath() (fp dt.Filepath, err error) {
-	fp, err = cs.GetFilepath()
-	if err != nil {
-		goto end
-	}
-	// This is needed in case filepath contains a subdirectory, e.g. tokens/token-bill@microsoft.com.json
-	err = fp.Dir().MkdirAll(0755)
-	if err != nil {
-		goto end
-	}
-end:
-	return fp, err
+	return cs.dir.fs, err
 }
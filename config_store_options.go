@@ -0,0 +1,152 @@
+package cfgstore
+
+import (
+	"io/fs"
+	"text/template"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-dt/appinfo"
+)
+
+// ConfigStoreOption configures the store returned by NewConfigStore. Options
+// are applied in order. ConfigStoreArgs is itself a ConfigStoreOption that sets
+// each of its non-zero fields, so existing calls passing a ConfigStoreArgs are
+// unchanged and may be combined with functional options.
+type ConfigStoreOption interface {
+	applyToConfigStoreArgs(*ConfigStoreArgs)
+}
+
+// configStoreOptionFunc is the ConfigStoreOption returned by the With*()
+// functions.
+type configStoreOptionFunc func(*ConfigStoreArgs)
+
+func (fn configStoreOptionFunc) applyToConfigStoreArgs(args *ConfigStoreArgs) {
+	fn(args)
+}
+
+func (args ConfigStoreArgs) applyToConfigStoreArgs(to *ConfigStoreArgs) {
+	if args.ConfigSlug != "" {
+		to.ConfigSlug = args.ConfigSlug
+	}
+	if args.RelFilepath != "" {
+		to.RelFilepath = args.RelFilepath
+	}
+	if args.DirsProvider != nil {
+		to.DirsProvider = args.DirsProvider
+	}
+	if args.CacheStat {
+		to.CacheStat = true
+	}
+	if args.Codec != nil {
+		to.Codec = args.Codec
+	}
+	if args.FileMode != 0 {
+		to.FileMode = args.FileMode
+	}
+	if args.Scaffold != nil {
+		to.Scaffold = args.Scaffold
+	}
+	if args.Tenant != "" {
+		to.Tenant = args.Tenant
+	}
+	if args.Placeholders != nil {
+		to.Placeholders = args.Placeholders
+	}
+	if args.LegacyFilepaths != nil {
+		to.LegacyFilepaths = args.LegacyFilepaths
+	}
+	if args.WriteExample {
+		to.WriteExample = true
+	}
+	if args.ExampleComments != nil {
+		to.ExampleComments = args.ExampleComments
+	}
+	if args.AppInfo != nil {
+		to.AppInfo = args.AppInfo
+	}
+}
+
+// WithSlug sets ConfigStoreArgs.ConfigSlug.
+func WithSlug(slug dt.PathSegment) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.ConfigSlug = slug
+	})
+}
+
+// WithRelFilepath sets ConfigStoreArgs.RelFilepath.
+func WithRelFilepath(rf dt.RelFilepath) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.RelFilepath = rf
+	})
+}
+
+// WithDirsProvider sets ConfigStoreArgs.DirsProvider.
+func WithDirsProvider(dp *DirsProvider) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.DirsProvider = dp
+	})
+}
+
+// WithCacheStat sets ConfigStoreArgs.CacheStat.
+func WithCacheStat() ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.CacheStat = true
+	})
+}
+
+// WithCodec sets ConfigStoreArgs.Codec.
+func WithCodec(codec Codec) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.Codec = codec
+	})
+}
+
+// WithFileMode sets ConfigStoreArgs.FileMode.
+func WithFileMode(mode fs.FileMode) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.FileMode = mode
+	})
+}
+
+// WithScaffold sets ConfigStoreArgs.Scaffold.
+func WithScaffold(tmpl *template.Template) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.Scaffold = tmpl
+	})
+}
+
+// WithTenant sets ConfigStoreArgs.Tenant.
+func WithTenant(tenant dt.PathSegment) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.Tenant = tenant
+	})
+}
+
+// WithPlaceholders sets ConfigStoreArgs.Placeholders.
+func WithPlaceholders(placeholders map[string]string) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.Placeholders = placeholders
+	})
+}
+
+// WithAppInfo sets ConfigStoreArgs.AppInfo.
+func WithAppInfo(info appinfo.AppInfo) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.AppInfo = info
+	})
+}
+
+// WithLegacyFilepaths sets ConfigStoreArgs.LegacyFilepaths.
+func WithLegacyFilepaths(fps ...dt.Filepath) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.LegacyFilepaths = fps
+	})
+}
+
+// WithExample sets ConfigStoreArgs.WriteExample and ExampleComments.
+func WithExample(comments map[string]string) ConfigStoreOption {
+	return configStoreOptionFunc(func(args *ConfigStoreArgs) {
+		args.WriteExample = true
+		args.ExampleComments = comments
+	})
+}
@@ -1,7 +1,12 @@
 package cfgstore
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/mikeschinkel/go-dt"
 	"github.com/mikeschinkel/go-dt/dtx"
@@ -15,6 +20,11 @@ type ConfigStores struct {
 	DirTypes []DirType
 	StoreMap ConfigStoreMap
 	//GetwdFunc func() (dt.DirPath, error)
+
+	// newRootConfig and rootConfigArgs are captured by LoadConfigStores so that
+	// Watch can re-run the same load and merge.
+	newRootConfig  func() RootConfig
+	rootConfigArgs RootConfigArgs
 }
 
 func (stores *ConfigStores) AppConfigStore() (cs ConfigStore) {
@@ -70,8 +80,52 @@ type RootConfigArgs struct {
 	DirTypes     []DirType
 	Options      Options
 	DirsProvider *DirsProvider
+
+	// Interpolate resolves ${path} references between values in the merged
+	// config. See Interpolate.
+	Interpolate bool
+
+	// MaxParallelLoads bounds how many layers are loaded concurrently, which
+	// helps with slow network home directories and remote backends. Defaults
+	// to DefaultMaxParallelLoads; 1 loads the layers one at a time. Layers are
+	// always merged, and their errors reported, in DirTypes order.
+	MaxParallelLoads int
+
+	// RequireExists skips the layers whose files do not exist, unless
+	// LoadPolicies says otherwise, rather than creating them, and fails with
+	// ErrNoConfigFound, listing the paths searched, if no layer is loaded. For
+	// tools that want users to run e.g. "myapp init" first.
+	RequireExists bool
+
+	// LoadPolicies sets, per DirType, whether a layer whose file does not exist
+	// is created, fails the load or is skipped, so callers control which layers
+	// may be written to disk. Unspecified layers are skipped if they are
+	// project config or RequireExists is set, and otherwise created.
+	LoadPolicies map[DirType]LoadPolicy
+
+	// OnFirstRun, if set, is called once the config is loaded the first time
+	// the app runs, i.e. when none of the layers' files existed and no earlier
+	// call was recorded, e.g. to show onboarding exactly once. See
+	// FirstRunMarkerFile.
+	OnFirstRun FirstRunFunc
+
+	// LockLayers takes a shared lock on each layer's file while the layers are
+	// loaded and merged, so the config is a consistent snapshot even if another
+	// process is updating a layer with UpdateJSON or another FileSystem.Lock
+	// holder. LockTimeout is how long to wait for each lock, and defaults to
+	// DefaultLockTimeout.
+	LockLayers  bool
+	LockTimeout time.Duration
+
+	// Results, if not nil, is filled in with what was found for each layer,
+	// even if the load fails. See LoadResults.
+	Results *LoadResults
 }
 
+// DefaultMaxParallelLoads is how many layers LoadConfigStores loads
+// concurrently when RootConfigArgs.MaxParallelLoads is zero.
+const DefaultMaxParallelLoads = 4
+
 type RootConfigPtr[RC any] interface {
 	RootConfig
 	*RC
@@ -93,8 +147,16 @@ func makeRootConfig[RC any, PRC RootConfigPtr[RC]]() PRC {
 // For simpler use cases, consider using LoadConfig, LoadCLIConfig, LoadProjectConfig,
 // or LoadDefaultConfig instead.
 func LoadConfigStores[RC any, PRC RootConfigPtr[RC]](stores *ConfigStores, args RootConfigArgs) (prc PRC, err error) {
-	var cs *configStore
-	var errs []error
+	return LoadConfigStoresContext[RC, PRC](context.Background(), stores, args)
+}
+
+// LoadConfigStoresContext is LoadConfigStores with a context that is passed to
+// each layer's load, and save if the layer's config is created. No further
+// layers are loaded once ctx is done.
+func LoadConfigStoresContext[RC any, PRC RootConfigPtr[RC]](ctx context.Context, stores *ConfigStores, args RootConfigArgs) (prc PRC, err error) {
+	var rc RootConfig
+	var results *LoadResults
+	var ok bool
 
 	if len(args.DirTypes) == 0 {
 		args.DirTypes = []DirType{
@@ -102,61 +164,236 @@ func LoadConfigStores[RC any, PRC RootConfigPtr[RC]](stores *ConfigStores, args
 			ProjectConfigDirType,
 		}
 	}
-
-	rcMap := make(map[DirType]PRC, len(args.DirTypes))
-	for dirType, store := range stores.StoreMap {
-		cs = store.(*configStore)
-		if args.DirsProvider != nil {
-			cs.dirsProvider = args.DirsProvider
+	stores.newRootConfig = func() RootConfig {
+		return makeRootConfig[RC, PRC]()
+	}
+	stores.rootConfigArgs = args
+	if args.DirsProvider != nil {
+		for _, store := range stores.StoreMap {
+			store.(*configStore).dirsProvider = args.DirsProvider
 		}
-		tmpPRC := makeRootConfig[RC, PRC]()
-		switch dirType {
-		case ProjectConfigDirType:
-			err = cs.loadConfigIfExists(tmpPRC, dirType, args.Options)
-			if err == nil && (tmpPRC == nil || dtx.IsZero(tmpPRC)) {
-				rcMap[dirType] = nil
-				continue
-			}
-		default:
-			err = cs.ensureConfig(tmpPRC, dirType, args.Options)
+	}
+	if po, ok := args.Options.(PlaceholderOptions); ok {
+		for _, store := range stores.StoreMap {
+			store.(*configStore).setPlaceholders(po.Placeholders())
 		}
+	}
+
+	results = args.Results
+	if results == nil && args.OnFirstRun != nil {
+		results = &LoadResults{}
+	}
+	rc, err = stores.loadRootConfig(ctx, args, results)
+	if err != nil {
+		goto end
+	}
+	if args.OnFirstRun != nil {
+		err = stores.handleFirstRun(ctx, rc, args, results)
+		if err != nil {
+			goto end
+		}
+	}
+	prc, ok = rc.(PRC)
+	if !ok {
+		err = NewErr(
+			ErrUnexpectedRootConfigType,
+			"expected_type", fmt.Sprintf("%T", prc),
+			"actual_type", fmt.Sprintf("%T", rc),
+		)
+		goto end
+	}
+end:
+	return prc, err
+}
+
+// loadRootConfig loads each store's config and merges them using the RootConfig
+// constructor captured by LoadConfigStores and args. results, if not nil, is
+// filled in even if the load fails.
+func (stores *ConfigStores) loadRootConfig(ctx context.Context, args RootConfigArgs, results *LoadResults) (rc RootConfig, err error) {
+	var errs []error
+	var rcMap RootConfigMap
+	var layers []loadedLayer
+	var unlock func()
+	var mergeSpan Span
+
+	ctx, span := startSpan(ctx, SpanArgs{Name: LoadConfigSpanName, DirTypes: args.DirTypes})
+	if args.LockLayers {
+		unlock, err = stores.lockLayers(ctx, args)
 		if err != nil {
-			fp, _ := cs.GetFilepath()
-			errs = append(errs, NewErr(
-				ErrFailedToEnsureConfig,
-				"filepath", fp,
-				err,
-			))
+			err = withErrorKind(err)
+			goto end
+		}
+		defer unlock()
+	}
+	rcMap = make(RootConfigMap, len(args.DirTypes))
+	layers = stores.loadLayers(ctx, args)
+	if results != nil {
+		results.Layers = make([]LayerLoadResult, len(layers))
+		for i, layer := range layers {
+			results.Layers[i] = layer.result
+		}
+	}
+	for _, layer := range layers {
+		if layer.err != nil {
+			errs = append(errs, layer.err)
 			continue
 		}
-		rcMap[dirType] = tmpPRC
+		if layer.rc != nil {
+			rcMap[layer.dirType] = layer.rc
+		}
 	}
 	err = CombineErrs(errs)
 	if err != nil {
 		goto end
 	}
+	if args.RequireExists && len(rcMap) == 0 {
+		err = withErrorKind(noConfigFoundErr(layers))
+		goto end
+	}
 
-	prc, err = mergeRootConfigs[RC, PRC](rcMap, args)
+	_, mergeSpan = startSpan(ctx, SpanArgs{Name: MergeSpanName, DirTypes: args.DirTypes})
+	rc, err = mergeRootConfigs(rcMap, args)
+	mergeSpan.End(err)
+	if err != nil {
+		goto end
+	}
+	if args.Interpolate {
+		err = Interpolate(rc)
+	}
 
 end:
-	return prc, err
+	span.End(err)
+	return rc, err
+}
+
+// loadedLayer is the result of loading the config of one of stores.DirTypes. rc
+// is nil for a project config that does not exist.
+type loadedLayer struct {
+	dirType DirType
+	rc      RootConfig
+	err     error
+	result  LayerLoadResult
+}
+
+// loadLayers loads the config of each of stores.DirTypes, up to
+// args.MaxParallelLoads at a time, returning them in DirTypes order. Layers not
+// yet started when ctx is done fail with its error.
+func (stores *ConfigStores) loadLayers(ctx context.Context, args RootConfigArgs) []loadedLayer {
+	var wg sync.WaitGroup
+
+	limit := args.MaxParallelLoads
+	if limit <= 0 {
+		limit = DefaultMaxParallelLoads
+	}
+	layers := make([]loadedLayer, len(stores.DirTypes))
+	sem := make(chan struct{}, limit)
+	for i, dirType := range stores.DirTypes {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			layers[i] = loadedLayer{
+				dirType: dirType,
+				err:     NewErr(ErrFailedToEnsureConfig, "dir_type", dirType.Slug(), contextErr(ctx)),
+				result:  LayerLoadResult{DirType: dirType},
+			}
+			continue
+		}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			layers[i] = stores.loadLayer(ctx, dirType, args)
+		})
+	}
+	wg.Wait()
+	return layers
+}
+
+func (stores *ConfigStores) loadLayer(ctx context.Context, dirType DirType, args RootConfigArgs) (layer loadedLayer) {
+	var fp dt.Filepath
+	var err error
+
+	cs := stores.StoreMap[dirType].(*configStore)
+	tmpRC := stores.newRootConfig()
+	layer.dirType = dirType
+	result := &layer.result
+	result.DirType = dirType
+	fp, err = cs.GetFilepath()
+	result.Existed = err == nil && cs.Exists()
+	if err == nil && !result.Existed {
+		// A legacy file is loaded in place of the store's, see LegacyFilepaths
+		legacyFp, found := cs.legacyFilepath()
+		if found {
+			fp = legacyFp
+			result.Existed = true
+		}
+	}
+	result.Filepath = fp
+	policy := args.loadPolicy(dirType)
+	switch {
+	case err != nil && policy == SkipIfMissingLoadPolicy:
+		// E.g. not being in a project is not a failure
+		result.Warnings = append(result.Warnings, err)
+		err = nil
+		goto end
+	case err != nil:
+	case policy == CreateLoadPolicy:
+		result.Created, err = cs.ensureConfig(ctx, tmpRC, dirType, args.Options)
+		if result.Created && result.Existed {
+			result.Warnings = append(result.Warnings, NewErr(ErrConfigFileHasNoSettings, "filepath", fp))
+		}
+	case !result.Existed && policy == RequireExistsLoadPolicy:
+		err = NewErr(ErrNoConfigFound, "searched", fp)
+	default:
+		err = cs.loadConfigIfExists(ctx, tmpRC, dirType, args.Options)
+		if err == nil && dtx.IsZero(tmpRC) {
+			if result.Existed {
+				result.Warnings = append(result.Warnings, NewErr(ErrConfigFileHasNoSettings, "filepath", fp))
+			}
+			goto end
+		}
+	}
+	if err != nil {
+		layer.err = NewErr(
+			ErrFailedToEnsureConfig,
+			"filepath", fp,
+			err,
+		)
+		goto end
+	}
+	layer.rc = tmpRC
+end:
+	return layer
+}
+
+// noConfigFoundErr returns ErrNoConfigFound with the paths of layers searched.
+func noConfigFoundErr(layers []loadedLayer) error {
+	var searched []string
+	for _, layer := range layers {
+		if layer.result.Filepath != "" {
+			searched = append(searched, string(layer.result.Filepath))
+		}
+	}
+	return NewErr(ErrNoConfigFound, "searched", strings.Join(searched, ", "))
 }
 
 var ErrNotValidConfigDirsAvailable = errors.New("not valid config dirs available")
 var ErrDirTypeNotAssignAfterMerge = errors.New("dirType not assigned after merge")
+var ErrUnexpectedRootConfigType = errors.New("unexpected root config type")
 
 // mergeRootConfigs also specifying the config stores in a map to enable unit testing
-func mergeRootConfigs[RC any, PRC RootConfigPtr[RC]](rcMap map[DirType]PRC, args RootConfigArgs) (prc PRC, err error) {
+func mergeRootConfigs(rcMap RootConfigMap, args RootConfigArgs) (rc RootConfig, err error) {
 
-	var rc RootConfig
 	var dirType DirType
 	var start, cnt int
+	var merged []DirType
 
 	// First, count the valid configs
 	for _, typ := range args.DirTypes {
 		if rcMap[typ] == nil {
 			continue
 		}
+		merged = append(merged, typ)
 		cnt++
 	}
 
@@ -166,8 +403,7 @@ func mergeRootConfigs[RC any, PRC RootConfigPtr[RC]](rcMap map[DirType]PRC, args
 			continue
 		}
 		// This is our starting config
-		prc = rcMap[typ]
-		rc = RootConfig(rcMap[typ])
+		rc = rcMap[typ]
 		// Skip over this config
 		start = i + 1
 		break
@@ -178,7 +414,7 @@ func mergeRootConfigs[RC any, PRC RootConfigPtr[RC]](rcMap map[DirType]PRC, args
 		goto end
 	}
 	if cnt <= 1 {
-		// If we only found one valid config this is our prc
+		// If we only found one valid config this is our rc
 		goto end
 	}
 	// Now merge the second config with the next, until we have merged all. OTOH, if
@@ -202,8 +438,17 @@ func mergeRootConfigs[RC any, PRC RootConfigPtr[RC]](rcMap map[DirType]PRC, args
 		)
 		goto end
 	}
-	// The last merged config will be the config we return
-	prc = rcMap[dirType]
 end:
-	return prc, err
+	if hasEventListeners() {
+		if dirType == UnspecifiedConfigDirType && len(merged) > 0 {
+			dirType = merged[0]
+		}
+		emitEvent(Event{
+			Kind:     MergeEventKind,
+			DirType:  dirType,
+			DirTypes: merged,
+			Err:      err,
+		})
+	}
+	return rc, err
 }
@@ -0,0 +1,88 @@
+package cfgstore
+
+import (
+	"context"
+	"sync"
+)
+
+// StoresChange describes the merged config produced after a change to one of
+// the files watched by ConfigStores.Watch.
+type StoresChange struct {
+	// RootConfig is the newly merged config, or nil if Err is set.
+	RootConfig RootConfig
+
+	// DirType identifies the layer whose file changed.
+	DirType DirType
+
+	// Missing reports that the layer's file does not exist, e.g. because it was
+	// deleted. Watch never creates a layer's file, even one LoadConfigStores
+	// would, so the layer is left out of RootConfig until the file is restored.
+	Missing bool
+
+	// Err is set when the changed file could not be loaded or the configs could
+	// not be merged. The previously delivered RootConfig remains valid.
+	Err error
+}
+
+// StoresWatchFunc is called by ConfigStores.Watch after each re-merge.
+type StoresWatchFunc func(StoresChange)
+
+// Watch watches the file of every store in stores and, whenever one changes,
+// re-runs the load and merge performed by LoadConfigStores and passes the new
+// merged RootConfig to fn along with the DirType of the layer that changed.
+// Unlike LoadConfigStores it does not create missing files: a layer whose file
+// was deleted is reported as Missing and left out of the merge. LoadConfigStores
+// must have been called on stores first.
+//
+// Watch blocks until ctx is canceled and returns once any in-progress call to fn
+// has returned. Calls to fn are never concurrent.
+func (stores *ConfigStores) Watch(ctx context.Context, fn StoresWatchFunc, args ...WatcherArgs) (err error) {
+	var watchers []*Watcher
+	var reloadArgs RootConfigArgs
+	var mutex sync.Mutex
+
+	if stores.newRootConfig == nil {
+		err = NewErr(ErrConfigStoresNotLoaded)
+		goto end
+	}
+	if len(args) == 0 {
+		args = []WatcherArgs{{}}
+	}
+	reloadArgs = stores.rootConfigArgs.withoutCreate()
+	for _, dirType := range stores.DirTypes {
+		w := NewWatcher(stores.StoreMap[dirType], args[0])
+		w.OnChange(func(ev WatchEvent) {
+			var change StoresChange
+			var results LoadResults
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			change.DirType = dirType
+			change.RootConfig, change.Err = stores.loadRootConfig(ctx, reloadArgs, &results)
+			if ctx.Err() != nil {
+				// Watch is returning, so the reload was abandoned
+				return
+			}
+			layer, _ := results.Layer(dirType)
+			change.Missing = !layer.Existed
+			if change.Err != nil {
+				change.RootConfig = nil
+				change.Err = WithErr(change.Err, ErrFailedToReloadConfig, "dir_type", dirType.Slug())
+			}
+			fn(change)
+		})
+		err = w.StartContext(ctx)
+		if err != nil {
+			err = WithErr(err, "dir_type", dirType.Slug())
+			goto end
+		}
+		watchers = append(watchers, w)
+	}
+	<-ctx.Done()
+end:
+	for _, w := range watchers {
+		w.Stop()
+	}
+	return err
+}
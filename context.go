@@ -0,0 +1,172 @@
+package cfgstore
+
+import (
+	"context"
+	jsonv2 "encoding/json/v2"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ContextConfigStore is implemented by a ConfigStore whose loads and saves take
+// a context, e.g. to bound a read from a remote FileSystem. See LoadContext.
+type ContextConfigStore interface {
+	LoadContext(ctx context.Context) ([]byte, error)
+	SaveContext(ctx context.Context, data []byte) error
+	LoadJSONContext(ctx context.Context, data any, opts ...jsonv2.Options) error
+	SaveJSONContext(ctx context.Context, data any) error
+}
+
+// LoadContext is store.Load with a context. If store is not a
+// ContextConfigStore ctx is only checked before the load.
+func LoadContext(ctx context.Context, store ConfigStore) (data []byte, err error) {
+	if ccs, ok := store.(ContextConfigStore); ok {
+		return ccs.LoadContext(ctx)
+	}
+	err = contextErr(ctx)
+	if err != nil {
+		goto end
+	}
+	data, err = store.Load()
+end:
+	return data, err
+}
+
+// SaveContext is store.Save with a context, see LoadContext.
+func SaveContext(ctx context.Context, store ConfigStore, data []byte) (err error) {
+	if ccs, ok := store.(ContextConfigStore); ok {
+		return ccs.SaveContext(ctx, data)
+	}
+	err = contextErr(ctx)
+	if err != nil {
+		goto end
+	}
+	err = store.Save(data)
+end:
+	return err
+}
+
+// LoadJSONContext is store.LoadJSON with a context, see LoadContext.
+func LoadJSONContext(ctx context.Context, store ConfigStore, data any, opts ...jsonv2.Options) (err error) {
+	if ccs, ok := store.(ContextConfigStore); ok {
+		return ccs.LoadJSONContext(ctx, data, opts...)
+	}
+	err = contextErr(ctx)
+	if err != nil {
+		goto end
+	}
+	err = store.LoadJSON(data, opts...)
+end:
+	return err
+}
+
+// SaveJSONContext is store.SaveJSON with a context, see LoadContext.
+func SaveJSONContext(ctx context.Context, store ConfigStore, data any) (err error) {
+	if ccs, ok := store.(ContextConfigStore); ok {
+		return ccs.SaveJSONContext(ctx, data)
+	}
+	err = contextErr(ctx)
+	if err != nil {
+		goto end
+	}
+	err = store.SaveJSON(data)
+end:
+	return err
+}
+
+// ContextFileSystem is implemented by a FileSystem whose operations can block,
+// e.g. one backed by a remote service, so that the *Context methods of a
+// ConfigStore can pass their context to it. Reads and writes of a FileSystem
+// that does not implement it cannot be interrupted once started, but the
+// context is still checked before each one.
+type ContextFileSystem interface {
+	ReadFileContext(ctx context.Context, fp dt.Filepath) ([]byte, error)
+	WriteFileContext(ctx context.Context, fp dt.Filepath, data []byte) error
+	LockContext(ctx context.Context, fp dt.Filepath, timeout time.Duration) (unlock func(), err error)
+}
+
+// contextErr returns ctx's error, wrapped so that it matches both
+// context.Canceled or context.DeadlineExceeded and ErrContextDone, or nil if
+// ctx is not done.
+func contextErr(ctx context.Context) (err error) {
+	err = ctx.Err()
+	if err != nil {
+		err = NewErr(ErrContextDone, err)
+	}
+	return err
+}
+
+// readFileContext reads fp with cfs in a ReadFileSpanName span.
+func readFileContext(ctx context.Context, cfs ContextFileSystem, fp dt.Filepath) (data []byte, err error) {
+	ctx, span := startSpan(ctx, SpanArgs{Name: ReadFileSpanName, Filepath: fp})
+	data, err = cfs.ReadFileContext(ctx, fp)
+	span.End(err)
+	return data, err
+}
+
+// writeFileContext writes data to fp on fSys, passing ctx to it if it is a
+// ContextFileSystem, in a WriteFileSpanName span.
+func writeFileContext(ctx context.Context, fSys FileSystem, fp dt.Filepath, data []byte) (err error) {
+	var span Span
+
+	err = contextErr(ctx)
+	if err != nil {
+		goto end
+	}
+	if cfs, ok := fSys.(ContextFileSystem); ok {
+		ctx, span = startSpan(ctx, SpanArgs{Name: WriteFileSpanName, Filepath: fp})
+		err = cfs.WriteFileContext(ctx, fp, data)
+		span.End(err)
+		goto end
+	}
+	err = fSys.WriteFile(fp, data)
+end:
+	return err
+}
+
+// LockContext acquires an exclusive lock on fp on fSys as FileSystem.Lock does,
+// but also gives up when ctx is done. If fSys is not a ContextFileSystem the
+// lock is waited for in the background, and released as soon as it is
+// acquired, after LockContext gives up. A ContextFileSystem's lock is acquired
+// in a LockSpanName span.
+func LockContext(ctx context.Context, fSys FileSystem, fp dt.Filepath, timeout time.Duration) (unlock func(), err error) {
+	type lockResult struct {
+		unlock func()
+		err    error
+	}
+	var locked chan lockResult
+	var result lockResult
+	var span Span
+
+	err = contextErr(ctx)
+	if err != nil {
+		err = NewErr(ErrFailedToLockFile, "lock_file", lockFilepath(fp), err)
+		goto end
+	}
+	if cfs, ok := fSys.(ContextFileSystem); ok {
+		ctx, span = startSpan(ctx, SpanArgs{Name: LockSpanName, Filepath: fp})
+		unlock, err = cfs.LockContext(ctx, fp, timeout)
+		span.End(err)
+		goto end
+	}
+	locked = make(chan lockResult, 1)
+	go func() {
+		var r lockResult
+		r.unlock, r.err = fSys.Lock(fp, timeout)
+		locked <- r
+	}()
+	select {
+	case result = <-locked:
+		unlock, err = result.unlock, result.err
+	case <-ctx.Done():
+		err = NewErr(ErrFailedToLockFile, "lock_file", lockFilepath(fp), contextErr(ctx))
+		go func() {
+			r := <-locked
+			if r.err == nil {
+				r.unlock()
+			}
+		}()
+	}
+end:
+	return unlock, withErrorKind(err)
+}
@@ -15,6 +15,25 @@ func LoadCLIConfig[RC any, PRC RootConfigPtr[RC]](args LoadConfigArgs) (PRC, err
 	return LoadConfig[RC, PRC](args)
 }
 
+// LoadAppConfig loads configuration from the app config directory only, the
+// OS's per-user config dir as returned by os.UserConfigDir(), e.g.
+// ~/Library/Application Support/<slug> on macOS or %AppData%\<slug> on Windows.
+// This is the convenience function for GUI and GUI-adjacent apps, which users
+// expect to keep their settings where other apps on the platform do, rather than
+// in ~/.config.
+//
+// Example:
+//
+//	config, err := cfgstore.LoadAppConfig[MyConfig, *MyConfig](cfgstore.LoadConfigArgs{
+//	    ConfigSlug: dt.PathSegment("myapp"),
+//	    ConfigFile: dt.RelFilepath("config.json"),
+//	    Options:    myOptions,  // or nil
+//	})
+func LoadAppConfig[RC any, PRC RootConfigPtr[RC]](args LoadConfigArgs) (PRC, error) {
+	args.DirTypes = []DirType{AppConfigDirType}
+	return LoadConfig[RC, PRC](args)
+}
+
 // LoadProjectConfig loads configuration from project directory only (./<slug>).
 // This is a convenience function for the common case of loading only project-specific configuration.
 //
@@ -46,3 +65,19 @@ func LoadDefaultConfig[RC any, PRC RootConfigPtr[RC]](args LoadConfigArgs) (PRC,
 	args.DirTypes = []DirType{CLIConfigDirType, ProjectConfigDirType}
 	return LoadConfig[RC, PRC](args)
 }
+
+// LoadAppDefaultConfig is LoadDefaultConfig for apps that keep their user-level
+// configuration in the app config directory, see LoadAppConfig: it loads App +
+// Project, with Project configuration taking precedence.
+//
+// Example:
+//
+//	config, err := cfgstore.LoadAppDefaultConfig[MyConfig, *MyConfig](cfgstore.LoadConfigArgs{
+//	    ConfigSlug: dt.PathSegment("myapp"),
+//	    ConfigFile: dt.RelFilepath("config.json"),
+//	    Options:    myOptions,  // or nil
+//	})
+func LoadAppDefaultConfig[RC any, PRC RootConfigPtr[RC]](args LoadConfigArgs) (PRC, error) {
+	args.DirTypes = []DirType{AppConfigDirType, ProjectConfigDirType}
+	return LoadConfig[RC, PRC](args)
+}
@@ -0,0 +1,102 @@
+package cstest
+
+import (
+	"encoding/json/jsontext"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+)
+
+// ConfigSize is the number of leaf values in a config generated by
+// GenerateConfig.
+type ConfigSize int
+
+const (
+	SmallConfigSize  ConfigSize = 10
+	MediumConfigSize ConfigSize = 100
+	LargeConfigSize  ConfigSize = 10_000
+
+	// HugeConfigSize generates about 10MB, the size of the state-like files
+	// LoadJSONStream and SaveJSONStream are for. It is not in ConfigSizes.
+	HugeConfigSize ConfigSize = 400_000
+)
+
+// ConfigSizes lists the sizes RunStoreBenchmarks runs each benchmark with.
+var ConfigSizes = []ConfigSize{
+	SmallConfigSize,
+	MediumConfigSize,
+	LargeConfigSize,
+}
+
+func (s ConfigSize) String() string {
+	switch s {
+	case SmallConfigSize:
+		return "small"
+	case MediumConfigSize:
+		return "medium"
+	case LargeConfigSize:
+		return "large"
+	case HugeConfigSize:
+		return "huge"
+	default:
+	}
+	return fmt.Sprintf("size-%d", int(s))
+}
+
+// GenerateConfig returns a deterministic, indented JSON object with size, rounded
+// up to a multiple of ten, leaf values: a mix of strings, numbers, booleans and arrays grouped into
+// sections of ten, the shape of a typical hand-edited config file.
+func GenerateConfig(size ConfigSize) []byte {
+	var sections []string
+
+	for i := 0; i < int(size); i += 10 {
+		sections = append(sections, fmt.Sprintf(
+			`{"name":"section-%d","enabled":%t,"port":%d,"timeout":"%ds","ratio":%d.5,`+
+				`"tags":["t%d"],"host":"host-%d.example.com","user":"user%d","retries":%d,"debug":%t}`,
+			i, i%20 == 0, 8000+i, i%60, i%7, i, i, i, i%5, i%3 == 0,
+		))
+	}
+	v := jsontext.Value(`{"sections":[` + strings.Join(sections, ",") + `]}`)
+	err := v.Indent(jsontext.WithIndent("  "))
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// StoreBenchmarkFunc is the body of a benchmark run by RunStoreBenchmarks. cs
+// has been seeded with data, and reset restores that content, e.g. with the
+// timer stopped between iterations that modify the file.
+type StoreBenchmarkFunc func(b *testing.B, cs cfgstore.ConfigStore, data []byte, reset func())
+
+// RunStoreBenchmarks runs fn as a sub-benchmark for each of ConfigSizes against
+// an in-memory CLI config store seeded with GenerateConfig, so that changes to
+// the cost of Save, Load and merge can be tracked with `go test -bench`, e.g.
+//
+//	func BenchmarkLoad(b *testing.B) {
+//		cstest.RunStoreBenchmarks(b, func(b *testing.B, cs cfgstore.ConfigStore, _ []byte, _ func()) {
+//			for b.Loop() {
+//				_, _ = cs.Load()
+//			}
+//		})
+//	}
+func RunStoreBenchmarks(b *testing.B, fn StoreBenchmarkFunc) {
+	for _, size := range ConfigSizes {
+		b.Run(size.String(), func(b *testing.B) {
+			data := GenerateConfig(size)
+			cs := NewFixture(b).InMemory().WithCLIConfig(data).Stores().CLIConfigStore()
+			reset := func() {
+				err := cs.Save(data)
+				if err != nil {
+					b.Fatalf("cstest: failed to reset store: %v", err)
+				}
+			}
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			fn(b, cs, data, reset)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package cstest
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ErrWriteOutsideTestRoot is returned by a ContainedFileSystem for a write to a
+// path outside its root.
+var ErrWriteOutsideTestRoot = errors.New("write outside test root")
+
+var _ cfgstore.FileStreamWriter = (*ContainedFileSystem)(nil)
+var _ cfgstore.FileMapper = (*ContainedFileSystem)(nil)
+var _ cfgstore.FileModeWriter = (*ContainedFileSystem)(nil)
+var _ cfgstore.FileSymlinker = (*ContainedFileSystem)(nil)
+
+// ContainedFileSystem wraps a FileSystem and fails the test, without performing
+// the write, if a store tries to write, create a directory, remove or lock a
+// path outside root, catching a misconfigured DirsProvider before it silently
+// writes into the developer's real ~/.config. Reads are not restricted.
+type ContainedFileSystem struct {
+	cfgstore.FileSystem
+	t    testing.TB
+	root dt.DirPath
+}
+
+// NewContainedFileSystem returns a ContainedFileSystem allowing writes within
+// root only. A nil fileSystem wraps the OS file system.
+func NewContainedFileSystem(t testing.TB, root dt.DirPath, fileSystem cfgstore.FileSystem) *ContainedFileSystem {
+	if fileSystem == nil {
+		fileSystem = cfgstore.OSFileSystem()
+	}
+	return &ContainedFileSystem{
+		FileSystem: fileSystem,
+		t:          t,
+		root:       root,
+	}
+}
+
+// GuardWrites replaces dp's FileSystem with a ContainedFileSystem allowing
+// writes within the TestRoot of args only, and returns dp. NewTestStore and
+// Fixture guard the providers they build on disk this way.
+func GuardWrites(t testing.TB, dp *cfgstore.DirsProvider, args *TestDirsProviderArgs) *cfgstore.DirsProvider {
+	dp.FileSystem = NewContainedFileSystem(t, args.GetTestRoot(""), dp.FileSystem)
+	return dp
+}
+
+// WriteFileFrom streams the write if the wrapped FileSystem is a
+// cfgstore.FileStreamWriter and otherwise buffers it.
+func (c *ContainedFileSystem) WriteFileFrom(fp dt.Filepath, write func(io.Writer) error) (err error) {
+	err = c.check("write", string(fp))
+	if err != nil {
+		goto end
+	}
+	err = cfgstore.WriteFileFrom(c.FileSystem, fp, write)
+end:
+	return err
+}
+
+// MapFile maps the file if the wrapped FileSystem is a cfgstore.FileMapper and
+// otherwise reads it.
+func (c *ContainedFileSystem) MapFile(fp dt.Filepath) ([]byte, func() error, error) {
+	return cfgstore.MapFile(c.FileSystem, fp)
+}
+
+func (c *ContainedFileSystem) WriteFile(fp dt.Filepath, data []byte) (err error) {
+	err = c.check("write", string(fp))
+	if err != nil {
+		goto end
+	}
+	err = c.FileSystem.WriteFile(fp, data)
+end:
+	return err
+}
+
+// WriteFileMode writes with mode if the wrapped FileSystem is a
+// cfgstore.FileModeWriter and otherwise ignores it.
+func (c *ContainedFileSystem) WriteFileMode(fp dt.Filepath, data []byte, mode fs.FileMode) (err error) {
+	err = c.check("write", string(fp))
+	if err != nil {
+		goto end
+	}
+	err = cfgstore.WriteFileMode(c.FileSystem, fp, data, mode)
+end:
+	return err
+}
+
+func (c *ContainedFileSystem) MkdirAll(dp dt.DirPath) (err error) {
+	err = c.check("mkdir", string(dp))
+	if err != nil {
+		goto end
+	}
+	err = c.FileSystem.MkdirAll(dp)
+end:
+	return err
+}
+
+func (c *ContainedFileSystem) Remove(fp dt.Filepath) (err error) {
+	err = c.check("remove", string(fp))
+	if err != nil {
+		goto end
+	}
+	err = c.FileSystem.Remove(fp)
+end:
+	return err
+}
+
+func (c *ContainedFileSystem) Lock(fp dt.Filepath, timeout time.Duration) (unlock func(), err error) {
+	err = c.check("lock", string(fp))
+	if err != nil {
+		goto end
+	}
+	unlock, err = c.FileSystem.Lock(fp, timeout)
+end:
+	return unlock, err
+}
+
+// RLock takes a shared lock if the wrapped FileSystem is a
+// cfgstore.FileSharedLocker and otherwise an exclusive one.
+func (c *ContainedFileSystem) RLock(fp dt.Filepath, timeout time.Duration) (unlock func(), err error) {
+	err = c.check("lock", string(fp))
+	if err != nil {
+		goto end
+	}
+	unlock, err = cfgstore.RLock(c.FileSystem, fp, timeout)
+end:
+	return unlock, err
+}
+
+// Symlink creates the link if the wrapped FileSystem is a
+// cfgstore.FileSymlinker and otherwise fails.
+func (c *ContainedFileSystem) Symlink(target, link dt.Filepath) (err error) {
+	err = c.check("symlink", string(link))
+	if err != nil {
+		goto end
+	}
+	err = cfgstore.Symlink(c.FileSystem, target, link)
+end:
+	return err
+}
+
+func (c *ContainedFileSystem) Readlink(link dt.Filepath) (dt.Filepath, error) {
+	return cfgstore.Readlink(c.FileSystem, link)
+}
+
+// Contains returns true if p is root or within it.
+func (c *ContainedFileSystem) Contains(p string) bool {
+	rel, err := filepath.Rel(string(c.root), p)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+func (c *ContainedFileSystem) check(op, p string) (err error) {
+	if c.Contains(p) {
+		goto end
+	}
+	c.t.Errorf("cstest: attempted to %s %s which is outside the test root %s", op, p, c.root)
+	err = dt.NewErr(ErrWriteOutsideTestRoot,
+		"op", op,
+		"path", p,
+		"test_root", c.root,
+	)
+end:
+	return err
+}
@@ -1,6 +1,7 @@
 package cstest
 
 import (
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -13,6 +14,10 @@ import (
 const (
 	WindowsAppConfigRelPathSegments = `AppData\Roaming`
 	macOSAppConfigRelPathSegments   = `Library/Application Support`
+	WindowsCacheRelPathSegments     = `AppData\Local`
+	macOSCacheRelPathSegments       = `Library/Caches`
+	unixCacheRelPathSegments        = `.cache`
+	unixStateRelPathSegments        = `.local/state`
 )
 
 type TestDirsProviderArgs struct {
@@ -21,40 +26,97 @@ type TestDirsProviderArgs struct {
 	TestRootFunc func() dt.DirPath
 	ProjectDir   dt.DirPath
 	ConfigSlug   dt.PathSegment
+
+	// GOOS is the OS, e.g. "windows", "darwin" or "linux", whose directory
+	// layout the provider fabricates. It defaults to runtime.GOOS. When it
+	// differs, the fabricated paths, as returned by WithoutTestRoot, take the
+	// target OS's shape, e.g. `C:\Users\coyote\AppData\Roaming`, while paths
+	// within TestRoot use the host's separators so they can still be written.
+	GOOS string
+
+	// mutex guards TestRoot and omitTestRoot so that a provider can be used by
+	// concurrent goroutines, and withoutMutex serializes WithoutTestRoot calls.
+	mutex        sync.Mutex
+	withoutMutex sync.Mutex
 	omitTestRoot bool
 }
 
+// RelConfigDir returns the CLI config dir for ConfigSlug relative to TestRoot.
 func (args *TestDirsProviderArgs) RelConfigDir() dt.PathSegments {
-	return dt.PathSegments(filepath.Join(
-		"Users",
-		string(args.Username),
-		string(cfgstore.DotConfigPathSegment),
-		string(args.ConfigSlug),
-	))
+	dir, _ := getTestCLIConfigDir(args)
+	return dt.PathSegments(filepath.Join(args.hostRel(dir), string(args.ConfigSlug)))
+}
+
+// TargetOS returns GOOS, or runtime.GOOS if GOOS is empty.
+func (args *TestDirsProviderArgs) TargetOS() string {
+	if args.GOOS == "" {
+		return runtime.GOOS
+	}
+	return args.GOOS
+}
+
+// joinPath joins elems to base using the target OS's separator.
+func (args *TestDirsProviderArgs) joinPath(base dt.DirPath, elems ...string) dt.DirPath {
+	if args.TargetOS() == runtime.GOOS {
+		return dt.DirPath(filepath.Join(append([]string{string(base)}, elems...)...))
+	}
+	if args.TargetOS() != "windows" {
+		return dt.DirPath(path.Join(append([]string{string(base)}, elems...)...))
+	}
+	for i, elem := range elems {
+		elems[i] = strings.ReplaceAll(elem, `\`, "/")
+	}
+	p := path.Join(append([]string{strings.ReplaceAll(string(base), `\`, "/")}, elems...)...)
+	return dt.DirPath(strings.ReplaceAll(p, "/", `\`))
+}
+
+// hostRel converts dp, a path in the target OS's shape, to a path relative to
+// the root of its volume using the host's separators.
+func (args *TestDirsProviderArgs) hostRel(dp dt.DirPath) string {
+	p := string(dp)
+	if args.TargetOS() == runtime.GOOS {
+		p = strings.TrimPrefix(p, string(dp.VolumeName()))
+	}
+	if args.TargetOS() == "windows" {
+		p = strings.ReplaceAll(p, `\`, "/")
+		if len(p) >= 2 && p[1] == ':' {
+			// Strip the drive letter
+			p = p[2:]
+		}
+	}
+	return filepath.FromSlash(strings.TrimLeft(p, "/"))
 }
 
 func (args *TestDirsProviderArgs) OmitTestRoot() bool {
+	args.mutex.Lock()
+	defer args.mutex.Unlock()
 	return args.omitTestRoot
 }
 
+func (args *TestDirsProviderArgs) setOmitTestRoot(omit bool) {
+	args.mutex.Lock()
+	args.omitTestRoot = omit
+	args.mutex.Unlock()
+}
+
 func (args *TestDirsProviderArgs) WithoutTestRoot(fn cfgstore.DirFunc) (dp dt.DirPath, err error) {
-	var mutex sync.Mutex
-	mutex.Lock()
-	args.omitTestRoot = true
-	dp, err = fn()
-	args.omitTestRoot = false
-	mutex.Unlock()
-	return dp, err
+	args.withoutMutex.Lock()
+	defer args.withoutMutex.Unlock()
+	args.setOmitTestRoot(true)
+	defer args.setOmitTestRoot(false)
+	return fn()
 }
 
 func (args *TestDirsProviderArgs) GetTestRoot(dp dt.DirPath) (_ dt.DirPath) {
-	if args.OmitTestRoot() {
+	args.mutex.Lock()
+	defer args.mutex.Unlock()
+	if args.omitTestRoot {
 		goto end
 	}
 	if args.TestRoot == "" {
 		args.TestRoot = args.TestRootFunc()
 	}
-	dp = dt.DirPathJoin(args.TestRoot, dp)
+	dp = dt.DirPathJoin(args.TestRoot, args.hostRel(dp))
 end:
 	return dp
 }
@@ -62,7 +124,7 @@ end:
 func NewTestDirsProvider(args *TestDirsProviderArgs) *cfgstore.DirsProvider {
 	return &cfgstore.DirsProvider{
 		UserHomeDirFunc: func() (dp dt.DirPath, err error) {
-			dp, err = getTestUserHomeDir(args.Username)
+			dp, err = getTestUserHomeDir(args)
 			if err != nil {
 				goto end
 			}
@@ -89,7 +151,7 @@ func NewTestDirsProvider(args *TestDirsProviderArgs) *cfgstore.DirsProvider {
 			return dp, err
 		},
 		UserConfigDirFunc: func() (dp dt.DirPath, err error) {
-			dp, err = getTestUserConfigDir(args.Username)
+			dp, err = getTestUserConfigDir(args)
 			if err != nil {
 				goto end
 			}
@@ -98,7 +160,7 @@ func NewTestDirsProvider(args *TestDirsProviderArgs) *cfgstore.DirsProvider {
 			return dp, err
 		},
 		CLIConfigDirFunc: func() (dp dt.DirPath, err error) {
-			dp, err = getTestCLIConfigDir(args.Username)
+			dp, err = getTestCLIConfigDir(args)
 			if err != nil {
 				goto end
 			}
@@ -106,13 +168,48 @@ func NewTestDirsProvider(args *TestDirsProviderArgs) *cfgstore.DirsProvider {
 		end:
 			return dp, err
 		},
+		UserCacheDirFunc: func() (dp dt.DirPath, err error) {
+			dp, err = getTestUserCacheDir(args)
+			if err != nil {
+				goto end
+			}
+			dp = args.GetTestRoot(dp)
+		end:
+			return dp, err
+		},
+		UserStateDirFunc: func() (dp dt.DirPath, err error) {
+			dp, err = getTestUserStateDir(args)
+			if err != nil {
+				goto end
+			}
+			dp = args.GetTestRoot(dp)
+		end:
+			return dp, err
+		},
+	}
+}
+
+// MemTestRoot is the TestRoot used by NewMemDirsProvider when none is given. It
+// only exists within the provider's MemFileSystem.
+const MemTestRoot dt.DirPath = "/cfgstore-mem"
+
+// NewMemDirsProvider returns a DirsProvider like NewTestDirsProvider whose
+// stores read and write a new MemFileSystem rather than the disk, so that tests
+// need no temp directories and can safely run in parallel. The MemFileSystem is
+// available as the returned provider's FileSystem.
+func NewMemDirsProvider(args *TestDirsProviderArgs) *cfgstore.DirsProvider {
+	if args.TestRoot == "" && args.TestRootFunc == nil {
+		args.TestRoot = MemTestRoot
 	}
+	dp := NewTestDirsProvider(args)
+	dp.FileSystem = NewMemFileSystem()
+	return dp
 }
 
 func getTestProjectDir(args *TestDirsProviderArgs) (dir dt.DirPath, err error) {
 	var homeDir dt.DirPath
 
-	homeDir, err = getTestUserHomeDir(args.Username)
+	homeDir, err = getTestUserHomeDir(args)
 	if err != nil {
 		goto end
 	}
@@ -125,13 +222,13 @@ func getTestProjectDir(args *TestDirsProviderArgs) (dir dt.DirPath, err error) {
 		goto end
 	}
 
-	switch runtime.GOOS {
+	switch args.TargetOS() {
 	default:
 		dir = args.ProjectDir
 	case "windows", "darwin", "ios":
 		rel, err := args.ProjectDir.Rel(homeDir)
 		if err == nil && len(rel) > 0 {
-			dir = dt.DirPathJoin(homeDir, rel.UpperFirst())
+			dir = args.joinPath(homeDir, string(rel.UpperFirst()))
 			goto end
 		}
 		dir = args.ProjectDir
@@ -140,20 +237,20 @@ end:
 	return dir, err
 }
 
-func getTestUserConfigDir(username dt.PathSegment) (dir dt.DirPath, err error) {
+func getTestUserConfigDir(args *TestDirsProviderArgs) (dir dt.DirPath, err error) {
 	var homeDir dt.DirPath
 
-	homeDir, err = getTestUserHomeDir(username)
+	homeDir, err = getTestUserHomeDir(args)
 	if err != nil {
 		goto end
 	}
-	switch runtime.GOOS {
+	switch args.TargetOS() {
 	case "windows":
-		dir = dt.DirPathJoin(homeDir, WindowsAppConfigRelPathSegments)
+		dir = args.joinPath(homeDir, WindowsAppConfigRelPathSegments)
 	case "darwin", "ios":
-		dir = dt.DirPathJoin(homeDir, macOSAppConfigRelPathSegments)
+		dir = args.joinPath(homeDir, macOSAppConfigRelPathSegments)
 	default: // Unix
-		dir = dt.DirPathJoin(homeDir, cfgstore.DotConfigPathSegment)
+		dir = args.joinPath(homeDir, string(cfgstore.DotConfigPathSegment))
 	}
 end:
 	if err != nil {
@@ -164,14 +261,62 @@ end:
 	return dir, err
 }
 
-func getTestCLIConfigDir(username dt.PathSegment) (dir dt.DirPath, err error) {
+func getTestUserCacheDir(args *TestDirsProviderArgs) (dir dt.DirPath, err error) {
+	var homeDir dt.DirPath
+
+	homeDir, err = getTestUserHomeDir(args)
+	if err != nil {
+		goto end
+	}
+	switch args.TargetOS() {
+	case "windows":
+		dir = args.joinPath(homeDir, WindowsCacheRelPathSegments)
+	case "darwin", "ios":
+		dir = args.joinPath(homeDir, macOSCacheRelPathSegments)
+	default: // Unix
+		dir = args.joinPath(homeDir, unixCacheRelPathSegments)
+	}
+end:
+	if err != nil {
+		err = dt.WithErr(err,
+			cfgstore.ErrFailedGettingUserCacheDir,
+		)
+	}
+	return dir, err
+}
+
+func getTestUserStateDir(args *TestDirsProviderArgs) (dir dt.DirPath, err error) {
+	var homeDir dt.DirPath
+
+	homeDir, err = getTestUserHomeDir(args)
+	if err != nil {
+		goto end
+	}
+	switch args.TargetOS() {
+	case "windows":
+		dir = args.joinPath(homeDir, WindowsCacheRelPathSegments, string(cfgstore.StatePathSegment))
+	case "darwin", "ios":
+		dir = args.joinPath(homeDir, macOSAppConfigRelPathSegments, string(cfgstore.StatePathSegment))
+	default: // Unix
+		dir = args.joinPath(homeDir, unixStateRelPathSegments)
+	}
+end:
+	if err != nil {
+		err = dt.WithErr(err,
+			cfgstore.ErrFailedGettingUserStateDir,
+		)
+	}
+	return dir, err
+}
+
+func getTestCLIConfigDir(args *TestDirsProviderArgs) (dir dt.DirPath, err error) {
 	var homeDir dt.DirPath
 
-	homeDir, err = getTestUserHomeDir(username)
+	homeDir, err = getTestUserHomeDir(args)
 	if err != nil {
 		goto end
 	}
-	dir = dt.DirPathJoin(homeDir, cfgstore.DotConfigPathSegment)
+	dir = args.joinPath(homeDir, string(cfgstore.DotConfigPathSegment))
 end:
 	if err != nil {
 		err = dt.WithErr(err,
@@ -181,12 +326,12 @@ end:
 	return dir, err
 }
 
-func getTestUserHomeDir(username dt.PathSegment) (dir dt.DirPath, err error) {
-	err = validateUsername(username)
+func getTestUserHomeDir(args *TestDirsProviderArgs) (dir dt.DirPath, err error) {
+	err = validateUsername(args.Username)
 	if err != nil {
 		goto end
 	}
-	switch runtime.GOOS {
+	switch args.TargetOS() {
 	case "windows":
 		dir = `C:\Users`
 	case "darwin":
@@ -194,7 +339,7 @@ func getTestUserHomeDir(username dt.PathSegment) (dir dt.DirPath, err error) {
 	default:
 		dir = "/home"
 	}
-	dir = dt.DirPathJoin(dir, username)
+	dir = args.joinPath(dir, string(args.Username))
 end:
 	if err != nil {
 		err = dt.WithErr(err,
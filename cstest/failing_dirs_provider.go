@@ -0,0 +1,115 @@
+package cstest
+
+import (
+	"errors"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ErrInjected is returned by the funcs of a FailingDirsProvider that were told
+// to fail with a nil error.
+var ErrInjected = errors.New("injected error")
+
+// FailingDirsProvider builds a DirsProvider whose chosen funcs return an error
+// while the others defer to a base provider, so the error branches for each
+// directory, e.g. ErrFailedGettingUserConfigDir, can be covered directly:
+//
+//	dp := cstest.NewFailingDirsProvider(cstest.NewTestDirsProvider(args)).
+//		FailUserConfigDir(nil).
+//		Build()
+type FailingDirsProvider struct {
+	base *cfgstore.DirsProvider
+	errs failingDirFuncErrs
+}
+
+type failingDirFuncErrs struct {
+	userHomeDir   error
+	getwd         error
+	projectDir    error
+	userConfigDir error
+	cliConfigDir  error
+	userCacheDir  error
+	userStateDir  error
+}
+
+// NewFailingDirsProvider returns a FailingDirsProvider deferring to base, or to
+// cfgstore.DefaultDirsProvider() if base is nil.
+func NewFailingDirsProvider(base *cfgstore.DirsProvider) *FailingDirsProvider {
+	if base == nil {
+		base = cfgstore.DefaultDirsProvider()
+	}
+	return &FailingDirsProvider{base: base}
+}
+
+// FailUserHomeDir makes UserHomeDirFunc return err, or ErrInjected if nil.
+func (b *FailingDirsProvider) FailUserHomeDir(err error) *FailingDirsProvider {
+	b.errs.userHomeDir = injectedErr(err)
+	return b
+}
+
+// FailGetwd makes GetwdFunc return err, or ErrInjected if nil.
+func (b *FailingDirsProvider) FailGetwd(err error) *FailingDirsProvider {
+	b.errs.getwd = injectedErr(err)
+	return b
+}
+
+// FailProjectDir makes ProjectDirFunc return err, or ErrInjected if nil.
+func (b *FailingDirsProvider) FailProjectDir(err error) *FailingDirsProvider {
+	b.errs.projectDir = injectedErr(err)
+	return b
+}
+
+// FailUserConfigDir makes UserConfigDirFunc return err, or ErrInjected if nil.
+func (b *FailingDirsProvider) FailUserConfigDir(err error) *FailingDirsProvider {
+	b.errs.userConfigDir = injectedErr(err)
+	return b
+}
+
+// FailCLIConfigDir makes CLIConfigDirFunc return err, or ErrInjected if nil.
+func (b *FailingDirsProvider) FailCLIConfigDir(err error) *FailingDirsProvider {
+	b.errs.cliConfigDir = injectedErr(err)
+	return b
+}
+
+// FailUserCacheDir makes UserCacheDirFunc return err, or ErrInjected if nil.
+func (b *FailingDirsProvider) FailUserCacheDir(err error) *FailingDirsProvider {
+	b.errs.userCacheDir = injectedErr(err)
+	return b
+}
+
+// FailUserStateDir makes UserStateDirFunc return err, or ErrInjected if nil.
+func (b *FailingDirsProvider) FailUserStateDir(err error) *FailingDirsProvider {
+	b.errs.userStateDir = injectedErr(err)
+	return b
+}
+
+// Build returns a copy of the base provider with the funcs chosen to fail
+// replaced.
+func (b *FailingDirsProvider) Build() *cfgstore.DirsProvider {
+	dp := *b.base
+	dp.UserHomeDirFunc = failingDirFunc(dp.UserHomeDirFunc, b.errs.userHomeDir)
+	dp.GetwdFunc = failingDirFunc(dp.GetwdFunc, b.errs.getwd)
+	dp.ProjectDirFunc = failingDirFunc(dp.ProjectDirFunc, b.errs.projectDir)
+	dp.UserConfigDirFunc = failingDirFunc(dp.UserConfigDirFunc, b.errs.userConfigDir)
+	dp.CLIConfigDirFunc = failingDirFunc(dp.CLIConfigDirFunc, b.errs.cliConfigDir)
+	dp.UserCacheDirFunc = failingDirFunc(dp.UserCacheDirFunc, b.errs.userCacheDir)
+	dp.UserStateDirFunc = failingDirFunc(dp.UserStateDirFunc, b.errs.userStateDir)
+	return &dp
+}
+
+func failingDirFunc(fn cfgstore.DirFunc, err error) cfgstore.DirFunc {
+	if err == nil {
+		return fn
+	}
+	return func() (dt.DirPath, error) {
+		return "", err
+	}
+}
+
+func injectedErr(err error) error {
+	if err == nil {
+		err = ErrInjected
+	}
+	return err
+}
@@ -0,0 +1,309 @@
+package cstest
+
+import (
+	"context"
+	"encoding/json/v2"
+	"io/fs"
+	"sync"
+	"syscall"
+
+	"github.com/mikeschinkel/go-cfgstore"
+)
+
+var (
+	_ cfgstore.ConfigStore        = (*FaultyStore)(nil)
+	_ cfgstore.ContextConfigStore = (*FaultyStore)(nil)
+	_ cfgstore.JSONStreamer       = (*FaultyStore)(nil)
+)
+
+// configStore lets wrappers embed a ConfigStore without the field's name
+// colliding with its ConfigStore() marker method.
+type configStore = cfgstore.ConfigStore
+
+// FaultyStore wraps a ConfigStore and can be programmed to make Load, LoadJSON,
+// Save and SaveJSON, and their variants, fail, so applications can test how
+// they handle errors from cfgstore. All other methods are passed through to the
+// wrapped store unchanged, e.g.
+//
+//	store := cstest.NewFaultyStore(cs).FailNthSave(2, nil)
+//	err := app.Run(store) // the 2nd save fails with ENOSPC
+type FaultyStore struct {
+	configStore
+	mutex     sync.Mutex
+	saves     int
+	loads     int
+	saveFault map[int]saveFault
+	loadErr   error
+	corrupt   func([]byte) []byte
+}
+
+type saveFault struct {
+	err     error
+	partial bool
+}
+
+// NewFaultyStore returns a FaultyStore wrapping cs with no faults programmed.
+func NewFaultyStore(cs cfgstore.ConfigStore) *FaultyStore {
+	return &FaultyStore{
+		configStore: cs,
+		saveFault:   make(map[int]saveFault),
+	}
+}
+
+// FailNthSave makes the nth call, counting from 1, to Save or SaveJSON return
+// err without writing. A nil err fails with ENOSPC.
+func (s *FaultyStore) FailNthSave(n int, err error) *FaultyStore {
+	s.mutex.Lock()
+	s.saveFault[n] = saveFault{err: s.pathError("write", err, syscall.ENOSPC)}
+	s.mutex.Unlock()
+	return s
+}
+
+// FailNthSaveMidWrite makes the nth call to Save or SaveJSON write only the first
+// half of its data and then fail with ENOSPC, simulating a non-atomic writer
+// running out of disk space, so the next Load returns truncated content.
+func (s *FaultyStore) FailNthSaveMidWrite(n int) *FaultyStore {
+	s.mutex.Lock()
+	s.saveFault[n] = saveFault{
+		err:     s.pathError("write", nil, syscall.ENOSPC),
+		partial: true,
+	}
+	s.mutex.Unlock()
+	return s
+}
+
+// FailLoads makes every Load and LoadJSON return err until ClearFaults is
+// called. A nil err fails with EACCES, which matches fs.ErrPermission.
+func (s *FaultyStore) FailLoads(err error) *FaultyStore {
+	s.mutex.Lock()
+	s.loadErr = s.pathError("open", err, syscall.EACCES)
+	s.mutex.Unlock()
+	return s
+}
+
+// CorruptLoads makes Load and LoadJSON pass the file's content through corrupt
+// before returning or unmarshaling it. A nil corrupt truncates the content to
+// half its length.
+func (s *FaultyStore) CorruptLoads(corrupt func([]byte) []byte) *FaultyStore {
+	if corrupt == nil {
+		corrupt = truncateHalf
+	}
+	s.mutex.Lock()
+	s.corrupt = corrupt
+	s.mutex.Unlock()
+	return s
+}
+
+// ClearFaults removes all programmed faults. The Saves and Loads counts are not
+// reset.
+func (s *FaultyStore) ClearFaults() {
+	s.mutex.Lock()
+	s.saveFault = make(map[int]saveFault)
+	s.loadErr = nil
+	s.corrupt = nil
+	s.mutex.Unlock()
+}
+
+// Saves returns the number of calls made to Save, SaveJSON and their Context
+// and Stream variants.
+func (s *FaultyStore) Saves() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.saves
+}
+
+// Loads returns the number of calls made to Load, LoadJSON and their Context,
+// Stream and Mapped variants.
+func (s *FaultyStore) Loads() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.loads
+}
+
+func (s *FaultyStore) Load() (data []byte, err error) {
+	return s.load(s.configStore.Load)
+}
+
+// LoadContext is faulted as Load is.
+func (s *FaultyStore) LoadContext(ctx context.Context) (data []byte, err error) {
+	return s.load(func() ([]byte, error) {
+		return cfgstore.LoadContext(ctx, s.configStore)
+	})
+}
+
+// load loads with load, Load or LoadContext, unless a fault is programmed.
+func (s *FaultyStore) load(load func() ([]byte, error)) (data []byte, err error) {
+	var corrupt func([]byte) []byte
+
+	corrupt, err = s.nextLoad()
+	if err != nil {
+		goto end
+	}
+	data, err = load()
+	if err != nil {
+		goto end
+	}
+	if corrupt != nil {
+		data = corrupt(data)
+	}
+end:
+	return data, err
+}
+
+func (s *FaultyStore) LoadJSON(data any, opts ...json.Options) error {
+	return s.loadJSON(s.configStore.LoadJSON, data, opts)
+}
+
+// LoadJSONContext is faulted as LoadJSON is.
+func (s *FaultyStore) LoadJSONContext(ctx context.Context, data any, opts ...json.Options) error {
+	return s.loadJSON(func(data any, opts ...json.Options) error {
+		return cfgstore.LoadJSONContext(ctx, s.configStore, data, opts...)
+	}, data, opts)
+}
+
+// LoadJSONStream is faulted as LoadJSON is.
+func (s *FaultyStore) LoadJSONStream(data any, opts ...json.Options) error {
+	return s.loadJSON(func(data any, opts ...json.Options) error {
+		return cfgstore.LoadJSONStream(s.configStore, data, opts...)
+	}, data, opts)
+}
+
+// LoadJSONMapped is faulted as LoadJSON is.
+func (s *FaultyStore) LoadJSONMapped(data any, opts ...json.Options) error {
+	return s.loadJSON(func(data any, opts ...json.Options) error {
+		return cfgstore.LoadJSONMapped(s.configStore, data, opts...)
+	}, data, opts)
+}
+
+// loadJSON loads with load, one of the wrapped store's LoadJSON methods, unless
+// a fault is programmed.
+func (s *FaultyStore) loadJSON(load func(any, ...json.Options) error, data any, opts []json.Options) (err error) {
+	var corrupt func([]byte) []byte
+	var raw []byte
+
+	corrupt, err = s.nextLoad()
+	if err != nil {
+		goto end
+	}
+	if corrupt == nil {
+		err = load(data, opts...)
+		goto end
+	}
+	raw, err = s.configStore.Load()
+	if err != nil {
+		goto end
+	}
+	err = json.Unmarshal(corrupt(raw), data, opts...)
+	if err != nil {
+		err = cfgstore.NewErr(cfgstore.ErrFailedToUnmarshalConfigFile, err)
+	}
+end:
+	return err
+}
+
+func (s *FaultyStore) Save(data []byte) (err error) {
+	return s.save(s.configStore.Save, data)
+}
+
+// SaveContext is faulted as Save is.
+func (s *FaultyStore) SaveContext(ctx context.Context, data []byte) (err error) {
+	return s.save(func(data []byte) error {
+		return cfgstore.SaveContext(ctx, s.configStore, data)
+	}, data)
+}
+
+// save saves data with save, Save or SaveContext, unless a fault is programmed.
+func (s *FaultyStore) save(save func([]byte) error, data []byte) (err error) {
+	var fault saveFault
+	var ok bool
+
+	fault, ok = s.nextSave()
+	if !ok {
+		err = save(data)
+		goto end
+	}
+	if fault.partial {
+		err = save(truncateHalf(data))
+		if err != nil {
+			goto end
+		}
+	}
+	err = fault.err
+end:
+	return err
+}
+
+func (s *FaultyStore) SaveJSON(data any) error {
+	return s.saveJSON(func() error {
+		return s.configStore.SaveJSON(data)
+	}, data)
+}
+
+// SaveJSONContext is faulted as SaveJSON is.
+func (s *FaultyStore) SaveJSONContext(ctx context.Context, data any) error {
+	return s.saveJSON(func() error {
+		return cfgstore.SaveJSONContext(ctx, s.configStore, data)
+	}, data)
+}
+
+// SaveJSONStream is faulted as SaveJSON is.
+func (s *FaultyStore) SaveJSONStream(data any, opts ...json.Options) error {
+	return s.saveJSON(func() error {
+		return cfgstore.SaveJSONStream(s.configStore, data, opts...)
+	}, data)
+}
+
+// saveJSON saves data with save, unless a fault is programmed.
+func (s *FaultyStore) saveJSON(save func() error, data any) (err error) {
+	var fault saveFault
+	var ok bool
+	var raw []byte
+
+	fault, ok = s.nextSave()
+	if !ok {
+		err = save()
+		goto end
+	}
+	if fault.partial {
+		raw, err = json.Marshal(data)
+		if err != nil {
+			goto end
+		}
+		err = s.configStore.Save(truncateHalf(raw))
+		if err != nil {
+			goto end
+		}
+	}
+	err = fault.err
+end:
+	return err
+}
+
+func (s *FaultyStore) nextLoad() (corrupt func([]byte) []byte, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.loads++
+	return s.corrupt, s.loadErr
+}
+
+func (s *FaultyStore) nextSave() (fault saveFault, ok bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.saves++
+	fault, ok = s.saveFault[s.saves]
+	return fault, ok
+}
+
+// pathError wraps err, or errno if err is nil, in an *fs.PathError for the
+// wrapped store's file as the os package would.
+func (s *FaultyStore) pathError(op string, err error, errno syscall.Errno) error {
+	if err == nil {
+		err = errno
+	}
+	fp, _ := s.configStore.GetFilepath()
+	return &fs.PathError{Op: op, Path: string(fp), Err: err}
+}
+
+func truncateHalf(data []byte) []byte {
+	return data[:len(data)/2]
+}
@@ -0,0 +1,234 @@
+package cstest
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+const (
+	DefaultFixtureUsername   dt.PathSegment  = "coyote"
+	DefaultFixtureProjectDir dt.DirPath      = "billboard"
+	DefaultFixtureConfigSlug dt.PathSegment  = "acme"
+	DefaultFixtureConfigFile dt.RelFilepath  = "config.json"
+	DefaultFixtureTokensDir  dt.PathSegments = "tokens"
+)
+
+// Fixture builds the directory layout for a set of layered config stores, e.g.
+//
+//	stores := cstest.NewFixture(t).
+//		WithCLIConfig(`{"name":"cli"}`).
+//		WithProjectConfig(&MyConfig{Name: "project"}).
+//		WithTokenFile("alice.json", token).
+//		Stores()
+//
+// Content passed to the With*() methods may be a string or []byte containing
+// JSON, which is written as-is, or any other value, which is marshaled as JSON.
+// Errors are reported with t.Fatal when Stores is called.
+type Fixture struct {
+	t            testing.TB
+	args         *TestDirsProviderArgs
+	relFilepath  dt.RelFilepath
+	inMemory     bool
+	dirTypes     []cfgstore.DirType
+	files        []fixtureFile
+	dirsProvider *cfgstore.DirsProvider
+	stores       *cfgstore.ConfigStores
+}
+
+// fixtureTemplate is the content of a fixtureFile to be rendered from a template.
+type fixtureTemplate dt.Filepath
+
+type fixtureFile struct {
+	dirType     cfgstore.DirType
+	relFilepath dt.RelFilepath
+	content     any
+}
+
+// NewFixture returns a Fixture rooted in NewTestRoot(t) for the user "coyote", the
+// project "billboard" and the config slug "acme", with config files named
+// config.json. Use WithArgs and WithConfigFile to change these.
+func NewFixture(t testing.TB) *Fixture {
+	return &Fixture{
+		t: t,
+		args: &TestDirsProviderArgs{
+			Username:   DefaultFixtureUsername,
+			ProjectDir: DefaultFixtureProjectDir,
+			ConfigSlug: DefaultFixtureConfigSlug,
+		},
+		relFilepath: DefaultFixtureConfigFile,
+	}
+}
+
+// WithArgs replaces the TestDirsProviderArgs used to build the DirsProvider. An
+// empty TestRoot and TestRootFunc defaults to NewTestRoot(t).
+func (f *Fixture) WithArgs(args *TestDirsProviderArgs) *Fixture {
+	f.args = args
+	return f
+}
+
+// WithConfigFile sets the name of each store's config file.
+func (f *Fixture) WithConfigFile(relFilepath dt.RelFilepath) *Fixture {
+	f.relFilepath = relFilepath
+	return f
+}
+
+// InMemory builds the stores on a MemFileSystem, see NewMemDirsProvider, rather
+// than in a test root on disk.
+func (f *Fixture) InMemory() *Fixture {
+	f.inMemory = true
+	return f
+}
+
+// WithDirTypes adds stores for dirTypes without writing a config file for them.
+func (f *Fixture) WithDirTypes(dirTypes ...cfgstore.DirType) *Fixture {
+	for _, dirType := range dirTypes {
+		f.addDirType(dirType)
+	}
+	return f
+}
+
+// WithAppConfig writes content as the AppConfigDirType store's config file.
+func (f *Fixture) WithAppConfig(content any) *Fixture {
+	return f.WithConfig(cfgstore.AppConfigDirType, content)
+}
+
+// WithCLIConfig writes content as the CLIConfigDirType store's config file.
+func (f *Fixture) WithCLIConfig(content any) *Fixture {
+	return f.WithConfig(cfgstore.CLIConfigDirType, content)
+}
+
+// WithProjectConfig writes content as the ProjectConfigDirType store's config
+// file.
+func (f *Fixture) WithProjectConfig(content any) *Fixture {
+	return f.WithConfig(cfgstore.ProjectConfigDirType, content)
+}
+
+// WithConfig writes content as the config file of the store for dirType.
+func (f *Fixture) WithConfig(dirType cfgstore.DirType, content any) *Fixture {
+	return f.WithFile(dirType, "", content)
+}
+
+// WithTokenFile writes content to tokens/<name> in the CLIConfigDirType store's
+// config directory.
+func (f *Fixture) WithTokenFile(name dt.PathSegment, content any) *Fixture {
+	return f.WithFile(cfgstore.CLIConfigDirType, dt.RelFilepathJoin(DefaultFixtureTokensDir, name), content)
+}
+
+// WithTemplateFile writes tmplFile, typically a file in testdata/, executed as a
+// text/template with the fixture's TemplateData, to relFilepath in the config
+// directory of the store for dirType. An empty relFilepath is the store's config
+// file. See TemplateData and TemplateFuncs.
+func (f *Fixture) WithTemplateFile(dirType cfgstore.DirType, relFilepath dt.RelFilepath, tmplFile dt.Filepath) *Fixture {
+	return f.WithFile(dirType, relFilepath, fixtureTemplate(tmplFile))
+}
+
+// TemplateData returns the data the fixture's templates are executed with.
+func (f *Fixture) TemplateData() TemplateData {
+	return NewTemplateData(f.args, f.DirsProvider())
+}
+
+// WithFile writes content to relFilepath in the config directory of the store
+// for dirType. An empty relFilepath is the store's config file.
+func (f *Fixture) WithFile(dirType cfgstore.DirType, relFilepath dt.RelFilepath, content any) *Fixture {
+	f.addDirType(dirType)
+	f.files = append(f.files, fixtureFile{
+		dirType:     dirType,
+		relFilepath: relFilepath,
+		content:     content,
+	})
+	return f
+}
+
+// Stores creates the fixture's files, once, and returns its stores ordered App,
+// CLI then Project so that LoadConfigStores gives later layers precedence. CLI
+// and Project stores are used if no store was added.
+func (f *Fixture) Stores() *cfgstore.ConfigStores {
+	f.t.Helper()
+	if f.stores != nil {
+		goto end
+	}
+	if len(f.dirTypes) == 0 {
+		f.WithDirTypes(cfgstore.CLIConfigDirType, cfgstore.ProjectConfigDirType)
+	}
+	slices.Sort(f.dirTypes)
+	f.stores = cfgstore.NewConfigStores(cfgstore.ConfigStoresArgs{
+		DirTypes:     f.dirTypes,
+		DirsProvider: f.DirsProvider(),
+		ConfigStoreArgs: cfgstore.ConfigStoreArgs{
+			ConfigSlug:   f.args.ConfigSlug,
+			RelFilepath:  f.relFilepath,
+			DirsProvider: f.DirsProvider(),
+		},
+	})
+	for _, file := range f.files {
+		f.writeFile(file)
+	}
+end:
+	return f.stores
+}
+
+// RootConfigArgs returns RootConfigArgs for passing the fixture's stores to
+// cfgstore.LoadConfigStores.
+func (f *Fixture) RootConfigArgs() cfgstore.RootConfigArgs {
+	stores := f.Stores()
+	return cfgstore.RootConfigArgs{
+		DirTypes:     stores.DirTypes,
+		DirsProvider: f.DirsProvider(),
+	}
+}
+
+// DirsProvider returns the DirsProvider the fixture's stores use. On disk its
+// FileSystem is a ContainedFileSystem that fails t for writes outside the test
+// root.
+func (f *Fixture) DirsProvider() *cfgstore.DirsProvider {
+	if f.dirsProvider != nil {
+		goto end
+	}
+	if f.args.TestRoot == "" && f.args.TestRootFunc == nil && !f.inMemory {
+		f.args.TestRoot = NewTestRoot(f.t)
+	}
+	if f.inMemory {
+		f.dirsProvider = NewMemDirsProvider(f.args)
+		goto end
+	}
+	f.dirsProvider = GuardWrites(f.t, NewTestDirsProvider(f.args), f.args)
+end:
+	return f.dirsProvider
+}
+
+// Args returns the TestDirsProviderArgs the fixture's DirsProvider was built from.
+func (f *Fixture) Args() *TestDirsProviderArgs {
+	return f.args
+}
+
+func (f *Fixture) addDirType(dirType cfgstore.DirType) {
+	if !slices.Contains(f.dirTypes, dirType) {
+		f.dirTypes = append(f.dirTypes, dirType)
+	}
+}
+
+func (f *Fixture) writeFile(file fixtureFile) {
+	var err error
+
+	f.t.Helper()
+	cs := f.stores.StoreMap[file.dirType]
+	if file.relFilepath != "" {
+		cs = cfgstore.SubStore(cs, file.relFilepath)
+	}
+	switch c := file.content.(type) {
+	case fixtureTemplate:
+		err = cs.Save(RenderTemplateFile(f.t, dt.Filepath(c), f.TemplateData()))
+	case string:
+		err = cs.Save([]byte(c))
+	case []byte:
+		err = cs.Save(c)
+	default:
+		err = cs.SaveJSON(c)
+	}
+	if err != nil {
+		f.t.Fatalf("cstest.Fixture: failed to write %s file %s: %v", file.dirType.Slug(), file.relFilepath, err)
+	}
+}
@@ -0,0 +1,153 @@
+package cstest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+// SlugSeeds are the valid and edge-case slugs FuzzSlugs seeds its corpus with.
+var SlugSeeds = []string{
+	"myapp",
+	"my-app",
+	"my_app",
+	"app123",
+	"a",
+	"very-long-application-name-with-many-parts",
+	"",
+	" ",
+	"app with spaces",
+	"app/with/slashes",
+	`app\with\backslashes`,
+	"app\nwith\nnewlines",
+	"../../../etc/passwd",
+	"../parent",
+	"/absolute/path",
+	"~/.ssh",
+}
+
+// RelFilepathSeeds are the valid and edge-case relative filepaths
+// FuzzRelFilepaths seeds its corpus with.
+var RelFilepathSeeds = []string{
+	"config.json",
+	"tokens/alice.json",
+	"a/b/c/d.json",
+	"",
+	".",
+	"..",
+	"../escape.json",
+	"/absolute.json",
+	`windows\style.json`,
+	"trailing/",
+	"with space.json",
+	"nul\x00byte.json",
+}
+
+// ConfigStoreFunc constructs a ConfigStore from a slug, e.g. a wrapper around
+// cfgstore.NewCLIConfigStore or an application's own constructor.
+type ConfigStoreFunc func(slug dt.PathSegment) cfgstore.ConfigStore
+
+// FuzzSlugs seeds f with SlugSeeds and fuzzes newStore, failing if it, or
+// GetFilepath on the store it returns, panics. Some slugs are invalid and it is
+// fine for GetFilepath to return an error for them, e.g.
+//
+//	func FuzzNewStore(f *testing.F) {
+//		cstest.FuzzSlugs(f, func(slug dt.PathSegment) cfgstore.ConfigStore {
+//			return myapp.NewStore(slug)
+//		})
+//	}
+func FuzzSlugs(f *testing.F, newStore ConfigStoreFunc) {
+	for _, seed := range SlugSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, slug string) {
+		CheckSlug(t, newStore, []byte(slug))
+	})
+}
+
+// CheckSlug runs the body of FuzzSlugs for a single slug, e.g. for a corpus
+// entry replayed by RunFuzzCorpus.
+func CheckSlug(t testing.TB, newStore ConfigStoreFunc, slug []byte) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("store constructor panicked with slug %q: %v", slug, r)
+		}
+	}()
+	cs := newStore(dt.PathSegment(slug))
+	_, _ = cs.GetFilepath()
+}
+
+// FuzzRelFilepaths seeds f with RelFilepathSeeds and calls fn for each input
+// with a panic recovered as a test failure.
+func FuzzRelFilepaths(f *testing.F, fn func(t *testing.T, relFilepath dt.RelFilepath)) {
+	for _, seed := range RelFilepathSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, relFilepath string) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("panicked with relative filepath %q: %v", relFilepath, r)
+			}
+		}()
+		fn(t, dt.RelFilepath(relFilepath))
+	})
+}
+
+// RunFuzzCorpus calls fn, as a subtest, with the input of each file in
+// <corpusDir>/<fuzzName>, e.g. "testdata/fuzz/FuzzNewStore", so that corpus
+// entries found while fuzzing locally are rerun as regression tests by a plain
+// `go test`. Files in the `go test fuzz v1` format written by the fuzzer are
+// decoded; any other file is passed as-is. A missing directory runs nothing.
+func RunFuzzCorpus(t *testing.T, corpusDir, fuzzName string, fn func(t *testing.T, data []byte)) {
+	t.Helper()
+	dir := filepath.Join(corpusDir, fuzzName)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		t.Fatalf("Failed to read corpus directory: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		t.Run(entry.Name(), func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				t.Fatalf("Failed to read corpus file: %v", err)
+			}
+			fn(t, decodeCorpusEntry(data))
+		})
+	}
+}
+
+// decodeCorpusEntry returns the first string or []byte value of a corpus file
+// in the `go test fuzz v1` format, or data unchanged for any other file.
+func decodeCorpusEntry(data []byte) []byte {
+	const header = "go test fuzz v1\n"
+
+	if !bytes.HasPrefix(data, []byte(header)) {
+		return data
+	}
+	for line := range strings.Lines(string(data[len(header):])) {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"string(", "[]byte("} {
+			if !strings.HasPrefix(line, prefix) || !strings.HasSuffix(line, ")") {
+				continue
+			}
+			s, err := strconv.Unquote(line[len(prefix) : len(line)-1])
+			if err == nil {
+				return []byte(s)
+			}
+		}
+	}
+	return data
+}
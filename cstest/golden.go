@@ -0,0 +1,82 @@
+package cstest
+
+import (
+	"encoding/json/jsontext"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+// UpdateGolden is set by the -update flag, e.g. `go test ./... -update`, which
+// makes the golden assertions rewrite their golden files instead of comparing
+// against them. Packages importing cstest should not define their own -update
+// flag.
+var UpdateGolden = flag.Bool("update", false, "update cstest golden files")
+
+// AssertStoreMatchesGolden fails t unless the file store last wrote matches the
+// golden file at golden, e.g. "testdata/want.json". Both are normalized to the
+// formatting SaveJSON writes so only differences in content fail. With -update
+// the golden file is written from the store's file instead.
+func AssertStoreMatchesGolden(t testing.TB, store cfgstore.ConfigStore, golden dt.Filepath) {
+	t.Helper()
+	data, err := store.Load()
+	if err != nil {
+		t.Fatalf("cstest: failed to load store file for golden %s: %v", golden, err)
+	}
+	AssertJSONMatchesGolden(t, data, golden)
+}
+
+// AssertJSONMatchesGolden fails t unless the JSON in data matches the golden
+// file at golden. See AssertStoreMatchesGolden.
+func AssertJSONMatchesGolden(t testing.TB, data []byte, golden dt.Filepath) {
+	var want []byte
+
+	t.Helper()
+	got, err := NormalizeJSON(data)
+	if err != nil {
+		t.Fatalf("cstest: invalid JSON for golden %s: %v", golden, err)
+	}
+	if *UpdateGolden {
+		err = os.MkdirAll(filepath.Dir(string(golden)), 0o755)
+		if err == nil {
+			err = os.WriteFile(string(golden), got, 0o644)
+		}
+		if err != nil {
+			t.Fatalf("cstest: failed to update golden %s: %v", golden, err)
+		}
+		return
+	}
+	want, err = os.ReadFile(string(golden))
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("cstest: golden %s does not exist; run with -update to create it", golden)
+	}
+	if err != nil {
+		t.Fatalf("cstest: failed to read golden %s: %v", golden, err)
+	}
+	want, err = NormalizeJSON(want)
+	if err != nil {
+		t.Fatalf("cstest: invalid JSON in golden %s: %v", golden, err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("cstest: JSON does not match golden %s; run with -update to accept\n--- want:\n%s--- got:\n%s", golden, want, got)
+	}
+}
+
+// NormalizeJSON reformats data indented by two spaces, as SaveJSON writes it,
+// with a trailing newline. The order of object members is preserved.
+func NormalizeJSON(data []byte) (normalized []byte, err error) {
+	v := jsontext.Value(append([]byte(nil), data...))
+	err = v.Indent(jsontext.WithIndent("  "))
+	if err != nil {
+		goto end
+	}
+	normalized = append(v, '\n')
+end:
+	return normalized, err
+}
@@ -0,0 +1,111 @@
+package cstest
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+// StoreLayout is the expected layout of one store in a ConfigStores.
+type StoreLayout struct {
+	// RelDir is the store's config dir, slash-separated and relative to the
+	// Layout's Root, e.g. "home/coyote/.config/acme". Empty skips the check.
+	RelDir string
+
+	// FileExists is whether the store's own config file exists.
+	FileExists bool
+
+	// Files lists other files, relative to the store's config dir, that must
+	// exist, e.g. "tokens/alice.json".
+	Files []dt.RelFilepath
+}
+
+// Layout is the expected layout of a ConfigStores.
+type Layout struct {
+	// Root is the directory StoreLayout.RelDir is relative to, typically the
+	// TestRoot of the stores' TestDirsProviderArgs.
+	Root dt.DirPath
+
+	// Stores maps each layer the ConfigStores must have, and no others, to its
+	// expected layout.
+	Stores map[cfgstore.DirType]StoreLayout
+}
+
+// AssertLayout fails t unless stores has exactly the layers in layout and each
+// layer's resolved config dir and files match, e.g.
+//
+//	cstest.AssertLayout(t, stores, cstest.Layout{
+//		Root: args.TestRoot,
+//		Stores: map[cfgstore.DirType]cstest.StoreLayout{
+//			cfgstore.CLIConfigDirType:     {RelDir: "home/coyote/.config/acme", FileExists: true},
+//			cfgstore.ProjectConfigDirType: {RelDir: "billboard/.acme"},
+//		},
+//	})
+func AssertLayout(t testing.TB, stores *cfgstore.ConfigStores, layout Layout) (ok bool) {
+	var want []cfgstore.DirType
+
+	t.Helper()
+	ok = true
+	for dirType := range layout.Stores {
+		want = append(want, dirType)
+	}
+	slices.Sort(want)
+	got := slices.Sorted(slices.Values(stores.DirTypes))
+	if !slices.Equal(got, want) {
+		t.Errorf("cstest: expected layers %v, got %v", dirTypeSlugs(want), dirTypeSlugs(got))
+		ok = false
+	}
+	for _, dirType := range want {
+		cs, found := stores.StoreMap[dirType]
+		if !found {
+			continue
+		}
+		if !assertStoreLayout(t, cs, layout.Root, layout.Stores[dirType]) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+func assertStoreLayout(t testing.TB, cs cfgstore.ConfigStore, root dt.DirPath, want StoreLayout) (ok bool) {
+	t.Helper()
+	slug := cs.DirType().Slug()
+	dir, err := cs.ConfigDir()
+	if err != nil {
+		t.Errorf("cstest: failed to get %s config dir: %v", slug, err)
+		return false
+	}
+	ok = true
+	if want.RelDir != "" {
+		wantDir := dt.DirPathJoin(root, filepath.FromSlash(want.RelDir))
+		if dir != wantDir {
+			t.Errorf("cstest: expected %s config dir %s, got %s", slug, wantDir, dir)
+			ok = false
+		}
+	}
+	fp := dt.FilepathJoin(dir, cs.GetRelFilepath())
+	_, err = cs.FileSystem().Stat(fp)
+	if exists := err == nil; exists != want.FileExists {
+		t.Errorf("cstest: expected %s config file %s to exist=%t, but exists=%t", slug, fp, want.FileExists, exists)
+		ok = false
+	}
+	for _, rf := range want.Files {
+		fp = dt.FilepathJoin(dir, rf)
+		_, err = cs.FileSystem().Stat(fp)
+		if err != nil {
+			t.Errorf("cstest: expected %s file %s to exist: %v", slug, fp, err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func dirTypeSlugs(dirTypes []cfgstore.DirType) (slugs []string) {
+	for _, dirType := range dirTypes {
+		slugs = append(slugs, dirType.Slug())
+	}
+	return slugs
+}
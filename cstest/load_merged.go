@@ -0,0 +1,49 @@
+package cstest
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+)
+
+type LoadMergedOptions struct {
+	// Options is passed to LoadConfigStores as RootConfigArgs.Options.
+	Options cfgstore.Options
+
+	// Interpolate resolves ${path} references in the merged config.
+	Interpolate bool
+
+	// OnDisk writes the seed files to NewTestRoot(t) rather than a MemFileSystem.
+	OnDisk bool
+}
+
+// LoadMerged writes seedCLI and seedProject as the CLI and project config files
+// of a new Fixture, loads and merges them with LoadConfigStores and returns the
+// merged config, calling t.Fatal on error. A seed may be a JSON string or []byte
+// or any value to marshal, and a nil seed leaves that layer's file absent, e.g.
+//
+//	cfg := cstest.LoadMerged[MyConfig](t, `{"theme":"dark"}`, &MyConfig{Name: "x"})
+func LoadMerged[RC any, PRC cfgstore.RootConfigPtr[RC]](t testing.TB, seedCLI, seedProject any, opts ...LoadMergedOptions) PRC {
+	t.Helper()
+	if len(opts) == 0 {
+		opts = []LoadMergedOptions{{}}
+	}
+	fix := NewFixture(t).WithDirTypes(cfgstore.CLIConfigDirType, cfgstore.ProjectConfigDirType)
+	if !opts[0].OnDisk {
+		fix.InMemory()
+	}
+	if seedCLI != nil {
+		fix.WithCLIConfig(seedCLI)
+	}
+	if seedProject != nil {
+		fix.WithProjectConfig(seedProject)
+	}
+	args := fix.RootConfigArgs()
+	args.Options = opts[0].Options
+	args.Interpolate = opts[0].Interpolate
+	prc, err := cfgstore.LoadConfigStores[RC, PRC](fix.Stores(), args)
+	if err != nil {
+		t.Fatalf("cstest: failed to load merged config: %v", err)
+	}
+	return prc
+}
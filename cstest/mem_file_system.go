@@ -0,0 +1,172 @@
+package cstest
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+var _ cfgstore.FileSystem = (*MemFileSystem)(nil)
+var _ cfgstore.FileModeWriter = (*MemFileSystem)(nil)
+
+// MemFileSystem is an in-memory cfgstore.FileSystem backed by an fstest.MapFS,
+// for tests that should never touch the disk. It is safe for concurrent use.
+type MemFileSystem struct {
+	mutex sync.RWMutex
+	files fstest.MapFS
+	locks map[string]chan struct{}
+}
+
+// NewMemFileSystem returns an empty MemFileSystem.
+func NewMemFileSystem() *MemFileSystem {
+	return &MemFileSystem{
+		files: make(fstest.MapFS),
+		locks: make(map[string]chan struct{}),
+	}
+}
+
+// memKey converts an absolute path into the unrooted slash-separated form used
+// as a key in an fstest.MapFS.
+func memKey(p string) string {
+	p = filepath.ToSlash(strings.TrimPrefix(p, filepath.VolumeName(p)))
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "" {
+		p = "."
+	}
+	return p
+}
+
+func (m *MemFileSystem) ReadFile(fp dt.Filepath) (data []byte, err error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	data, err = m.files.ReadFile(memKey(string(fp)))
+	if err != nil {
+		err = &fs.PathError{Op: "open", Path: string(fp), Err: fs.ErrNotExist}
+	}
+	return data, err
+}
+
+// WriteFile stores a copy of data as the content of fp, replacing any existing
+// content, which is atomic because it is done under a lock.
+func (m *MemFileSystem) WriteFile(fp dt.Filepath, data []byte) error {
+	return m.WriteFileMode(fp, data, 0644)
+}
+
+// WriteFileMode is WriteFile for a file that is left with mode.
+func (m *MemFileSystem) WriteFileMode(fp dt.Filepath, data []byte, mode fs.FileMode) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.files[memKey(string(fp))] = &fstest.MapFile{
+		Data:    append([]byte(nil), data...),
+		Mode:    mode,
+		ModTime: time.Now(),
+	}
+	return nil
+}
+
+func (m *MemFileSystem) Stat(fp dt.Filepath) (info fs.FileInfo, err error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	info, err = m.files.Stat(memKey(string(fp)))
+	if err != nil {
+		err = &fs.PathError{Op: "stat", Path: string(fp), Err: fs.ErrNotExist}
+	}
+	return info, err
+}
+
+func (m *MemFileSystem) MkdirAll(dp dt.DirPath) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	key := memKey(string(dp))
+	if _, ok := m.files[key]; !ok && key != "." {
+		m.files[key] = &fstest.MapFile{
+			Mode:    fs.ModeDir | 0755,
+			ModTime: time.Now(),
+		}
+	}
+	return nil
+}
+
+func (m *MemFileSystem) Remove(fp dt.Filepath) (err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	key := memKey(string(fp))
+	if _, ok := m.files[key]; !ok {
+		err = &fs.PathError{Op: "remove", Path: string(fp), Err: fs.ErrNotExist}
+		goto end
+	}
+	delete(m.files, key)
+end:
+	return err
+}
+
+// DirFS returns a read-only view of the directory at dp that reflects later
+// writes.
+func (m *MemFileSystem) DirFS(dp dt.DirPath) fs.FS {
+	return memDirFS{
+		mfs: m,
+		dir: memKey(string(dp)),
+	}
+}
+
+// Lock acquires an in-process lock on fp.
+func (m *MemFileSystem) Lock(fp dt.Filepath, timeout time.Duration) (unlock func(), err error) {
+	key := memKey(string(fp))
+
+	m.mutex.Lock()
+	lock, ok := m.locks[key]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		m.locks[key] = lock
+	}
+	m.mutex.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+		unlock = func() { <-lock }
+	case <-time.After(timeout):
+		err = dt.NewErr(
+			cfgstore.ErrFailedToLockFile,
+			cfgstore.ErrLockTimeout,
+			"filepath", fp,
+			"timeout", timeout,
+		)
+	}
+	return unlock, err
+}
+
+// Files returns the paths of every file in m, which is useful when debugging.
+func (m *MemFileSystem) Files() (files []dt.Filepath) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for key, file := range m.files {
+		if file.Mode.IsDir() {
+			continue
+		}
+		files = append(files, dt.Filepath(filepath.FromSlash("/"+key)))
+	}
+	slices.Sort(files)
+	return files
+}
+
+type memDirFS struct {
+	mfs *MemFileSystem
+	dir string
+}
+
+func (d memDirFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	d.mfs.mutex.RLock()
+	defer d.mfs.mutex.RUnlock()
+	return d.mfs.files.Open(path.Join(d.dir, name))
+}
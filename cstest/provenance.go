@@ -0,0 +1,56 @@
+package cstest
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+)
+
+// AssertValueFrom fails t unless the value at path in the merged config comes
+// from the layer for dirType, as reported by stores.ValueSource, so that
+// merge-precedence regressions are caught, e.g.
+//
+//	cstest.AssertValueFrom(t, stores, "theme", cfgstore.ProjectConfigDirType)
+func AssertValueFrom(t testing.TB, stores *cfgstore.ConfigStores, path string, dirType cfgstore.DirType) bool {
+	t.Helper()
+	src, found, err := stores.ValueSource(path)
+	switch {
+	case err != nil:
+		t.Errorf("cstest: failed to get source of %q: %v", path, err)
+	case !found:
+		t.Errorf("cstest: expected %q to come from the %s layer, but no layer sets it", path, dirType.Slug())
+	case src.DirType != dirType:
+		t.Errorf("cstest: expected %q to come from the %s layer, but it comes from the %s layer (%s)",
+			path, dirType.Slug(), src.DirType.Slug(), src.Filepath)
+	default:
+		return true
+	}
+	return false
+}
+
+// AssertValueSources fails t unless exactly the layers for dirTypes, in
+// DirTypes order, set the value at path. No dirTypes asserts that no layer sets
+// it.
+func AssertValueSources(t testing.TB, stores *cfgstore.ConfigStores, path string, dirTypes ...cfgstore.DirType) bool {
+	var got []string
+
+	t.Helper()
+	sources, err := stores.ValueSources(path)
+	if err != nil {
+		t.Errorf("cstest: failed to get sources of %q: %v", path, err)
+		return false
+	}
+	for _, src := range sources {
+		got = append(got, src.DirType.Slug())
+	}
+	want := make([]string, len(dirTypes))
+	for i, dirType := range dirTypes {
+		want[i] = dirType.Slug()
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("cstest: expected %q to be set by layers %v, but it is set by %v", path, want, got)
+		return false
+	}
+	return true
+}
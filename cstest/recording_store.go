@@ -0,0 +1,235 @@
+package cstest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json/v2"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+var (
+	_ cfgstore.ConfigStore        = (*RecordingStore)(nil)
+	_ cfgstore.SubStorer          = (*RecordingStore)(nil)
+	_ cfgstore.ContextConfigStore = (*RecordingStore)(nil)
+	_ cfgstore.JSONStreamer       = (*RecordingStore)(nil)
+)
+
+// Operation is a call to a RecordingStore that read or wrote its file.
+type Operation struct {
+	// Method is the name of the ConfigStore method called, e.g. "SaveJSON".
+	Method string
+
+	// Path is the store's RelFilepath, e.g. "config.json" or, for a SubStore,
+	// "tokens/alice.json".
+	Path dt.RelFilepath
+
+	// Saved is true if Method wrote the file.
+	Saved bool
+
+	// Hash is the hex SHA-256 of the file's content after the call, or empty if
+	// the file does not exist or could not be read.
+	Hash string
+
+	// Err is the error the call returned.
+	Err error
+
+	data []byte
+}
+
+// RecordingStore wraps a ConfigStore and records every call that reads or
+// writes the store's file, including those made through stores returned by its
+// SubStore method, so tests can assert what a command persisted, e.g.
+//
+//	store := cstest.NewRecordingStore(cs)
+//	err := app.Login(store)
+//	store.AssertSaved(t, "tokens/alice.json", 1)
+//	store.AssertSaved(t, "config.json", 0)
+type RecordingStore struct {
+	configStore
+	recorder *recorder
+}
+
+type recorder struct {
+	mutex sync.Mutex
+	ops   []Operation
+}
+
+// NewRecordingStore returns a RecordingStore wrapping cs with nothing recorded.
+func NewRecordingStore(cs cfgstore.ConfigStore) *RecordingStore {
+	return &RecordingStore{
+		configStore: cs,
+		recorder:    &recorder{},
+	}
+}
+
+// Operations returns the recorded operations in the order they were performed.
+func (s *RecordingStore) Operations() []Operation {
+	s.recorder.mutex.Lock()
+	defer s.recorder.mutex.Unlock()
+	return slices.Clone(s.recorder.ops)
+}
+
+// Saves returns the successful operations that wrote path.
+func (s *RecordingStore) Saves(path dt.RelFilepath) (ops []Operation) {
+	for _, op := range s.Operations() {
+		if op.Saved && op.Err == nil && op.Path == path {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// Reset discards the recorded operations.
+func (s *RecordingStore) Reset() {
+	s.recorder.mutex.Lock()
+	s.recorder.ops = nil
+	s.recorder.mutex.Unlock()
+}
+
+// AssertSaved fails t unless path was successfully written exactly times times.
+func (s *RecordingStore) AssertSaved(t testing.TB, path dt.RelFilepath, times int) bool {
+	t.Helper()
+	n := len(s.Saves(path))
+	if n != times {
+		t.Errorf("cstest: expected %s to be saved %d time(s), but it was saved %d time(s)", path, times, n)
+		return false
+	}
+	return true
+}
+
+// AssertLoaded fails t unless path was read exactly times times.
+func (s *RecordingStore) AssertLoaded(t testing.TB, path dt.RelFilepath, times int) bool {
+	var n int
+
+	t.Helper()
+	for _, op := range s.Operations() {
+		if !op.Saved && op.Path == path {
+			n++
+		}
+	}
+	if n != times {
+		t.Errorf("cstest: expected %s to be loaded %d time(s), but it was loaded %d time(s)", path, times, n)
+		return false
+	}
+	return true
+}
+
+// Replay writes the content recorded for each successful save, in order, to the
+// same path relative to to's config directory, e.g. to reproduce a recorded
+// session against a fresh store.
+func (s *RecordingStore) Replay(to cfgstore.ConfigStore) (err error) {
+	for _, op := range s.Operations() {
+		if !op.Saved || op.Err != nil || op.data == nil {
+			continue
+		}
+		err = cfgstore.SubStore(to, op.Path).Save(op.data)
+		if err != nil {
+			err = cfgstore.WithErr(err, "method", op.Method, "path", op.Path)
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+func (s *RecordingStore) SubStore(relFilepath dt.RelFilepath) cfgstore.ConfigStore {
+	return &RecordingStore{
+		configStore: cfgstore.SubStore(s.configStore, relFilepath),
+		recorder:    s.recorder,
+	}
+}
+
+func (s *RecordingStore) Load() (data []byte, err error) {
+	data, err = s.configStore.Load()
+	s.record("Load", false, err)
+	return data, err
+}
+
+func (s *RecordingStore) LoadJSON(data any, opts ...json.Options) (err error) {
+	err = s.configStore.LoadJSON(data, opts...)
+	s.record("LoadJSON", false, err)
+	return err
+}
+
+func (s *RecordingStore) Save(data []byte) (err error) {
+	err = s.configStore.Save(data)
+	s.record("Save", true, err)
+	return err
+}
+
+func (s *RecordingStore) SaveJSON(data any) (err error) {
+	err = s.configStore.SaveJSON(data)
+	s.record("SaveJSON", true, err)
+	return err
+}
+
+func (s *RecordingStore) LoadContext(ctx context.Context) (data []byte, err error) {
+	data, err = cfgstore.LoadContext(ctx, s.configStore)
+	s.record("LoadContext", false, err)
+	return data, err
+}
+
+func (s *RecordingStore) LoadJSONContext(ctx context.Context, data any, opts ...json.Options) (err error) {
+	err = cfgstore.LoadJSONContext(ctx, s.configStore, data, opts...)
+	s.record("LoadJSONContext", false, err)
+	return err
+}
+
+func (s *RecordingStore) SaveContext(ctx context.Context, data []byte) (err error) {
+	err = cfgstore.SaveContext(ctx, s.configStore, data)
+	s.record("SaveContext", true, err)
+	return err
+}
+
+func (s *RecordingStore) SaveJSONContext(ctx context.Context, data any) (err error) {
+	err = cfgstore.SaveJSONContext(ctx, s.configStore, data)
+	s.record("SaveJSONContext", true, err)
+	return err
+}
+
+func (s *RecordingStore) LoadJSONStream(data any, opts ...json.Options) (err error) {
+	err = cfgstore.LoadJSONStream(s.configStore, data, opts...)
+	s.record("LoadJSONStream", false, err)
+	return err
+}
+
+func (s *RecordingStore) LoadJSONMapped(data any, opts ...json.Options) (err error) {
+	err = cfgstore.LoadJSONMapped(s.configStore, data, opts...)
+	s.record("LoadJSONMapped", false, err)
+	return err
+}
+
+func (s *RecordingStore) SaveJSONStream(data any, opts ...json.Options) (err error) {
+	err = cfgstore.SaveJSONStream(s.configStore, data, opts...)
+	s.record("SaveJSONStream", true, err)
+	return err
+}
+
+// record appends an Operation for method, reading the store's file through its
+// FileSystem so that hooks are not re-run.
+func (s *RecordingStore) record(method string, saved bool, err error) {
+	op := Operation{
+		Method: method,
+		Path:   s.configStore.GetRelFilepath(),
+		Saved:  saved,
+		Err:    err,
+	}
+	fp, fpErr := s.configStore.GetFilepath()
+	if fpErr == nil {
+		data, readErr := s.configStore.FileSystem().ReadFile(fp)
+		if readErr == nil {
+			sum := sha256.Sum256(data)
+			op.Hash = hex.EncodeToString(sum[:])
+			op.data = data
+		}
+	}
+	s.recorder.mutex.Lock()
+	s.recorder.ops = append(s.recorder.ops, op)
+	s.recorder.mutex.Unlock()
+}
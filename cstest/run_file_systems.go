@@ -0,0 +1,40 @@
+package cstest
+
+import (
+	"testing"
+)
+
+// FileSystemName names the backend a RunOnFileSystems subtest runs against.
+type FileSystemName string
+
+const (
+	DiskFileSystemName   FileSystemName = "disk"
+	MemoryFileSystemName FileSystemName = "memory"
+)
+
+// FileSystemNames lists the backends RunOnFileSystems runs fn against, in order.
+var FileSystemNames = []FileSystemName{
+	DiskFileSystemName,
+	MemoryFileSystemName,
+}
+
+// RunOnFileSystems runs fn as a subtest once with a Fixture on disk, in a new
+// test root, and once with a Fixture InMemory, so that a single test body
+// verifies both backends behave the same, e.g.
+//
+//	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+//		cs := fix.WithCLIConfig(`{"theme":"dark"}`).Stores().CLIConfigStore()
+//		...
+//	})
+func RunOnFileSystems(t *testing.T, fn func(t *testing.T, fix *Fixture)) {
+	t.Helper()
+	for _, name := range FileSystemNames {
+		t.Run(string(name), func(t *testing.T) {
+			fix := NewFixture(t)
+			if name == MemoryFileSystemName {
+				fix.InMemory()
+			}
+			fn(t, fix)
+		})
+	}
+}
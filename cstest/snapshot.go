@@ -0,0 +1,142 @@
+package cstest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+// TreeEntry is a file or directory captured by SnapshotTree.
+type TreeEntry struct {
+	Mode fs.FileMode
+
+	// Data is the content of a file, or nil for a directory.
+	Data []byte
+}
+
+// TreeSnapshot is the state of every file and directory below Root, keyed by
+// slash-separated path relative to Root.
+type TreeSnapshot struct {
+	Root    dt.DirPath
+	Entries map[string]TreeEntry
+}
+
+type SnapshotOptions struct {
+	// FileSystem is the FileSystem to read root from, e.g. the FileSystem of a
+	// provider returned by NewMemDirsProvider. Defaults to the OS file system.
+	FileSystem cfgstore.FileSystem
+}
+
+// SnapshotTree captures the path, mode and content of every file and directory
+// below root. A root that does not exist yet gives an empty snapshot, so that an
+// operation that creates it can be diffed.
+func SnapshotTree(t testing.TB, root dt.DirPath, opts ...SnapshotOptions) TreeSnapshot {
+	t.Helper()
+	if len(opts) == 0 {
+		opts = []SnapshotOptions{{}}
+	}
+	fileSystem := opts[0].FileSystem
+	if fileSystem == nil {
+		fileSystem = cfgstore.OSFileSystem()
+	}
+	snap := TreeSnapshot{
+		Root:    root,
+		Entries: make(map[string]TreeEntry),
+	}
+	fsys := fileSystem.DirFS(root)
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		var info fs.FileInfo
+		var entry TreeEntry
+
+		if path == "." && errors.Is(err, fs.ErrNotExist) {
+			return fs.SkipAll
+		}
+		if err != nil {
+			goto end
+		}
+		if path == "." {
+			goto end
+		}
+		info, err = d.Info()
+		if err != nil {
+			goto end
+		}
+		entry.Mode = info.Mode()
+		if !d.IsDir() {
+			entry.Data, err = fs.ReadFile(fsys, path)
+			if err != nil {
+				goto end
+			}
+		}
+		snap.Entries[path] = entry
+	end:
+		return err
+	})
+	if err != nil {
+		t.Fatalf("cstest: failed to snapshot %s: %v", root, err)
+	}
+	return snap
+}
+
+// TreeDiff lists the paths, relative to the snapshots' root, that differ
+// between two TreeSnapshots. Each list is sorted.
+type TreeDiff struct {
+	Created  []string
+	Modified []string
+	Deleted  []string
+}
+
+// IsEmpty returns true if the snapshots were identical.
+func (d TreeDiff) IsEmpty() bool {
+	return len(d.Created) == 0 && len(d.Modified) == 0 && len(d.Deleted) == 0
+}
+
+func (d TreeDiff) String() string {
+	var sb strings.Builder
+
+	for _, p := range d.Created {
+		fmt.Fprintf(&sb, "+ %s\n", p)
+	}
+	for _, p := range d.Modified {
+		fmt.Fprintf(&sb, "~ %s\n", p)
+	}
+	for _, p := range d.Deleted {
+		fmt.Fprintf(&sb, "- %s\n", p)
+	}
+	return sb.String()
+}
+
+// DiffSnapshots returns which entries were created, modified, in content or
+// mode, and deleted between before and after, e.g.
+//
+//	before := cstest.SnapshotTree(t, root)
+//	err := app.Init(store)
+//	diff := cstest.DiffSnapshots(before, cstest.SnapshotTree(t, root))
+//	assert.Equal(t, []string{".config", ".config/acme", ".config/acme/config.json"}, diff.Created)
+func DiffSnapshots(before, after TreeSnapshot) (diff TreeDiff) {
+	for path, a := range after.Entries {
+		b, ok := before.Entries[path]
+		switch {
+		case !ok:
+			diff.Created = append(diff.Created, path)
+		case a.Mode != b.Mode || !bytes.Equal(a.Data, b.Data):
+			diff.Modified = append(diff.Modified, path)
+		}
+	}
+	for path := range before.Entries {
+		if _, ok := after.Entries[path]; !ok {
+			diff.Deleted = append(diff.Deleted, path)
+		}
+	}
+	slices.Sort(diff.Created)
+	slices.Sort(diff.Modified)
+	slices.Sort(diff.Deleted)
+	return diff
+}
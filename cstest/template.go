@@ -0,0 +1,83 @@
+package cstest
+
+import (
+	"bytes"
+	"encoding/json/v2"
+	"os"
+	"testing"
+	"text/template"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+// TemplateData is the data fixture templates are executed with, so fixtures can
+// contain paths within the fake config layout without manual patching, e.g.
+//
+//	{"cache": {{ json .CLIConfigDir }}, "user": "{{ .Username }}"}
+type TemplateData struct {
+	TestRoot         dt.DirPath
+	Username         dt.PathSegment
+	ConfigSlug       dt.PathSegment
+	HomeDir          dt.DirPath
+	ProjectDir       dt.DirPath
+	AppConfigDir     dt.DirPath
+	CLIConfigDir     dt.DirPath
+	ProjectConfigDir dt.DirPath
+	CacheDir         dt.DirPath
+	ProjectCacheDir  dt.DirPath
+	StateDir         dt.DirPath
+}
+
+// TemplateFuncs are the functions available to fixture templates in addition to
+// text/template's builtins. json encodes its argument as JSON, which quotes and
+// escapes paths, e.g. Windows paths containing backslashes.
+var TemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// NewTemplateData returns the TemplateData for the layout fabricated by dp for
+// args. Directories that cannot be resolved are left empty.
+func NewTemplateData(args *TestDirsProviderArgs, dp *cfgstore.DirsProvider) (data TemplateData) {
+	data = TemplateData{
+		TestRoot:   args.TestRoot,
+		Username:   args.Username,
+		ConfigSlug: args.ConfigSlug,
+	}
+	data.HomeDir, _ = dp.UserHomeDirFunc()
+	data.ProjectDir, _ = dp.ProjectDirFunc()
+	data.AppConfigDir, _ = cfgstore.ConfigDir(cfgstore.AppConfigDirType, args.ConfigSlug, dp)
+	data.CLIConfigDir, _ = cfgstore.ConfigDir(cfgstore.CLIConfigDirType, args.ConfigSlug, dp)
+	data.ProjectConfigDir, _ = cfgstore.ConfigDir(cfgstore.ProjectConfigDirType, args.ConfigSlug, dp)
+	data.CacheDir, _ = cfgstore.GetSharedCacheDir(args.ConfigSlug, cfgstore.CacheOptions{DirsProvider: dp})
+	data.ProjectCacheDir, _ = cfgstore.GetProjectCacheDir(args.ConfigSlug, cfgstore.CacheOptions{DirsProvider: dp})
+	data.StateDir, _ = cfgstore.StateDir(args.ConfigSlug, dp)
+	return data
+}
+
+// RenderTemplateFile executes the text/template in tmplFile, typically a file in
+// testdata/, with data and TemplateFuncs, calling t.Fatal on error.
+func RenderTemplateFile(t testing.TB, tmplFile dt.Filepath, data any) []byte {
+	var buf bytes.Buffer
+
+	t.Helper()
+	src, err := os.ReadFile(string(tmplFile))
+	if err != nil {
+		t.Fatalf("cstest: failed to read template %s: %v", tmplFile, err)
+	}
+	tmpl, err := template.New(string(tmplFile.Base())).
+		Funcs(TemplateFuncs).
+		Option("missingkey=error").
+		Parse(string(src))
+	if err != nil {
+		t.Fatalf("cstest: failed to parse template %s: %v", tmplFile, err)
+	}
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		t.Fatalf("cstest: failed to execute template %s: %v", tmplFile, err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,45 @@
+package cstest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// NewTestRoot returns a new, empty directory for t to use as a TestRoot. Its
+// last segment is derived from t.Name() so that failure output identifies the
+// test, and it lives within t.TempDir() so that it is unique to each call,
+// safe for tests using t.Parallel() and removed when t completes.
+func NewTestRoot(t testing.TB) dt.DirPath {
+	t.Helper()
+	return dt.DirPathJoin(t.TempDir(), testRootSegment(t.Name()))
+}
+
+// NewTestDirsProviderArgs returns TestDirsProviderArgs for the user "coyote",
+// the project "billboard" and the config slug "acme" rooted in NewTestRoot(t).
+func NewTestDirsProviderArgs(t testing.TB) *TestDirsProviderArgs {
+	t.Helper()
+	return &TestDirsProviderArgs{
+		Username:   DefaultFixtureUsername,
+		ProjectDir: DefaultFixtureProjectDir,
+		ConfigSlug: DefaultFixtureConfigSlug,
+		TestRoot:   NewTestRoot(t),
+	}
+}
+
+// testRootSegment converts a test name, which may contain slashes for subtests
+// and other characters unsuitable for a filename, into a single path segment.
+func testRootSegment(name string) dt.PathSegment {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return dt.PathSegment(name)
+}
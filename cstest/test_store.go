@@ -0,0 +1,33 @@
+package cstest
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+)
+
+// NewTestStore returns a store of dirType for the config slug and relFilepath
+// whose directories are fabricated within NewTestRoot(t) for the user "coyote"
+// and the project "billboard". The store's config dir is removed when t
+// completes, and t fails if the store writes outside the test root.
+func NewTestStore(t testing.TB, dirType cfgstore.DirType, slug dt.PathSegment, relFilepath dt.RelFilepath) cfgstore.ConfigStore {
+	t.Helper()
+	args := NewTestDirsProviderArgs(t)
+	args.ConfigSlug = slug
+	cs := cfgstore.NewConfigStore(dirType, cfgstore.ConfigStoreArgs{
+		ConfigSlug:   slug,
+		RelFilepath:  relFilepath,
+		DirsProvider: GuardWrites(t, NewTestDirsProvider(args), args),
+	})
+	t.Cleanup(func() {
+		dir, err := cs.ConfigDir()
+		if err == nil {
+			err = dir.RemoveAll()
+		}
+		if err != nil {
+			t.Errorf("cstest: failed to remove config dir for %s store: %v", dirType.Slug(), err)
+		}
+	})
+	return cs
+}
@@ -0,0 +1,227 @@
+package cfgstore
+
+import (
+	jsonv2 "encoding/json/v2"
+	"errors"
+	"io/fs"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// diagnoseProbeFile is written and removed to check that a directory is
+// writable.
+const diagnoseProbeFile dt.PathSegment = ".cfgstore-probe"
+
+// diagnoseEnvVars are the environment variables that change where config,
+// cache and state directories are found.
+var diagnoseEnvVars = []string{
+	"HOME",
+	"XDG_CONFIG_HOME",
+	"XDG_CACHE_HOME",
+	"XDG_STATE_HOME",
+	"USERPROFILE",
+	"APPDATA",
+	"LOCALAPPDATA",
+}
+
+// Diagnosis is a report of where a set of stores' files are and what state
+// they are in, for support tooling and --debug output. See Diagnose.
+type Diagnosis struct {
+	Layers      []LayerDiagnosis
+	Environment EnvironmentDiagnosis
+}
+
+// LayerDiagnosis is the part of a Diagnosis for one store.
+type LayerDiagnosis struct {
+	DirType   DirType
+	ConfigDir dt.DirPath
+	Filepath  dt.Filepath
+	Exists    bool
+
+	// Size, Mode and ModTime are those of Filepath, if it exists.
+	Size    int64
+	Mode    fs.FileMode
+	ModTime time.Time
+
+	// Writable reports whether a file could be created in ConfigDir or, if it
+	// does not exist yet, in its nearest existing parent.
+	Writable bool
+
+	// Findings lists the problems found, e.g. directories that could not be
+	// resolved or a file that is not valid JSON. It is empty for a healthy
+	// layer.
+	Findings []error
+}
+
+// EnvironmentDiagnosis is the part of a Diagnosis for the process's
+// environment.
+type EnvironmentDiagnosis struct {
+	GOOS string
+
+	// Vars are the environment variables that affect where directories are
+	// found, such as XDG_CONFIG_HOME, that are set.
+	Vars []EnvVar
+
+	// Sandbox names the sandbox the process appears to run in, which may
+	// redirect or restrict its directories: "flatpak", "snap",
+	// "macos-app-sandbox", "container", or "" for none.
+	Sandbox string
+}
+
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+type DiagnoseOptions struct {
+	// LookupEnv looks up an environment variable. Defaults to os.LookupEnv.
+	LookupEnv func(name string) (string, bool)
+}
+
+// Diagnose reports, for each store in stores in DirTypes order, the resolved
+// directory and file, whether the file exists and its size, mode and
+// modification time, whether the directory is writable and any problems found,
+// and also the environment variables and sandbox that affect where directories
+// are. Diagnose does not fail; problems are reported as Findings, which Err
+// combines.
+func Diagnose(stores *ConfigStores, opts ...DiagnoseOptions) (d Diagnosis) {
+	if len(opts) == 0 {
+		opts = []DiagnoseOptions{{}}
+	}
+	if opts[0].LookupEnv == nil {
+		opts[0].LookupEnv = os.LookupEnv
+	}
+	for _, dirType := range stores.DirTypes {
+		d.Layers = append(d.Layers, diagnoseLayer(stores.StoreMap[dirType]))
+	}
+	d.Environment = diagnoseEnvironment(opts[0].LookupEnv)
+	return d
+}
+
+// Err returns the combined Findings of every layer, or nil if there are none.
+func (d Diagnosis) Err() error {
+	var errs []error
+	for _, layer := range d.Layers {
+		for _, finding := range layer.Findings {
+			errs = append(errs, WithErr(finding, "dir_type", layer.DirType.Slug()))
+		}
+	}
+	return CombineErrs(errs)
+}
+
+func diagnoseLayer(store ConfigStore) (layer LayerDiagnosis) {
+	var info fs.FileInfo
+	var data []byte
+	var value any
+	var err error
+
+	fSys := store.FileSystem()
+	layer.DirType = store.DirType()
+	layer.ConfigDir, err = store.ConfigDir()
+	if err != nil {
+		layer.Findings = append(layer.Findings, err)
+		goto end
+	}
+	layer.Filepath, err = store.GetFilepath()
+	if err != nil {
+		layer.Findings = append(layer.Findings, err)
+		goto end
+	}
+	layer.Writable, err = probeWritable(fSys, layer.ConfigDir)
+	if err != nil {
+		layer.Findings = append(layer.Findings, NewErr(ErrConfigDirNotWritable, "config_dir", layer.ConfigDir, err))
+	}
+	info, err = fSys.Stat(layer.Filepath)
+	if errors.Is(err, fs.ErrNotExist) {
+		goto end
+	}
+	if err != nil {
+		layer.Findings = append(layer.Findings, withErrorKind(err))
+		goto end
+	}
+	layer.Exists = true
+	layer.Size = info.Size()
+	layer.Mode = info.Mode()
+	layer.ModTime = info.ModTime()
+	if runtime.GOOS != "windows" && layer.Mode.Perm()&0o002 != 0 {
+		layer.Findings = append(layer.Findings, NewErr(ErrConfigFileWorldWritable,
+			"filepath", layer.Filepath,
+			"mode", layer.Mode.String(),
+		))
+	}
+	data, err = fSys.ReadFile(layer.Filepath)
+	if err != nil {
+		layer.Findings = append(layer.Findings, withErrorKind(NewErr(ErrFailedToReadConfigFile, err)))
+		goto end
+	}
+	err = jsonv2.Unmarshal(data, &value)
+	if err != nil {
+		layer.Findings = append(layer.Findings, withErrorKind(NewErr(ErrFailedToUnmarshalConfigFile, err)))
+	}
+end:
+	return layer
+}
+
+// probeWritable creates and removes a file in dir or, if dir does not exist,
+// in its nearest existing parent.
+func probeWritable(fSys FileSystem, dir dt.DirPath) (writable bool, err error) {
+	var fp dt.Filepath
+
+	for {
+		_, err = fSys.Stat(dt.Filepath(dir))
+		if err == nil || dir.Dir() == dir {
+			break
+		}
+		dir = dir.Dir()
+	}
+	if err != nil {
+		goto end
+	}
+	fp = dt.FilepathJoin(dir, diagnoseProbeFile+dt.PathSegment(strconv.Itoa(os.Getpid())))
+	err = fSys.WriteFile(fp, nil)
+	if err != nil {
+		goto end
+	}
+	writable = true
+	err = fSys.Remove(fp)
+end:
+	return writable, err
+}
+
+func diagnoseEnvironment(lookupEnv func(string) (string, bool)) (env EnvironmentDiagnosis) {
+	env.GOOS = runtime.GOOS
+	for _, name := range diagnoseEnvVars {
+		value, ok := lookupEnv(name)
+		if ok {
+			env.Vars = append(env.Vars, EnvVar{Name: name, Value: value})
+		}
+	}
+	env.Sandbox = detectSandbox(lookupEnv)
+	return env
+}
+
+func detectSandbox(lookupEnv func(string) (string, bool)) string {
+	isSet := func(name string) bool {
+		value, ok := lookupEnv(name)
+		return ok && value != ""
+	}
+	switch {
+	case isSet("FLATPAK_ID"):
+		return "flatpak"
+	case isSet("SNAP"):
+		return "snap"
+	case isSet("APP_SANDBOX_CONTAINER_ID"):
+		return "macos-app-sandbox"
+	case isSet("container"):
+		return "container"
+	}
+	_, err := os.Stat("/.dockerenv")
+	if err == nil {
+		return "container"
+	}
+	return ""
+}
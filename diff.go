@@ -0,0 +1,155 @@
+package cfgstore
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"errors"
+)
+
+// ChangeKind is how the value at a Change's Path differs.
+type ChangeKind int
+
+const (
+	UnspecifiedChangeKind ChangeKind = iota
+	AddedChangeKind                  // The value is only in the second document
+	RemovedChangeKind                // The value is only in the first document
+	ChangedChangeKind                // The documents have different values
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case AddedChangeKind:
+		return "Added"
+	case RemovedChangeKind:
+		return "Removed"
+	case ChangedChangeKind:
+		return "Changed"
+	case UnspecifiedChangeKind:
+		return "Unspecified"
+	default:
+	}
+	return "Invalid"
+}
+
+func (k ChangeKind) Slug() string {
+	switch k {
+	case AddedChangeKind:
+		return "added"
+	case RemovedChangeKind:
+		return "removed"
+	case ChangedChangeKind:
+		return "changed"
+	case UnspecifiedChangeKind:
+		return "unspecified"
+	default:
+	}
+	return "invalid"
+}
+
+// Change is a difference found by Diff at a dotted path.
+type Change struct {
+	Path string
+	Kind ChangeKind
+
+	// Old and New are the values in the first and second document, as GetValue
+	// returns them. Old is nil for AddedChangeKind and New is nil for
+	// RemovedChangeKind.
+	Old any
+	New any
+}
+
+// Diff returns the key-level differences between the files of stores a and b,
+// e.g. two layers of a ConfigStores, with a's values as Old and b's as New. A
+// file that does not exist is empty. See DiffJSON.
+func Diff(a, b ConfigStore) (changes []Change, err error) {
+	var aData, bData []byte
+
+	aData, err = loadForDiff(a)
+	if err != nil {
+		goto end
+	}
+	bData, err = loadForDiff(b)
+	if err != nil {
+		goto end
+	}
+	changes, err = DiffJSON(aData, bData)
+end:
+	return changes, err
+}
+
+// DiffJSON returns the key-level differences between the JSON documents a and b,
+// e.g. a store's content and what is about to be saved to it, for a preview of
+// what will change. Either may be empty. The scalars, empty objects and empty
+// arrays of b that are not in a or differ from a are listed first, in b's
+// order, followed by those of a that are not in b, in a's order. Values are
+// compared canonically, so 1.0 and 1 are equal.
+func DiffJSON(a, b []byte) (changes []Change, err error) {
+	var aLeaves, bLeaves []auditLeaf
+	var aIndex, bIndex map[string]int
+	var change Change
+
+	aLeaves, err = documentLeaves(a)
+	if err != nil {
+		goto end
+	}
+	bLeaves, err = documentLeaves(b)
+	if err != nil {
+		goto end
+	}
+	aIndex = leafIndex(aLeaves)
+	bIndex = leafIndex(bLeaves)
+	for _, leaf := range bLeaves {
+		i, found := aIndex[leaf.path]
+		switch {
+		case !found:
+			change = Change{Path: leaf.path, Kind: AddedChangeKind}
+		case !equalLeaves(aLeaves[i].value, leaf.value):
+			change = Change{Path: leaf.path, Kind: ChangedChangeKind}
+			err = jsonv2.Unmarshal(aLeaves[i].value, &change.Old)
+		default:
+			continue
+		}
+		if err == nil {
+			err = jsonv2.Unmarshal(leaf.value, &change.New)
+		}
+		if err != nil {
+			goto end
+		}
+		changes = append(changes, change)
+	}
+	for _, leaf := range aLeaves {
+		_, found := bIndex[leaf.path]
+		if found {
+			continue
+		}
+		change = Change{Path: leaf.path, Kind: RemovedChangeKind}
+		err = jsonv2.Unmarshal(leaf.value, &change.Old)
+		if err != nil {
+			goto end
+		}
+		changes = append(changes, change)
+	}
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToDiffConfig, err)
+	}
+	return changes, err
+}
+
+func loadForDiff(store ConfigStore) (data []byte, err error) {
+	data, err = store.Load()
+	if errors.Is(err, ErrFileDoesNotExist) {
+		err = nil
+	}
+	return data, err
+}
+
+// equalLeaves returns true if the encoded values a and b are canonically equal.
+func equalLeaves(a, b []byte) bool {
+	aValue, bValue := jsontext.Value(bytes.Clone(a)), jsontext.Value(bytes.Clone(b))
+	if aValue.Canonicalize() != nil || bValue.Canonicalize() != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(aValue, bValue)
+}
@@ -32,6 +32,20 @@ func (dt DirType) Slug() string {
 	return "invalid"
 }
 
+// ParseDirType returns the DirType whose Slug is slug, e.g. for a command-line
+// flag.
+func ParseDirType(slug string) (dirType DirType, err error) {
+	for _, dirType = range []DirType{AppConfigDirType, CLIConfigDirType, ProjectConfigDirType} {
+		if dirType.Slug() == slug {
+			goto end
+		}
+	}
+	dirType = UnspecifiedConfigDirType
+	err = NewErr(ErrInvalidConfigDirType, "dir_type", slug)
+end:
+	return dirType, err
+}
+
 const (
 	UnspecifiedConfigDirType DirType = iota
 	AppConfigDirType                 // The value os.UserConfigDir() returns
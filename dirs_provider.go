@@ -13,15 +13,23 @@ type DirsProvider struct {
 	UserConfigDirFunc DirFunc
 	CLIConfigDirFunc  DirFunc
 	UserCacheDirFunc  DirFunc
+	UserStateDirFunc  DirFunc
+
+	// FileSystem is used by stores to read and write their files. Defaults to
+	// OSFileSystem().
+	FileSystem FileSystem
+}
+
+// WithProjectDir returns a copy of dp whose ProjectDirFunc returns dir, for
+// callers who already know the project root.
+func (dp *DirsProvider) WithProjectDir(dir dt.DirPath) *DirsProvider {
+	newDP := *dp
+	newDP.ProjectDirFunc = func() (dt.DirPath, error) {
+		return dir, nil
+	}
+	return &newDP
 }
 
-//func (dp DirsProvider) WithProjectDir(dir dt.DirPath) DirsProvider {
-//	newDP := dp
-//	newDP.ProjectDirFunc = func() (dt.DirPath, error) {
-//		return dir, nil
-//	}
-//	return newDP
-//}
 //func (dp DirsProvider) WithUserConfigDir(dir dt.DirPath) DirsProvider {
 //	newDP := dp
 //	newDP.UserConfigDirFunc = func() (dt.DirPath, error) {
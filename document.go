@@ -0,0 +1,499 @@
+package cfgstore
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// The document model below lets path-based accessors edit a store's JSON file
+// without knowing the app's struct. Objects keep their members in file order
+// and scalars keep their original text, so that setting one value rewrites the
+// rest of the file as it was, other than indentation.
+//
+// A node is one of *jsonObject, *jsonArray or jsontext.Value for scalars. A nil
+// node is an absent or empty document.
+
+type jsonObject struct {
+	members []jsonMember
+}
+
+type jsonMember struct {
+	name  string
+	value any
+}
+
+type jsonArray struct {
+	items []any
+}
+
+func (obj *jsonObject) index(name string) int {
+	return slices.IndexFunc(obj.members, func(m jsonMember) bool {
+		return m.name == name
+	})
+}
+
+// parseDocument parses data into a node. Empty data parses as a nil node.
+func parseDocument(data []byte) (node any, err error) {
+	var dec *jsontext.Decoder
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		goto end
+	}
+	dec = jsontext.NewDecoder(bytes.NewReader(data))
+	node, err = decodeNode(dec)
+	if err != nil {
+		goto end
+	}
+	_, err = dec.ReadToken()
+	switch {
+	case errors.Is(err, io.EOF):
+		err = nil
+	case err == nil:
+		err = NewErr(ErrUnexpectedTrailingData)
+	}
+end:
+	if err != nil {
+		node = nil
+		err = NewErr(ErrFailedToUnmarshalConfigFile, err)
+	}
+	return node, err
+}
+
+func decodeNode(dec *jsontext.Decoder) (node any, err error) {
+	var tok jsontext.Token
+	var value jsontext.Value
+
+	switch dec.PeekKind() {
+	case '{':
+		obj := &jsonObject{}
+		_, err = dec.ReadToken()
+		for err == nil && dec.PeekKind() != '}' {
+			var member jsonMember
+			tok, err = dec.ReadToken()
+			if err != nil {
+				goto end
+			}
+			member.name = tok.String()
+			member.value, err = decodeNode(dec)
+			obj.members = append(obj.members, member)
+		}
+		if err != nil {
+			goto end
+		}
+		_, err = dec.ReadToken()
+		node = obj
+	case '[':
+		arr := &jsonArray{}
+		_, err = dec.ReadToken()
+		for err == nil && dec.PeekKind() != ']' {
+			var item any
+			item, err = decodeNode(dec)
+			arr.items = append(arr.items, item)
+		}
+		if err != nil {
+			goto end
+		}
+		_, err = dec.ReadToken()
+		node = arr
+	default:
+		value, err = dec.ReadValue()
+		// The decoder reuses its buffer so the value must be copied
+		node = value.Clone()
+	}
+end:
+	return node, err
+}
+
+// encodeDocument encodes node as indented JSON.
+func encodeDocument(node any) (data []byte, err error) {
+	var buf bytes.Buffer
+
+	if node == nil {
+		node = &jsonObject{}
+	}
+	enc := jsontext.NewEncoder(&buf, jsontext.WithIndent("  "))
+	err = encodeNode(enc, node)
+	if err != nil {
+		goto end
+	}
+	data = buf.Bytes()
+end:
+	return data, err
+}
+
+func encodeNode(enc *jsontext.Encoder, node any) (err error) {
+	switch n := node.(type) {
+	case *jsonObject:
+		err = enc.WriteToken(jsontext.BeginObject)
+		for i := 0; err == nil && i < len(n.members); i++ {
+			err = enc.WriteToken(jsontext.String(n.members[i].name))
+			if err == nil {
+				err = encodeNode(enc, n.members[i].value)
+			}
+		}
+		if err == nil {
+			err = enc.WriteToken(jsontext.EndObject)
+		}
+	case *jsonArray:
+		err = enc.WriteToken(jsontext.BeginArray)
+		for i := 0; err == nil && i < len(n.items); i++ {
+			err = encodeNode(enc, n.items[i])
+		}
+		if err == nil {
+			err = enc.WriteToken(jsontext.EndArray)
+		}
+	case jsontext.Value:
+		err = enc.WriteValue(n)
+	default:
+		err = NewErr(ErrUnexpectedNodeType, "type", fmt.Sprintf("%T", node))
+	}
+	return err
+}
+
+// valueToNode converts a Go value into a node by marshaling it.
+func valueToNode(value any) (node any, err error) {
+	var data []byte
+
+	data, err = jsonv2.Marshal(value)
+	if err != nil {
+		err = NewErr(ErrFailedToMarshalValue, err)
+		goto end
+	}
+	node, err = parseDocument(data)
+end:
+	return node, err
+}
+
+// nodeToValue converts a node into map[string]any, []any, string, float64, bool
+// or nil values.
+func nodeToValue(node any) (value any, err error) {
+	var data []byte
+
+	if node == nil {
+		goto end
+	}
+	data, err = encodeDocument(node)
+	if err != nil {
+		goto end
+	}
+	err = jsonv2.Unmarshal(data, &value)
+end:
+	return value, err
+}
+
+// lookupNode returns the node found by following segments from root.
+func lookupNode(root any, segments []string) (node any, found bool) {
+	node = root
+	for _, seg := range segments {
+		switch n := node.(type) {
+		case *jsonObject:
+			i := n.index(seg)
+			if i < 0 {
+				goto end
+			}
+			node = n.members[i].value
+		case *jsonArray:
+			i, ok := arrayIndex(seg, len(n.items))
+			if !ok {
+				goto end
+			}
+			node = n.items[i]
+		default:
+			goto end
+		}
+	}
+	found = node != nil
+end:
+	if !found {
+		node = nil
+	}
+	return node, found
+}
+
+// setNode sets the node found by following segments from root to value,
+// creating intermediate objects as needed, and returns the possibly new root.
+// An array index equal to the array's length, or "-", appends to it.
+func setNode(root any, segments []string, value any) (_ any, err error) {
+	var parent any
+
+	if len(segments) == 0 {
+		root = value
+		goto end
+	}
+	if root == nil {
+		root = &jsonObject{}
+	}
+	parent = root
+	for n, seg := range segments {
+		last := n == len(segments)-1
+		switch p := parent.(type) {
+		case *jsonObject:
+			i := p.index(seg)
+			switch {
+			case last && i >= 0:
+				p.members[i].value = value
+			case last:
+				p.members = append(p.members, jsonMember{name: seg, value: value})
+			case i >= 0:
+				parent = p.members[i].value
+			default:
+				child := &jsonObject{}
+				p.members = append(p.members, jsonMember{name: seg, value: child})
+				parent = child
+			}
+		case *jsonArray:
+			if seg == "-" {
+				// RFC 6901 uses "-" for the position after the last element
+				seg = strconv.Itoa(len(p.items))
+			}
+			i, ok := arrayIndex(seg, len(p.items)+1)
+			if !ok {
+				err = NewErr(ErrInvalidValuePath, "segment", seg, "length", len(p.items))
+				goto end
+			}
+			switch {
+			case i == len(p.items) && last:
+				p.items = append(p.items, value)
+			case i == len(p.items):
+				child := &jsonObject{}
+				p.items = append(p.items, child)
+				parent = child
+			case last:
+				p.items[i] = value
+			default:
+				parent = p.items[i]
+			}
+		default:
+			err = NewErr(ErrValuePathNotContainer, "segment", seg)
+			goto end
+		}
+	}
+end:
+	return root, err
+}
+
+// unsetNode removes the node found by following segments from root. Array
+// elements after a removed element shift down. If prune is true, objects and
+// arrays left empty by the removal are removed too, other than root.
+func unsetNode(root any, segments []string, prune bool) (found bool) {
+	var parents []any
+	var node any
+
+	if len(segments) == 0 {
+		goto end
+	}
+	// Collect each parent along the path so empty ones can be pruned
+	node = root
+	for _, seg := range segments {
+		parents = append(parents, node)
+		node, found = lookupNode(node, []string{seg})
+		if !found {
+			goto end
+		}
+	}
+	for n := len(segments) - 1; n >= 0; n-- {
+		removeChild(parents[n], segments[n])
+		if !prune || n == 0 || !isEmptyNode(parents[n]) {
+			break
+		}
+	}
+end:
+	return found
+}
+
+// removeChild removes the member or element identified by seg from parent,
+// which must contain it.
+func removeChild(parent any, seg string) {
+	switch p := parent.(type) {
+	case *jsonObject:
+		p.members = slices.Delete(p.members, p.index(seg), p.index(seg)+1)
+	case *jsonArray:
+		i, _ := arrayIndex(seg, len(p.items))
+		p.items = slices.Delete(p.items, i, i+1)
+	}
+}
+
+func isStringNode(node any) bool {
+	raw, ok := node.(jsontext.Value)
+	return ok && raw.Kind() == '"'
+}
+
+func isScalarNode(node any) (scalar bool) {
+	_, scalar = node.(jsontext.Value)
+	return scalar
+}
+
+func isEmptyNode(node any) (empty bool) {
+	switch n := node.(type) {
+	case *jsonObject:
+		empty = len(n.members) == 0
+	case *jsonArray:
+		empty = len(n.items) == 0
+	}
+	return empty
+}
+
+// walkNode calls fn for each node below node, depth first and in document
+// order, passing its segments. fn returning SkipChildren skips the node's
+// children; any other error stops the walk.
+func walkNode(node any, segments []string, fn func(segments []string, node any) error) (err error) {
+	var children []jsonMember
+
+	switch n := node.(type) {
+	case *jsonObject:
+		children = n.members
+	case *jsonArray:
+		for i, item := range n.items {
+			children = append(children, jsonMember{name: strconv.Itoa(i), value: item})
+		}
+	}
+	for _, child := range children {
+		segs := append(slices.Clip(segments), child.name)
+		err = fn(segs, child.value)
+		if errors.Is(err, SkipChildren) {
+			err = nil
+			continue
+		}
+		if err == nil {
+			err = walkNode(child.value, segs, fn)
+		}
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+// mergeObject deep-merges patch into target following RFC 7396 JSON Merge
+// Patch: objects are merged recursively, null removes a member and any other
+// value replaces the member. New members are added after existing ones.
+func mergeObject(target, patch *jsonObject) {
+	for _, m := range patch.members {
+		i := target.index(m.name)
+		patchObj, isObj := m.value.(*jsonObject)
+		switch {
+		case isNullNode(m.value):
+			if i >= 0 {
+				target.members = slices.Delete(target.members, i, i+1)
+			}
+		case i >= 0 && isObj:
+			targetObj, ok := target.members[i].value.(*jsonObject)
+			if !ok {
+				targetObj = &jsonObject{}
+				target.members[i].value = targetObj
+			}
+			mergeObject(targetObj, patchObj)
+		case isObj:
+			// Merge into an empty object so that nulls within patchObj are dropped
+			targetObj := &jsonObject{}
+			mergeObject(targetObj, patchObj)
+			target.members = append(target.members, jsonMember{name: m.name, value: targetObj})
+		case i >= 0:
+			target.members[i].value = m.value
+		default:
+			target.members = append(target.members, m)
+		}
+	}
+}
+
+// overlayObject layers after, the newly marshaled form of a struct, over
+// target, the document the struct was loaded from, so that members the struct
+// does not model are kept. Members of after replace those of target, with
+// objects overlaid recursively. Members of target that were in before, the
+// struct as loaded, but not in after, e.g. omitempty fields that were cleared,
+// are removed. before may be nil.
+func overlayObject(target, before, after *jsonObject) {
+	if before != nil {
+		for _, m := range before.members {
+			if after.index(m.name) >= 0 {
+				continue
+			}
+			i := target.index(m.name)
+			if i >= 0 {
+				target.members = slices.Delete(target.members, i, i+1)
+			}
+		}
+	}
+	for _, m := range after.members {
+		i := target.index(m.name)
+		if i < 0 {
+			target.members = append(target.members, m)
+			continue
+		}
+		targetObj, ok1 := target.members[i].value.(*jsonObject)
+		afterObj, ok2 := m.value.(*jsonObject)
+		if !ok1 || !ok2 {
+			target.members[i].value = m.value
+			continue
+		}
+		var beforeObj *jsonObject
+		if before != nil {
+			if j := before.index(m.name); j >= 0 {
+				beforeObj, _ = before.members[j].value.(*jsonObject)
+			}
+		}
+		overlayObject(targetObj, beforeObj, afterObj)
+	}
+}
+
+func isNullNode(node any) bool {
+	raw, ok := node.(jsontext.Value)
+	return ok && raw.Kind() == 'n'
+}
+
+// mapStrings replaces every string value within node, but not object member
+// names, with the result of calling fn on it, and returns the possibly new node.
+func mapStrings(node any, fn func(string) (string, error)) (_ any, err error) {
+	var s string
+	var raw []byte
+
+	switch n := node.(type) {
+	case *jsonObject:
+		for i := 0; err == nil && i < len(n.members); i++ {
+			n.members[i].value, err = mapStrings(n.members[i].value, fn)
+		}
+	case *jsonArray:
+		for i := 0; err == nil && i < len(n.items); i++ {
+			n.items[i], err = mapStrings(n.items[i], fn)
+		}
+	case jsontext.Value:
+		if n.Kind() != '"' {
+			break
+		}
+		s, err = unquoteJSON(n)
+		if err != nil {
+			break
+		}
+		s, err = fn(s)
+		if err != nil {
+			break
+		}
+		raw, err = jsontext.AppendQuote(nil, s)
+		node = jsontext.Value(raw)
+	}
+	return node, err
+}
+
+// arrayIndex parses seg as an index that is less than limit. As in RFC 6901 an
+// index is "0" or digits without a leading zero, so "+1" and "01" are not.
+func arrayIndex(seg string, limit int) (i int, ok bool) {
+	var err error
+
+	if !isDigits(seg) || len(seg) > 1 && seg[0] == '0' {
+		goto end
+	}
+	i, err = strconv.Atoi(seg)
+	if err != nil || i >= limit {
+		goto end
+	}
+	ok = true
+end:
+	return i, ok
+}
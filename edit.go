@@ -0,0 +1,189 @@
+package cfgstore
+
+import (
+	"bytes"
+	"context"
+	jsonv2 "encoding/json/v2"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// EditValidateFunc returns an error if data, the edited content of a store's
+// file, must not be saved.
+type EditValidateFunc func(data []byte) error
+
+type EditOptions struct {
+	// Editor is the command line of the editor, e.g. "code --wait". Defaults to
+	// $VISUAL, then $EDITOR.
+	Editor string
+
+	// LookupEnv reads VISUAL and EDITOR. Defaults to os.LookupEnv.
+	LookupEnv func(string) (string, bool)
+
+	// Stdin, Stdout and Stderr are connected to the editor. They default to
+	// those of the process.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Retry, if set, is called when the edited content is invalid, typically to
+	// show err and ask the user whether to fix it. If it returns true the editor
+	// is opened again on the edited content.
+	Retry func(err error) bool
+}
+
+// EditInteractive opens a copy of the store's file in the user's editor and,
+// once the editor exits, checks that the edited copy is valid JSON and passes
+// validate, if not nil, before atomically replacing the file with it, as
+// `kubectl edit` does. A file that does not exist starts as an empty object.
+// changed is false, and nothing is saved, if the content was not modified.
+//
+// If the edited content is invalid and is not fixed the copy is kept so the
+// user's edits are not lost, and the returned error has its path as
+// "edited_file".
+func EditInteractive(store ConfigStore, validate EditValidateFunc, opts ...EditOptions) (changed bool, err error) {
+	var original, edited []byte
+	var editor []string
+	var tmp *os.File
+	var keep bool
+
+	if len(opts) == 0 {
+		opts = []EditOptions{{}}
+	}
+	editor = editorCommand(opts[0])
+	if len(editor) == 0 {
+		err = NewErr(ErrNoEditor)
+		goto end
+	}
+	original, err = LoadContext(forUpdate(context.Background()), store)
+	if errors.Is(err, ErrFileDoesNotExist) {
+		original, err = []byte("{}\n"), nil
+	}
+	if err != nil {
+		goto end
+	}
+	tmp, err = os.CreateTemp("", editTempPattern(store))
+	if err != nil {
+		goto end
+	}
+	defer func() {
+		if !keep {
+			LogOnError(os.Remove(tmp.Name()))
+		}
+	}()
+	_, err = tmp.Write(original)
+	err = CombineErrs([]error{err, tmp.Close()})
+	if err != nil {
+		goto end
+	}
+	for {
+		err = runEditor(editor, tmp.Name(), opts[0])
+		if err != nil {
+			goto end
+		}
+		edited, err = os.ReadFile(tmp.Name())
+		if err != nil {
+			goto end
+		}
+		if bytes.Equal(edited, original) {
+			goto end
+		}
+		err = validateEdit(edited, validate)
+		if err == nil {
+			break
+		}
+		if opts[0].Retry == nil || !opts[0].Retry(err) {
+			keep = true
+			goto end
+		}
+	}
+	err = store.Save(edited)
+	changed = err == nil
+end:
+	switch {
+	case keep:
+		err = NewErr(ErrFailedToEditConfig, "edited_file", tmp.Name(), err)
+	case err != nil:
+		err = NewErr(ErrFailedToEditConfig, err)
+	}
+	return changed, err
+}
+
+// ValidateJSONAs returns an EditValidateFunc that requires the edited content
+// to unmarshal into a T without members T does not model.
+func ValidateJSONAs[T any](opts ...jsonv2.Options) EditValidateFunc {
+	opts = append([]jsonv2.Options{jsonv2.RejectUnknownMembers(true)}, opts...)
+	return func(data []byte) error {
+		var v T
+		return jsonv2.Unmarshal(data, &v, opts...)
+	}
+}
+
+func validateEdit(data []byte, validate EditValidateFunc) (err error) {
+	var value any
+
+	err = jsonv2.Unmarshal(data, &value)
+	if err != nil {
+		err = NewErr(ErrFailedToUnmarshalConfigFile, err)
+		goto end
+	}
+	if validate != nil {
+		err = validate(data)
+	}
+end:
+	if err != nil {
+		err = NewErr(ErrInvalidEdit, err)
+	}
+	return err
+}
+
+// editorCommand returns the command line of the user's editor, preferring
+// VISUAL to EDITOR as other tools do, split into fields so that e.g.
+// "code --wait" works.
+func editorCommand(opts EditOptions) []string {
+	if opts.Editor != "" {
+		return strings.Fields(opts.Editor)
+	}
+	lookupEnv := opts.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		value, ok := lookupEnv(name)
+		if ok && strings.TrimSpace(value) != "" {
+			return strings.Fields(value)
+		}
+	}
+	return nil
+}
+
+func runEditor(editor []string, fp string, opts EditOptions) (err error) {
+	cmd := exec.Command(editor[0], append(editor[1:], fp)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = opts.Stdin, opts.Stdout, opts.Stderr
+	if cmd.Stdin == nil {
+		cmd.Stdin = os.Stdin
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	err = cmd.Run()
+	if err != nil {
+		err = NewErr(ErrEditorFailed, "editor", editor[0], err)
+	}
+	return err
+}
+
+// editTempPattern names the copy after the store's file, keeping its extension
+// so the editor recognizes it as JSON.
+func editTempPattern(store ConfigStore) string {
+	name := filepath.Base(string(store.GetRelFilepath()))
+	ext := filepath.Ext(name)
+	return string(store.ConfigSlug()) + "-" + strings.TrimSuffix(name, ext) + "-*" + ext
+}
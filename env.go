@@ -0,0 +1,121 @@
+package cfgstore
+
+import (
+	"os"
+	"strings"
+)
+
+type ExpandEnvOptions struct {
+	// LookupEnv looks up an environment variable. Defaults to os.LookupEnv.
+	LookupEnv func(name string) (string, bool)
+
+	// Strict causes a reference to an unset variable without a default to fail
+	// the load with ErrEnvVarNotSet rather than expand to an empty string.
+	Strict bool
+}
+
+// ExpandEnvHook returns a pre-load hook that expands references to environment
+// variables within the string values of a store's JSON file, so that paths and
+// endpoints can refer to the environment:
+//
+//	err := cfgstore.AddPreLoadHook(store, cfgstore.ExpandEnvHook())
+//
+// ${VAR} expands to the value of VAR and ${VAR:-default} expands to default if
+// VAR is unset or empty. $${ escapes a literal ${. Object keys, $VAR without
+// braces and ${...} that does not contain a valid variable name are left as is.
+//
+// Loads for an update, see LoadHookArgs.ForUpdate, are not expanded, so edits
+// such as SetValue and UpdateJSON keep the references in the file rather than
+// writing the values, which may be secrets, to it.
+func ExpandEnvHook(opts ...ExpandEnvOptions) LoadHook {
+	if len(opts) == 0 {
+		opts = []ExpandEnvOptions{{}}
+	}
+	opt := opts[0]
+	if opt.LookupEnv == nil {
+		opt.LookupEnv = os.LookupEnv
+	}
+	return func(args *LoadHookArgs) (err error) {
+		var doc any
+
+		if args.ForUpdate {
+			goto end
+		}
+		doc, err = parseDocument(args.Data)
+		if err != nil || doc == nil {
+			goto end
+		}
+		doc, err = mapStrings(doc, func(s string) (string, error) {
+			return expandEnv(s, opt)
+		})
+		if err != nil {
+			goto end
+		}
+		args.Data, err = encodeDocument(doc)
+	end:
+		return err
+	}
+}
+
+// expandEnv expands the ${VAR} and ${VAR:-default} references in s.
+func expandEnv(s string, opt ExpandEnvOptions) (_ string, err error) {
+	var sb strings.Builder
+
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			sb.WriteString(s)
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			// $${ is an escaped ${
+			sb.WriteString(s[:i-1])
+			sb.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+		sb.WriteString(s[:i])
+		s = s[i:]
+		closing := strings.IndexByte(s, '}')
+		if closing < 0 {
+			sb.WriteString(s)
+			break
+		}
+		name, def, hasDef := strings.Cut(s[2:closing], ":-")
+		if !isEnvVarName(name) {
+			sb.WriteString(s[:2])
+			s = s[2:]
+			continue
+		}
+		value, ok := opt.LookupEnv(name)
+		switch {
+		case value != "":
+		case hasDef:
+			value = def
+		case !ok && opt.Strict:
+			err = NewErr(ErrEnvVarNotSet, "name", name)
+			goto end
+		}
+		sb.WriteString(value)
+		s = s[closing+1:]
+	}
+end:
+	return sb.String(), err
+}
+
+func isEnvVarName(name string) (valid bool) {
+	if name == "" {
+		goto end
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			goto end
+		}
+	}
+	valid = true
+end:
+	return valid
+}
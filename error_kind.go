@@ -0,0 +1,186 @@
+package cfgstore
+
+import (
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+// ErrorKind classifies why an operation failed so that applications can branch
+// on the class of failure without matching sentinels or strings. Errors
+// returned by stores carry their ErrorKind, when one applies, as a typed error
+// in their chain:
+//
+//	var kind cfgstore.ErrorKind
+//	if errors.As(err, &kind) && kind == cfgstore.NotFoundErrorKind {
+//		// offer to create the config
+//	}
+//
+// ErrorKindOf does the same and also classifies errors from other sources.
+type ErrorKind int
+
+const (
+	UnknownErrorKind           ErrorKind = iota
+	NotFoundErrorKind                    // The file does not exist
+	PermissionDeniedErrorKind            // The OS denied access to a file or directory
+	CorruptErrorKind                     // The file's content could not be decoded
+	ConflictErrorKind                    // Another writer holds the lock, or the file already exists
+	ReadOnlyErrorKind                    // The file system is mounted read-only
+	RemoteUnavailableErrorKind           // A remote backend could not be reached
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case NotFoundErrorKind:
+		return "not found"
+	case PermissionDeniedErrorKind:
+		return "permission denied"
+	case CorruptErrorKind:
+		return "corrupt"
+	case ConflictErrorKind:
+		return "conflict"
+	case ReadOnlyErrorKind:
+		return "read-only"
+	case RemoteUnavailableErrorKind:
+		return "remote unavailable"
+	case UnknownErrorKind:
+		return "unknown"
+	default:
+	}
+	return "invalid"
+}
+
+func (k ErrorKind) Slug() string {
+	switch k {
+	case NotFoundErrorKind:
+		return "not_found"
+	case PermissionDeniedErrorKind:
+		return "permission_denied"
+	case CorruptErrorKind:
+		return "corrupt"
+	case ConflictErrorKind:
+		return "conflict"
+	case ReadOnlyErrorKind:
+		return "read_only"
+	case RemoteUnavailableErrorKind:
+		return "remote_unavailable"
+	case UnknownErrorKind:
+		return "unknown"
+	default:
+	}
+	return "invalid"
+}
+
+// Error makes an ErrorKind an error so that it can be carried in, and found
+// with errors.As or errors.Is in, an error's chain.
+func (k ErrorKind) Error() string {
+	return k.String()
+}
+
+// ErrorKindOf returns the ErrorKind carried in err's chain or, if there is none,
+// the kind its causes indicate, e.g. NotFoundErrorKind for fs.ErrNotExist. A
+// FileSystem backed by a remote service can wrap its errors with
+// RemoteUnavailableErrorKind, e.g. WithErr(err, RemoteUnavailableErrorKind, ...),
+// to have them reported as such.
+func ErrorKindOf(err error) (kind ErrorKind) {
+	if err == nil || errors.As(err, &kind) {
+		goto end
+	}
+	kind = classifyErr(err)
+end:
+	return kind
+}
+
+// IsNotExist returns true if err is a NotFoundErrorKind error, e.g. because a
+// store's file does not exist.
+func IsNotExist(err error) bool {
+	return ErrorKindOf(err) == NotFoundErrorKind
+}
+
+// IsAlreadyExists returns true if err is because a file that was to be created
+// already exists, e.g. ErrConfigAlreadyExists from InitProjectConfig.
+func IsAlreadyExists(err error) bool {
+	return errors.Is(err, ErrConfigAlreadyExists) || errors.Is(err, fs.ErrExist)
+}
+
+// IsPermission returns true if err is a PermissionDeniedErrorKind error.
+func IsPermission(err error) bool {
+	return ErrorKindOf(err) == PermissionDeniedErrorKind
+}
+
+// IsCorrupt returns true if err is a CorruptErrorKind error, e.g. because a
+// store's file is not valid JSON.
+func IsCorrupt(err error) bool {
+	return ErrorKindOf(err) == CorruptErrorKind
+}
+
+// IsConflict returns true if err is a ConflictErrorKind error, e.g. because
+// another writer holds a lock.
+func IsConflict(err error) bool {
+	return ErrorKindOf(err) == ConflictErrorKind
+}
+
+// IsReadOnly returns true if err is a ReadOnlyErrorKind error.
+func IsReadOnly(err error) bool {
+	return ErrorKindOf(err) == ReadOnlyErrorKind
+}
+
+// IsRemoteUnavailable returns true if err is a RemoteUnavailableErrorKind
+// error.
+func IsRemoteUnavailable(err error) bool {
+	return ErrorKindOf(err) == RemoteUnavailableErrorKind
+}
+
+// classifyErr returns the ErrorKind indicated by err's sentinels and causes.
+func classifyErr(err error) ErrorKind {
+	var syntaxErr *jsontext.SyntacticError
+	var semanticErr *jsonv2.SemanticError
+
+	switch {
+	case errors.Is(err, ErrFailedToUnmarshalConfigFile),
+		errors.Is(err, ErrFailedToUnmarshalCacheFile),
+		errors.Is(err, ErrFailedToUnmarshalLazyValue),
+		errors.As(err, &syntaxErr),
+		errors.As(err, &semanticErr):
+		return CorruptErrorKind
+	case errors.Is(err, ErrFileDoesNotExist),
+		errors.Is(err, ErrNoConfigFound),
+		errors.Is(err, fs.ErrNotExist):
+		return NotFoundErrorKind
+	case errors.Is(err, syscall.EROFS):
+		return ReadOnlyErrorKind
+	case errors.Is(err, fs.ErrPermission):
+		return PermissionDeniedErrorKind
+	case errors.Is(err, ErrLockTimeout),
+		errors.Is(err, ErrConfigAlreadyExists),
+		errors.Is(err, ErrRelocateConflict),
+		errors.Is(err, fs.ErrExist):
+		return ConflictErrorKind
+	case errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.EHOSTUNREACH),
+		errors.Is(err, syscall.ENETUNREACH),
+		errors.Is(err, syscall.ETIMEDOUT):
+		return RemoteUnavailableErrorKind
+	}
+	return UnknownErrorKind
+}
+
+// withErrorKind adds the ErrorKind of err to its rightmost doterr entry, so it
+// can be found with errors.As, unless err already carries one or none applies.
+func withErrorKind(err error) error {
+	var kind ErrorKind
+
+	if err == nil || errors.As(err, &kind) {
+		goto end
+	}
+	kind = classifyErr(err)
+	if kind == UnknownErrorKind {
+		goto end
+	}
+	err = buildErr(err, []any{kind})
+end:
+	return err
+}
@@ -23,6 +23,15 @@ var (
 	ErrFailedGettingCLIConfigDir  = errors.New("failed to get CLI config dir")
 	ErrFailedGettingUserHomeDir   = errors.New("failed to get user home dir")
 	ErrFailedGettingUserCacheDir  = errors.New("failed to get user cache dir")
+	ErrFailedGettingUserStateDir  = errors.New("failed to get user state dir")
+)
+
+var (
+	ErrPreSaveHookFailed  = errors.New("pre-save hook failed")
+	ErrPostSaveHookFailed = errors.New("post-save hook failed")
+	ErrPreLoadHookFailed  = errors.New("pre-load hook failed")
+	ErrPostLoadHookFailed = errors.New("post-load hook failed")
+	ErrHooksNotSupported  = errors.New("store does not support hooks")
 )
 
 var ErrFailedToEnsureConfig = errors.New("failed to ensure config")
@@ -38,3 +47,159 @@ var (
 var ErrInvalidConfigFilepath = errors.New("invalid config filepath")
 
 var ErrNoRootConfigsLoaded = errors.New("no root configs loaded")
+
+var (
+	ErrWatcherAlreadyStarted = errors.New("watcher already started")
+	ErrFailedToStartWatcher  = errors.New("failed to start watcher")
+	ErrFailedToReloadConfig  = errors.New("failed to reload config")
+	ErrConfigStoresNotLoaded = errors.New("config stores not loaded")
+)
+
+var (
+	ErrInvalidValuePath       = errors.New("invalid value path")
+	ErrValuePathNotContainer  = errors.New("value path does not refer to an object or array")
+	ErrFailedToMarshalValue   = errors.New("failed to marshal value")
+	ErrUnexpectedTrailingData = errors.New("unexpected data after top-level value")
+	ErrUnexpectedNodeType     = errors.New("unexpected document node type")
+	ErrValueTypeMismatch      = errors.New("value is not of the expected type")
+	ErrArrayIndexOutOfRange   = errors.New("array index out of range")
+	ErrFailedToMergeJSON      = errors.New("failed to merge JSON")
+)
+
+var (
+	ErrFailedToLockFile     = errors.New("failed to lock file")
+	ErrLockTimeout          = errors.New("timed out waiting for lock")
+	ErrFailedToUpdateConfig = errors.New("failed to update config")
+	ErrUpdateFuncFailed     = errors.New("update func failed")
+)
+
+var ErrEnvVarNotSet = errors.New("environment variable not set")
+
+var ErrFailedToListFiles = errors.New("failed to list files")
+
+var (
+	ErrFailedToInterpolate  = errors.New("failed to interpolate config values")
+	ErrInvalidInterpolation = errors.New("invalid interpolation")
+)
+
+var ErrFailedToRotateLog = errors.New("failed to rotate log")
+
+var ErrInvalidLogLevel = errors.New("invalid log level")
+
+var (
+	ErrFailedToOpenAuditLog  = errors.New("failed to open audit log")
+	ErrFailedToWriteAuditLog = errors.New("failed to write audit log")
+)
+
+var ErrFailedToMigrateLogs = errors.New("failed to migrate logs")
+
+var (
+	ErrFailedToReadCacheFile      = errors.New("failed to read cache file")
+	ErrFailedToWriteCacheFile     = errors.New("failed to write cache file")
+	ErrFailedToDeleteCacheFile    = errors.New("failed to delete cache file")
+	ErrFailedToUnmarshalCacheFile = errors.New("failed to unmarshal cache file")
+	ErrCacheCreateFuncFailed      = errors.New("cache create function failed")
+)
+
+var ErrFailedToPruneCache = errors.New("failed to prune cache")
+
+var ErrFailedToGetUsage = errors.New("failed to get disk usage")
+
+var ErrFailedToUnmarshalLazyValue = errors.New("failed to unmarshal lazy value")
+
+var ErrContextDone = errors.New("context done")
+
+var ErrFileTooLargeToMap = errors.New("file too large to map into memory")
+
+var (
+	ErrInvalidCacheVersion      = errors.New("invalid cache version")
+	ErrFailedToRemoveStaleCache = errors.New("failed to remove stale cache versions")
+)
+
+var (
+	ErrFailedToSaveMergedConfig = errors.New("failed to save merged config")
+	ErrDirTypeNotInStores       = errors.New("dir type not in config stores")
+)
+
+var (
+	ErrFailedToPromptConfig = errors.New("failed to prompt for config")
+	ErrInvalidPromptAnswer  = errors.New("invalid prompt answer")
+	ErrAnswerNotOfFieldType = errors.New("answer not of field type")
+)
+
+var (
+	ErrFailedToEditConfig = errors.New("failed to edit config")
+	ErrNoEditor           = errors.New("neither VISUAL nor EDITOR is set")
+	ErrEditorFailed       = errors.New("editor failed")
+	ErrInvalidEdit        = errors.New("edited config is invalid")
+)
+
+var (
+	ErrConfigDirNotWritable    = errors.New("config dir not writable")
+	ErrConfigFileWorldWritable = errors.New("config file writable by other users")
+)
+
+var (
+	ErrFailedToRelocateConfig = errors.New("failed to relocate config")
+	ErrRelocateConflict       = errors.New("config exists at both old and new locations")
+	ErrSymlinksNotSupported   = errors.New("file system does not support symlinks")
+)
+
+var (
+	ErrFailedToExportArchive = errors.New("failed to export config archive")
+	ErrFailedToImportArchive = errors.New("failed to import config archive")
+	ErrUnsafeArchiveEntry    = errors.New("unsafe archive entry")
+	ErrArchiveTooLarge       = errors.New("archive too large")
+)
+
+var ErrFailedToDiffConfig = errors.New("failed to diff config")
+
+var ErrFailedToRenderScaffold = errors.New("failed to render config scaffold")
+
+var (
+	ErrInvalidTenant            = errors.New("invalid tenant")
+	ErrFailedToListTenants      = errors.New("failed to list tenants")
+	ErrFailedToGetCurrentTenant = errors.New("failed to get current tenant")
+	ErrFailedToSwitchTenant     = errors.New("failed to switch tenant")
+	ErrTenantsNotSupported      = errors.New("store does not support tenants")
+)
+
+var ErrConfigFileHasNoSettings = errors.New("config file has no settings")
+
+var ErrNoConfigFound = errors.New("no config file found")
+
+var (
+	ErrFailedToPurgeConfig = errors.New("failed to purge config")
+	ErrPurgeNotConfirmed   = errors.New("purge not confirmed by the config slug")
+	ErrUnsafePurgePath     = errors.New("unsafe config dir to purge")
+	ErrPurgeNotSupported   = errors.New("store does not support purging")
+)
+
+var (
+	ErrUnknownPlaceholder      = errors.New("unknown placeholder in config file path")
+	ErrInvalidPlaceholderValue = errors.New("invalid placeholder value in config file path")
+)
+
+var (
+	ErrFailedToLoadGOOSVariant = errors.New("failed to load config file variant")
+	ErrGOOSVariantNotObject    = errors.New("config file and its variant must be JSON objects")
+)
+
+var ErrFirstRunFuncFailed = errors.New("first run func failed")
+
+var ErrFailedToStatConfig = errors.New("failed to stat config file")
+
+var ErrFailedToWriteExample = errors.New("failed to write example config file")
+
+var ErrInvalidConfigSlug = errors.New("invalid config slug")
+
+var ErrFailedToLoadOrInitConfig = errors.New("failed to load or initialize config")
+
+var (
+	ErrFailedToSaveFile      = errors.New("failed to save file")
+	ErrFailedToLoadFile      = errors.New("failed to load file")
+	ErrFailedToDeleteFile    = errors.New("failed to delete file")
+	ErrInvalidAttachmentPath = errors.New("invalid file path in config dir")
+	ErrAttachmentTooLarge    = errors.New("file too large")
+	ErrMissingCacheAppName   = errors.New("cache app name is required")
+)
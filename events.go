@@ -0,0 +1,158 @@
+package cfgstore
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// EventKind identifies the config operation an Event describes.
+type EventKind int
+
+const (
+	UnspecifiedEventKind EventKind = iota
+	LoadEventKind                  // A store's file was loaded
+	SaveEventKind                  // A store's file was written
+	CreateEventKind                // A default config was created for a store
+	DeleteEventKind                // A store's file or directory was removed
+	MigrateEventKind               // A store's file was moved from another location
+	MergeEventKind                 // The configs from several stores were merged
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case LoadEventKind:
+		return "Load"
+	case SaveEventKind:
+		return "Save"
+	case CreateEventKind:
+		return "Create"
+	case DeleteEventKind:
+		return "Delete"
+	case MigrateEventKind:
+		return "Migrate"
+	case MergeEventKind:
+		return "Merge"
+	case UnspecifiedEventKind:
+		return "Unspecified"
+	default:
+	}
+	return "Invalid"
+}
+
+func (k EventKind) Slug() string {
+	switch k {
+	case LoadEventKind:
+		return "load"
+	case SaveEventKind:
+		return "save"
+	case CreateEventKind:
+		return "create"
+	case DeleteEventKind:
+		return "delete"
+	case MigrateEventKind:
+		return "migrate"
+	case MergeEventKind:
+		return "merge"
+	case UnspecifiedEventKind:
+		return "unspecified"
+	default:
+	}
+	return "invalid"
+}
+
+// Event describes a config operation. Events are delivered synchronously to
+// every listener registered with AddEventListener.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+
+	// Store is the store the operation was performed on, or nil for operations
+	// such as MergeEventKind that span several stores.
+	Store    ConfigStore
+	DirType  DirType
+	Filepath dt.Filepath
+
+	// DirTypes lists the layers that were merged, in order, for MergeEventKind.
+	DirTypes []DirType
+
+	// Err is the error the operation failed with, if any.
+	Err error
+}
+
+// EventListener receives Events. Listeners are called on the goroutine that
+// performed the operation so they should return quickly.
+type EventListener func(Event)
+
+type eventListenerEntry struct {
+	id       int
+	listener EventListener
+}
+
+var (
+	eventMutex     sync.RWMutex
+	eventListeners []eventListenerEntry
+	lastListenerID int
+)
+
+// AddEventListener registers listener to receive an Event for every load, save,
+// create, delete, migration and merge performed by this package, e.g. to build
+// an audit log. Call the returned func to remove the listener.
+func AddEventListener(listener EventListener) (remove func()) {
+	eventMutex.Lock()
+	lastListenerID++
+	id := lastListenerID
+	eventListeners = append(eventListeners, eventListenerEntry{
+		id:       id,
+		listener: listener,
+	})
+	eventMutex.Unlock()
+
+	return func() {
+		eventMutex.Lock()
+		eventListeners = slices.DeleteFunc(eventListeners, func(e eventListenerEntry) bool {
+			return e.id == id
+		})
+		eventMutex.Unlock()
+	}
+}
+
+// hasEventListeners lets callers skip building an Event nobody will receive.
+func hasEventListeners() bool {
+	eventMutex.RLock()
+	defer eventMutex.RUnlock()
+	return len(eventListeners) > 0
+}
+
+func emitEvent(ev Event) {
+	eventMutex.RLock()
+	listeners := slices.Clone(eventListeners)
+	eventMutex.RUnlock()
+
+	if len(listeners) == 0 {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	for _, e := range listeners {
+		e.listener(ev)
+	}
+}
+
+// emitStoreEvent emits an Event of kind for an operation performed on cs.
+func (cs *configStore) emitStoreEvent(kind EventKind, err error) {
+	if !hasEventListeners() {
+		return
+	}
+	fp, _ := cs.GetFilepath()
+	emitEvent(Event{
+		Kind:     kind,
+		Store:    cs,
+		DirType:  cs.dirType,
+		Filepath: fp,
+		Err:      err,
+	})
+}
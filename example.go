@@ -0,0 +1,132 @@
+package cfgstore
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ExampleCommentMember is the member at the top of an example file written for
+// ConfigStoreArgs.WriteExample that explains it. Each setting documented by
+// ExampleComments is preceded by a member named ExampleCommentMember + "_" and
+// its name, e.g. "_comment_port", as JSON has no comments.
+const ExampleCommentMember = "_comment"
+
+// ExampleFilepath returns the path of the example file written beside fp for
+// ConfigStoreArgs.WriteExample, e.g. config.example.json for config.json.
+func ExampleFilepath(fp dt.Filepath) dt.Filepath {
+	ext := filepath.Ext(string(fp))
+	return dt.Filepath(strings.TrimSuffix(string(fp), ext) + ".example" + ext)
+}
+
+// saveExample writes the example file for the store's config, with the defaults
+// that Normalize gives a new RootConfig of rc's type, unless it already has
+// that content.
+func (cs *configStore) saveExample(rc RootConfig, dirType DirType, opts Options) (err error) {
+	var fp dt.Filepath
+	var data, existing []byte
+
+	fp, err = cs.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	data, err = cs.renderExample(rc, dirType, fp, opts)
+	if err != nil {
+		goto end
+	}
+	existing, err = cs.FileSystem().ReadFile(ExampleFilepath(fp))
+	if err == nil && bytes.Equal(existing, data) {
+		goto end
+	}
+	err = WriteFileMode(cs.FileSystem(), ExampleFilepath(fp), data, 0644)
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToWriteExample, "filepath", ExampleFilepath(fp), err)
+	}
+	return err
+}
+
+// renderExample returns the defaults of rc's type encoded with the store's Codec
+// or, without one, as JSON with the store's ExampleComments.
+func (cs *configStore) renderExample(rc RootConfig, dirType DirType, fp dt.Filepath, opts Options) (data []byte, err error) {
+	var node, headerNode any
+
+	defaults := reflect.New(reflect.TypeOf(rc).Elem()).Interface().(RootConfig)
+	err = defaults.Normalize(NormalizeArgs{
+		DirType:    dirType,
+		SourceFile: fp,
+		Options:    opts,
+	})
+	if err != nil {
+		goto end
+	}
+	if cs.codec != nil {
+		data, err = cs.codec.Marshal(defaults)
+		goto end
+	}
+	node, err = valueToNode(defaults)
+	if err != nil {
+		goto end
+	}
+	if obj, ok := node.(*jsonObject); ok {
+		err = commentExample(obj, cs.exampleComments)
+		if err != nil {
+			goto end
+		}
+		headerNode, err = valueToNode(fmt.Sprintf(
+			"Example %s settings with their defaults. Copy the ones to change into %s; this file is rewritten and never read.",
+			cs.configSlug, filepath.Base(string(fp)),
+		))
+		if err != nil {
+			goto end
+		}
+		obj.members = append([]jsonMember{{name: ExampleCommentMember, value: headerNode}}, obj.members...)
+	}
+	data, err = encodeDocument(node)
+	if err != nil {
+		goto end
+	}
+	data = append(data, '\n')
+end:
+	return data, err
+}
+
+// commentExample inserts a comment member before each member of doc named by a
+// path in comments. Paths that are not in doc are ignored, as settings whose
+// defaults are omitted from the JSON cannot be commented.
+func commentExample(doc *jsonObject, comments map[string]string) (err error) {
+	var segments []string
+	var node any
+
+	for path, comment := range comments {
+		segments, err = splitValuePath(path)
+		if err != nil {
+			goto end
+		}
+		if len(segments) == 0 {
+			continue
+		}
+		parent, _ := lookupNode(doc, segments[:len(segments)-1])
+		obj, ok := parent.(*jsonObject)
+		if !ok {
+			continue
+		}
+		name := segments[len(segments)-1]
+		i := obj.index(name)
+		if i < 0 {
+			continue
+		}
+		node, err = valueToNode(comment)
+		if err != nil {
+			goto end
+		}
+		member := jsonMember{name: ExampleCommentMember + "_" + name, value: node}
+		obj.members = append(obj.members[:i], append([]jsonMember{member}, obj.members[i:]...)...)
+	}
+end:
+	return err
+}
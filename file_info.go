@@ -0,0 +1,53 @@
+package cfgstore
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ConfigFileInfo describes a store's file, as returned by Stat.
+type ConfigFileInfo struct {
+	Filepath dt.Filepath
+	Size     int64
+	ModTime  time.Time
+	Mode     fs.FileMode
+
+	// IsSymlink is true if Filepath is a symbolic link, in which case Size,
+	// ModTime and Mode describe the file it links to. It is always false if
+	// the store's FileSystem is not a FileSymlinker.
+	IsSymlink bool
+}
+
+// Stat returns the size, modification time and mode of the store's file, e.g.
+// to show when the config was last modified or to decide whether a copy of it
+// is stale. A file that does not exist fails with an error matching
+// ErrFileDoesNotExist and fs.ErrNotExist.
+func Stat(store ConfigStore) (info ConfigFileInfo, err error) {
+	var fi fs.FileInfo
+
+	info.Filepath, err = store.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	fi, err = store.FileSystem().Stat(info.Filepath)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = NewErr(ErrFileDoesNotExist, err)
+	}
+	if err != nil {
+		goto end
+	}
+	info.Size = fi.Size()
+	info.ModTime = fi.ModTime()
+	info.Mode = fi.Mode()
+	_, err = Readlink(store.FileSystem(), info.Filepath)
+	info.IsSymlink = err == nil
+	err = nil
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToStatConfig, "filepath", info.Filepath, err))
+	}
+	return info, err
+}
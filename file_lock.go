@@ -0,0 +1,63 @@
+package cfgstore
+
+import (
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// DefaultLockTimeout is how long UpdateJSON waits to acquire the lock on a
+// store's file before giving up.
+const DefaultLockTimeout = 10 * time.Second
+
+const lockRetryInterval = 10 * time.Millisecond
+
+// lockFileExt is appended to a file's path to name the sidecar that locks it.
+const lockFileExt = ".lock"
+
+// lockFilepath returns the path of the sidecar file used to lock fp. A sidecar
+// is used so the lock survives the store's file being atomically replaced.
+func lockFilepath(fp dt.Filepath) dt.Filepath {
+	return dt.Filepath(string(fp) + lockFileExt)
+}
+
+// lockFile acquires an exclusive lock on fp that is honored by other processes
+// using this package, waiting up to timeout for it. Call unlock to release it.
+func lockFile(fp dt.Filepath, timeout time.Duration) (unlock func(), err error) {
+	return acquireLockFile(fp, timeout, tryLockFile)
+}
+
+// rLockFile acquires a shared lock on fp, as lockFile does an exclusive one.
+func rLockFile(fp dt.Filepath, timeout time.Duration) (unlock func(), err error) {
+	return acquireLockFile(fp, timeout, tryRLockFile)
+}
+
+// acquireLockFile calls try for fp's lock sidecar until it acquires the lock,
+// fails, or timeout passes.
+func acquireLockFile(fp dt.Filepath, timeout time.Duration, try func(dt.Filepath) (func(), bool, error)) (unlock func(), err error) {
+	var acquired bool
+	var deadline time.Time
+
+	lockFP := lockFilepath(fp)
+	err = lockFP.Dir().MkdirAll(0755)
+	if err != nil {
+		goto end
+	}
+	deadline = time.Now().Add(timeout)
+	for {
+		unlock, acquired, err = try(lockFP)
+		if err != nil || acquired {
+			goto end
+		}
+		if time.Now().After(deadline) {
+			err = NewErr(ErrLockTimeout, "timeout", timeout)
+			goto end
+		}
+		time.Sleep(lockRetryInterval)
+	}
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToLockFile, "lock_file", lockFP, err)
+	}
+	return unlock, err
+}
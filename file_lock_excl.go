@@ -0,0 +1,132 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package cfgstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// staleLockAge is how old a lock file must be for it to be broken even though
+// the process that created it may still be running. Locks are only held for a
+// read-modify-write, so one this old was left by a process that hung or whose
+// PID has been reused.
+const staleLockAge = time.Minute
+
+// tryLockFile attempts to exclusively create lockFP, which is removed on unlock.
+// The lock file records the PID of its creator and when it was created, so that
+// unlike with flock(2), where the kernel releases the lock, a lock left by a
+// crashed process can be broken, see breakStaleLock.
+func tryLockFile(lockFP dt.Filepath) (unlock func(), acquired bool, err error) {
+	var file *os.File
+
+	file, err = os.OpenFile(string(lockFP), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		err = nil
+		breakStaleLock(lockFP)
+		goto end
+	}
+	if err != nil {
+		goto end
+	}
+	_, err = fmt.Fprintf(file, "%d %s\n", os.Getpid(), time.Now().UTC().Format(time.RFC3339Nano))
+	err = CombineErrs([]error{err, file.Close()})
+	if err != nil {
+		LogOnError(os.Remove(string(lockFP)))
+		goto end
+	}
+	acquired = true
+	unlock = func() {
+		LogOnError(os.Remove(string(lockFP)))
+	}
+end:
+	return unlock, acquired, err
+}
+
+// tryRLockFile takes an exclusive lock, as lock files cannot be shared.
+func tryRLockFile(lockFP dt.Filepath) (unlock func(), acquired bool, err error) {
+	return tryLockFile(lockFP)
+}
+
+// breakStaleLock removes lockFP if it is older than staleLockAge or the process
+// that created it has exited, so that the next attempt can take the lock.
+//
+// This is best effort. The PID is only checked on the host the lock is taken
+// on, so a lock left on a shared filesystem by another host waits out
+// staleLockAge, as does one whose PID has been reused. A lock held for longer
+// than staleLockAge is broken while it is still in use. And two processes that
+// find the same stale lock can race, so that one removes the lock the other has
+// just taken; the content is re-read before removing to narrow that window.
+func breakStaleLock(lockFP dt.Filepath) {
+	data, err := os.ReadFile(string(lockFP))
+	if err != nil {
+		return
+	}
+	info, err := os.Stat(string(lockFP))
+	if err != nil {
+		return
+	}
+	if !lockIsStale(data, info.ModTime()) {
+		return
+	}
+	current, err := os.ReadFile(string(lockFP))
+	if err != nil || !bytes.Equal(current, data) {
+		// Another process broke the lock and took it
+		return
+	}
+	err = os.Remove(string(lockFP))
+	if !errors.Is(err, fs.ErrNotExist) {
+		LogOnError(err)
+	}
+}
+
+// lockIsStale reports whether the lock file with content data, modified at
+// modTime, is older than staleLockAge or records the PID of a process that has
+// exited. A lock file whose content is not yet written, or was written by an
+// older version, is aged by modTime.
+func lockIsStale(data []byte, modTime time.Time) bool {
+	var pid int
+
+	created := modTime
+	fields := strings.Fields(string(data))
+	if len(fields) == 2 {
+		pid, _ = strconv.Atoi(fields[0])
+		t, err := time.Parse(time.RFC3339Nano, fields[1])
+		if err == nil {
+			created = t
+		}
+	}
+	switch {
+	case time.Since(created) > staleLockAge:
+		return true
+	case pid > 0 && pid != os.Getpid() && processIsGone(pid):
+		return true
+	}
+	return false
+}
+
+// processIsGone reports whether no process with pid is running. It returns
+// false when that cannot be determined, so that a lock in use is not broken.
+func processIsGone(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		// On Windows FindProcess opens the process, which fails if it has exited
+		return runtime.GOOS == "windows" && !errors.Is(err, fs.ErrPermission)
+	}
+	defer func() { LogOnError(p.Release()) }()
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return errors.Is(err, os.ErrProcessDone) || errors.Is(err, syscall.ESRCH)
+}
@@ -0,0 +1,50 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package cfgstore
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// tryLockFile attempts to take an exclusive flock(2) lock on lockFP without
+// blocking. The kernel releases the lock if the process exits without
+// unlocking.
+func tryLockFile(lockFP dt.Filepath) (unlock func(), acquired bool, err error) {
+	return tryFlock(lockFP, syscall.LOCK_EX)
+}
+
+// tryRLockFile attempts to take a shared flock(2) lock on lockFP without
+// blocking.
+func tryRLockFile(lockFP dt.Filepath) (unlock func(), acquired bool, err error) {
+	return tryFlock(lockFP, syscall.LOCK_SH)
+}
+
+func tryFlock(lockFP dt.Filepath, how int) (unlock func(), acquired bool, err error) {
+	var file *os.File
+
+	file, err = os.OpenFile(string(lockFP), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		goto end
+	}
+	err = syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		err = nil
+		CloseOrLog(file)
+		goto end
+	}
+	if err != nil {
+		CloseOrLog(file)
+		goto end
+	}
+	acquired = true
+	unlock = func() {
+		LogOnError(syscall.Flock(int(file.Fd()), syscall.LOCK_UN))
+		CloseOrLog(file)
+	}
+end:
+	return unlock, acquired, err
+}
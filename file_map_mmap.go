@@ -0,0 +1,45 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package cfgstore
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// mapFile maps the file at fp into memory read-only. An empty file, which
+// cannot be mapped, is returned as empty data.
+func mapFile(fp dt.Filepath) (data []byte, unmap func() error, err error) {
+	var file *os.File
+	var info os.FileInfo
+
+	unmap = func() error { return nil }
+	file, err = os.Open(string(fp))
+	if err != nil {
+		goto end
+	}
+	defer CloseOrLog(file)
+	info, err = file.Stat()
+	if err != nil {
+		goto end
+	}
+	if info.Size() == 0 {
+		goto end
+	}
+	if int64(int(info.Size())) != info.Size() {
+		err = NewErr(ErrFileTooLargeToMap, "size", info.Size())
+		goto end
+	}
+	data, err = syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		err = &os.PathError{Op: "mmap", Path: string(fp), Err: err}
+		goto end
+	}
+	unmap = func() error {
+		return syscall.Munmap(data)
+	}
+end:
+	return data, unmap, err
+}
@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package cfgstore
+
+import (
+	"github.com/mikeschinkel/go-dt"
+)
+
+// mapFile reads the file at fp into memory on platforms without mmap(2).
+func mapFile(fp dt.Filepath) (data []byte, unmap func() error, err error) {
+	data, err = dt.ReadFile(fp)
+	return data, func() error { return nil }, err
+}
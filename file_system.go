@@ -0,0 +1,327 @@
+package cfgstore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// FileSystem performs the file operations of a ConfigStore. Stores use the
+// FileSystem of their DirsProvider, which defaults to OSFileSystem(). Tests can
+// use the in-memory implementation in cstest to avoid touching the disk.
+type FileSystem interface {
+	ReadFile(dt.Filepath) ([]byte, error)
+
+	// WriteFile must replace the file atomically, so that concurrent readers see
+	// either the old or the new content but never a partial write. It must not
+	// retain data after it returns.
+	WriteFile(dt.Filepath, []byte) error
+
+	Stat(dt.Filepath) (fs.FileInfo, error)
+	MkdirAll(dt.DirPath) error
+	Remove(dt.Filepath) error
+
+	// DirFS returns a read-only view of the directory at dp.
+	DirFS(dp dt.DirPath) fs.FS
+
+	// Lock acquires an exclusive lock on fp, waiting up to timeout for it.
+	Lock(fp dt.Filepath, timeout time.Duration) (unlock func(), err error)
+}
+
+// FileStreamWriter is implemented by a FileSystem that can write a file from a
+// stream, as SaveJSONStream does for large files, without holding its content
+// in memory. WriteFileFrom must replace the file atomically, as WriteFile does.
+type FileStreamWriter interface {
+	WriteFileFrom(fp dt.Filepath, write func(io.Writer) error) error
+}
+
+// WriteFileFrom writes the content written by write to fp on fSys, streaming it
+// if fSys is a FileStreamWriter and otherwise buffering it for WriteFile.
+func WriteFileFrom(fSys FileSystem, fp dt.Filepath, write func(io.Writer) error) (err error) {
+	var buf bytes.Buffer
+
+	if fsw, ok := fSys.(FileStreamWriter); ok {
+		err = fsw.WriteFileFrom(fp, write)
+		goto end
+	}
+	err = write(&buf)
+	if err != nil {
+		goto end
+	}
+	err = fSys.WriteFile(fp, buf.Bytes())
+end:
+	return err
+}
+
+// FileModeWriter is implemented by a FileSystem that can write a file with the
+// given permissions, as stores with a ConfigStoreArgs.FileMode do.
+// WriteFileMode must replace the file atomically, as WriteFile does.
+type FileModeWriter interface {
+	WriteFileMode(fp dt.Filepath, data []byte, mode fs.FileMode) error
+}
+
+// WriteFileMode writes data to fp on fSys with mode if fSys is a FileModeWriter,
+// and otherwise with WriteFile, ignoring mode.
+func WriteFileMode(fSys FileSystem, fp dt.Filepath, data []byte, mode fs.FileMode) error {
+	if fmw, ok := fSys.(FileModeWriter); ok {
+		return fmw.WriteFileMode(fp, data, mode)
+	}
+	return fSys.WriteFile(fp, data)
+}
+
+// FileMapper is implemented by a FileSystem that can map a file into memory
+// read-only, as LoadJSONMapped does for very large files, rather than copying
+// its content into a byte slice. The data must not be modified nor used after
+// unmap is called.
+type FileMapper interface {
+	MapFile(fp dt.Filepath) (data []byte, unmap func() error, err error)
+}
+
+// MapFile maps the file at fp on fSys into memory if fSys is a FileMapper and
+// otherwise reads it with ReadFile, in which case unmap does nothing.
+func MapFile(fSys FileSystem, fp dt.Filepath) (data []byte, unmap func() error, err error) {
+	if fm, ok := fSys.(FileMapper); ok {
+		return fm.MapFile(fp)
+	}
+	data, err = fSys.ReadFile(fp)
+	return data, func() error { return nil }, err
+}
+
+// FileSymlinker is implemented by a FileSystem that supports symbolic links, as
+// Relocate needs to leave one at a config's old location.
+type FileSymlinker interface {
+	Symlink(target, link dt.Filepath) error
+	Readlink(link dt.Filepath) (dt.Filepath, error)
+}
+
+// Symlink creates link as a symbolic link to target on fSys if fSys is a
+// FileSymlinker and otherwise fails with ErrSymlinksNotSupported.
+func Symlink(fSys FileSystem, target, link dt.Filepath) error {
+	if sl, ok := fSys.(FileSymlinker); ok {
+		return sl.Symlink(target, link)
+	}
+	return NewErr(ErrSymlinksNotSupported, "link", link)
+}
+
+// Readlink returns the target of the symbolic link at link on fSys if fSys is a
+// FileSymlinker and otherwise fails with ErrSymlinksNotSupported.
+func Readlink(fSys FileSystem, link dt.Filepath) (dt.Filepath, error) {
+	if sl, ok := fSys.(FileSymlinker); ok {
+		return sl.Readlink(link)
+	}
+	return "", NewErr(ErrSymlinksNotSupported, "link", link)
+}
+
+// FileRenamer is implemented by a FileSystem that can move a file in one step,
+// as MigrateLogs does to move log files to the state dir.
+type FileRenamer interface {
+	Rename(from, to dt.Filepath) error
+}
+
+// Rename moves from to to on fSys, with fSys.Rename if fSys is a FileRenamer
+// and otherwise by copying the file's content and removing from.
+func Rename(fSys FileSystem, from, to dt.Filepath) (err error) {
+	var data []byte
+
+	if fr, ok := fSys.(FileRenamer); ok {
+		err = fr.Rename(from, to)
+		goto end
+	}
+	data, err = fSys.ReadFile(from)
+	if err != nil {
+		goto end
+	}
+	err = fSys.WriteFile(to, data)
+	if err != nil {
+		goto end
+	}
+	err = fSys.Remove(from)
+end:
+	return err
+}
+
+// FileSharedLocker is implemented by a FileSystem that supports shared locks,
+// which any number of readers can hold at once while excluding the exclusive
+// lock of FileSystem.Lock.
+type FileSharedLocker interface {
+	RLock(fp dt.Filepath, timeout time.Duration) (unlock func(), err error)
+}
+
+// RLock acquires a shared lock on fp on fSys if fSys is a FileSharedLocker and
+// otherwise an exclusive lock with fSys.Lock, waiting up to timeout for it.
+func RLock(fSys FileSystem, fp dt.Filepath, timeout time.Duration) (unlock func(), err error) {
+	if sl, ok := fSys.(FileSharedLocker); ok {
+		return sl.RLock(fp, timeout)
+	}
+	return fSys.Lock(fp, timeout)
+}
+
+// streamBufferSize is the size of the buffers large files are streamed through.
+const streamBufferSize = 64 << 10
+
+var _ FileStreamWriter = osFileSystem{}
+var _ FileMapper = osFileSystem{}
+var _ FileModeWriter = osFileSystem{}
+var _ FileSymlinker = osFileSystem{}
+var _ FileRenamer = osFileSystem{}
+
+type osFileSystem struct{}
+
+// OSFileSystem returns the FileSystem that operates on the host's file system.
+func OSFileSystem() FileSystem {
+	return osFileSystem{}
+}
+
+func (osFileSystem) ReadFile(fp dt.Filepath) ([]byte, error) {
+	return dt.ReadFile(fp)
+}
+
+// WriteFile writes data to a temporary file alongside fp and then renames it
+// over fp so that readers, including other processes, never see a partially
+// written file.
+func (osFileSystem) WriteFile(fp dt.Filepath, data []byte) error {
+	return writeFileAtomic(fp, 0, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// WriteFileMode is WriteFile for a file that is left with mode whether or not
+// it existed.
+func (osFileSystem) WriteFileMode(fp dt.Filepath, data []byte, mode fs.FileMode) error {
+	return writeFileAtomic(fp, mode, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// WriteFileFrom is WriteFile for content written by write, which is buffered
+// rather than held in memory in full.
+func (osFileSystem) WriteFileFrom(fp dt.Filepath, write func(io.Writer) error) error {
+	return writeFileAtomic(fp, 0, func(w io.Writer) (err error) {
+		bw := bufio.NewWriterSize(w, streamBufferSize)
+		err = write(bw)
+		if err == nil {
+			err = bw.Flush()
+		}
+		return err
+	})
+}
+
+// writeFileAtomic calls write with a temporary file alongside fp and then
+// renames it over fp, leaving it with mode, or if mode is zero the permissions
+// of the file it replaces or 0644. If fp is a symlink, e.g. one managed by a
+// dotfiles tool or left by Relocate, the file it links to is replaced and the
+// link is kept. The temporary file is synced before the rename so a crash
+// leaves either the old or the new content.
+func writeFileAtomic(fp dt.Filepath, mode os.FileMode, write func(io.Writer) error) (err error) {
+	var file *os.File
+	var info os.FileInfo
+
+	fp = resolveSymlinks(fp)
+	if mode == 0 {
+		mode = 0644
+		info, err = fp.Stat()
+		if err == nil {
+			// Keep the permissions of the file being replaced
+			mode = info.Mode().Perm()
+		}
+	}
+
+	file, err = dt.CreateTemp(fp.Dir(), "."+string(fp.Base())+".*.tmp")
+	if err != nil {
+		goto end
+	}
+	err = write(file)
+	if err == nil {
+		err = file.Chmod(mode)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	err = errors.Join(err, file.Close())
+	if err == nil {
+		err = os.Rename(file.Name(), string(fp))
+	}
+	if err != nil {
+		LogOnError(os.Remove(file.Name()))
+		goto end
+	}
+
+end:
+	return err
+}
+
+// resolveSymlinks returns the file fp links to, or fp itself if it is not a
+// symlink or the file it links to cannot be resolved, e.g. as it does not
+// exist yet.
+func resolveSymlinks(fp dt.Filepath) dt.Filepath {
+	resolved, err := filepath.EvalSymlinks(string(fp))
+	if err != nil {
+		return fp
+	}
+	return dt.Filepath(resolved)
+}
+
+// MapFile maps the file at fp with mmap(2) on platforms that support it, and
+// otherwise reads it. Because WriteFile replaces files by renaming rather than
+// rewriting them, saves do not change the content of a mapped file.
+func (osFileSystem) MapFile(fp dt.Filepath) (data []byte, unmap func() error, err error) {
+	return mapFile(fp)
+}
+
+func (osFileSystem) Stat(fp dt.Filepath) (fs.FileInfo, error) {
+	return fp.Stat()
+}
+
+func (osFileSystem) MkdirAll(dp dt.DirPath) error {
+	return dp.MkdirAll(0755)
+}
+
+func (osFileSystem) Remove(fp dt.Filepath) error {
+	return os.Remove(string(fp))
+}
+
+func (osFileSystem) Rename(from, to dt.Filepath) error {
+	return os.Rename(string(from), string(to))
+}
+
+func (osFileSystem) Symlink(target, link dt.Filepath) error {
+	return os.Symlink(string(target), string(link))
+}
+
+func (osFileSystem) Readlink(link dt.Filepath) (target dt.Filepath, err error) {
+	var s string
+
+	s, err = os.Readlink(string(link))
+	target = dt.Filepath(s)
+	return target, err
+}
+
+func (osFileSystem) DirFS(dp dt.DirPath) fs.FS {
+	return dt.DirFS(dp)
+}
+
+func (osFileSystem) Lock(fp dt.Filepath, timeout time.Duration) (func(), error) {
+	return lockFile(fp, timeout)
+}
+
+func (osFileSystem) RLock(fp dt.Filepath, timeout time.Duration) (func(), error) {
+	return rLockFile(fp, timeout)
+}
+
+// FileSystem returns the FileSystem of the store's DirsProvider, or
+// OSFileSystem() if it has none.
+func (cs *configStore) FileSystem() FileSystem {
+	if cs.dirsProvider == nil || cs.dirsProvider.FileSystem == nil {
+		return OSFileSystem()
+	}
+	return cs.dirsProvider.FileSystem
+}
@@ -0,0 +1,112 @@
+package cfgstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// FirstRunMarkerFile is the file, in the config dir of the first of a load's
+// DirTypes that is not ProjectConfigDirType, that records that
+// RootConfigArgs.OnFirstRun has been called. Loads of project config alone
+// never call OnFirstRun, as the project's config dir is not the app's.
+const FirstRunMarkerFile dt.RelFilepath = ".first-run"
+
+// FirstRunArgs is passed to RootConfigArgs.OnFirstRun.
+type FirstRunArgs struct {
+	Context    context.Context
+	Stores     *ConfigStores
+	RootConfig RootConfig
+
+	// MarkerFilepath is where the first run is recorded once OnFirstRun
+	// returns.
+	MarkerFilepath dt.Filepath
+}
+
+// FirstRunFunc is called by a load the first time an app runs, see
+// RootConfigArgs.OnFirstRun.
+type FirstRunFunc func(FirstRunArgs) error
+
+// firstRunPending is the content of a FirstRunMarkerFile while OnFirstRun is
+// being called.
+const firstRunPending = "pending\n"
+
+// handleFirstRun calls args.OnFirstRun if none of the layers in results existed
+// before the load and no first run has been recorded, and then records it. A
+// load whose config already existed, e.g. from before the app used OnFirstRun,
+// is recorded without calling it. The marker is pending while OnFirstRun is
+// called, so if it fails it is called again by the next load.
+func (stores *ConfigStores) handleFirstRun(ctx context.Context, rc RootConfig, args RootConfigArgs, results *LoadResults) (err error) {
+	var fp dt.Filepath
+	var marker ConfigStore
+	var data []byte
+	var pending bool
+
+	marker = stores.firstRunMarker(args.DirTypes)
+	if marker == nil {
+		goto end
+	}
+	fp, err = marker.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	data, err = LoadContext(ctx, marker)
+	switch {
+	case errors.Is(err, ErrFileDoesNotExist):
+		err = nil
+	case err != nil:
+		goto end
+	case string(data) != firstRunPending:
+		// Already recorded
+		goto end
+	default:
+		pending = true
+	}
+	if pending || !existedBefore(results) {
+		err = SaveContext(ctx, marker, []byte(firstRunPending))
+		if err != nil {
+			goto end
+		}
+		err = args.OnFirstRun(FirstRunArgs{
+			Context:        ctx,
+			Stores:         stores,
+			RootConfig:     rc,
+			MarkerFilepath: fp,
+		})
+		if err != nil {
+			err = NewErr(ErrFirstRunFuncFailed, err)
+			goto end
+		}
+	}
+	err = SaveContext(ctx, marker, []byte(time.Now().UTC().Format(time.RFC3339)+"\n"))
+end:
+	if err != nil {
+		err = withErrorKind(WithErr(err, "marker_filepath", fp))
+	}
+	return err
+}
+
+// firstRunMarker returns a store for the FirstRunMarkerFile of the first of
+// dirTypes that is not ProjectConfigDirType, or nil if there is none.
+func (stores *ConfigStores) firstRunMarker(dirTypes []DirType) (marker ConfigStore) {
+	for _, dirType := range dirTypes {
+		if dirType != ProjectConfigDirType {
+			marker = SubStore(stores.StoreMap[dirType], FirstRunMarkerFile)
+			break
+		}
+	}
+	return marker
+}
+
+// existedBefore returns true if any of the layers in results existed before
+// they were loaded.
+func existedBefore(results *LoadResults) bool {
+	for _, layer := range results.Layers {
+		if layer.Existed {
+			return true
+		}
+	}
+	return false
+}
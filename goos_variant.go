@@ -0,0 +1,94 @@
+package cfgstore
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+type GOOSVariantOptions struct {
+	// GOOS is the operating system whose variant is overlaid. Defaults to
+	// runtime.GOOS.
+	GOOS string
+}
+
+// GOOSVariantFilepath returns the variant of fp for goos, named the way Go
+// names platform-specific source files, e.g. config_windows.json for
+// config.json.
+func GOOSVariantFilepath(fp dt.Filepath, goos string) dt.Filepath {
+	ext := filepath.Ext(string(fp))
+	return dt.Filepath(strings.TrimSuffix(string(fp), ext) + "_" + goos + ext)
+}
+
+// GOOSVariantHook returns a pre-load hook that overlays the variant of the
+// store's file for the current operating system, e.g. config_darwin.json over
+// config.json, if it exists, so platform-specific settings can live in
+// platform-specific files:
+//
+//	err := cfgstore.AddPreLoadHook(store, cfgstore.GOOSVariantHook())
+//
+// The variant is merged as a JSON Merge Patch: objects are merged recursively,
+// null removes a member and other values replace the base file's. Both files
+// must hold JSON objects. Loads for an update, see LoadHookArgs.ForUpdate, are
+// not overlaid, so edits such as SetValue and UpdateJSON change only the base
+// file and never copy the variant's settings into it. Saves write only the base
+// file, so a config loaded with LoadJSON and saved with SaveJSON does take them
+// on.
+func GOOSVariantHook(opts ...GOOSVariantOptions) LoadHook {
+	if len(opts) == 0 {
+		opts = []GOOSVariantOptions{{}}
+	}
+	goos := opts[0].GOOS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	return func(args *LoadHookArgs) (err error) {
+		var data []byte
+		var base, variant any
+		var baseObj, variantObj *jsonObject
+		var ok bool
+
+		fp := GOOSVariantFilepath(args.Filepath, goos)
+		if args.ForUpdate {
+			goto end
+		}
+		data, err = args.Store.FileSystem().ReadFile(fp)
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+			goto end
+		}
+		if err != nil {
+			goto end
+		}
+		variant, err = parseDocument(data)
+		if err != nil || variant == nil {
+			goto end
+		}
+		base, err = parseDocument(args.Data)
+		if err != nil {
+			goto end
+		}
+		if base == nil {
+			base = &jsonObject{}
+		}
+		baseObj, ok = base.(*jsonObject)
+		if ok {
+			variantObj, ok = variant.(*jsonObject)
+		}
+		if !ok {
+			err = NewErr(ErrGOOSVariantNotObject)
+			goto end
+		}
+		mergeObject(baseObj, variantObj)
+		args.Data, err = encodeDocument(baseObj)
+	end:
+		if err != nil {
+			err = withErrorKind(NewErr(ErrFailedToLoadGOOSVariant, "variant_filepath", fp, err))
+		}
+		return err
+	}
+}
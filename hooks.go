@@ -0,0 +1,227 @@
+package cfgstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// SaveHookArgs is passed to the hooks registered with AddPreSaveHook and
+// AddPostSaveHook.
+type SaveHookArgs struct {
+	Store    ConfigStore
+	Filepath dt.Filepath
+
+	// Context is the context passed to SaveContext or SaveJSONContext, or
+	// context.Background() for the methods without one.
+	Context context.Context
+
+	// Value is the value passed to SaveJSON, or nil for Save. Pre-save hooks may
+	// mutate it, or replace it, before it is marshaled.
+	Value any
+
+	// Data is the content to be written. It is nil for pre-save hooks called by
+	// SaveJSON because Value has not been marshaled yet. Pre-save hooks called by
+	// Save may replace it.
+	Data []byte
+
+	// State carries values from a save's pre-save hooks to its post-save hooks,
+	// so that concurrent saves do not share them. Hooks should key their
+	// entries with a value only they use, as for context.WithValue.
+	State map[any]any
+}
+
+// SaveHook is called before or after a store writes its file. A pre-save hook
+// that returns an error aborts the save.
+type SaveHook func(*SaveHookArgs) error
+
+// LoadHookArgs is passed to the hooks registered with AddPreLoadHook and
+// AddPostLoadHook.
+type LoadHookArgs struct {
+	Store    ConfigStore
+	Filepath dt.Filepath
+
+	// Context is the context passed to LoadContext or LoadJSONContext, or
+	// context.Background() for the methods without one.
+	Context context.Context
+
+	// Data is the content read from the store's file. Pre-load hooks may replace
+	// it, e.g. to decrypt it, before it is returned by Load or unmarshaled by
+	// LoadJSON.
+	Data []byte
+
+	// Value is the value passed to LoadJSON after it has been unmarshaled, or nil
+	// for Load. It is always nil for pre-load hooks.
+	Value any
+
+	// ForUpdate is true when the content is loaded to be changed and saved back,
+	// e.g. by SetValue or UpdateJSON. Hooks that only derive values for reading,
+	// such as ExpandEnvHook and GOOSVariantHook, must then leave Data as it is
+	// so that what they derive is not written to the file.
+	ForUpdate bool
+}
+
+// LoadHook is called after a store reads its file. A hook that returns an
+// error causes the load to fail.
+type LoadHook func(*LoadHookArgs) error
+
+// forUpdateKey marks a context passed to a load whose content will be saved
+// back, see LoadHookArgs.ForUpdate.
+type forUpdateKey struct{}
+
+// forUpdate returns ctx marked for a load whose content will be saved back.
+func forUpdate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forUpdateKey{}, true)
+}
+
+// isForUpdate reports whether ctx was returned by forUpdate.
+func isForUpdate(ctx context.Context) bool {
+	marked, _ := ctx.Value(forUpdateKey{}).(bool)
+	return marked
+}
+
+// storeHooks holds the hooks registered on a configStore.
+type storeHooks struct {
+	preSave  []SaveHook
+	postSave []SaveHook
+	preLoad  []LoadHook
+	postLoad []LoadHook
+}
+
+// HookRegistrar is implemented by a ConfigStore that runs hooks when it loads
+// and saves its file. See AddPreSaveHook.
+type HookRegistrar interface {
+	AddPreSaveHook(SaveHook)
+	AddPostSaveHook(SaveHook)
+	AddPreLoadHook(LoadHook)
+	AddPostLoadHook(LoadHook)
+}
+
+// AddPreSaveHook calls store.AddPreSaveHook if store is a HookRegistrar and
+// otherwise fails with ErrHooksNotSupported.
+func AddPreSaveHook(store ConfigStore, hook SaveHook) error {
+	if hr, ok := store.(HookRegistrar); ok {
+		hr.AddPreSaveHook(hook)
+		return nil
+	}
+	return NewErr(ErrHooksNotSupported, "hook", "pre-save")
+}
+
+// AddPostSaveHook calls store.AddPostSaveHook if store is a HookRegistrar and
+// otherwise fails with ErrHooksNotSupported.
+func AddPostSaveHook(store ConfigStore, hook SaveHook) error {
+	if hr, ok := store.(HookRegistrar); ok {
+		hr.AddPostSaveHook(hook)
+		return nil
+	}
+	return NewErr(ErrHooksNotSupported, "hook", "post-save")
+}
+
+// AddPreLoadHook calls store.AddPreLoadHook if store is a HookRegistrar and
+// otherwise fails with ErrHooksNotSupported.
+func AddPreLoadHook(store ConfigStore, hook LoadHook) error {
+	if hr, ok := store.(HookRegistrar); ok {
+		hr.AddPreLoadHook(hook)
+		return nil
+	}
+	return NewErr(ErrHooksNotSupported, "hook", "pre-load")
+}
+
+// AddPostLoadHook calls store.AddPostLoadHook if store is a HookRegistrar and
+// otherwise fails with ErrHooksNotSupported.
+func AddPostLoadHook(store ConfigStore, hook LoadHook) error {
+	if hr, ok := store.(HookRegistrar); ok {
+		hr.AddPostLoadHook(hook)
+		return nil
+	}
+	return NewErr(ErrHooksNotSupported, "hook", "post-load")
+}
+
+// AddPreSaveHook registers hook to be called before the store's file is written,
+// e.g. to validate a config or to bump a `modified_at` field.
+func (cs *configStore) AddPreSaveHook(hook SaveHook) {
+	// Clip so that copies made by WithDirType() never share appended hooks
+	cs.hooks.preSave = append(slices.Clip(cs.hooks.preSave), hook)
+}
+
+// AddPostSaveHook registers hook to be called after the store's file has been
+// successfully written, e.g. to notify or sync.
+func (cs *configStore) AddPostSaveHook(hook SaveHook) {
+	cs.hooks.postSave = append(slices.Clip(cs.hooks.postSave), hook)
+}
+
+// AddPreLoadHook registers hook to be called after the store's file is read but
+// before its content is returned or unmarshaled, e.g. to decrypt it or to
+// expand environment variables.
+func (cs *configStore) AddPreLoadHook(hook LoadHook) {
+	cs.hooks.preLoad = append(slices.Clip(cs.hooks.preLoad), hook)
+}
+
+// AddPostLoadHook registers hook to be called after the store's file has been
+// successfully loaded and, for LoadJSON, unmarshaled, e.g. to record metrics.
+func (cs *configStore) AddPostLoadHook(hook LoadHook) {
+	cs.hooks.postLoad = append(slices.Clip(cs.hooks.postLoad), hook)
+}
+
+// AddPreSaveHook registers hook on every store in stores, failing with
+// ErrHooksNotSupported for those that are not HookRegistrars.
+func (stores *ConfigStores) AddPreSaveHook(hook SaveHook) error {
+	var errs []error
+	for _, dirType := range stores.DirTypes {
+		errs = append(errs, AddPreSaveHook(stores.StoreMap[dirType], hook))
+	}
+	return CombineErrs(errs)
+}
+
+// AddPostSaveHook registers hook on every store in stores, failing with
+// ErrHooksNotSupported for those that are not HookRegistrars.
+func (stores *ConfigStores) AddPostSaveHook(hook SaveHook) error {
+	var errs []error
+	for _, dirType := range stores.DirTypes {
+		errs = append(errs, AddPostSaveHook(stores.StoreMap[dirType], hook))
+	}
+	return CombineErrs(errs)
+}
+
+// AddPreLoadHook registers hook on every store in stores, failing with
+// ErrHooksNotSupported for those that are not HookRegistrars.
+func (stores *ConfigStores) AddPreLoadHook(hook LoadHook) error {
+	var errs []error
+	for _, dirType := range stores.DirTypes {
+		errs = append(errs, AddPreLoadHook(stores.StoreMap[dirType], hook))
+	}
+	return CombineErrs(errs)
+}
+
+// AddPostLoadHook registers hook on every store in stores, failing with
+// ErrHooksNotSupported for those that are not HookRegistrars.
+func (stores *ConfigStores) AddPostLoadHook(hook LoadHook) error {
+	var errs []error
+	for _, dirType := range stores.DirTypes {
+		errs = append(errs, AddPostLoadHook(stores.StoreMap[dirType], hook))
+	}
+	return CombineErrs(errs)
+}
+
+func runLoadHooks(hooks []LoadHook, args *LoadHookArgs) (err error) {
+	for _, hook := range hooks {
+		err = hook(args)
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+func runSaveHooks(hooks []SaveHook, args *SaveHookArgs) (err error) {
+	for _, hook := range hooks {
+		err = hook(args)
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return err
+}
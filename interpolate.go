@@ -0,0 +1,147 @@
+package cfgstore
+
+import (
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"strings"
+)
+
+// Interpolate resolves references between values within v, which must be a
+// pointer to a value that can be marshaled to and unmarshaled from a JSON object,
+// such as a merged RootConfig. Within string values, ${path} is replaced by the
+// value at the dotted path or JSON Pointer, e.g.:
+//
+//	{"paths": {"data_dir": "/var/lib/app"}, "log_file": "${paths.data_dir}/app.log"}
+//
+// References may themselves contain references. A reference to an object or an
+// array, or a cycle of references, fails with ErrInvalidInterpolation. $${
+// escapes a literal ${, and ${...} that does not refer to an existing path, e.g.
+// ${HOME}, is left as is.
+func Interpolate(v any) (err error) {
+	var data []byte
+	var doc, out any
+
+	data, err = jsonv2.Marshal(v)
+	if err != nil {
+		err = NewErr(ErrFailedToMarshalValue, err)
+		goto end
+	}
+	// Look references up in an unmodified copy so each is resolved exactly once
+	doc, err = parseDocument(data)
+	if err != nil {
+		goto end
+	}
+	out, err = parseDocument(data)
+	if err != nil {
+		goto end
+	}
+	out, err = mapStrings(out, (&interpolator{
+		doc:      doc,
+		resolved: make(map[string]string),
+		visiting: make(map[string]bool),
+	}).resolveString)
+	if err != nil {
+		goto end
+	}
+	data, err = encodeDocument(out)
+	if err != nil {
+		goto end
+	}
+	err = jsonv2.Unmarshal(data, v)
+	if err != nil {
+		err = NewErr(ErrFailedToUnmarshalConfigFile, err)
+		goto end
+	}
+end:
+	if err != nil {
+		err = WithErr(err, ErrFailedToInterpolate)
+	}
+	return err
+}
+
+type interpolator struct {
+	doc      any
+	resolved map[string]string
+	visiting map[string]bool
+}
+
+// resolveString replaces the references in s with the values they refer to.
+func (ip *interpolator) resolveString(s string) (_ string, err error) {
+	var sb strings.Builder
+	var value string
+
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			sb.WriteString(s)
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			// $${ is an escaped ${
+			sb.WriteString(s[:i-1])
+			sb.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+		sb.WriteString(s[:i])
+		s = s[i:]
+		closing := strings.IndexByte(s, '}')
+		if closing < 0 {
+			sb.WriteString(s)
+			break
+		}
+		path := s[2:closing]
+		segments, pathErr := splitValuePath(path)
+		_, found := lookupNode(ip.doc, segments)
+		if pathErr != nil || len(segments) == 0 || !found {
+			// Not a reference to a value in this config
+			sb.WriteString(s[:2])
+			s = s[2:]
+			continue
+		}
+		value, err = ip.resolvePath(path, segments)
+		if err != nil {
+			goto end
+		}
+		sb.WriteString(value)
+		s = s[closing+1:]
+	}
+end:
+	return sb.String(), err
+}
+
+// resolvePath returns the fully resolved text of the scalar at path.
+func (ip *interpolator) resolvePath(path string, segments []string) (value string, err error) {
+	var node any
+	var ok bool
+
+	value, ok = ip.resolved[path]
+	if ok {
+		goto end
+	}
+	if ip.visiting[path] {
+		err = NewErr(ErrInvalidInterpolation, "reason", "cycle", "path", path)
+		goto end
+	}
+	ip.visiting[path] = true
+	defer delete(ip.visiting, path)
+
+	node, _ = lookupNode(ip.doc, segments)
+	switch {
+	case isStringNode(node):
+		value, err = unquoteJSON(node.(jsontext.Value))
+		if err == nil {
+			value, err = ip.resolveString(value)
+		}
+	case isScalarNode(node):
+		value = string(node.(jsontext.Value))
+	default:
+		err = NewErr(ErrInvalidInterpolation, "reason", "not a scalar", "path", path)
+	}
+	if err != nil {
+		goto end
+	}
+	ip.resolved[path] = value
+end:
+	return value, err
+}
@@ -0,0 +1,216 @@
+package cfgstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"errors"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// JSONStreamer is implemented by a ConfigStore that can load and save large
+// files without holding their content in memory. See LoadJSONStream.
+type JSONStreamer interface {
+	LoadJSONStream(data any, opts ...jsonv2.Options) error
+	LoadJSONMapped(data any, opts ...jsonv2.Options) error
+	SaveJSONStream(data any, opts ...jsonv2.Options) error
+}
+
+// LoadJSONStream calls store.LoadJSONStream if store is a JSONStreamer, and
+// otherwise store.LoadJSON.
+func LoadJSONStream(store ConfigStore, data any, opts ...jsonv2.Options) error {
+	if js, ok := store.(JSONStreamer); ok {
+		return js.LoadJSONStream(data, opts...)
+	}
+	return store.LoadJSON(data, opts...)
+}
+
+// LoadJSONMapped calls store.LoadJSONMapped if store is a JSONStreamer, and
+// otherwise store.LoadJSON.
+func LoadJSONMapped(store ConfigStore, data any, opts ...jsonv2.Options) error {
+	if js, ok := store.(JSONStreamer); ok {
+		return js.LoadJSONMapped(data, opts...)
+	}
+	return store.LoadJSON(data, opts...)
+}
+
+// SaveJSONStream calls store.SaveJSONStream if store is a JSONStreamer, and
+// otherwise store.SaveJSON, ignoring opts.
+func SaveJSONStream(store ConfigStore, data any, opts ...jsonv2.Options) error {
+	if js, ok := store.(JSONStreamer); ok {
+		return js.SaveJSONStream(data, opts...)
+	}
+	return store.SaveJSON(data)
+}
+
+// LoadJSONStream is LoadJSON for large files, e.g. state-like files of tens of
+// megabytes kept in a project store. It decodes the file as it is read rather
+// than reading it into memory first, so only the decoded value is held in full.
+//
+// Pre-load hooks need the whole content, so a store with pre-load hooks, or a
+// Codec, falls back to LoadJSON. Post-load hooks receive the Value but no Data, and
+// concurrent calls are not collapsed into one read as they are for LoadJSON.
+func (cs *configStore) LoadJSONStream(data any, opts ...jsonv2.Options) (err error) {
+	var fSys fs.FS
+	var f fs.File
+	var fp dt.Filepath
+
+	if len(cs.hooks.preLoad) > 0 || cs.codec != nil {
+		return cs.LoadJSON(data, opts...)
+	}
+	start := time.Now()
+	_, span := cs.startSpan(context.Background(), LoadSpanName)
+	fp, err = cs.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	fSys, err = cs.getFS()
+	if err != nil {
+		err = WithErr(ErrFailedToGetConfigFileSystem, err)
+		goto end
+	}
+	f, err = fSys.Open(string(cs.relFilepath))
+	if errors.Is(err, fs.ErrNotExist) {
+		err = NewErr(ErrFileDoesNotExist, err)
+	}
+	if err != nil {
+		err = NewErr(ErrFailedToReadConfigFile, ErrFailedToReadFile, err)
+		goto end
+	}
+	defer CloseOrLog(f)
+
+	err = jsonv2.UnmarshalRead(bufio.NewReaderSize(f, streamBufferSize), data, opts...)
+	if err != nil {
+		err = NewErr(ErrFailedToUnmarshalConfigFile, err)
+		goto end
+	}
+	err = runLoadHooks(cs.hooks.postLoad, &LoadHookArgs{
+		Store:    cs,
+		Filepath: fp,
+		Context:  context.Background(),
+		Value:    data,
+	})
+	if err != nil {
+		err = NewErr(ErrPostLoadHookFailed, err)
+	}
+end:
+	if err != nil {
+		err = withErrorKind(WithErr(err, ErrFailedToLoadJSON))
+	}
+	cs.recordOperation(LoadEventKind, start, 0, err)
+	span.End(err)
+	return err
+}
+
+// LoadJSONMapped is LoadJSON for very large, read-mostly files. It maps the file
+// into memory, if the store's FileSystem is a FileMapper as OSFileSystem() is
+// on Unix-like platforms, and parses it in place rather than first copying it
+// into a byte slice, so its pages are shared with the OS page cache. The
+// mapping is released before LoadJSONMapped returns, so custom unmarshalers
+// must copy what they keep, as encoding/json already requires.
+//
+// A store with pre-load hooks, which may replace the content, or a Codec falls
+// back to LoadJSON. Post-load hooks receive the Value but no Data.
+func (cs *configStore) LoadJSONMapped(data any, opts ...jsonv2.Options) (err error) {
+	var fp dt.Filepath
+	var raw []byte
+	var unmap func() error
+
+	if len(cs.hooks.preLoad) > 0 || cs.codec != nil {
+		return cs.LoadJSON(data, opts...)
+	}
+	start := time.Now()
+	_, span := cs.startSpan(context.Background(), LoadSpanName)
+	fp, err = cs.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	raw, unmap, err = MapFile(cs.FileSystem(), fp)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = NewErr(ErrFileDoesNotExist, err)
+	}
+	if err != nil {
+		err = NewErr(ErrFailedToReadConfigFile, ErrFailedToReadFile, err)
+		goto end
+	}
+	err = jsonv2.Unmarshal(raw, data, opts...)
+	err = CombineErrs([]error{err, unmap()})
+	if err != nil {
+		err = NewErr(ErrFailedToUnmarshalConfigFile, err)
+		goto end
+	}
+	err = runLoadHooks(cs.hooks.postLoad, &LoadHookArgs{
+		Store:    cs,
+		Filepath: fp,
+		Context:  context.Background(),
+		Value:    data,
+	})
+	if err != nil {
+		err = NewErr(ErrPostLoadHookFailed, err)
+	}
+end:
+	if err != nil {
+		err = withErrorKind(WithErr(err, ErrFailedToLoadJSON))
+	}
+	cs.recordOperation(LoadEventKind, start, len(raw), err)
+	span.End(err)
+	return err
+}
+
+// SaveJSONStream is SaveJSON for large files. It encodes data straight to the
+// file, through a temporary file that is renamed over it, rather than
+// marshaling it into memory first, if the store's FileSystem is a
+// FileStreamWriter as OSFileSystem() is. opts default to the indentation
+// SaveJSON uses; pass jsontext.Multiline(false) to write compact JSON.
+//
+// Pre-save hooks may mutate the Value as for SaveJSON, but post-save hooks
+// receive no Data. A store with a Codec or FileMode falls back to SaveJSON.
+func (cs *configStore) SaveJSONStream(data any, opts ...jsonv2.Options) (err error) {
+	if cs.codec != nil || cs.fileMode != 0 {
+		return cs.SaveJSON(data)
+	}
+	if len(opts) == 0 {
+		opts = []jsonv2.Options{jsontext.WithIndent("  ")}
+	}
+	start := time.Now()
+	_, span := cs.startSpan(context.Background(), SaveSpanName)
+	args := &SaveHookArgs{
+		Store:   cs,
+		Context: context.Background(),
+		Value:   data,
+	}
+	args.Filepath, err = cs.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	err = runSaveHooks(cs.hooks.preSave, args)
+	if err != nil {
+		err = NewErr(ErrPreSaveHookFailed, err)
+		goto end
+	}
+	err = cs.FileSystem().MkdirAll(args.Filepath.Dir())
+	if err != nil {
+		goto end
+	}
+	err = WriteFileFrom(cs.FileSystem(), args.Filepath, func(w io.Writer) error {
+		return jsonv2.MarshalWrite(w, args.Value, opts...)
+	})
+	if err != nil {
+		goto end
+	}
+	cs.stat.set(true)
+	err = runSaveHooks(cs.hooks.postSave, args)
+	if err != nil {
+		err = NewErr(ErrPostSaveHookFailed, err)
+	}
+end:
+	err = withErrorKind(err)
+	cs.recordOperation(SaveEventKind, start, 0, err)
+	span.End(err)
+	return err
+}
@@ -0,0 +1,56 @@
+package cfgstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// lockLayers takes a shared lock on the file of each of stores.DirTypes that
+// exists, in DirTypes order, so that writers that lock, such as UpdateJSON,
+// cannot change a layer while the layers are loaded and merged. Files shared by
+// several DirTypes are locked once. Call unlock to release the locks.
+func (stores *ConfigStores) lockLayers(ctx context.Context, args RootConfigArgs) (unlock func(), err error) {
+	var unlocks []func()
+	var locked []dt.Filepath
+
+	timeout := args.LockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+	unlock = func() {
+		for _, fn := range slices.Backward(unlocks) {
+			fn()
+		}
+	}
+	for _, dirType := range stores.DirTypes {
+		var fp dt.Filepath
+		var release func()
+
+		cs := stores.StoreMap[dirType].(*configStore)
+		fp, err = cs.GetFilepath()
+		if err != nil || !cs.Exists() || slices.Contains(locked, fp) {
+			// Missing layers are reported, or created, by loadLayer
+			err = nil
+			continue
+		}
+		err = contextErr(ctx)
+		if err != nil {
+			goto end
+		}
+		release, err = RLock(cs.FileSystem(), fp, timeout)
+		if err != nil {
+			err = NewErr(ErrFailedToLockFile, "filepath", fp, "dir_type", dirType.Slug(), err)
+			goto end
+		}
+		unlocks = append(unlocks, release)
+		locked = append(locked, fp)
+	}
+end:
+	if err != nil {
+		unlock()
+		unlock = nil
+	}
+	return unlock, err
+}
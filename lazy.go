@@ -0,0 +1,97 @@
+package cfgstore
+
+import (
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"sync"
+	"sync/atomic"
+)
+
+// Lazy holds a config section that is only unmarshaled when Get is first
+// called, so apps with large, seldom-used sections, e.g. embedded datasets, do
+// not pay to decode them at startup:
+//
+//	type MyConfig struct {
+//		Name     string                   `json:"name"`
+//		Datasets cfgstore.Lazy[[]Dataset] `json:"datasets,omitzero"`
+//	}
+//
+// Loading only validates the section's JSON and keeps a copy of it. Saving a
+// section that was never decoded writes that copy back unchanged. A field typed
+// as jsontext.Value also defers decoding, but leaves it to the app.
+//
+// Get decodes with default options; options passed to LoadJSON do not apply.
+// Copies of a Lazy share its decoded value.
+type Lazy[T any] struct {
+	state *lazyState[T]
+}
+
+type lazyState[T any] struct {
+	once    sync.Once
+	raw     jsontext.Value
+	value   T
+	err     error
+	decoded atomic.Bool
+}
+
+// NewLazy returns a Lazy holding value, which Get returns without decoding.
+func NewLazy[T any](value T) Lazy[T] {
+	l := Lazy[T]{state: &lazyState[T]{value: value}}
+	l.state.once.Do(func() {})
+	l.state.decoded.Store(true)
+	return l
+}
+
+// Get returns the section's value, unmarshaling it on the first call. A zero
+// Lazy returns the zero value of T. The error, if any, is returned by every
+// call.
+func (l Lazy[T]) Get() (value T, err error) {
+	s := l.state
+	if s == nil {
+		goto end
+	}
+	s.once.Do(func() {
+		s.err = jsonv2.Unmarshal(s.raw, &s.value)
+		if s.err != nil {
+			s.err = NewErr(ErrFailedToUnmarshalLazyValue, s.err)
+		}
+		s.decoded.Store(true)
+	})
+	value, err = s.value, s.err
+end:
+	return value, err
+}
+
+// Raw returns the section's JSON as loaded, or nil if it was not loaded.
+func (l Lazy[T]) Raw() jsontext.Value {
+	if l.state == nil {
+		return nil
+	}
+	return l.state.raw
+}
+
+// IsZero returns true if l holds neither loaded JSON nor a value, so that
+// `json:",omitzero"` omits it.
+func (l Lazy[T]) IsZero() bool {
+	return l.state == nil
+}
+
+// MarshalJSON marshals the decoded value if Get has been called, or NewLazy
+// used, otherwise the JSON as loaded.
+func (l Lazy[T]) MarshalJSON() ([]byte, error) {
+	s := l.state
+	switch {
+	case s == nil:
+		return []byte("null"), nil
+	case s.decoded.Load() && s.err == nil:
+		return jsonv2.Marshal(s.value)
+	}
+	return s.raw, nil
+}
+
+// UnmarshalJSON keeps a copy of data to decode when Get is first called.
+func (l *Lazy[T]) UnmarshalJSON(data []byte) error {
+	raw := jsontext.Value(data).Clone()
+	l.state = &lazyState[T]{raw: raw}
+	return nil
+}
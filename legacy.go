@@ -0,0 +1,35 @@
+package cfgstore
+
+import (
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// legacyFilepath returns the first of the store's LegacyFilepaths whose file
+// exists, with a leading "~/" expanded to the user's home dir. Paths that
+// cannot be expanded are skipped.
+func (cs *configStore) legacyFilepath() (fp dt.Filepath, found bool) {
+	var home dt.DirPath
+	var err error
+
+	for _, legacy := range cs.legacyFilepaths {
+		fp = legacy
+		rest, isHome := strings.CutPrefix(string(legacy), "~/")
+		if isHome {
+			home, err = cs.dirsProvider.UserHomeDirFunc()
+			if err != nil {
+				continue
+			}
+			fp = dt.FilepathJoin(home, dt.RelFilepath(rest))
+		}
+		_, err = cs.FileSystem().Stat(fp)
+		if err == nil {
+			found = true
+			goto end
+		}
+	}
+	fp = ""
+end:
+	return fp, found
+}
@@ -0,0 +1,60 @@
+package cfgstore
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ListFiles returns the relative paths of the files in the store's config
+// directory that match pattern, e.g. "tokens/*.json", using the syntax of
+// path.Match. Directories, and the lock and temporary files this package creates
+// while saving, are omitted. A config directory that does not exist yet has no
+// files.
+func ListFiles(store ConfigStore, pattern string) (files []dt.RelFilepath, err error) {
+	var dir dt.DirPath
+	var fSys fs.FS
+	var matches []string
+
+	dir, err = store.ConfigDir()
+	if err != nil {
+		err = WithErr(ErrFailedToGetConfigFileSystem, err)
+		goto end
+	}
+	fSys = store.FileSystem().DirFS(dir)
+	matches, err = fs.Glob(fSys, pattern)
+	if err != nil {
+		goto end
+	}
+	for _, match := range matches {
+		if isInternalFile(fSys, match) {
+			continue
+		}
+		info, statErr := fs.Stat(fSys, match)
+		if statErr != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, dt.RelFilepath(match))
+	}
+end:
+	if err != nil {
+		err = WithErr(err, ErrFailedToListFiles, "pattern", pattern)
+	}
+	return files, err
+}
+
+// isInternalFile reports whether name is a lock sidecar or a temporary file
+// written by writeFile.
+func isInternalFile(fSys fs.FS, name string) (internal bool) {
+	base := path.Base(name)
+	switch {
+	case strings.HasPrefix(base, ".") && strings.HasSuffix(base, ".tmp"):
+		internal = true
+	case strings.HasSuffix(name, lockFileExt):
+		_, err := fs.Stat(fSys, strings.TrimSuffix(name, lockFileExt))
+		internal = err == nil
+	}
+	return internal
+}
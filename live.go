@@ -0,0 +1,88 @@
+package cfgstore
+
+import (
+	"sync/atomic"
+)
+
+// Live holds the most recently loaded version of a config so that concurrent
+// goroutines can each read a consistent snapshot while a Watcher swaps in newly
+// reloaded versions. The *RC returned by Get must be treated as read-only; to
+// change the config, build a new value and pass it to Set.
+type Live[RC any] struct {
+	ptr atomic.Pointer[RC]
+}
+
+type LiveArgs struct {
+	// Options is passed to Normalize when *RC implements RootConfig.
+	Options Options
+
+	// OnError, if set, is called when a change could not be loaded. The
+	// previously loaded config remains in place.
+	OnError func(error)
+}
+
+// NewLive returns a Live initialized with rc, which may be nil.
+func NewLive[RC any](rc *RC) *Live[RC] {
+	l := &Live[RC]{}
+	l.ptr.Store(rc)
+	return l
+}
+
+// Get returns the current config.
+func (l *Live[RC]) Get() *RC {
+	return l.ptr.Load()
+}
+
+// Set atomically replaces the current config with rc.
+func (l *Live[RC]) Set(rc *RC) {
+	l.ptr.Store(rc)
+}
+
+// Attach registers l with w so that each change to the watched file is
+// unmarshaled, with the store's Codec if it has one, into a new *RC, normalized
+// if it is a RootConfig, and then swapped in. Invalid content or a removed file
+// leaves the current config in place.
+func (l *Live[RC]) Attach(w *Watcher, args LiveArgs) {
+	w.OnChange(func(ev WatchEvent) {
+		var rc *RC
+		var err error
+
+		if !ev.Exists {
+			goto end
+		}
+		if ev.Err != nil {
+			err = ev.Err
+			goto end
+		}
+		rc, err = l.reload(ev, args)
+		if err != nil {
+			goto end
+		}
+		l.Set(rc)
+	end:
+		if err != nil && args.OnError != nil {
+			args.OnError(err)
+		}
+	})
+}
+
+func (l *Live[RC]) reload(ev WatchEvent, args LiveArgs) (rc *RC, err error) {
+	rc = new(RC)
+	err = unmarshalStore(ev.Store, ev.Data, rc)
+	if err != nil {
+		err = NewErr(ErrFailedToUnmarshalConfigFile, err)
+		goto end
+	}
+	if root, ok := any(rc).(RootConfig); ok {
+		err = root.Normalize(NormalizeArgs{
+			DirType:    ev.Store.DirType(),
+			SourceFile: ev.Filepath,
+			Options:    args.Options,
+		})
+	}
+end:
+	if err != nil {
+		err = WithErr(err, ErrFailedToReloadConfig, "filepath", ev.Filepath)
+	}
+	return rc, err
+}
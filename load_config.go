@@ -1,6 +1,9 @@
 package cfgstore
 
 import (
+	"context"
+	"time"
+
 	"github.com/mikeschinkel/go-dt"
 )
 
@@ -10,7 +13,28 @@ type LoadConfigArgs struct {
 	ConfigFile   dt.RelFilepath
 	DirTypes     []DirType     // optional: defaults to [CLIConfigDirType, ProjectConfigDirType]
 	DirsProvider *DirsProvider // optional: defaults to DefaultDirsProvider()
+	ProjectDir   dt.DirPath    // optional: overrides DirsProvider.ProjectDirFunc
 	Options      Options       // optional: can be nil
+	Interpolate  bool          // optional: resolve ${path} references after merge
+	Results      *LoadResults  // optional: filled in with what was found for each DirType
+
+	// RequireExists fails with ErrNoConfigFound, rather than creating a
+	// default config file, if none of the DirTypes has one. See
+	// RootConfigArgs.RequireExists.
+	RequireExists bool
+
+	// LoadPolicies sets what happens to each DirType whose file does not
+	// exist. See RootConfigArgs.LoadPolicies.
+	LoadPolicies map[DirType]LoadPolicy
+
+	// OnFirstRun is called the first time the app runs. See
+	// RootConfigArgs.OnFirstRun.
+	OnFirstRun FirstRunFunc
+
+	// LockLayers takes shared locks on the DirTypes' files while they are
+	// loaded and merged. See RootConfigArgs.LockLayers.
+	LockLayers  bool
+	LockTimeout time.Duration
 }
 
 // LoadConfig loads configuration from one or more config stores with sensible defaults.
@@ -19,8 +43,14 @@ type LoadConfigArgs struct {
 // Defaults applied:
 // - DirTypes: [CLIConfigDirType, ProjectConfigDirType] if not specified
 // - DirsProvider: DefaultDirsProvider() if not specified
+// - ProjectDir: overrides the DirsProvider's project dir if specified
 // - Options: nil is acceptable (passed through to Normalize)
 func LoadConfig[RC any, PRC RootConfigPtr[RC]](args LoadConfigArgs) (prc PRC, err error) {
+	return LoadConfigContext[RC, PRC](context.Background(), args)
+}
+
+// LoadConfigContext is LoadConfig with a context, see LoadConfigStoresContext.
+func LoadConfigContext[RC any, PRC RootConfigPtr[RC]](ctx context.Context, args LoadConfigArgs) (prc PRC, err error) {
 	// Apply defaults
 	if len(args.DirTypes) == 0 {
 		args.DirTypes = []DirType{CLIConfigDirType, ProjectConfigDirType}
@@ -28,6 +58,9 @@ func LoadConfig[RC any, PRC RootConfigPtr[RC]](args LoadConfigArgs) (prc PRC, er
 	if args.DirsProvider == nil {
 		args.DirsProvider = DefaultDirsProvider()
 	}
+	if args.ProjectDir != "" {
+		args.DirsProvider = args.DirsProvider.WithProjectDir(args.ProjectDir)
+	}
 
 	// Create config stores
 	configStores := NewConfigStores(ConfigStoresArgs{
@@ -40,9 +73,16 @@ func LoadConfig[RC any, PRC RootConfigPtr[RC]](args LoadConfigArgs) (prc PRC, er
 	})
 
 	// Load config using LoadConfigStores
-	return LoadConfigStores[RC, PRC](configStores, RootConfigArgs{
-		DirTypes:     args.DirTypes,
-		Options:      args.Options,
-		DirsProvider: args.DirsProvider,
+	return LoadConfigStoresContext[RC, PRC](ctx, configStores, RootConfigArgs{
+		DirTypes:      args.DirTypes,
+		Options:       args.Options,
+		DirsProvider:  args.DirsProvider,
+		Interpolate:   args.Interpolate,
+		Results:       args.Results,
+		RequireExists: args.RequireExists,
+		LoadPolicies:  args.LoadPolicies,
+		OnFirstRun:    args.OnFirstRun,
+		LockLayers:    args.LockLayers,
+		LockTimeout:   args.LockTimeout,
 	})
 }
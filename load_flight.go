@@ -0,0 +1,110 @@
+package cfgstore
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// loadFlightKey identifies the reads of one store's file that can share a
+// flight. The store is part of the key because its pre-load hooks, FileSystem
+// and legacy filepaths all shape the shared content, so dedup only applies
+// per store instance: two stores for the same file each read it. Loads for an
+// update never share a read with others as their pre-load hooks differ, see
+// LoadHookArgs.ForUpdate.
+type loadFlightKey struct {
+	store       *configStore
+	relFilepath dt.RelFilepath
+	forUpdate   bool
+}
+
+// loadFlight is a read of a store's file that callers arriving while it is in
+// progress wait for instead of reading the file themselves.
+type loadFlight struct {
+	done    chan struct{}
+	waiters int
+	args    *LoadHookArgs
+	err     error
+}
+
+var loadFlights = struct {
+	mutex   sync.Mutex
+	flights map[loadFlightKey]*loadFlight
+}{
+	flights: make(map[loadFlightKey]*loadFlight),
+}
+
+// sharedLoad returns the result of load, collapsing concurrent calls for the
+// same store and file into one disk read, e.g. during a startup fan-out. Each
+// caller gets its own copy of the content so post-load hooks and unmarshaling
+// never share mutable state; only the read and the pre-load hooks are shared.
+// Decoding is not shared because callers unmarshal into their own types, with
+// their own options. See load for pooled.
+//
+// A waiter gives up when its own ctx is done, and reads the file itself if the
+// read it waited for failed because the leader's ctx was done.
+func (cs *configStore) sharedLoad(ctx context.Context, pooled bool) (args *LoadHookArgs, err error) {
+	key := loadFlightKey{
+		store:       cs,
+		relFilepath: cs.relFilepath,
+		forUpdate:   isForUpdate(ctx),
+	}
+
+	loadFlights.mutex.Lock()
+	flight, ok := loadFlights.flights[key]
+	if ok {
+		flight.waiters++
+		loadFlights.mutex.Unlock()
+		select {
+		case <-flight.done:
+		case <-ctx.Done():
+			err = NewErr(ErrFailedToReadFile, contextErr(ctx))
+			goto end
+		}
+		err = flight.err
+		if errors.Is(err, ErrContextDone) && ctx.Err() == nil {
+			args, err = cs.load(ctx, pooled)
+			goto end
+		}
+		if err != nil {
+			goto end
+		}
+		shared := *flight.args
+		shared.Data = slices.Clone(shared.Data)
+		shared.Context = ctx
+		args = &shared
+		goto end
+	}
+	flight = &loadFlight{done: make(chan struct{})}
+	loadFlights.flights[key] = flight
+	loadFlights.mutex.Unlock()
+	args, err = cs.leadLoad(ctx, key, flight, pooled)
+end:
+	return args, err
+}
+
+// leadLoad reads the store's file for flight and, if any callers are waiting,
+// shares a copy of the result with them. Waiters are released even if load
+// panics.
+func (cs *configStore) leadLoad(ctx context.Context, key loadFlightKey, flight *loadFlight, pooled bool) (args *LoadHookArgs, err error) {
+	defer func() {
+		loadFlights.mutex.Lock()
+		delete(loadFlights.flights, key)
+		waiters := flight.waiters
+		loadFlights.mutex.Unlock()
+		if waiters > 0 && flight.err == nil {
+			shared := *args
+			shared.Data = slices.Clone(args.Data)
+			flight.args = &shared
+		}
+		close(flight.done)
+	}()
+	// Waiters fail rather than see a nil result if load panics
+	flight.err = ErrFailedToReadFile
+	args, err = cs.load(ctx, pooled)
+	flight.err = err
+	return args, err
+}
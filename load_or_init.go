@@ -0,0 +1,113 @@
+package cfgstore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// LoadOrInit loads the config of store into a new RC, normalizing it, or if its
+// file does not exist creates it from a copy of defaults, or the zero RC if
+// defaults is nil, normalized, and reports created, so callers can tell users
+// where their new config is:
+//
+//	cfg, created, err := cfgstore.LoadOrInit(store, &MyConfig{Theme: "dark"}, nil)
+//	if created {
+//	    fmt.Printf("created your config at %s\n", fp)
+//	}
+//
+// The file is checked for and created while holding the lock on it, see
+// FileSystem.Lock, so of several processes starting at once exactly one
+// creates it and the others load it. Unlike EnsureConfig, a file that exists
+// but has no settings is loaded rather than replaced.
+func LoadOrInit[RC any, PRC RootConfigPtr[RC]](store ConfigStore, defaults PRC, opts Options) (prc PRC, created bool, err error) {
+	return LoadOrInitContext[RC, PRC](context.Background(), store, defaults, opts)
+}
+
+// LoadOrInitContext is LoadOrInit with a context that is passed to the lock,
+// load and save.
+func LoadOrInitContext[RC any, PRC RootConfigPtr[RC]](ctx context.Context, store ConfigStore, defaults PRC, opts Options) (prc PRC, created bool, err error) {
+	var fp dt.Filepath
+	var unlock func()
+
+	prc = PRC(new(RC))
+	fp, err = store.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	unlock, err = LockContext(ctx, store.FileSystem(), fp, DefaultLockTimeout)
+	if err != nil {
+		goto end
+	}
+	defer unlock()
+
+	// Stat rather than Exists, whose result may be cached
+	_, err = store.FileSystem().Stat(fp)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		err = nil
+		created = !hasLegacyFile(store)
+	case err != nil:
+		goto end
+	}
+	if defaults != nil && created {
+		*prc = *defaults
+	}
+	err = loadOrInit(ctx, store, prc, fp, created, opts)
+	if err != nil {
+		goto end
+	}
+end:
+	if err != nil {
+		created = false
+		err = withErrorKind(NewErr(ErrFailedToLoadOrInitConfig, "filepath", fp, err))
+	}
+	return prc, created, err
+}
+
+// loadOrInit loads rc from store, or if create is set creates the store's file
+// from rc, normalizing rc either way.
+func loadOrInit(ctx context.Context, store ConfigStore, rc RootConfig, fp dt.Filepath, create bool, opts Options) (err error) {
+	cs, ok := store.(*configStore)
+	switch {
+	case ok && create:
+		err = cs.createConfig(ctx, rc, cs.dirType, opts)
+		if err == nil && cs.writeExample {
+			err = cs.saveExample(rc, cs.dirType, opts)
+		}
+		goto end
+	case ok:
+		err = cs.loadConfigIfExists(ctx, rc, cs.dirType, opts)
+		goto end
+	}
+	// Other ConfigStores, e.g. test doubles, are used through the interface
+	if !create {
+		err = LoadJSONContext(ctx, store, rc)
+		if err != nil {
+			goto end
+		}
+	}
+	err = rc.Normalize(NormalizeArgs{
+		DirType:    store.DirType(),
+		SourceFile: fp,
+		Options:    opts,
+	})
+	if err != nil || !create {
+		goto end
+	}
+	err = SaveJSONContext(ctx, store, rc)
+end:
+	return err
+}
+
+// hasLegacyFile returns true if store reads one of its legacy files, see
+// ConfigStoreArgs.LegacyFilepaths, as its file does not exist.
+func hasLegacyFile(store ConfigStore) (found bool) {
+	cs, ok := store.(*configStore)
+	if ok {
+		_, found = cs.legacyFilepath()
+	}
+	return found
+}
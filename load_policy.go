@@ -0,0 +1,73 @@
+package cfgstore
+
+// LoadPolicy is what loading does with a layer whose file does not exist. See
+// RootConfigArgs.LoadPolicies.
+type LoadPolicy int
+
+const (
+	UnspecifiedLoadPolicy   LoadPolicy = iota
+	CreateLoadPolicy                   // Create a default config file
+	RequireExistsLoadPolicy            // Fail with ErrNoConfigFound
+	SkipIfMissingLoadPolicy            // Leave the layer out of the merge
+)
+
+func (p LoadPolicy) String() string {
+	switch p {
+	case CreateLoadPolicy:
+		return "Create"
+	case RequireExistsLoadPolicy:
+		return "RequireExists"
+	case SkipIfMissingLoadPolicy:
+		return "SkipIfMissing"
+	case UnspecifiedLoadPolicy:
+		return "Unspecified"
+	default:
+	}
+	return "Invalid"
+}
+
+func (p LoadPolicy) Slug() string {
+	switch p {
+	case CreateLoadPolicy:
+		return "create"
+	case RequireExistsLoadPolicy:
+		return "require-exists"
+	case SkipIfMissingLoadPolicy:
+		return "skip-if-missing"
+	case UnspecifiedLoadPolicy:
+		return "unspecified"
+	default:
+	}
+	return "invalid"
+}
+
+// loadPolicy returns the LoadPolicy for dirType: the one in args.LoadPolicies
+// or, if it is unspecified, SkipIfMissingLoadPolicy for project config and when
+// args.RequireExists is set, and otherwise CreateLoadPolicy.
+func (args RootConfigArgs) loadPolicy(dirType DirType) (policy LoadPolicy) {
+	policy = args.LoadPolicies[dirType]
+	switch {
+	case policy != UnspecifiedLoadPolicy:
+	case dirType == ProjectConfigDirType, args.RequireExists:
+		policy = SkipIfMissingLoadPolicy
+	default:
+		policy = CreateLoadPolicy
+	}
+	return policy
+}
+
+// withoutCreate returns a copy of args in which layers that would be created if
+// their files were missing are left out of the merge instead, so that a reload
+// by ConfigStores.Watch does not recreate a file that was deleted.
+func (args RootConfigArgs) withoutCreate() RootConfigArgs {
+	policies := make(map[DirType]LoadPolicy, len(args.DirTypes))
+	for _, dirType := range args.DirTypes {
+		policy := args.loadPolicy(dirType)
+		if policy == CreateLoadPolicy {
+			policy = SkipIfMissingLoadPolicy
+		}
+		policies[dirType] = policy
+	}
+	args.LoadPolicies = policies
+	return args
+}
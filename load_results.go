@@ -0,0 +1,77 @@
+package cfgstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// LoadResults reports what LoadConfigStores found for each layer, for apps that
+// tell the user where their config came from. Pass a pointer in
+// RootConfigArgs.Results or LoadConfigArgs.Results to have it filled in:
+//
+//	var results cfgstore.LoadResults
+//	cfg, err := cfgstore.LoadConfig[MyConfig](cfgstore.LoadConfigArgs{
+//	    ConfigSlug: "myapp",
+//	    ConfigFile: "config.json",
+//	    Results:    &results,
+//	})
+//	fmt.Println(results) // loaded cli config from ~/.config/myapp/config.json, no project config found
+type LoadResults struct {
+	// Layers are in DirTypes order.
+	Layers []LayerLoadResult
+}
+
+// LayerLoadResult is the part of a LoadResults for one layer.
+type LayerLoadResult struct {
+	DirType DirType
+
+	// Filepath is the layer's file or, if only one of its store's
+	// LegacyFilepaths exists, that file.
+	Filepath dt.Filepath
+
+	// Existed reports whether the file existed before the load, and Created
+	// whether the load created it. Both are true for a file that had no
+	// settings and was replaced with a default config.
+	Existed bool
+	Created bool
+
+	// Warnings lists problems that did not fail the load, e.g. a project dir
+	// that could not be found or a file with no settings, wrapping
+	// ErrConfigFileHasNoSettings, that was ignored or replaced.
+	Warnings []error
+}
+
+// Layer returns the result for dirType, if it was loaded.
+func (r LoadResults) Layer(dirType DirType) (layer LayerLoadResult, ok bool) {
+	for _, layer = range r.Layers {
+		if layer.DirType == dirType {
+			ok = true
+			goto end
+		}
+	}
+	layer = LayerLoadResult{}
+end:
+	return layer, ok
+}
+
+// String returns a one-line summary of r, e.g. "loaded cli config from
+// /home/me/.config/myapp/config.json, no project config found".
+func (r LoadResults) String() string {
+	parts := make([]string, len(r.Layers))
+	for i, layer := range r.Layers {
+		parts[i] = layer.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r LayerLoadResult) String() string {
+	switch {
+	case r.Created:
+		return fmt.Sprintf("created %s config at %s", r.DirType.Slug(), r.Filepath)
+	case r.Existed:
+		return fmt.Sprintf("loaded %s config from %s", r.DirType.Slug(), r.Filepath)
+	}
+	return fmt.Sprintf("no %s config found", r.DirType.Slug())
+}
@@ -0,0 +1,115 @@
+package cfgstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// LogFormat selects the slog.Handler CreateWriterLogger writes the log file
+// with.
+type LogFormat int
+
+const (
+	JSONLogFormat LogFormat = iota // slog.JSONHandler, one JSON object per line
+	TextLogFormat                  // slog.TextHandler, key=value pairs that are easier to read
+)
+
+func (f LogFormat) String() string {
+	switch f {
+	case JSONLogFormat:
+		return "JSON"
+	case TextLogFormat:
+		return "Text"
+	default:
+	}
+	return "Invalid"
+}
+
+func (f LogFormat) Slug() string {
+	switch f {
+	case JSONLogFormat:
+		return "json"
+	case TextLogFormat:
+		return "text"
+	default:
+	}
+	return "invalid"
+}
+
+// LogHandlerFunc creates a slog.Handler that writes records to w, honoring opts,
+// e.g. slog.NewJSONHandler or a handler from another logging package.
+type LogHandlerFunc func(w io.Writer, opts *slog.HandlerOptions) slog.Handler
+
+// newLogHandler returns a handler that writes records to w in format.
+func newLogHandler(w io.Writer, format LogFormat, opts *slog.HandlerOptions) (h slog.Handler) {
+	switch format {
+	case TextLogFormat:
+		h = slog.NewTextHandler(w, opts)
+	default:
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return h
+}
+
+var (
+	_ slog.Handler      = multiHandler(nil)
+	_ dt.FilepathGetter = multiHandler(nil)
+)
+
+// multiHandler passes each record to every one of its handlers that is enabled
+// for the record's level.
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+
+	for _, h := range m {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		errs = append(errs, h.Handle(ctx, r.Clone()))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// Filepath returns the file the first of the handlers that writes to a file
+// writes to.
+func (m multiHandler) Filepath() (fp dt.Filepath) {
+	for _, h := range m {
+		getter, ok := h.(dt.FilepathGetter)
+		if ok {
+			fp = getter.Filepath()
+			break
+		}
+	}
+	return fp
+}
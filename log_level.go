@@ -0,0 +1,95 @@
+package cfgstore
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// LogLevelEnvVarSuffix is appended to the upper-cased ConfigSlug to name the
+// environment variable ResolveLogLevel reads, e.g. MYAPP_LOG_LEVEL.
+const LogLevelEnvVarSuffix = "_LOG_LEVEL"
+
+type LogLevelArgs struct {
+	// ConfigSlug names the environment variable read, e.g. "myapp" reads
+	// MYAPP_LOG_LEVEL. Characters other than letters and digits become "_".
+	ConfigSlug dt.PathSegment
+
+	// EnvVar overrides the name derived from ConfigSlug.
+	EnvVar string
+
+	// ConfigLevel is the level from the app's loaded config, e.g. the value of
+	// a "log_level" property. Empty means the config does not set one.
+	ConfigLevel string
+
+	// Default is the level used when neither the environment variable nor
+	// ConfigLevel is set. Defaults to slog.LevelInfo.
+	Default slog.Level
+
+	// LookupEnv looks up an environment variable. Defaults to os.LookupEnv.
+	LookupEnv func(name string) (string, bool)
+}
+
+// ResolveLogLevel returns the level to pass as WriterLoggerArgs.Level so debug
+// logging can be enabled without code changes. The environment variable takes
+// precedence over ConfigLevel, which takes precedence over Default, e.g.
+//
+//	level, err := cfgstore.ResolveLogLevel(cfgstore.LogLevelArgs{
+//		ConfigSlug:  "myapp",
+//		ConfigLevel: cfg.LogLevel,
+//	})
+//
+// Levels are parsed by slog.Level.UnmarshalText, so "debug", "WARN" and
+// "info+2" are all valid.
+func ResolveLogLevel(args LogLevelArgs) (level slog.Level, err error) {
+	var value, source string
+	var ok bool
+
+	level = args.Default
+	lookupEnv := args.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	envVar := args.EnvVar
+	if envVar == "" {
+		envVar = LogLevelEnvVar(args.ConfigSlug)
+	}
+	value, ok = lookupEnv(envVar)
+	source = "env_var"
+	if !ok || value == "" {
+		value = args.ConfigLevel
+		source = "config"
+	}
+	if value == "" {
+		goto end
+	}
+	err = level.UnmarshalText([]byte(value))
+	if err != nil {
+		err = NewErr(ErrInvalidLogLevel,
+			"source", source,
+			"env_var", envVar,
+			"level", value,
+			err,
+		)
+		level = args.Default
+	}
+end:
+	return level, err
+}
+
+// LogLevelEnvVar returns the name of the environment variable ResolveLogLevel
+// reads for configSlug, e.g. MY_APP_LOG_LEVEL for "my-app".
+func LogLevelEnvVar(configSlug dt.PathSegment) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, string(configSlug))
+	return name + LogLevelEnvVarSuffix
+}
@@ -0,0 +1,406 @@
+package cfgstore
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json/v2"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// rotatedTimeFormat is the UTC timestamp appended to the base name of a rotated
+// log file. It sorts lexically in time order.
+const rotatedTimeFormat = "20060102T150405.000"
+
+// LogRotation configures when a RotatingFile rotates and which rotated files it
+// retains. The zero value never rotates.
+type LogRotation struct {
+	// MaxSize is the size in bytes the log file may grow to before it is
+	// rotated. Zero means no limit.
+	MaxSize int64
+
+	// MaxAge is how old the first record in the log file may get before it is
+	// rotated. Zero means no limit.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of rotated files to retain, newest first. Zero
+	// retains all of them.
+	MaxBackups int
+
+	// MaxBackupAge is how long rotated files are retained. Zero retains them
+	// regardless of age.
+	MaxBackupAge time.Duration
+
+	// Compress gzips rotated files, adding a .gz extension.
+	Compress bool
+}
+
+// IsZero returns true if r never rotates.
+func (r LogRotation) IsZero() bool {
+	return r.MaxSize == 0 && r.MaxAge == 0
+}
+
+var _ io.WriteCloser = (*RotatingFile)(nil)
+
+// RotatingFile is an append-only log file that is renamed to
+// <name>-<timestamp><ext>, e.g. app-20250102T150405.000.log, and replaced with
+// an empty file when it would exceed its LogRotation's MaxSize or its first
+// record is older than MaxAge. Each Write is assumed to be one complete record,
+// as written by a slog.Handler. It is safe for concurrent use.
+type RotatingFile struct {
+	filepath dt.Filepath
+	rotation LogRotation
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	started  time.Time
+}
+
+// OpenRotatingFile opens, or creates, the log file at fp for appending, rotating
+// it first if it is already due.
+func OpenRotatingFile(fp dt.Filepath, rotation LogRotation) (rf *RotatingFile, err error) {
+	rf = &RotatingFile{
+		filepath: fp,
+		rotation: rotation,
+	}
+	err = rf.open()
+	if err != nil {
+		goto end
+	}
+	if rf.due(0) {
+		err = rf.rotate()
+	}
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToRotateLog, "log_file", fp, err)
+		rf = nil
+	}
+	return rf, err
+}
+
+// Filepath returns the path of the current log file.
+func (rf *RotatingFile) Filepath() dt.Filepath {
+	return rf.filepath
+}
+
+// Write appends p to the log file, rotating it first if writing p would make it
+// due. If rotating fails p is still written, to the log file as it was, and the
+// failure is returned.
+func (rf *RotatingFile) Write(p []byte) (n int, err error) {
+	var rotateErr error
+
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	if rf.due(int64(len(p))) {
+		rotateErr = rf.rotate()
+		if rotateErr != nil {
+			rotateErr = NewErr(ErrFailedToRotateLog, "log_file", rf.filepath, rotateErr)
+		}
+	}
+	if rf.size == 0 {
+		rf.started = time.Now()
+		rf.saveStarted(p)
+	}
+	n, err = rf.file.Write(p)
+	rf.size += int64(n)
+	err = CombineErrs([]error{rotateErr, err})
+	return n, err
+}
+
+// Rotate rotates the log file now, unless it is empty.
+func (rf *RotatingFile) Rotate() (err error) {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	if rf.size == 0 {
+		goto end
+	}
+	err = rf.rotate()
+	if err != nil {
+		err = NewErr(ErrFailedToRotateLog, "log_file", rf.filepath, err)
+	}
+end:
+	return err
+}
+
+// Close closes the log file.
+func (rf *RotatingFile) Close() error {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	return rf.file.Close()
+}
+
+// Backups returns the paths of the retained rotated files, oldest first.
+func (rf *RotatingFile) Backups() (fps []dt.Filepath, err error) {
+	var entries []os.DirEntry
+
+	dir := filepath.Dir(string(rf.filepath))
+	prefix, ext := rf.splitName()
+	entries, err = os.ReadDir(dir)
+	if err != nil {
+		goto end
+	}
+	for _, entry := range entries {
+		_, ok := parseRotatedName(entry.Name(), prefix, ext)
+		if !ok {
+			continue
+		}
+		fps = append(fps, dt.Filepath(filepath.Join(dir, entry.Name())))
+	}
+	slices.Sort(fps)
+end:
+	return fps, err
+}
+
+func (rf *RotatingFile) open() (err error) {
+	var info fs.FileInfo
+
+	err = os.MkdirAll(filepath.Dir(string(rf.filepath)), 0755)
+	if err != nil {
+		goto end
+	}
+	rf.file, err = os.OpenFile(string(rf.filepath), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		goto end
+	}
+	info, err = rf.file.Stat()
+	if err != nil {
+		goto end
+	}
+	rf.size = info.Size()
+	rf.started = info.ModTime()
+	if rf.size > 0 {
+		rf.started = firstRecordTime(rf.filepath, rf.loadStarted(info.ModTime()))
+	}
+end:
+	return err
+}
+
+// startedFilepath returns the path of the hidden file beside the log file that
+// records when its first record was written, e.g. .app.log.started, for
+// formats firstRecordTime cannot parse, e.g. those of a LogHandlerFunc.
+func (rf *RotatingFile) startedFilepath() string {
+	dir, base := filepath.Split(string(rf.filepath))
+	return filepath.Join(dir, "."+base+".started")
+}
+
+// saveStarted records rf.started in the file at startedFilepath if the time of
+// record, the first in the log file, cannot be parsed from it, e.g. for the
+// formats of a LogHandlerFunc. For JSON and text records it is not needed.
+func (rf *RotatingFile) saveStarted(record []byte) {
+	_, ok := recordTime(record)
+	if ok {
+		return
+	}
+	data := rf.started.UTC().Format(time.RFC3339Nano) + "\n"
+	LogOnError(os.WriteFile(rf.startedFilepath(), []byte(data), 0644))
+}
+
+// loadStarted returns the time recorded by saveStarted, or fallback if there is
+// none or it is later than fallback, the log file's ModTime, as it must then
+// have been recorded for another file.
+func (rf *RotatingFile) loadStarted(fallback time.Time) time.Time {
+	data, err := os.ReadFile(rf.startedFilepath())
+	if err != nil {
+		return fallback
+	}
+	started, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil || started.After(fallback) {
+		return fallback
+	}
+	return started
+}
+
+// due returns true if the log file must be rotated before n more bytes are
+// written to it.
+func (rf *RotatingFile) due(n int64) bool {
+	r := rf.rotation
+	switch {
+	case rf.size == 0:
+		return false
+	case r.MaxSize > 0 && rf.size+n > r.MaxSize:
+		return true
+	case r.MaxAge > 0 && time.Since(rf.started) > r.MaxAge:
+		return true
+	}
+	return false
+}
+
+// rotate renames the log file and opens a new one. If the log file cannot be
+// closed or renamed it is reopened, so that later records are not lost. Failing
+// to compress the renamed file is reported after the new one is opened.
+func (rf *RotatingFile) rotate() (err error) {
+	var rotated string
+	var gzipErr error
+
+	err = rf.file.Close()
+	if err == nil {
+		rotated = rf.rotatedName(time.Now())
+		err = os.Rename(string(rf.filepath), rotated)
+	}
+	if err != nil {
+		err = CombineErrs([]error{err, rf.open()})
+		goto end
+	}
+	if rf.rotation.Compress {
+		// The renamed file is kept uncompressed
+		gzipErr = gzipFile(rotated)
+	}
+	err = rf.open()
+	if err != nil {
+		goto end
+	}
+	err = CombineErrs([]error{gzipErr, rf.prune()})
+end:
+	return err
+}
+
+// rotatedName returns the path to rename the log file to when rotating it at
+// now, moving now forward a millisecond at a time past any existing rotated file.
+func (rf *RotatingFile) rotatedName(now time.Time) (rotated string) {
+	dir := filepath.Dir(string(rf.filepath))
+	prefix, ext := rf.splitName()
+	for {
+		rotated = filepath.Join(dir, prefix+now.UTC().Format(rotatedTimeFormat)+ext)
+		_, err := os.Stat(rotated)
+		if errors.Is(err, fs.ErrNotExist) {
+			_, err = os.Stat(rotated + ".gz")
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		now = now.Add(time.Millisecond)
+	}
+	return rotated
+}
+
+// prune removes the rotated files not retained by MaxBackups and MaxBackupAge.
+func (rf *RotatingFile) prune() (err error) {
+	var fps []dt.Filepath
+	var errs []error
+	var prefix, ext string
+
+	r := rf.rotation
+	if r.MaxBackups == 0 && r.MaxBackupAge == 0 {
+		goto end
+	}
+	fps, err = rf.Backups()
+	if err != nil {
+		goto end
+	}
+	prefix, ext = rf.splitName()
+	for i, fp := range fps {
+		rotatedAt, _ := parseRotatedName(filepath.Base(string(fp)), prefix, ext)
+		switch {
+		case r.MaxBackups > 0 && i < len(fps)-r.MaxBackups:
+		case r.MaxBackupAge > 0 && time.Since(rotatedAt) > r.MaxBackupAge:
+		default:
+			continue
+		}
+		errs = append(errs, fp.Remove())
+	}
+	err = errors.Join(errs...)
+end:
+	return err
+}
+
+// splitName returns the prefix and extension of rotated file names, e.g.
+// "app-" and ".log" for app.log.
+func (rf *RotatingFile) splitName() (prefix, ext string) {
+	base := filepath.Base(string(rf.filepath))
+	ext = filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// parseRotatedName returns the time a file named name was rotated at, and false
+// if name is not the name of a file rotated from prefix and ext.
+func parseRotatedName(name, prefix, ext string) (rotatedAt time.Time, ok bool) {
+	var err error
+
+	name = strings.TrimSuffix(name, ".gz")
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		goto end
+	}
+	name = strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+	rotatedAt, err = time.Parse(rotatedTimeFormat, name)
+	ok = err == nil
+end:
+	return rotatedAt, ok
+}
+
+// firstRecordTime returns the time of the record on the first line of fp, see
+// recordTime, or fallback if it cannot be read.
+func firstRecordTime(fp dt.Filepath, fallback time.Time) time.Time {
+	f, err := os.Open(string(fp))
+	if err != nil {
+		return fallback
+	}
+	defer CloseOrLog(f)
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return fallback
+	}
+	t, ok := recordTime(line)
+	if !ok {
+		return fallback
+	}
+	return t
+}
+
+// recordTime returns the time of record, as written by slog.JSONHandler or
+// slog.TextHandler, and false if it has none that can be parsed.
+func recordTime(record []byte) (t time.Time, ok bool) {
+	var r struct {
+		Time time.Time `json:"time"`
+	}
+
+	err := json.Unmarshal(record, &r)
+	if err != nil {
+		r.Time, err = textRecordTime(string(record))
+	}
+	return r.Time, err == nil && !r.Time.IsZero()
+}
+
+// textRecordTime parses the time=<RFC 3339 time> field that begins a record
+// written by slog.TextHandler.
+func textRecordTime(line string) (time.Time, error) {
+	value := strings.TrimPrefix(line, "time=")
+	value, _, _ = strings.Cut(strings.TrimSpace(value), " ")
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// gzipFile replaces the file at fp with a gzipped copy named fp + ".gz".
+func gzipFile(fp string) (err error) {
+	var in, out *os.File
+	var zw *gzip.Writer
+
+	in, err = os.Open(fp)
+	if err != nil {
+		goto end
+	}
+	defer CloseOrLog(in)
+	out, err = os.OpenFile(fp+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		goto end
+	}
+	zw = gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if err == nil {
+		err = zw.Close()
+	}
+	err = CombineErrs([]error{err, out.Close()})
+	if err != nil {
+		goto end
+	}
+	err = os.Remove(fp)
+end:
+	return err
+}
@@ -2,22 +2,36 @@ package cfgstore
 
 import (
 	"log/slog"
+	"sync"
+	"sync/atomic"
 )
 
-var logger *slog.Logger
+var logger atomic.Pointer[slog.Logger]
 
+var warnDefaultLogger sync.Once
+
+// Logger returns the logger set with SetLogger, or slog.Default() if none was
+// set.
 func Logger() *slog.Logger {
-	EnsureLogger()
-	return logger
+	return EnsureLogger()
 }
 
+// SetLogger sets the logger cfgstore logs to. Passing nil reverts to
+// slog.Default().
 func SetLogger(l *slog.Logger) {
-	logger = l
+	logger.Store(l)
 }
 
+// EnsureLogger returns the logger set with SetLogger. If none was set it returns
+// slog.Default(), logging a one-time warning that SetLogger was not called.
 func EnsureLogger() *slog.Logger {
-	if logger == nil {
-		panic("Must call cfgstore.SetLogger() with a *slog.Logger before reaching this check.")
+	l := logger.Load()
+	if l != nil {
+		return l
 	}
-	return logger
+	l = slog.Default()
+	warnDefaultLogger.Do(func() {
+		l.Warn("cfgstore.SetLogger() was not called; logging to slog.Default()")
+	})
+	return l
 }
@@ -0,0 +1,89 @@
+package cfgstore
+
+import (
+	"sync"
+	"time"
+)
+
+// Metrics receives measurements of the config IO performed by this package,
+// e.g. to export them to a monitoring system; see the cfgprom module for a
+// Prometheus adapter. Methods are called on the goroutine that performed the
+// operation, so they should return quickly, and must be safe for concurrent
+// use.
+type Metrics interface {
+	// ObserveOperation is called once per load, save or creation of a store's
+	// file, whether or not it succeeded.
+	ObserveOperation(OperationMetric)
+
+	// ObserveCacheLookup is called by CachedConfig.Load with hit set if the
+	// cached value was returned without reading the file.
+	ObserveCacheLookup(hit bool)
+}
+
+// OperationMetric describes an operation reported to Metrics.ObserveOperation.
+type OperationMetric struct {
+	Kind     EventKind
+	DirType  DirType
+	Duration time.Duration
+
+	// Size is how many bytes were read or written, or 0 if the operation
+	// failed or streamed the file without measuring it.
+	Size int64
+
+	// Err is the error the operation failed with, if any. See ErrorKindOf.
+	Err error
+}
+
+var (
+	metricsMutex   sync.RWMutex
+	currentMetrics Metrics
+)
+
+// SetMetrics makes m receive the measurements of every store, replacing the
+// Metrics set before, if any. A nil m stops measuring. Call the returned func to
+// restore the previous Metrics, e.g. at the end of a test.
+func SetMetrics(m Metrics) (restore func()) {
+	metricsMutex.Lock()
+	prev := currentMetrics
+	currentMetrics = m
+	metricsMutex.Unlock()
+
+	return func() {
+		metricsMutex.Lock()
+		currentMetrics = prev
+		metricsMutex.Unlock()
+	}
+}
+
+func getMetrics() Metrics {
+	metricsMutex.RLock()
+	defer metricsMutex.RUnlock()
+	return currentMetrics
+}
+
+// recordOperation reports an operation of kind on cs, started at start, to the
+// Metrics and event listeners, if any.
+func (cs *configStore) recordOperation(kind EventKind, start time.Time, size int, err error) {
+	m := getMetrics()
+	if m != nil {
+		if err != nil {
+			size = 0
+		}
+		m.ObserveOperation(OperationMetric{
+			Kind:     kind,
+			DirType:  cs.dirType,
+			Duration: time.Since(start),
+			Size:     int64(size),
+			Err:      err,
+		})
+	}
+	cs.emitStoreEvent(kind, err)
+}
+
+// observeCacheLookup reports a CachedConfig lookup to the Metrics, if any.
+func observeCacheLookup(hit bool) {
+	m := getMetrics()
+	if m != nil {
+		m.ObserveCacheLookup(hit)
+	}
+}
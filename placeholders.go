@@ -0,0 +1,134 @@
+package cfgstore
+
+import (
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-dt/appinfo"
+)
+
+// The placeholders every store expands in its RelFilepath, in addition to
+// those in ConfigStoreArgs.Placeholders, which can override them.
+const (
+	GOOSPlaceholder   = "goos"   // runtime.GOOS, e.g. "darwin"
+	GOARCHPlaceholder = "goarch" // runtime.GOARCH, e.g. "arm64"
+)
+
+// The placeholders a store created with ConfigStoreArgs.AppInfo expands, if
+// the AppInfo has a value for them.
+const (
+	VersionPlaceholder = "version" // AppInfo.Version(), e.g. "v1.4.0"
+	AppPlaceholder     = "app"     // AppInfo.AppSlug(), e.g. "myapp"
+)
+
+// PlaceholderOptions is implemented by Options that supply the values of
+// placeholders, e.g. {"profile": "work"} for a --profile flag, to the stores
+// LoadConfigStores loads. They override the stores' own, and are expanded when
+// the stores' files are resolved.
+type PlaceholderOptions interface {
+	Options
+	Placeholders() map[string]string
+}
+
+// newPlaceholders returns the built-in placeholders, and those of info if it is
+// not nil, overridden by values.
+func newPlaceholders(info appinfo.AppInfo, values map[string]string) map[string]string {
+	placeholders := map[string]string{
+		GOOSPlaceholder:   runtime.GOOS,
+		GOARCHPlaceholder: runtime.GOARCH,
+	}
+	if info != nil {
+		if info.Version() != "" {
+			placeholders[VersionPlaceholder] = string(info.Version())
+		}
+		if info.AppSlug() != "" {
+			placeholders[AppPlaceholder] = string(info.AppSlug())
+		}
+	}
+	maps.Copy(placeholders, values)
+	return placeholders
+}
+
+// setRelFilepath sets the store's file to rf, expanding its placeholders.
+// Expanding them here rather than in GetFilepath keeps resolving the file free
+// of writes, so a store can be shared by goroutines.
+func (cs *configStore) setRelFilepath(rf dt.RelFilepath) {
+	cs.rawRelFilepath = rf
+	cs.expandRelFilepath()
+	cs.filepath.invalidate()
+}
+
+// setPlaceholders sets the values of placeholders over the store's own and
+// expands them in the store's file.
+func (cs *configStore) setPlaceholders(values map[string]string) {
+	placeholders := maps.Clone(cs.placeholders)
+	maps.Copy(placeholders, values)
+	cs.placeholders = placeholders
+	cs.expandRelFilepath()
+	cs.filepath.invalidate()
+}
+
+// expandRelFilepath sets the store's file to its RelFilepath with the
+// placeholders expanded. If they cannot be, it is kept as is and GetFilepath
+// reports why.
+func (cs *configStore) expandRelFilepath() {
+	cs.relFilepath, cs.relFilepathErr = expandPlaceholders(cs.rawRelFilepath, cs.placeholders)
+	if cs.relFilepathErr != nil {
+		cs.relFilepath = cs.rawRelFilepath
+	}
+}
+
+// expandPlaceholders replaces each {name} in rf with placeholders[name]. A name
+// without a value fails with ErrUnknownPlaceholder, and a value that is not a
+// single path segment with ErrInvalidPlaceholderValue, so that a placeholder
+// cannot lead outside the config dir. A "{" without a closing "}" is kept.
+func expandPlaceholders(rf dt.RelFilepath, placeholders map[string]string) (expanded dt.RelFilepath, err error) {
+	var sb strings.Builder
+
+	s := string(rf)
+	if !strings.Contains(s, "{") {
+		expanded = rf
+		goto end
+	}
+	for {
+		start := strings.IndexByte(s, '{')
+		if start < 0 {
+			break
+		}
+		length := strings.IndexByte(s[start:], '}')
+		if length < 0 {
+			break
+		}
+		name := s[start+1 : start+length]
+		value, ok := placeholders[name]
+		if !ok {
+			err = NewErr(ErrUnknownPlaceholder, "placeholder", name, "filepath", rf)
+			goto end
+		}
+		if !isLocalSegment(value) {
+			err = NewErr(ErrInvalidPlaceholderValue, "placeholder", name, "value", value, "filepath", rf)
+			goto end
+		}
+		sb.WriteString(s[:start])
+		sb.WriteString(value)
+		s = s[start+length+1:]
+	}
+	sb.WriteString(s)
+	expanded = dt.RelFilepath(sb.String())
+end:
+	return expanded, err
+}
+
+// isLocalSegment returns true if name is a single path segment on every
+// platform, so that the path it names is within its parent directory.
+func isLocalSegment(name string) bool {
+	return name != "" &&
+		name != "." &&
+		fs.ValidPath(name) &&
+		filepath.IsLocal(name) &&
+		!strings.ContainsAny(name, `/\:`)
+}
@@ -0,0 +1,176 @@
+package cfgstore
+
+import (
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+)
+
+// DefaultMaxPromptAttempts is how many times PromptConfig asks for a field
+// whose answers are invalid before failing.
+const DefaultMaxPromptAttempts = 3
+
+// PromptField describes a config value to ask the user for, e.g. in a CLI's
+// init command.
+type PromptField struct {
+	// Path is where the answer is stored in the config, as for GetValue, e.g.
+	// "name" or "server.port".
+	Path string
+
+	// Label is what to ask the user. Defaults to Path.
+	Label string
+
+	// Default is the answer used when the user enters nothing.
+	Default string
+
+	// Validate, if set, rejects an answer by returning an error, and the user
+	// is asked again.
+	Validate func(answer string) error
+}
+
+type PromptArgs struct {
+	Field PromptField
+
+	// Err is why the previous answer for Field was rejected, or nil on the
+	// first attempt.
+	Err error
+}
+
+// Prompter asks the user for args.Field, showing args.Err if it is set, and
+// returns their answer. An empty answer selects the field's Default.
+type Prompter func(args PromptArgs) (answer string, err error)
+
+type PromptOptions struct {
+	// MaxAttempts bounds how many times a field is asked for. Defaults to
+	// DefaultMaxPromptAttempts.
+	MaxAttempts int
+}
+
+// PromptConfig asks prompter for each of fields in turn and stores the answers
+// in rc, which must marshal as a JSON object. Answers for a field whose value
+// in rc is a number or boolean must parse as one. A field answered with an
+// empty Default is left unchanged. A nil prompter accepts every Default, e.g.
+// for a --yes flag.
+func PromptConfig(rc any, fields []PromptField, prompter Prompter, opts ...PromptOptions) (err error) {
+	var doc, node any
+	var segments []string
+	var data []byte
+
+	if len(opts) == 0 {
+		opts = []PromptOptions{{}}
+	}
+	if opts[0].MaxAttempts <= 0 {
+		opts[0].MaxAttempts = DefaultMaxPromptAttempts
+	}
+	doc, err = valueToNode(rc)
+	if err != nil {
+		goto end
+	}
+	for _, field := range fields {
+		if field.Label == "" {
+			field.Label = field.Path
+		}
+		segments, err = splitValuePath(field.Path)
+		if err != nil {
+			goto end
+		}
+		current, _ := lookupNode(doc, segments)
+		node, err = promptField(field, current, prompter, opts[0].MaxAttempts)
+		if err != nil {
+			err = WithErr(err, "path", field.Path)
+			goto end
+		}
+		if node == nil {
+			continue
+		}
+		doc, err = setNode(doc, segments, node)
+		if err != nil {
+			goto end
+		}
+	}
+	data, err = encodeDocument(doc)
+	if err != nil {
+		goto end
+	}
+	err = jsonv2.Unmarshal(data, rc)
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToPromptConfig, err)
+	}
+	return err
+}
+
+// promptField asks prompter for field until it gives a valid answer, returning
+// the answer as a node of the same kind as current, or nil if it is empty.
+func promptField(field PromptField, current any, prompter Prompter, maxAttempts int) (node any, err error) {
+	var answer string
+	var invalid error
+
+	for range maxAttempts {
+		answer = field.Default
+		if prompter != nil {
+			answer, err = prompter(PromptArgs{Field: field, Err: invalid})
+			if err != nil {
+				goto end
+			}
+		}
+		if answer == "" {
+			answer = field.Default
+		}
+		invalid = validateAnswer(field, answer)
+		if invalid == nil && answer != "" {
+			node, invalid = answerNode(answer, current)
+		}
+		if invalid == nil {
+			goto end
+		}
+		if prompter == nil {
+			// Asking again would only return the same Default
+			break
+		}
+	}
+	err = NewErr(ErrInvalidPromptAnswer, "answer", answer, invalid)
+end:
+	return node, err
+}
+
+func validateAnswer(field PromptField, answer string) (err error) {
+	if field.Validate != nil {
+		err = field.Validate(answer)
+	}
+	return err
+}
+
+// answerNode converts answer to a node, parsing it as JSON if current is a
+// number or boolean, and otherwise as a string.
+func answerNode(answer string, current any) (node any, err error) {
+	var value jsontext.Value
+	var want string
+	var ok bool
+
+	raw, _ := current.(jsontext.Value)
+	switch raw.Kind() {
+	case '0':
+		want = "number"
+	case 't', 'f':
+		want = "boolean"
+	default:
+		node, err = valueToNode(answer)
+		goto end
+	}
+	value = jsontext.Value(answer)
+	if value.IsValid() {
+		switch value.Kind() {
+		case '0':
+			ok = want == "number"
+		case 't', 'f':
+			ok = want == "boolean"
+		}
+	}
+	if !ok {
+		err = NewErr(ErrAnswerNotOfFieldType, "type", want)
+		goto end
+	}
+	node = value
+end:
+	return node, err
+}
@@ -0,0 +1,102 @@
+package cfgstore
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// Purger is implemented by a ConfigStore that can remove its whole config
+// directory, as Purge needs.
+type Purger interface {
+	Purge(confirmSlug dt.PathSegment) error
+}
+
+// Purge calls store.Purge if store is a Purger and otherwise fails with
+// ErrPurgeNotSupported.
+func Purge(store ConfigStore, confirmSlug dt.PathSegment) error {
+	if p, ok := store.(Purger); ok {
+		return p.Purge(confirmSlug)
+	}
+	return NewErr(ErrPurgeNotSupported, "config_slug", store.ConfigSlug())
+}
+
+// Purge removes the store's whole config directory, e.g. ~/.config/<slug> or
+// <project>/.<slug>, including every file in it and the directories of all of
+// its tenants, for implementing e.g. "myapp config reset --all". As a safety
+// rail confirmSlug must match the store's slug, and the directory must be the
+// one its DirsProvider gives for the slug: an absolute path named for the slug
+// directly within a parent that is not a root directory. Otherwise nothing is
+// removed and ErrPurgeNotConfirmed or ErrUnsafePurgePath is returned. Purging a
+// directory that does not exist succeeds.
+func (cs *configStore) Purge(confirmSlug dt.PathSegment) (err error) {
+	var dir, expected dt.DirPath
+
+	if confirmSlug != cs.configSlug {
+		err = NewErr(ErrPurgeNotConfirmed, "confirm_slug", confirmSlug, "config_slug", cs.configSlug)
+		goto end
+	}
+	if !isLocalSegment(string(cs.configSlug)) {
+		err = NewErr(ErrUnsafePurgePath, "config_slug", cs.configSlug)
+		goto end
+	}
+	dir, err = cs.tenantsDir()
+	if err != nil {
+		goto end
+	}
+	expected, err = ConfigDir(cs.dirType, cs.configSlug, cs.dirsProvider)
+	if err != nil {
+		goto end
+	}
+	err = checkPurgeDir(dir, expected, cs.configSlug)
+	if err != nil {
+		goto end
+	}
+	err = removeTree(cs.FileSystem(), dir)
+	if err == nil {
+		err = cs.FileSystem().Remove(dt.Filepath(dir))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		goto end
+	}
+	cs.Invalidate()
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToPurgeConfig, "config_dir", dir, err))
+	}
+	if hasEventListeners() && dir != "" {
+		emitEvent(Event{
+			Kind:     DeleteEventKind,
+			Store:    cs,
+			DirType:  cs.dirType,
+			Filepath: dt.Filepath(dir),
+			Err:      err,
+		})
+	}
+	return err
+}
+
+// checkPurgeDir returns ErrUnsafePurgePath unless dir is expected, an absolute
+// path named for slug whose parent is not a root directory.
+func checkPurgeDir(dir, expected dt.DirPath, slug dt.PathSegment) (err error) {
+	clean := dt.DirPath(filepath.Clean(string(dir)))
+	parent := clean.Dir()
+	base := strings.TrimPrefix(string(clean.Base()), ".")
+	switch {
+	case clean != dt.DirPath(filepath.Clean(string(expected))):
+	case !filepath.IsAbs(string(clean)):
+	case base != string(slug):
+	case parent == "" || parent.Dir() == parent:
+	default:
+		goto end
+	}
+	err = NewErr(ErrUnsafePurgePath, "config_dir", dir, "expected_dir", expected)
+end:
+	return err
+}
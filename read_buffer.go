@@ -0,0 +1,92 @@
+package cfgstore
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"sync"
+)
+
+// maxPooledReadBuffer is the capacity above which a read or write buffer is left
+// for the garbage collector rather than pooled, so one huge file does not pin memory.
+const maxPooledReadBuffer = 1 << 20
+
+// writeBufferPool holds the buffers SaveJSON marshals values into when no
+// post-save hook could keep a reference to the marshaled content.
+var writeBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// readBufferPool holds *[]byte buffers that LoadJSON reads files into and
+// releases once they have been unmarshaled.
+var readBufferPool = sync.Pool{
+	New: func() any {
+		return new([]byte)
+	},
+}
+
+// readPooledFile reads name from fSys into a buffer from readBufferPool, sized
+// from the file's Stat so that it is read without growing. Pass the result to
+// releaseReadBuffer once nothing refers to it.
+func readPooledFile(fSys fs.FS, name string) (data []byte, err error) {
+	var f fs.File
+	var info fs.FileInfo
+	var n int
+
+	f, err = fSys.Open(name)
+	if err != nil {
+		goto end
+	}
+	defer CloseOrLog(f)
+	info, err = f.Stat()
+	if err != nil {
+		goto end
+	}
+	// One more byte than the size so the final Read reports io.EOF without
+	// first growing the buffer
+	data = (*readBufferPool.Get().(*[]byte))[:0]
+	data = slices.Grow(data, int(info.Size())+1)
+	for {
+		n, err = f.Read(data[len(data):cap(data)])
+		data = data[:len(data)+n]
+		if errors.Is(err, io.EOF) {
+			err = nil
+			goto end
+		}
+		if err != nil {
+			goto end
+		}
+		if len(data) == cap(data) {
+			// The file grew after Stat
+			data = slices.Grow(data, cap(data))
+		}
+	}
+end:
+	if err != nil && data != nil {
+		releaseReadBuffer(data)
+		data = nil
+	}
+	return data, err
+}
+
+// releaseReadBuffer returns data, as read by readPooledFile, to readBufferPool.
+func releaseReadBuffer(data []byte) {
+	if cap(data) > maxPooledReadBuffer {
+		return
+	}
+	data = data[:0]
+	readBufferPool.Put(&data)
+}
+
+// releaseWriteBuffer returns buf, taken from writeBufferPool, to it.
+func releaseWriteBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledReadBuffer {
+		return
+	}
+	buf.Reset()
+	writeBufferPool.Put(buf)
+}
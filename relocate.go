@@ -0,0 +1,234 @@
+package cfgstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// RelocateResult is what Relocate did.
+type RelocateResult int
+
+const (
+	NothingToRelocate RelocateResult = iota // Neither the old nor the new file exists
+	Relocated                               // The old file was moved to the new location
+	AlreadyRelocated                        // Only the new file, or a remnant of the old one, exists
+)
+
+func (r RelocateResult) String() string {
+	switch r {
+	case NothingToRelocate:
+		return "Nothing to relocate"
+	case Relocated:
+		return "Relocated"
+	case AlreadyRelocated:
+		return "Already relocated"
+	default:
+	}
+	return "Invalid"
+}
+
+func (r RelocateResult) Slug() string {
+	switch r {
+	case NothingToRelocate:
+		return "nothing-to-relocate"
+	case Relocated:
+		return "relocated"
+	case AlreadyRelocated:
+		return "already-relocated"
+	default:
+	}
+	return "invalid"
+}
+
+// RelocateRemnant is what Relocate leaves at a config's old location.
+type RelocateRemnant int
+
+const (
+	NoRelocateRemnant      RelocateRemnant = iota // The old file is removed
+	StubRelocateRemnant                           // The old file is replaced by a stub
+	SymlinkRelocateRemnant                        // The old file is replaced by a symlink to the new one
+)
+
+func (r RelocateRemnant) String() string {
+	switch r {
+	case NoRelocateRemnant:
+		return "None"
+	case StubRelocateRemnant:
+		return "Stub"
+	case SymlinkRelocateRemnant:
+		return "Symlink"
+	default:
+	}
+	return "Invalid"
+}
+
+func (r RelocateRemnant) Slug() string {
+	switch r {
+	case NoRelocateRemnant:
+		return "none"
+	case StubRelocateRemnant:
+		return "stub"
+	case SymlinkRelocateRemnant:
+		return "symlink"
+	default:
+	}
+	return "invalid"
+}
+
+type RelocateOptions struct {
+	// Remnant is what to leave at the old location. Defaults to removing the old
+	// file.
+	Remnant RelocateRemnant
+
+	// Stub is the content of the file left by StubRelocateRemnant. Defaults to
+	// {"moved_to": "<new filepath>"}. Relocate recognizes an old file with this
+	// content as already relocated, so it must not change between runs.
+	Stub []byte
+
+	// Overwrite replaces the new file with the old one if both exist with
+	// different content, rather than failing with ErrRelocateConflict.
+	Overwrite bool
+}
+
+// Relocate moves the config file of oldStore, e.g. a legacy ~/.myapp.json, to
+// the location of newStore, typically on upgrade, and then removes the old file
+// or replaces it with a stub or a symlink per opts. The content is moved through
+// the stores' Load and Save so that their hooks apply.
+//
+// Relocate is idempotent, so it can be called on every start: if only the new
+// file exists, or the old location holds the stub or a symlink to the new file,
+// it does nothing and returns AlreadyRelocated. If both files exist with
+// different content it fails with ErrRelocateConflict, whose ErrorKind is
+// ConflictErrorKind, unless opts.Overwrite is set. A successful move emits a
+// MigrateEventKind Event for newStore.
+func Relocate(oldStore, newStore ConfigStore, opts ...RelocateOptions) (result RelocateResult, err error) {
+	var oldFp, newFp, target dt.Filepath
+	var raw, data, newData []byte
+	var unlock func()
+	var linkErr error
+
+	if len(opts) == 0 {
+		opts = []RelocateOptions{{}}
+	}
+	oldFS := oldStore.FileSystem()
+	oldFp, err = oldStore.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	newFp, err = newStore.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	if _, ok := oldFS.(FileSymlinker); !ok && opts[0].Remnant == SymlinkRelocateRemnant {
+		err = NewErr(ErrSymlinksNotSupported, "link", oldFp)
+		goto end
+	}
+	if opts[0].Remnant == StubRelocateRemnant && opts[0].Stub == nil {
+		opts[0].Stub, err = marshalIndented(map[string]string{"moved_to": string(newFp)})
+		if err != nil {
+			goto end
+		}
+	}
+
+	// Hold the new file's lock so concurrent starts do not both move the file
+	err = newStore.FileSystem().MkdirAll(newFp.Dir())
+	if err != nil {
+		goto end
+	}
+	unlock, err = newStore.FileSystem().Lock(newFp, DefaultLockTimeout)
+	if err != nil {
+		goto end
+	}
+	defer unlock()
+
+	target, linkErr = Readlink(oldFS, oldFp)
+	if linkErr == nil && target == newFp {
+		result = AlreadyRelocated
+		goto end
+	}
+	raw, err = oldFS.ReadFile(oldFp)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+		if newStore.Exists() {
+			result = AlreadyRelocated
+		}
+		goto end
+	}
+	if err != nil {
+		goto end
+	}
+	if opts[0].Stub != nil && bytes.Equal(raw, opts[0].Stub) {
+		result = AlreadyRelocated
+		goto end
+	}
+	// The old file is moved as it is on disk, see LoadHookArgs.ForUpdate
+	data, err = LoadContext(forUpdate(context.Background()), oldStore)
+	if err != nil {
+		goto end
+	}
+	if newStore.Exists() {
+		newData, err = newStore.Load()
+		if err != nil {
+			goto end
+		}
+	}
+	switch {
+	case newData == nil:
+		err = newStore.Save(data)
+	case bytes.Equal(newData, data):
+		// A previous run saved the new file but did not clean up the old one
+	case opts[0].Overwrite:
+		err = newStore.Save(data)
+	default:
+		err = NewErr(ErrRelocateConflict)
+	}
+	if err != nil {
+		goto end
+	}
+	err = relocateRemnant(oldFS, oldFp, newFp, opts[0])
+	if err != nil {
+		goto end
+	}
+	result = Relocated
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToRelocateConfig,
+			"old_filepath", oldFp,
+			"new_filepath", newFp,
+			err,
+		))
+	}
+	if (result == Relocated || err != nil) && hasEventListeners() {
+		emitEvent(Event{
+			Kind:     MigrateEventKind,
+			Store:    newStore,
+			DirType:  newStore.DirType(),
+			Filepath: newFp,
+			Err:      err,
+		})
+	}
+	return result, err
+}
+
+// relocateRemnant removes the old file at oldFp or replaces it with the remnant
+// in opts.
+func relocateRemnant(fSys FileSystem, oldFp, newFp dt.Filepath, opts RelocateOptions) (err error) {
+	switch opts.Remnant {
+	case StubRelocateRemnant:
+		err = fSys.WriteFile(oldFp, opts.Stub)
+	case SymlinkRelocateRemnant:
+		err = fSys.Remove(oldFp)
+		if err != nil {
+			goto end
+		}
+		err = Symlink(fSys, newFp, oldFp)
+	default:
+		err = fSys.Remove(oldFp)
+	}
+end:
+	return err
+}
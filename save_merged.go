@@ -0,0 +1,147 @@
+package cfgstore
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+	"errors"
+)
+
+type SaveMergedConfigOptions struct {
+	// DirType is the layer the config is saved to. Defaults to the last of
+	// stores.DirTypes, the one with the highest precedence.
+	DirType DirType
+
+	// OnlyDiffs saves only the values that differ from those the layers before
+	// DirType would merge to, so that the saved layer overrides what it changes
+	// rather than pinning every value it inherits. Objects are compared member by
+	// member; arrays and scalars are compared whole.
+	OnlyDiffs bool
+}
+
+// SaveMergedConfig saves rc, typically the merged config returned by
+// LoadConfigStores or LoadDefaultConfig or a modified copy of it, to the store
+// of one of stores' layers, replacing that store's file:
+//
+//	cfg, err := cfgstore.LoadConfigStores[MyConfig, *MyConfig](stores, args)
+//	cfg.Theme = "dark"
+//	err = cfgstore.SaveMergedConfig(stores, cfg, cfgstore.SaveMergedConfigOptions{
+//		DirType:   cfgstore.ProjectConfigDirType,
+//		OnlyDiffs: true,
+//	})
+//
+// Values that a lower layer sets but rc does not cannot be expressed by a higher
+// layer, so OnlyDiffs ignores them.
+func SaveMergedConfig(stores *ConfigStores, rc RootConfig, opts ...SaveMergedConfigOptions) (err error) {
+	var after, lower, diff any
+	var data []byte
+	var store ConfigStore
+
+	if len(opts) == 0 {
+		opts = []SaveMergedConfigOptions{{}}
+	}
+	dirType := opts[0].DirType
+	if dirType == UnspecifiedConfigDirType && len(stores.DirTypes) > 0 {
+		dirType = stores.DirTypes[len(stores.DirTypes)-1]
+	}
+	store = stores.StoreMap[dirType]
+	if store == nil {
+		err = NewErr(ErrDirTypeNotInStores)
+		goto end
+	}
+	if !opts[0].OnlyDiffs {
+		err = store.SaveJSON(rc)
+		goto end
+	}
+	after, err = valueToNode(rc)
+	if err != nil {
+		goto end
+	}
+	lower, err = stores.mergeLowerDocuments(dirType)
+	if err != nil {
+		goto end
+	}
+	diff, _ = diffNode(after, lower)
+	data, err = encodeDocument(diff)
+	if err != nil {
+		goto end
+	}
+	err = store.Save(data)
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToSaveMergedConfig, "dir_type", dirType.Slug(), err)
+	}
+	return err
+}
+
+// mergeLowerDocuments merges the files of the layers before dirType as JSON
+// documents, in DirTypes order. Files that do not exist are skipped.
+func (stores *ConfigStores) mergeLowerDocuments(dirType DirType) (merged *jsonObject, err error) {
+	var data []byte
+	var doc any
+
+	merged = &jsonObject{}
+	for _, typ := range stores.DirTypes {
+		if typ == dirType {
+			break
+		}
+		data, err = stores.StoreMap[typ].Load()
+		if errors.Is(err, ErrFileDoesNotExist) {
+			err = nil
+			continue
+		}
+		if err != nil {
+			goto end
+		}
+		doc, err = parseDocument(data)
+		if err != nil {
+			goto end
+		}
+		obj, ok := doc.(*jsonObject)
+		if ok {
+			mergeObject(merged, obj)
+		}
+	}
+end:
+	return merged, err
+}
+
+// diffNode returns the parts of after that differ from lower, and false if there
+// are none.
+func diffNode(after, lower any) (diff any, differs bool) {
+	afterObj, ok1 := after.(*jsonObject)
+	lowerObj, ok2 := lower.(*jsonObject)
+	if !ok1 || !ok2 {
+		return after, !equalNodes(after, lower)
+	}
+	obj := &jsonObject{}
+	for _, m := range afterObj.members {
+		i := lowerObj.index(m.name)
+		if i < 0 {
+			obj.members = append(obj.members, m)
+			continue
+		}
+		d, ok := diffNode(m.value, lowerObj.members[i].value)
+		if ok {
+			obj.members = append(obj.members, jsonMember{name: m.name, value: d})
+		}
+	}
+	return obj, len(obj.members) > 0
+}
+
+// equalNodes returns true if a and b encode to the same canonical JSON, so that
+// e.g. 1.0 and 1 are equal.
+func equalNodes(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	aData, aErr := encodeDocument(a)
+	bData, bErr := encodeDocument(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	aValue, bValue := jsontext.Value(aData), jsontext.Value(bData)
+	if aValue.Canonicalize() != nil || bValue.Canonicalize() != nil {
+		return false
+	}
+	return bytes.Equal(aValue, bValue)
+}
@@ -0,0 +1,70 @@
+package cfgstore
+
+import (
+	"bytes"
+	"context"
+	jsonv2 "encoding/json/v2"
+	"text/template"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ScaffoldData is the data a ConfigStoreArgs.Scaffold template is executed with
+// when the store creates a config that does not exist yet, e.g.
+//
+//	{
+//	  "_comment": "Settings for {{ .ConfigSlug }}, see https://example.com/docs",
+//	  "name": {{ json .Config.Name }},
+//	  "_example_proxy": "http://proxy.example.com:8080",
+//	  "proxy": ""
+//	}
+//
+// JSON has no comments, so a JSON scaffold documents itself with members the
+// config ignores, as above; a store with a Codec, e.g. for YAML, can use the
+// format's comments.
+type ScaffoldData struct {
+	// Config is the normalized RootConfig that would otherwise be saved.
+	Config     any
+	DirType    DirType
+	ConfigSlug dt.PathSegment
+	Filepath   dt.Filepath
+}
+
+// ScaffoldFuncs are functions for scaffold templates, to be added with
+// template.Funcs. json encodes its argument as JSON, which quotes and escapes
+// strings and paths.
+var ScaffoldFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := jsonv2.Marshal(v)
+		return string(b), err
+	},
+}
+
+// saveScaffold renders the store's Scaffold template for rc and, if the result
+// decodes into rc, saves it. rc is left with the values of the saved file.
+func (cs *configStore) saveScaffold(ctx context.Context, rc RootConfig, fp dt.Filepath) (err error) {
+	var buf bytes.Buffer
+
+	err = cs.scaffold.Execute(&buf, ScaffoldData{
+		Config:     rc,
+		DirType:    cs.dirType,
+		ConfigSlug: cs.configSlug,
+		Filepath:   fp,
+	})
+	if err != nil {
+		err = NewErr(ErrFailedToRenderScaffold, "template", cs.scaffold.Name(), err)
+		goto end
+	}
+	if cs.codec != nil {
+		err = cs.codec.Unmarshal(buf.Bytes(), rc)
+	} else {
+		err = jsonv2.Unmarshal(buf.Bytes(), rc)
+	}
+	if err != nil {
+		err = NewErr(ErrFailedToRenderScaffold, "template", cs.scaffold.Name(), ErrFailedToUnmarshalConfigFile, err)
+		goto end
+	}
+	err = cs.SaveContext(ctx, buf.Bytes())
+end:
+	return err
+}
@@ -0,0 +1,138 @@
+package cfgstore
+
+import (
+	"io/fs"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// statCache remembers whether a store's file exists. See
+// ConfigStoreArgs.CacheStat.
+type statCache struct {
+	state atomic.Int32
+}
+
+const (
+	unknownStat int32 = iota
+	existsStat
+	missingStat
+)
+
+// newStatCache returns a statCache if enabled, otherwise nil.
+func newStatCache(enabled bool) *statCache {
+	if !enabled {
+		return nil
+	}
+	return &statCache{}
+}
+
+// get returns whether the file exists and true if that is cached.
+func (sc *statCache) get() (exists, ok bool) {
+	if sc == nil {
+		return false, false
+	}
+	state := sc.state.Load()
+	return state == existsStat, state != unknownStat
+}
+
+func (sc *statCache) set(exists bool) {
+	if sc == nil {
+		return
+	}
+	state := missingStat
+	if exists {
+		state = existsStat
+	}
+	sc.state.Store(state)
+}
+
+func (sc *statCache) invalidate() {
+	if sc == nil {
+		return
+	}
+	sc.state.Store(unknownStat)
+}
+
+// filepathCache memoizes a store's resolved filepath until configDir or
+// relFilepath changes. Stores are shared by goroutines, so it is resolved and
+// read under mu.
+type filepathCache struct {
+	mu sync.Mutex
+	fp dt.Filepath
+}
+
+// resolve returns the memoized filepath, calling fn to resolve it if there is
+// none. A failure is not memoized.
+func (fc *filepathCache) resolve(fn func() (dt.Filepath, error)) (fp dt.Filepath, err error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.fp != "" {
+		fp = fc.fp
+		goto end
+	}
+	fp, err = fn()
+	if err != nil {
+		goto end
+	}
+	fc.fp = fp
+end:
+	return fp, err
+}
+
+func (fc *filepathCache) invalidate() {
+	fc.mu.Lock()
+	fc.fp = ""
+	fc.mu.Unlock()
+}
+
+// configDirCache memoizes a store's config dir and the fs.FS over it, which are
+// resolved on first use. Stores are shared by goroutines, so both are resolved
+// and read under mu.
+type configDirCache struct {
+	mu   sync.Mutex
+	path dt.DirPath
+	fs   fs.FS
+}
+
+// set replaces the config dir and the fs.FS over it.
+func (dc *configDirCache) set(path dt.DirPath, fsys fs.FS) {
+	dc.mu.Lock()
+	dc.path = path
+	dc.fs = fsys
+	dc.mu.Unlock()
+}
+
+// clone returns a copy of dc for a copy of its store.
+func (dc *configDirCache) clone() *configDirCache {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return &configDirCache{
+		path: dc.path,
+		fs:   dc.fs,
+	}
+}
+
+// Invalidator is implemented by a ConfigStore that caches what it knows about
+// its file. See Invalidate.
+type Invalidator interface {
+	Invalidate()
+}
+
+// Invalidate calls store.Invalidate if store is an Invalidator. Call it after
+// the store's file may have been created or removed other than through it.
+func Invalidate(store ConfigStore) {
+	if inv, ok := store.(Invalidator); ok {
+		inv.Invalidate()
+	}
+}
+
+// Invalidate discards what the store has cached about its file: its resolved
+// filepath and, if ConfigStoreArgs.CacheStat was set, whether it exists. Call it
+// after the file may have been created or removed other than through the store.
+// A config dir set with SetConfigDir is kept.
+func (cs *configStore) Invalidate() {
+	cs.filepath.invalidate()
+	cs.stat.invalidate()
+}
@@ -0,0 +1,77 @@
+package cfgstore
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// StatePathSegment is the subdirectory of the user config dir on macOS, and of
+// the user cache dir on Windows, that UserStateDir returns. Those platforms have
+// no dir for state of their own and, without it, state would share the dirs
+// that AppConfigDir and GetSharedCacheDir return, so Purge, ExportArchive,
+// PruneCache and RemoveStaleCacheVersions would take the logs and audit log
+// along.
+const StatePathSegment dt.PathSegment = "State"
+
+// UserStateDir returns the directory for user-specific state that should
+// persist between runs but is not configuration, such as logs and history:
+//   - $XDG_STATE_HOME, if set to an absolute path, on any OS
+//   - macOS: ~/Library/Application Support/State
+//   - Windows: %LOCALAPPDATA%\State
+//   - Linux and others: ~/.local/state
+func UserStateDir() (dir dt.DirPath, err error) {
+	var s string
+
+	s = os.Getenv("XDG_STATE_HOME")
+	if filepath.IsAbs(s) {
+		dir = dt.DirPath(s)
+		goto end
+	}
+	switch runtime.GOOS {
+	case "darwin", "ios":
+		dir, err = dt.UserConfigDir()
+		if err != nil {
+			goto end
+		}
+		dir = dt.DirPathJoin(dir, StatePathSegment)
+	case "windows":
+		dir, err = dt.UserCacheDir()
+		if err != nil {
+			goto end
+		}
+		dir = dt.DirPathJoin(dir, StatePathSegment)
+	default:
+		dir, err = dt.UserHomeDir()
+		if err != nil {
+			goto end
+		}
+		dir = dt.DirPathJoin3(dir, ".local", "state")
+	}
+end:
+	return dir, err
+}
+
+// StateDir returns the state directory for configSlug, <UserStateDir>/<slug>,
+// e.g. ~/.local/state/myapp on Linux.
+func StateDir(configSlug dt.PathSegment, dps ...*DirsProvider) (dir dt.DirPath, err error) {
+	var dp *DirsProvider
+
+	stateDirFunc := DirFunc(UserStateDir)
+	if dps != nil {
+		dp = dps[0]
+	}
+	if dp != nil && dp.UserStateDirFunc != nil {
+		stateDirFunc = dp.UserStateDirFunc
+	}
+	dir, err = stateDirFunc()
+	if err != nil {
+		err = NewErr(ErrFailedGettingUserStateDir, err)
+		goto end
+	}
+	dir = dt.DirPathJoin(dir, configSlug)
+end:
+	return dir, err
+}
@@ -0,0 +1,217 @@
+package cfgstore
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// CurrentTenantFile is the file, in the directory above the tenants' config
+// dirs, that SwitchTenant records the current tenant in.
+const CurrentTenantFile dt.RelFilepath = ".current-tenant"
+
+// TenantConfigStore is implemented by a ConfigStore whose config dir can be
+// namespaced by tenant, e.g. ~/.config/<slug>/<tenant>/config.json, for tools
+// that manage several accounts or organizations per user:
+//
+//	ts := store.(cfgstore.TenantConfigStore)
+//	tenant, err := ts.CurrentTenant()
+//	store = ts.WithTenant(tenant)
+type TenantConfigStore interface {
+	Tenant() dt.PathSegment
+	WithTenant(dt.PathSegment) ConfigStore
+	Tenants() ([]dt.PathSegment, error)
+	CurrentTenant() (dt.PathSegment, error)
+	SwitchTenant(dt.PathSegment) error
+}
+
+// Tenants calls store.Tenants if store is a TenantConfigStore and otherwise
+// fails with ErrTenantsNotSupported.
+func Tenants(store ConfigStore) ([]dt.PathSegment, error) {
+	if ts, ok := store.(TenantConfigStore); ok {
+		return ts.Tenants()
+	}
+	return nil, NewErr(ErrTenantsNotSupported, "config_slug", store.ConfigSlug())
+}
+
+// CurrentTenant calls store.CurrentTenant if store is a TenantConfigStore and
+// otherwise fails with ErrTenantsNotSupported.
+func CurrentTenant(store ConfigStore) (dt.PathSegment, error) {
+	if ts, ok := store.(TenantConfigStore); ok {
+		return ts.CurrentTenant()
+	}
+	return "", NewErr(ErrTenantsNotSupported, "config_slug", store.ConfigSlug())
+}
+
+// SwitchTenant calls store.SwitchTenant if store is a TenantConfigStore and
+// otherwise fails with ErrTenantsNotSupported.
+func SwitchTenant(store ConfigStore, tenant dt.PathSegment) error {
+	if ts, ok := store.(TenantConfigStore); ok {
+		return ts.SwitchTenant(tenant)
+	}
+	return NewErr(ErrTenantsNotSupported, "config_slug", store.ConfigSlug(), "tenant", tenant)
+}
+
+// Tenant returns the namespace the store's config dir is in, or "" for none.
+func (cs *configStore) Tenant() dt.PathSegment {
+	return cs.tenant
+}
+
+// WithTenant returns a copy of the store for tenant's namespace, e.g.
+// ~/.config/<slug>/<tenant>/config.json, for tools that manage several accounts
+// or organizations per user. An empty tenant returns the store without a
+// namespace. Hooks are copied.
+func (cs *configStore) WithTenant(tenant dt.PathSegment) ConfigStore {
+	store := *cs
+	store.tenant = tenant
+	store.dir = &configDirCache{}
+	// An invalid tenant is left for ConfigDir to report
+	base, err := cs.tenantsDir()
+	if err == nil && (tenant == "" || validateTenant(tenant) == nil) {
+		store.dir.path = joinTenant(base, tenant)
+	}
+	store.filepath = &filepathCache{}
+	store.stat = newStatCache(cs.stat != nil)
+	return &store
+}
+
+// Tenants returns the tenants whose config dir, a directory beside the store's,
+// contains the store's file, sorted by name. Tenants that have not saved the
+// file yet are not listed.
+func (cs *configStore) Tenants() (tenants []dt.PathSegment, err error) {
+	var base dt.DirPath
+	var entries []fs.DirEntry
+
+	base, err = cs.tenantsDir()
+	if err != nil {
+		goto end
+	}
+	entries, err = fs.ReadDir(cs.FileSystem().DirFS(base), ".")
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+		goto end
+	}
+	if err != nil {
+		goto end
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		fp := dt.FilepathJoin(dt.DirPathJoin(base, entry.Name()), cs.relFilepath)
+		_, statErr := cs.FileSystem().Stat(fp)
+		if statErr == nil {
+			tenants = append(tenants, dt.PathSegment(entry.Name()))
+		}
+	}
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToListTenants, err)
+	}
+	return tenants, err
+}
+
+// CurrentTenant returns the tenant last passed to SwitchTenant for stores with
+// the same slug and DirType, or "" if there is none, so an app can start in it.
+// See TenantConfigStore.
+func (cs *configStore) CurrentTenant() (tenant dt.PathSegment, err error) {
+	var base dt.DirPath
+	var data []byte
+
+	base, err = cs.tenantsDir()
+	if err != nil {
+		goto end
+	}
+	data, err = cs.FileSystem().ReadFile(dt.FilepathJoin(base, CurrentTenantFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+		goto end
+	}
+	if err != nil {
+		goto end
+	}
+	tenant = dt.PathSegment(bytes.TrimSpace(data))
+	if tenant != "" {
+		err = validateTenant(tenant)
+	}
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToGetCurrentTenant, err))
+	}
+	return tenant, err
+}
+
+// SwitchTenant moves the store to tenant's namespace, as WithTenant does, and
+// records tenant as the one CurrentTenant returns. An empty tenant switches to
+// the store without a namespace.
+func (cs *configStore) SwitchTenant(tenant dt.PathSegment) (err error) {
+	var base dt.DirPath
+
+	if tenant != "" {
+		err = validateTenant(tenant)
+		if err != nil {
+			goto end
+		}
+	}
+	base, err = cs.tenantsDir()
+	if err != nil {
+		goto end
+	}
+	err = cs.FileSystem().MkdirAll(base)
+	if err != nil {
+		goto end
+	}
+	err = cs.FileSystem().WriteFile(dt.FilepathJoin(base, CurrentTenantFile), []byte(tenant+"\n"))
+	if err != nil {
+		goto end
+	}
+	cs.tenant = tenant
+	cs.SetConfigDir(joinTenant(base, tenant))
+end:
+	if err != nil {
+		err = withErrorKind(NewErr(ErrFailedToSwitchTenant, "tenant", tenant, err))
+	}
+	return err
+}
+
+// tenantsDir returns the directory the tenants' config dirs are in, which is
+// the store's config dir if it has no tenant.
+func (cs *configStore) tenantsDir() (dir dt.DirPath, err error) {
+	dir, err = cs.ConfigDir()
+	if err != nil {
+		goto end
+	}
+	if cs.tenant != "" {
+		dir = dir.Dir()
+	}
+end:
+	return dir, err
+}
+
+func joinTenant(dir dt.DirPath, tenant dt.PathSegment) dt.DirPath {
+	if tenant == "" {
+		return dir
+	}
+	return dt.DirPathJoin(dir, tenant)
+}
+
+// validateTenant returns ErrInvalidTenant unless tenant is a single path
+// segment that names a directory on every platform. Names beginning with a dot
+// are reserved for files such as CurrentTenantFile.
+func validateTenant(tenant dt.PathSegment) (err error) {
+	name := string(tenant)
+	valid := fs.ValidPath(name) &&
+		path.Base(name) == name &&
+		filepath.IsLocal(name) &&
+		!strings.ContainsAny(name, `/\:`) &&
+		!strings.HasPrefix(name, ".")
+	if !valid {
+		err = NewErr(ErrInvalidTenant, "tenant", tenant)
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-dt/appinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAppInfo layers the app's config from only the CLI config dir.
+type testAppInfo struct {
+	appinfo.AppInfo
+}
+
+func (testAppInfo) DirTypes() []cfgstore.DirType {
+	return []cfgstore.DirType{cfgstore.CLIConfigDirType}
+}
+
+func TestNewConfigStoresFromAppInfo(t *testing.T) {
+	t.Parallel()
+	info := appinfo.New(appinfo.Args{
+		Name:       "Acme",
+		ConfigSlug: TestConfigSlug,
+		ConfigFile: "settings.json",
+	})
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+
+	t.Run("default dir types", func(t *testing.T) {
+		t.Parallel()
+		stores := cfgstore.NewConfigStoresFromAppInfo(info, cfgstore.WithDirsProvider(dp))
+		assert.Equal(t, []cfgstore.DirType{cfgstore.CLIConfigDirType, cfgstore.ProjectConfigDirType}, stores.DirTypes)
+		for _, store := range stores.StoreMap {
+			assert.Equal(t, info.ConfigSlug(), store.ConfigSlug())
+			assert.Equal(t, info.ConfigFile(), store.GetRelFilepath())
+		}
+	})
+
+	t.Run("app dir types", func(t *testing.T) {
+		t.Parallel()
+		stores := cfgstore.NewConfigStoresFromAppInfo(testAppInfo{AppInfo: info}, cfgstore.WithDirsProvider(dp))
+		assert.Equal(t, []cfgstore.DirType{cfgstore.CLIConfigDirType}, stores.DirTypes)
+
+		require.NoError(t, stores.CLIConfigStore().Save([]byte(`{"name":"acme"}`)))
+		rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, cfgstore.RootConfigArgs{
+			DirTypes: stores.DirTypes,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "acme", rc.Name)
+	})
+
+	t.Run("load with store options", func(t *testing.T) {
+		t.Parallel()
+		info := appinfo.New(appinfo.Args{
+			Name:       "Acme",
+			Version:    "v1.4.0",
+			ConfigSlug: TestConfigSlug,
+			ConfigFile: "{version}/settings.b64",
+		})
+		dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+		storeOpts := []cfgstore.ConfigStoreOption{cfgstore.WithDirsProvider(dp), cfgstore.WithCodec(base64Codec{})}
+		stores := cfgstore.NewConfigStoresFromAppInfo(testAppInfo{AppInfo: info}, storeOpts...)
+		require.NoError(t, stores.CLIConfigStore().SaveJSON(&testRootConfig{Name: "acme"}))
+		assert.Equal(t, dt.RelFilepath("v1.4.0/settings.b64"), stores.CLIConfigStore().GetRelFilepath())
+
+		rc, err := cfgstore.LoadConfigForApp[testRootConfig](testAppInfo{AppInfo: info}, nil, storeOpts...)
+		require.NoError(t, err)
+		assert.Equal(t, "acme", rc.Name)
+	})
+}
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newArchive returns a tar.gz archive of hdrs, with "{}" as the content of each
+// regular file.
+func newArchive(t *testing.T, hdrs ...*tar.Header) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, hdr := range hdrs {
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = 2
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		if hdr.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte("{}"))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return &buf
+}
+
+func TestArchive(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		src := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, src.Save([]byte(`{"name":"src"}`)))
+		require.NoError(t, cfgstore.SubStore(src, "profiles/work.json").Save([]byte(`{"name":"work"}`)))
+
+		var buf bytes.Buffer
+		require.NoError(t, cfgstore.ExportArchive(src, &buf))
+
+		dst := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		files, err := cfgstore.ImportArchive(dst, &buf)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []dt.RelFilepath{"config.json", "profiles/work.json"}, files)
+		data, err := cfgstore.SubStore(dst, "profiles/work.json").Load()
+		require.NoError(t, err)
+		assert.Equal(t, `{"name":"work"}`, string(data))
+	})
+
+	t.Run("redaction", func(t *testing.T) {
+		t.Parallel()
+		src := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, src.Save([]byte(`{"name":"src","auth":{"Token":"s3cret","user":"coyote"}}`)))
+
+		var buf bytes.Buffer
+		require.NoError(t, cfgstore.ExportArchive(src, &buf, cfgstore.ExportArchiveOptions{
+			RedactKeys: []string{"token"},
+		}))
+		dst := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		_, err := cfgstore.ImportArchive(dst, &buf)
+		require.NoError(t, err)
+		data, err := dst.Load()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"src","auth":{"Token":"REDACTED","user":"coyote"}}`, string(data))
+	})
+
+	t.Run("existing files", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, store.Save([]byte(`{"name":"old"}`)))
+		var buf bytes.Buffer
+		require.NoError(t, cfgstore.ExportArchive(store, &buf))
+		archive := buf.Bytes()
+
+		_, err := cfgstore.ImportArchive(store, bytes.NewReader(archive))
+		assert.ErrorIs(t, err, cfgstore.ErrConfigAlreadyExists)
+		assert.Equal(t, cfgstore.ConflictErrorKind, cfgstore.ErrorKindOf(err))
+
+		_, err = cfgstore.ImportArchive(store, bytes.NewReader(archive), cfgstore.ImportArchiveOptions{
+			Overwrite: true,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("unsafe entries", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			name string
+			hdr  *tar.Header
+		}{
+			{name: "parent", hdr: &tar.Header{Typeflag: tar.TypeReg, Name: "../evil.json"}},
+			{name: "absolute", hdr: &tar.Header{Typeflag: tar.TypeReg, Name: "/etc/evil.json"}},
+			{name: "backslash", hdr: &tar.Header{Typeflag: tar.TypeReg, Name: `..\evil.json`}},
+			{name: "symlink", hdr: &tar.Header{Typeflag: tar.TypeSymlink, Name: "link.json", Linkname: "/etc/passwd"}},
+			{name: "lock file", hdr: &tar.Header{Typeflag: tar.TypeReg, Name: "config.json.lock", Mode: 0644}},
+			{name: "temp file", hdr: &tar.Header{Typeflag: tar.TypeReg, Name: "profiles/.work.json.tmp", Mode: 0644}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+				archive := newArchive(t,
+					&tar.Header{Typeflag: tar.TypeReg, Name: "config.json", Mode: 0644},
+					tt.hdr,
+				)
+				files, err := cfgstore.ImportArchive(store, archive)
+				assert.ErrorIs(t, err, cfgstore.ErrUnsafeArchiveEntry)
+				assert.Empty(t, files)
+				assert.False(t, store.Exists(), "nothing is written from an unsafe archive")
+			})
+		}
+	})
+
+	t.Run("store file saved with hooks", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		var saved []string
+		require.NoError(t, cfgstore.AddPostSaveHook(store, func(args *cfgstore.SaveHookArgs) error {
+			saved = append(saved, string(args.Data))
+			return nil
+		}))
+		archive := newArchive(t,
+			&tar.Header{Typeflag: tar.TypeReg, Name: "config.json", Mode: 0644},
+			&tar.Header{Typeflag: tar.TypeReg, Name: "other.json", Mode: 0644},
+		)
+		files, err := cfgstore.ImportArchive(store, archive)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []dt.RelFilepath{"config.json", "other.json"}, files)
+		assert.Equal(t, []string{"{}"}, saved, "only the store's own file goes through Save")
+	})
+
+	t.Run("group and other write access dropped", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		archive := newArchive(t,
+			&tar.Header{Typeflag: tar.TypeReg, Name: "other.json", Mode: 0666},
+			&tar.Header{Typeflag: tar.TypeReg, Name: "run.sh", Mode: 0777},
+		)
+		_, err := cfgstore.ImportArchive(store, archive)
+		require.NoError(t, err)
+		dir, err := store.ConfigDir()
+		require.NoError(t, err)
+		for name, want := range map[dt.RelFilepath]fs.FileMode{"other.json": 0644, "run.sh": 0755} {
+			info, err := store.FileSystem().Stat(dt.FilepathJoin(dir, name))
+			require.NoError(t, err)
+			assert.Equal(t, want, info.Mode().Perm(), name)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		archive := newArchive(t,
+			&tar.Header{Typeflag: tar.TypeReg, Name: "a.json", Mode: 0644},
+			&tar.Header{Typeflag: tar.TypeReg, Name: "b.json", Mode: 0644},
+		)
+		_, err := cfgstore.ImportArchive(store, archive, cfgstore.ImportArchiveOptions{MaxBytes: 3})
+		assert.ErrorIs(t, err, cfgstore.ErrArchiveTooLarge)
+	})
+}
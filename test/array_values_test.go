@@ -0,0 +1,39 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendValue(t *testing.T) {
+	cs := newValuesStore(t, `{"name":"acme"}`)
+
+	require.NoError(t, cfgstore.AppendValue(cs, "registries", "https://a.example"))
+	require.NoError(t, cfgstore.AppendValue(cs, "registries", "https://b.example"))
+	assert.JSONEq(t, `{"name":"acme","registries":["https://a.example","https://b.example"]}`, loadString(t, cs))
+
+	err := cfgstore.AppendValue(cs, "name", "x")
+	assert.ErrorIs(t, err, cfgstore.ErrValueTypeMismatch)
+}
+
+func TestInsertAndRemoveValueAt(t *testing.T) {
+	cs := newValuesStore(t, `{"tags":["a","c"]}`)
+
+	require.NoError(t, cfgstore.InsertValueAt(cs, "tags", 1, "b"))
+	require.NoError(t, cfgstore.InsertValueAt(cs, "tags", 0, "_"))
+	assert.JSONEq(t, `{"tags":["_","a","b","c"]}`, loadString(t, cs))
+
+	require.NoError(t, cfgstore.RemoveValueAt(cs, "tags", 0))
+	require.NoError(t, cfgstore.RemoveValueAt(cs, "/tags", 2))
+	assert.JSONEq(t, `{"tags":["a","b"]}`, loadString(t, cs))
+
+	err := cfgstore.RemoveValueAt(cs, "tags", 2)
+	assert.ErrorIs(t, err, cfgstore.ErrArrayIndexOutOfRange)
+
+	err = cfgstore.InsertValueAt(cs, "tags", 3, "x")
+	assert.ErrorIs(t, err, cfgstore.ErrArrayIndexOutOfRange)
+	assert.JSONEq(t, `{"tags":["a","b"]}`, loadString(t, cs))
+}
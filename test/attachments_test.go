@@ -0,0 +1,63 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigStore_Files(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		key := []byte{0x00, 0xff, 0x10, 0x80}
+		require.NoError(t, cfgstore.SaveFile(store, "keys/id.key", key, cfgstore.FileOptions{Mode: 0600}))
+
+		data, err := cfgstore.LoadFile(store, "keys/id.key")
+		require.NoError(t, err)
+		assert.Equal(t, key, data)
+		dir, err := store.ConfigDir()
+		require.NoError(t, err)
+		info, err := os.Stat(string(dt.FilepathJoin(dir, "keys/id.key")))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		require.NoError(t, cfgstore.DeleteFile(store, "keys/id.key"))
+		_, err = cfgstore.LoadFile(store, "keys/id.key")
+		assert.ErrorIs(t, err, cfgstore.ErrFileDoesNotExist)
+		assert.Equal(t, cfgstore.NotFoundErrorKind, cfgstore.ErrorKindOf(err))
+		assert.NoError(t, cfgstore.DeleteFile(store, "keys/id.key"), "deleting a missing file is not an error")
+	})
+
+	t.Run("size limits", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		limit := cfgstore.FileOptions{MaxBytes: 3}
+		err := cfgstore.SaveFile(store, "asset.bin", []byte("four"), limit)
+		assert.ErrorIs(t, err, cfgstore.ErrAttachmentTooLarge)
+
+		require.NoError(t, cfgstore.SaveFile(store, "asset.bin", []byte("four")))
+		_, err = cfgstore.LoadFile(store, "asset.bin", limit)
+		assert.ErrorIs(t, err, cfgstore.ErrAttachmentTooLarge)
+	})
+
+	t.Run("invalid paths", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		for _, rel := range []dt.RelFilepath{"", "../escape.bin", "/etc/passwd", "config.json", "./config.json", "data.bin.lock"} {
+			err := cfgstore.SaveFile(store, rel, []byte("x"))
+			assert.ErrorIs(t, err, cfgstore.ErrInvalidAttachmentPath, "SaveFile(%q)", rel)
+			_, err = cfgstore.LoadFile(store, rel)
+			assert.ErrorIs(t, err, cfgstore.ErrInvalidAttachmentPath, "LoadFile(%q)", rel)
+			err = cfgstore.DeleteFile(store, rel)
+			assert.ErrorIs(t, err, cfgstore.ErrInvalidAttachmentPath, "DeleteFile(%q)", rel)
+		}
+	})
+}
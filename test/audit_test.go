@@ -0,0 +1,178 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAuditRecords(t *testing.T, fp dt.Filepath) (records []cfgstore.AuditRecord) {
+	t.Helper()
+	f, err := os.Open(string(fp))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, f.Close()) }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record cfgstore.AuditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestAuditLog_RecordsSaves(t *testing.T) {
+	t.Parallel()
+	store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, "acme", "config.json")
+	audit, err := cfgstore.NewAuditLog(cfgstore.AuditLogArgs{
+		Filepath: dt.Filepath(filepath.Join(t.TempDir(), "audit.jsonl")),
+		User:     "coyote",
+	})
+	require.NoError(t, err)
+	require.NoError(t, audit.Attach(store))
+
+	require.NoError(t, store.Save([]byte(`{"name":"acme","port":80,"tags":["a"]}`)))
+	require.NoError(t, cfgstore.SetValue(store, "port", 8080))
+	require.NoError(t, store.Save([]byte(`{"name":"acme","port":8080,"debug":true}`)))
+
+	fp, err := store.GetFilepath()
+	require.NoError(t, err)
+	records := readAuditRecords(t, audit.Filepath())
+	require.Len(t, records, 3)
+	for _, record := range records {
+		assert.Equal(t, "coyote", record.User)
+		assert.Equal(t, "cli", record.DirType)
+		assert.Equal(t, fp, record.Filepath)
+		assert.False(t, record.Time.IsZero())
+	}
+	assert.Equal(t, cfgstore.CreateAuditAction, records[0].Action)
+	assert.Equal(t, []cfgstore.AuditChange{
+		{Path: "name", Op: cfgstore.AddedAuditChangeOp},
+		{Path: "port", Op: cfgstore.AddedAuditChangeOp},
+		{Path: "tags.0", Op: cfgstore.AddedAuditChangeOp},
+	}, records[0].Changes)
+	assert.Equal(t, cfgstore.SaveAuditAction, records[1].Action)
+	assert.Equal(t, []cfgstore.AuditChange{
+		{Path: "port", Op: cfgstore.ChangedAuditChangeOp},
+	}, records[1].Changes)
+	assert.Equal(t, []cfgstore.AuditChange{
+		{Path: "debug", Op: cfgstore.AddedAuditChangeOp},
+		{Path: "tags.0", Op: cfgstore.RemovedAuditChangeOp},
+	}, records[2].Changes)
+}
+
+func TestAuditLog_RecordsInterleavedSaves(t *testing.T) {
+	t.Parallel()
+	store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, "acme", "config.json")
+	other := store.WithDirType(cfgstore.CLIConfigDirType)
+	audit, err := cfgstore.NewAuditLog(cfgstore.AuditLogArgs{
+		Filepath: dt.Filepath(filepath.Join(t.TempDir(), "audit.jsonl")),
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.Save([]byte(`{"port":80}`)))
+	require.NoError(t, audit.Attach(store))
+	require.NoError(t, audit.Attach(other))
+
+	// Save the file again between the store's pre-save and post-save hooks
+	nested := false
+	require.NoError(t, cfgstore.AddPreSaveHook(store, func(*cfgstore.SaveHookArgs) error {
+		if nested {
+			return nil
+		}
+		nested = true
+		return other.Save([]byte(`{"port":81}`))
+	}))
+	require.NoError(t, store.Save([]byte(`{"port":82,"debug":true}`)))
+
+	records := readAuditRecords(t, audit.Filepath())
+	require.Len(t, records, 2)
+	for _, record := range records {
+		assert.Equal(t, cfgstore.SaveAuditAction, record.Action)
+	}
+	assert.Equal(t, []cfgstore.AuditChange{
+		{Path: "port", Op: cfgstore.ChangedAuditChangeOp},
+		{Path: "debug", Op: cfgstore.AddedAuditChangeOp},
+	}, records[1].Changes)
+}
+
+func TestAuditLog_WriteFailureDoesNotFailSave(t *testing.T) {
+	t.Parallel()
+	store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, "acme", "config.json")
+	notDir := filepath.Join(t.TempDir(), "not-a-dir")
+	require.NoError(t, os.WriteFile(notDir, nil, 0600))
+	audit, err := cfgstore.NewAuditLog(cfgstore.AuditLogArgs{
+		Filepath: dt.Filepath(filepath.Join(notDir, "audit.jsonl")),
+	})
+	require.NoError(t, err)
+	require.NoError(t, audit.Attach(store))
+
+	require.NoError(t, store.Save([]byte(`{"port":80}`)))
+	data, err := store.Load()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"port":80}`, string(data))
+}
+
+func TestAuditLog_HandleEvent(t *testing.T) {
+	t.Parallel()
+	audit, err := cfgstore.NewAuditLog(cfgstore.AuditLogArgs{
+		Filepath: dt.Filepath(filepath.Join(t.TempDir(), "audit.jsonl")),
+		User:     "coyote",
+	})
+	require.NoError(t, err)
+
+	audit.HandleEvent(cfgstore.Event{Kind: cfgstore.SaveEventKind, Filepath: "/a/config.json"})
+	audit.HandleEvent(cfgstore.Event{
+		Kind:     cfgstore.DeleteEventKind,
+		DirType:  cfgstore.ProjectConfigDirType,
+		Filepath: "/a/config.json",
+	})
+
+	records := readAuditRecords(t, audit.Filepath())
+	require.Len(t, records, 1)
+	assert.Equal(t, cfgstore.DeleteAuditAction, records[0].Action)
+	assert.Equal(t, "project", records[0].DirType)
+	assert.Empty(t, records[0].Changes)
+}
+
+func TestAuditLog_RecordsAttachments(t *testing.T) {
+	store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, "acme", "config.json")
+	audit, err := cfgstore.NewAuditLog(cfgstore.AuditLogArgs{
+		Filepath: dt.Filepath(filepath.Join(t.TempDir(), "audit.jsonl")),
+		User:     "coyote",
+	})
+	require.NoError(t, err)
+	require.NoError(t, audit.Attach(store))
+	defer cfgstore.AddEventListener(audit.HandleEvent)()
+
+	require.NoError(t, store.Save([]byte(`{"name":"acme"}`)))
+	require.NoError(t, cfgstore.SaveFile(store, "certs/client.key", []byte("key")))
+	require.NoError(t, cfgstore.DeleteFile(store, "certs/client.key"))
+
+	fp, err := store.GetFilepath()
+	require.NoError(t, err)
+	dir, err := store.ConfigDir()
+	require.NoError(t, err)
+	records := readAuditRecords(t, audit.Filepath())
+	require.Len(t, records, 3, "the store's save should be recorded once")
+	assert.Equal(t, cfgstore.CreateAuditAction, records[0].Action)
+	assert.Equal(t, fp, records[0].Filepath)
+	assert.Equal(t, cfgstore.SaveAuditAction, records[1].Action)
+	assert.Equal(t, dt.FilepathJoin(dir, "certs/client.key"), records[1].Filepath)
+	assert.Equal(t, "cli", records[1].DirType)
+	assert.Equal(t, cfgstore.DeleteAuditAction, records[2].Action)
+	assert.Equal(t, records[1].Filepath, records[2].Filepath)
+}
+
+func TestAuditChanges_NotJSON(t *testing.T) {
+	t.Parallel()
+	assert.Empty(t, cfgstore.AuditChanges([]byte("not json"), []byte(`{"a":1}`)))
+	assert.Empty(t, cfgstore.AuditChanges(nil, nil))
+}
@@ -0,0 +1,171 @@
+package test
+
+import (
+	"encoding/json/v2"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateConfig(t *testing.T) {
+	for _, size := range cstest.ConfigSizes {
+		t.Run(size.String(), func(t *testing.T) {
+			cs := cstest.NewFixture(t).InMemory().WithCLIConfig(cstest.GenerateConfig(size)).Stores().CLIConfigStore()
+			keys, err := cfgstore.Keys(cs, "")
+			require.NoError(t, err)
+			assert.Equal(t, int(size), len(keys))
+		})
+	}
+}
+
+func BenchmarkLoad(b *testing.B) {
+	cstest.RunStoreBenchmarks(b, func(b *testing.B, cs cfgstore.ConfigStore, _ []byte, _ func()) {
+		for b.Loop() {
+			_, err := cs.Load()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSave(b *testing.B) {
+	cstest.RunStoreBenchmarks(b, func(b *testing.B, cs cfgstore.ConfigStore, data []byte, _ func()) {
+		for b.Loop() {
+			err := cs.Save(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSetValue(b *testing.B) {
+	cstest.RunStoreBenchmarks(b, func(b *testing.B, cs cfgstore.ConfigStore, _ []byte, reset func()) {
+		for b.Loop() {
+			err := cfgstore.SetValue(cs, "sections.0.port", 9000)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.StopTimer()
+			reset()
+			b.StartTimer()
+		}
+	})
+}
+
+func BenchmarkLoadConfigStores(b *testing.B) {
+	fix := cstest.NewFixture(b).InMemory().
+		WithCLIConfig(&testRootConfig{Name: "cli", Theme: "dark"}).
+		WithProjectConfig(&testRootConfig{Name: "project"})
+	stores, args := fix.Stores(), fix.RootConfigArgs()
+	b.ReportAllocs()
+	for b.Loop() {
+		_, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExists(b *testing.B) {
+	cs := cstest.NewFixture(b).WithCLIConfig(`{"name":"cli"}`).Stores().CLIConfigStore()
+	b.ReportAllocs()
+	for b.Loop() {
+		if !cs.Exists() {
+			b.Fatal("config file does not exist")
+		}
+	}
+}
+
+func BenchmarkLoadJSON(b *testing.B) {
+	cstest.RunStoreBenchmarks(b, func(b *testing.B, cs cfgstore.ConfigStore, _ []byte, _ func()) {
+		for b.Loop() {
+			var cfg map[string]any
+			err := cs.LoadJSON(&cfg)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSaveJSON(b *testing.B) {
+	cstest.RunStoreBenchmarks(b, func(b *testing.B, cs cfgstore.ConfigStore, data []byte, _ func()) {
+		var cfg map[string]any
+		err := json.Unmarshal(data, &cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for b.Loop() {
+			err = cs.SaveJSON(cfg)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkLoad_OnDisk and BenchmarkSave_OnDisk measure the OS file system
+// paths that RunStoreBenchmarks' in-memory stores skip.
+func BenchmarkLoad_OnDisk(b *testing.B) {
+	data := cstest.GenerateConfig(cstest.MediumConfigSize)
+	cs := cstest.NewFixture(b).WithCLIConfig(data).Stores().CLIConfigStore()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		_, err := cs.Load()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSave_OnDisk(b *testing.B) {
+	data := cstest.GenerateConfig(cstest.MediumConfigSize)
+	cs := cstest.NewFixture(b).WithCLIConfig(data).Stores().CLIConfigStore()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		err := cs.Save(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkHugeConfig compares LoadJSON and SaveJSON with their streaming
+// counterparts for a file of about 10MB on disk.
+func BenchmarkHugeConfig(b *testing.B) {
+	data := cstest.GenerateConfig(cstest.HugeConfigSize)
+	cs := cstest.NewFixture(b).WithCLIConfig(data).Stores().CLIConfigStore()
+	var cfg generatedConfig
+	err := cs.LoadJSON(&cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarks := []struct {
+		name string
+		fn   func() error
+	}{
+		{"LoadJSON", func() error { return cs.LoadJSON(&generatedConfig{}) }},
+		{"LoadJSONStream", func() error { return cfgstore.LoadJSONStream(cs, &generatedConfig{}) }},
+		{"SaveJSON", func() error { return cs.SaveJSON(&cfg) }},
+		{"SaveJSONStream", func() error { return cfgstore.SaveJSONStream(cs, &cfg) }},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for b.Loop() {
+				err := bm.fn()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
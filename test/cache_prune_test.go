@@ -0,0 +1,79 @@
+package test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneCache(t *testing.T) {
+	t.Parallel()
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+	opts := cfgstore.CacheOptions{DirsProvider: dp}
+	now := time.Now()
+
+	// name, size and age of each cached file
+	files := []struct {
+		rel  dt.RelFilepath
+		size int
+		age  time.Duration
+	}{
+		{"stale.json", 10, 48 * time.Hour},
+		{"cli/old.bin", 100, 3 * time.Hour},
+		{"cli/mid.bin", 100, 2 * time.Hour},
+		{"cli/new.bin", 100, time.Hour},
+		{"mcp/repo/tarball.tgz", 50, 30 * time.Hour},
+	}
+	for _, f := range files {
+		store := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{Slug: "acme", RelFilepath: f.rel, DirsProvider: dp})
+		require.NoError(t, store.Save(make([]byte, f.size)))
+		fp, err := store.GetFilepath()
+		require.NoError(t, err)
+		require.NoError(t, os.Chtimes(string(fp), now.Add(-f.age), now.Add(-f.age)))
+	}
+	dir, err := cfgstore.GetSharedCacheDir("acme", opts)
+	require.NoError(t, err)
+
+	result, err := cfgstore.PruneCache("acme", 24*time.Hour, 150, opts)
+	require.NoError(t, err)
+
+	var removed []dt.Filepath
+	var reasons []cfgstore.PruneReason
+	for _, f := range result.Removed {
+		removed = append(removed, f.Filepath)
+		reasons = append(reasons, f.Reason)
+	}
+	assert.Equal(t, []dt.Filepath{
+		dt.FilepathJoin(dir, "stale.json"),
+		dt.FilepathJoin(dir, "mcp/repo/tarball.tgz"),
+		dt.FilepathJoin(dir, "cli/old.bin"),
+		dt.FilepathJoin(dir, "cli/mid.bin"),
+	}, removed)
+	assert.Equal(t, []cfgstore.PruneReason{
+		cfgstore.ExpiredPruneReason,
+		cfgstore.ExpiredPruneReason,
+		cfgstore.OversizePruneReason,
+		cfgstore.OversizePruneReason,
+	}, reasons)
+	assert.Equal(t, int64(260), result.RemovedBytes)
+	assert.Equal(t, int64(100), result.RemainingBytes)
+
+	_, err = os.Stat(string(dt.DirPathJoin(dir, "mcp")))
+	assert.ErrorIs(t, err, os.ErrNotExist, "empty directories are removed")
+	_, err = os.Stat(string(dt.FilepathJoin(dir, "cli/new.bin")))
+	assert.NoError(t, err)
+}
+
+func TestPruneCache_MissingDir(t *testing.T) {
+	t.Parallel()
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+	result, err := cfgstore.PruneCache("acme", time.Hour, 0, cfgstore.CacheOptions{DirsProvider: dp})
+	require.NoError(t, err)
+	assert.Empty(t, result.Removed)
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cachedIndex struct {
+	Repos []string `json:"repos"`
+}
+
+func TestCacheStore_SaveLoadDelete(t *testing.T) {
+	t.Parallel()
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		dp := fix.DirsProvider()
+		store := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{
+			Slug:         "acme",
+			AppName:      "cli",
+			RelFilepath:  "repos/index.json",
+			DirsProvider: dp,
+		})
+
+		dir, err := store.CacheDir()
+		require.NoError(t, err)
+		want, err := cfgstore.GetAppCacheDir("acme", "cli", cfgstore.CacheOptions{DirsProvider: dp})
+		require.NoError(t, err)
+		assert.Equal(t, want, dir)
+
+		assert.False(t, store.Exists())
+		_, err = store.Load()
+		assert.ErrorIs(t, err, cfgstore.ErrFileDoesNotExist)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		require.NoError(t, store.SaveJSON(cachedIndex{Repos: []string{"a", "b"}}))
+		assert.True(t, store.Exists())
+		var got cachedIndex
+		require.NoError(t, store.LoadJSON(&got))
+		assert.Equal(t, []string{"a", "b"}, got.Repos)
+
+		sub := store.SubStore("repos/a.tar")
+		require.NoError(t, sub.Save([]byte("tarball")))
+		data, err := sub.Load()
+		require.NoError(t, err)
+		assert.Equal(t, "tarball", string(data))
+		fp, err := sub.GetFilepath()
+		require.NoError(t, err)
+		assert.Equal(t, dt.FilepathJoin(dir, "repos/a.tar"), fp)
+
+		require.NoError(t, store.Delete())
+		assert.False(t, store.Exists())
+		assert.True(t, sub.Exists())
+		require.NoError(t, store.Delete(), "deleting a missing file is not an error")
+	})
+}
+
+func TestCacheStore_LoadJSONInvalid(t *testing.T) {
+	t.Parallel()
+	store := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{
+		Slug:         "acme",
+		RelFilepath:  "index.json",
+		DirsProvider: cstest.NewMemDirsProvider(cstest.NewTestDirsProviderArgs(t)),
+	})
+	require.NoError(t, store.Save([]byte("{")))
+	var got cachedIndex
+	assert.ErrorIs(t, store.LoadJSON(&got), cfgstore.ErrFailedToUnmarshalCacheFile)
+}
+
+func TestCacheStore_LoadOrCreateGeneratesOnce(t *testing.T) {
+	t.Parallel()
+	const callers = 10
+
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		dp := fix.DirsProvider()
+		var created atomic.Int32
+		var wg sync.WaitGroup
+		for range callers {
+			wg.Go(func() {
+				// Use a separate store per goroutine as separate processes would
+				store := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{
+					Slug:         "acme",
+					RelFilepath:  "build/artifact.bin",
+					DirsProvider: dp,
+				})
+				data, err := store.LoadOrCreate(func() ([]byte, error) {
+					created.Add(1)
+					time.Sleep(20 * time.Millisecond)
+					return []byte("artifact"), nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, "artifact", string(data))
+			})
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), created.Load())
+
+		// A lock sidecar is neither counted nor removed by PruneCache
+		result, err := cfgstore.PruneCache("acme", 0, 1, cfgstore.CacheOptions{DirsProvider: dp})
+		require.NoError(t, err)
+		require.Len(t, result.Removed, 1)
+		assert.Equal(t, int64(0), result.RemainingBytes)
+	})
+}
+
+func TestCacheStore_LoadOrCreateError(t *testing.T) {
+	t.Parallel()
+	store := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{
+		Slug:         "acme",
+		RelFilepath:  "index.json",
+		DirsProvider: cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t)),
+	})
+	failure := errors.New("fetch failed")
+	_, err := store.LoadOrCreate(func() ([]byte, error) {
+		return nil, failure
+	})
+	assert.ErrorIs(t, err, cfgstore.ErrCacheCreateFuncFailed)
+	assert.ErrorIs(t, err, failure)
+	assert.False(t, store.Exists())
+}
+
+func TestCacheStore_LockTimeout(t *testing.T) {
+	t.Parallel()
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		store := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{
+			Slug:         "acme",
+			RelFilepath:  "index.json",
+			DirsProvider: fix.DirsProvider(),
+		})
+		unlock, err := store.Lock(0)
+		require.NoError(t, err)
+
+		_, err = store.LoadOrCreate(func() ([]byte, error) {
+			return []byte("{}"), nil
+		}, cfgstore.UpdateOptions{LockTimeout: 50 * time.Millisecond})
+		assert.ErrorIs(t, err, cfgstore.ErrLockTimeout)
+
+		unlock()
+		_, err = store.LoadOrCreate(func() ([]byte, error) {
+			return []byte("{}"), nil
+		})
+		assert.NoError(t, err)
+	})
+}
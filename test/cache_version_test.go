@@ -0,0 +1,142 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveStaleCacheVersions(t *testing.T) {
+	t.Parallel()
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		opts := cfgstore.CacheOptions{DirsProvider: fix.DirsProvider()}
+		for _, version := range []dt.PathSegment{"v1.2.0", "v1.4.0-rc.1", "v1.4.0", "1.10.0", "latest", "1", "2024"} {
+			store := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{
+				Slug:         "acme",
+				AppName:      "cli",
+				Version:      version,
+				RelFilepath:  "repos/index.json",
+				DirsProvider: fix.DirsProvider(),
+			})
+			require.NoError(t, store.Save([]byte(`{}`)))
+			dir, err := store.CacheDir()
+			require.NoError(t, err)
+			want, err := cfgstore.GetVersionedCacheDir("acme", "cli", version, opts)
+			require.NoError(t, err)
+			assert.Equal(t, want, dir)
+		}
+		appDir, err := cfgstore.GetAppCacheDir("acme", "cli", opts)
+		require.NoError(t, err)
+
+		removed, err := cfgstore.RemoveStaleCacheVersions("acme", "cli", "v1.4.0", opts)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []dt.DirPath{
+			dt.DirPathJoin(appDir, "v1.2.0"),
+			dt.DirPathJoin(appDir, "v1.4.0-rc.1"),
+		}, removed)
+
+		for version, exists := range map[dt.PathSegment]bool{
+			"v1.2.0":      false,
+			"v1.4.0-rc.1": false,
+			"v1.4.0":      true,
+			"1.10.0":      true,
+			"latest":      true,
+			"1":           true,
+			"2024":        true,
+		} {
+			store := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{
+				Slug:         "acme",
+				AppName:      "cli",
+				Version:      version,
+				RelFilepath:  "repos/index.json",
+				DirsProvider: fix.DirsProvider(),
+			})
+			assert.Equal(t, exists, store.Exists(), version)
+		}
+	})
+}
+
+func TestRemoveStaleCacheVersions_Prereleases(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		current dt.PathSegment
+		exists  map[dt.PathSegment]bool
+	}{
+		{
+			name:    "numeric identifiers compare numerically",
+			current: "v1.0.0-rc.2",
+			exists:  map[dt.PathSegment]bool{"v1.0.0-rc.1": false, "v1.0.0-rc.2": true, "v1.0.0-rc.10": true},
+		},
+		{
+			name:    "more identifiers are newer",
+			current: "v1.0.0-alpha.1",
+			exists:  map[dt.PathSegment]bool{"v1.0.0-alpha": false, "v1.0.0-alpha.1": true, "v1.0.0-alpha.beta": true},
+		},
+		{
+			name:    "numeric identifiers are older than alphanumeric ones",
+			current: "v1.0.0-alpha.beta",
+			exists:  map[dt.PathSegment]bool{"v1.0.0-alpha.1": false, "v1.0.0-alpha.beta": true, "v1.0.0-beta": true},
+		},
+		{
+			name:    "prereleases are older than their release",
+			current: "v1.0.0-rc.10",
+			exists:  map[dt.PathSegment]bool{"v1.0.0-rc.9": false, "v1.0.0-rc.10": true, "v1.0.0": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dp := cstest.NewMemDirsProvider(cstest.NewTestDirsProviderArgs(t))
+			opts := cfgstore.CacheOptions{DirsProvider: dp}
+			newStore := func(version dt.PathSegment) cfgstore.CacheStore {
+				return cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{
+					Slug:         "acme",
+					AppName:      "cli",
+					Version:      version,
+					RelFilepath:  "repos/index.json",
+					DirsProvider: dp,
+				})
+			}
+			for version := range tt.exists {
+				require.NoError(t, newStore(version).Save([]byte(`{}`)))
+			}
+			_, err := cfgstore.RemoveStaleCacheVersions("acme", "cli", tt.current, opts)
+			require.NoError(t, err)
+			for version, exists := range tt.exists {
+				assert.Equal(t, exists, newStore(version).Exists(), version)
+			}
+		})
+	}
+}
+
+func TestRemoveStaleCacheVersions_InvalidCurrent(t *testing.T) {
+	t.Parallel()
+	opts := cfgstore.CacheOptions{
+		DirsProvider: cstest.NewMemDirsProvider(cstest.NewTestDirsProviderArgs(t)),
+	}
+	for _, current := range []dt.PathSegment{"latest", "1", "1.", "v+1.2", "1.+2", "1.2.-0", " 1.2", "1.2.3.4"} {
+		_, err := cfgstore.RemoveStaleCacheVersions("acme", "cli", current, opts)
+		assert.ErrorIs(t, err, cfgstore.ErrInvalidCacheVersion, current)
+	}
+}
+
+func TestRemoveStaleCacheVersions_MissingAppName(t *testing.T) {
+	t.Parallel()
+	dp := cstest.NewMemDirsProvider(cstest.NewTestDirsProviderArgs(t))
+	opts := cfgstore.CacheOptions{DirsProvider: dp}
+	dir, err := cfgstore.GetAppCacheDir("acme", "", opts)
+	require.NoError(t, err)
+	other := dt.DirPathJoin(dir, "1.0")
+	require.NoError(t, dp.FileSystem.MkdirAll(other))
+
+	removed, err := cfgstore.RemoveStaleCacheVersions("acme", "", "v2.0.0", opts)
+	assert.ErrorIs(t, err, cfgstore.ErrMissingCacheAppName)
+	assert.Empty(t, removed)
+	_, err = dp.FileSystem.Stat(dt.Filepath(other))
+	assert.NoError(t, err, "nothing is removed")
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedConfig(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	cc := cfgstore.NewCachedConfig[testData](cs)
+
+	_, err := cc.Load()
+	assert.ErrorIs(t, err, cfgstore.ErrFileDoesNotExist)
+
+	var loads int
+	require.NoError(t, cfgstore.AddPostLoadHook(cs, func(*cfgstore.LoadHookArgs) error {
+		loads++
+		return nil
+	}))
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Alice", Age: 30}))
+
+	first, err := cc.Load()
+	require.NoError(t, err)
+	assert.Equal(t, &testData{Name: "Alice", Age: 30}, first)
+	second, err := cc.Load()
+	require.NoError(t, err)
+	assert.Same(t, first, second, "an unchanged file is not reloaded")
+	assert.Equal(t, 1, loads)
+
+	// Saving through the store invalidates the cache
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Bob", Age: 30}))
+	got, err := cc.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", got.Name)
+	assert.Equal(t, 2, loads)
+
+	// So does a change by another process, detected by its modification time
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(string(fp), []byte(`{"Name":"Carol","Age":30}`), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(string(fp), later, later))
+	got, err = cc.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "Carol", got.Name)
+	assert.Equal(t, 3, loads)
+
+	cc.Invalidate()
+	_, err = cc.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 4, loads)
+
+	require.NoError(t, os.Remove(string(fp)))
+	_, err = cc.Load()
+	assert.ErrorIs(t, err, cfgstore.ErrFileDoesNotExist)
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cfgotel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestCfgotel(t *testing.T) {
+	t.Parallel()
+	sr := tracetest.NewSpanRecorder()
+	tracer := cfgotel.New(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+
+	ctx, parent := tracer.Start(context.Background(), cfgstore.SpanArgs{
+		Name:     cfgstore.LoadConfigSpanName,
+		DirTypes: []cfgstore.DirType{cfgstore.CLIConfigDirType, cfgstore.ProjectConfigDirType},
+	})
+	_, child := tracer.Start(ctx, cfgstore.SpanArgs{
+		Name:     cfgstore.LoadSpanName,
+		DirType:  cfgstore.CLIConfigDirType,
+		Filepath: "/home/alice/.config/acme/config.json",
+	})
+	child.End(errors.New("network home dir unavailable"))
+	parent.End(nil)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	load, loadConfig := spans[0], spans[1]
+
+	assert.Equal(t, cfgstore.LoadConfigSpanName, loadConfig.Name())
+	assert.Equal(t, cfgotel.InstrumentationName, loadConfig.InstrumentationScope().Name)
+	assert.Equal(t, codes.Unset, loadConfig.Status().Code)
+	assert.Equal(t, []attribute.KeyValue{
+		cfgotel.DirTypesKey.StringSlice([]string{
+			cfgstore.CLIConfigDirType.Slug(),
+			cfgstore.ProjectConfigDirType.Slug(),
+		}),
+	}, loadConfig.Attributes())
+
+	assert.Equal(t, cfgstore.LoadSpanName, load.Name())
+	assert.Equal(t, loadConfig.SpanContext().SpanID(), load.Parent().SpanID())
+	assert.Equal(t, []attribute.KeyValue{
+		cfgotel.DirTypeKey.String(cfgstore.CLIConfigDirType.Slug()),
+		cfgotel.FilepathKey.String("/home/alice/.config/acme/config.json"),
+	}, load.Attributes())
+	assert.Equal(t, codes.Error, load.Status().Code)
+	assert.Equal(t, "network home dir unavailable", load.Status().Description)
+	require.Len(t, load.Events(), 1)
+	assert.Equal(t, "exception", load.Events()[0].Name)
+}
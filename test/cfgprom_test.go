@@ -0,0 +1,57 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cfgprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCfgprom(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewPedanticRegistry()
+	m, err := cfgprom.New(cfgprom.Options{Registerer: reg})
+	require.NoError(t, err)
+
+	m.ObserveOperation(cfgstore.OperationMetric{
+		Kind:     cfgstore.LoadEventKind,
+		DirType:  cfgstore.CLIConfigDirType,
+		Duration: 5 * time.Millisecond,
+		Size:     1024,
+	})
+	m.ObserveOperation(cfgstore.OperationMetric{
+		Kind:    cfgstore.LoadEventKind,
+		DirType: cfgstore.ProjectConfigDirType,
+		Err:     cfgstore.NewErr(cfgstore.ErrFileDoesNotExist, errors.New("missing")),
+	})
+	m.ObserveCacheLookup(true)
+	m.ObserveCacheLookup(false)
+	m.ObserveCacheLookup(true)
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP cfgstore_operations_total Config file operations, by kind and dir type.
+# TYPE cfgstore_operations_total counter
+cfgstore_operations_total{dir_type="cli",op="load"} 1
+cfgstore_operations_total{dir_type="project",op="load"} 1
+# HELP cfgstore_errors_total Failed config file operations, by kind and error kind.
+# TYPE cfgstore_errors_total counter
+cfgstore_errors_total{error_kind="not_found",op="load"} 1
+# HELP cfgstore_cache_lookups_total CachedConfig loads, by whether they were served from the cache.
+# TYPE cfgstore_cache_lookups_total counter
+cfgstore_cache_lookups_total{result="hit"} 2
+cfgstore_cache_lookups_total{result="miss"} 1
+`), "cfgstore_operations_total", "cfgstore_errors_total", "cfgstore_cache_lookups_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, testutil.CollectAndCount(reg, "cfgstore_operation_duration_seconds"))
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "cfgstore_file_size_bytes"), "failed loads have no size")
+
+	_, err = cfgprom.New(cfgprom.Options{Registerer: reg})
+	assert.Error(t, err, "the metrics are already registered")
+}
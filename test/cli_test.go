@@ -0,0 +1,182 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cmd/cfgstore/cli"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cliRun struct {
+	t   *testing.T
+	dp  *cfgstore.DirsProvider
+	env map[string]string
+}
+
+func newCLIRun(t *testing.T) *cliRun {
+	return &cliRun{
+		t:   t,
+		dp:  cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t)),
+		env: map[string]string{},
+	}
+}
+
+// run runs the cfgstore command with args, adding -slug, and returns its exit
+// code, stdout and stderr.
+func (c *cliRun) run(cmd string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = cli.Run(cli.Args{
+		Args:   append([]string{cmd, "-slug", string(TestConfigSlug)}, args...),
+		Stdin:  strings.NewReader(""),
+		Stdout: &out,
+		Stderr: &errOut,
+		LookupEnv: func(name string) (value string, ok bool) {
+			value, ok = c.env[name]
+			return value, ok
+		},
+		DirsProvider: c.dp,
+	})
+	return code, out.String(), errOut.String()
+}
+
+func TestCLI(t *testing.T) {
+	t.Parallel()
+
+	t.Run("set, get, list and unset", func(t *testing.T) {
+		t.Parallel()
+		c := newCLIRun(t)
+
+		code, _, stderr := c.run("get", "server.port")
+		assert.Equal(t, cli.ExitError, code)
+		assert.Contains(t, stderr, "value not found")
+
+		for _, args := range [][]string{
+			{"server.port", "8080"},
+			{"server.host", "localhost"},
+			{"-string", "version", "2"},
+		} {
+			code, _, stderr = c.run("set", args...)
+			require.Equal(t, cli.ExitOK, code, stderr)
+		}
+
+		_, stdout, _ := c.run("get", "server.port")
+		assert.Equal(t, "8080\n", stdout)
+		_, stdout, _ = c.run("get", "server.host")
+		assert.Equal(t, "localhost\n", stdout)
+		_, stdout, _ = c.run("get", "version")
+		assert.Equal(t, "2\n", stdout)
+		_, stdout, _ = c.run("get", "server")
+		assert.JSONEq(t, `{"host":"localhost","port":8080}`, stdout)
+
+		_, stdout, _ = c.run("list", "server")
+		assert.Equal(t, "server.port\nserver.host\n", stdout)
+
+		code, _, _ = c.run("unset", "-prune", "server.port")
+		assert.Equal(t, cli.ExitOK, code)
+		_, stdout, _ = c.run("list")
+		assert.Equal(t, "server.host\nversion\n", stdout)
+	})
+
+	t.Run("path", func(t *testing.T) {
+		t.Parallel()
+		c := newCLIRun(t)
+		_, stdout, _ := c.run("path", "-file", "tokens/alice.json")
+		fp, err := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "tokens/alice.json").GetFilepath()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Base(string(fp)), filepath.Base(strings.TrimSpace(stdout)))
+		assert.Contains(t, stdout, string(TestConfigSlug))
+	})
+
+	t.Run("lint and doctor", func(t *testing.T) {
+		t.Parallel()
+		c := newCLIRun(t)
+		code, stdout, _ := c.run("doctor")
+		assert.Equal(t, cli.ExitOK, code)
+		assert.Contains(t, stdout, "missing")
+
+		c.run("set", "name", "alice")
+		code, stdout, _ = c.run("lint")
+		assert.Equal(t, cli.ExitOK, code)
+		assert.Equal(t, "ok\n", stdout)
+
+		store := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+			cfgstore.WithSlug(TestConfigSlug),
+			cfgstore.WithRelFilepath("config.json"),
+			cfgstore.WithDirsProvider(c.dp),
+		)
+		require.NoError(t, store.Save([]byte(`{"name":"alice","name":"bob"}`)))
+		code, _, stderr := c.run("lint")
+		assert.Equal(t, cli.ExitError, code)
+		assert.Contains(t, stderr, "invalid config")
+
+		code, stdout, _ = c.run("doctor")
+		assert.Equal(t, cli.ExitError, code)
+		assert.Regexp(t, `(?m)^cli\s+\S+config\.json\s+error: `, stdout)
+
+		dir := t.TempDir()
+		_, stdout, _ = c.run("doctor", "-dir-type", "project", "-config-dir", dir)
+		assert.Regexp(t, `(?m)^project\s+`+regexp.QuoteMeta(filepath.Join(dir, "config.json"))+`\s`, stdout)
+		code, _, _ = c.run("doctor", "-dir-type", "bogus", "-config-dir", dir)
+		assert.Equal(t, cli.ExitUsage, code)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		t.Parallel()
+		c := newCLIRun(t)
+		c.run("set", "name", "alice")
+		c.run("set", "port", "80")
+		c.run("set", "-dir-type", "project", "name", "bob")
+		c.run("set", "-dir-type", "project", "theme", "dark")
+
+		code, stdout, stderr := c.run("diff", "project")
+		require.Equal(t, cli.ExitOK, code, stderr)
+		assert.Equal(t, "~ name: \"alice\" -> \"bob\"\n+ theme: \"dark\"\n- port: 80\n", stdout)
+
+		code, _, _ = c.run("diff", "nope")
+		assert.Equal(t, cli.ExitUsage, code)
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("editor script needs a POSIX shell")
+		}
+		c := newCLIRun(t)
+		code, _, stderr := c.run("edit")
+		assert.Equal(t, cli.ExitError, code)
+		assert.Contains(t, stderr, "neither VISUAL nor EDITOR")
+
+		editor := filepath.Join(t.TempDir(), "editor.sh")
+		require.NoError(t, os.WriteFile(editor, []byte("#!/bin/sh\necho '{\"theme\":\"dark\"}' > \"$1\"\n"), 0755))
+		c.env["EDITOR"] = editor
+		code, _, stderr = c.run("edit")
+		require.Equal(t, cli.ExitOK, code, stderr)
+		_, stdout, _ := c.run("get", "theme")
+		assert.Equal(t, "dark\n", stdout)
+	})
+
+	t.Run("usage errors", func(t *testing.T) {
+		t.Parallel()
+		c := newCLIRun(t)
+		code, _, _ := c.run("frob")
+		assert.Equal(t, cli.ExitUsage, code)
+		code, _, _ = c.run("get")
+		assert.Equal(t, cli.ExitUsage, code)
+		code, _, _ = c.run("path", "-dir-type", "bogus")
+		assert.Equal(t, cli.ExitUsage, code)
+
+		var stderr bytes.Buffer
+		code = cli.Run(cli.Args{Args: []string{"path"}, Stderr: &stderr})
+		assert.Equal(t, cli.ExitUsage, code)
+		assert.Contains(t, stderr.String(), "missing -slug")
+	})
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	jsonv2 "encoding/json/v2"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ cfgstore.ConfigReader = (*fsReader)(nil)
+
+// fsReader is a read-only ConfigReader over an fs.FS, as for an embed.FS.
+type fsReader struct {
+	fsys fs.FS
+	fp   dt.Filepath
+}
+
+func (r *fsReader) Load() ([]byte, error) {
+	return fs.ReadFile(r.fsys, string(r.fp))
+}
+
+func (r *fsReader) LoadJSON(data any, opts ...jsonv2.Options) (err error) {
+	var raw []byte
+
+	raw, err = r.Load()
+	if err != nil {
+		goto end
+	}
+	err = jsonv2.Unmarshal(raw, data, opts...)
+end:
+	return err
+}
+
+func (r *fsReader) Exists() bool {
+	_, err := fs.Stat(r.fsys, string(r.fp))
+	return err == nil
+}
+
+func (r *fsReader) GetFilepath() (dt.Filepath, error) {
+	return r.fp, nil
+}
+
+func (r *fsReader) ConfigDir() (dt.DirPath, error) {
+	return r.fp.Dir(), nil
+}
+
+// readName only needs to read, so it accepts any ConfigReader.
+func readName(r cfgstore.ConfigReader) (string, error) {
+	var data testData
+	err := r.LoadJSON(&data)
+	return data.Name, err
+}
+
+func TestConfigReader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ConfigStore is a ConfigReader", func(t *testing.T) {
+		t.Parallel()
+		cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+		var w cfgstore.ConfigWriter = cs
+		require.NoError(t, w.SaveJSON(&testData{Name: "Alice"}))
+
+		name, err := readName(cs)
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", name)
+	})
+
+	t.Run("read-only backend", func(t *testing.T) {
+		t.Parallel()
+		r := &fsReader{
+			fsys: fstest.MapFS{
+				"defaults/config.json": {Data: []byte(`{"Name":"Bob"}`)},
+			},
+			fp: "defaults/config.json",
+		}
+		assert.True(t, r.Exists())
+		name, err := readName(r)
+		require.NoError(t, err)
+		assert.Equal(t, "Bob", name)
+
+		_, isWriter := any(r).(cfgstore.ConfigWriter)
+		assert.False(t, isWriter)
+	})
+}
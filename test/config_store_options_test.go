@@ -0,0 +1,133 @@
+package test
+
+import (
+	"encoding/base64"
+	"encoding/json/v2"
+	"runtime"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// base64Codec stores values as base64-encoded JSON, standing in for a format
+// such as YAML.
+type base64Codec struct{}
+
+func (base64Codec) Marshal(v any) (data []byte, err error) {
+	data, err = json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.AppendEncode(nil, data), nil
+}
+
+func (base64Codec) Unmarshal(data []byte, v any) (err error) {
+	data, err = base64.StdEncoding.AppendDecode(nil, data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func TestNewConfigStore_Options(t *testing.T) {
+	t.Parallel()
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+	withArgs := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType, cfgstore.ConfigStoreArgs{
+		ConfigSlug:   TestConfigSlug,
+		RelFilepath:  "config.json",
+		DirsProvider: dp,
+	})
+	withOptions := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+		cfgstore.WithSlug(TestConfigSlug),
+		cfgstore.WithRelFilepath("config.json"),
+		cfgstore.WithDirsProvider(dp),
+	)
+	want, err := withArgs.GetFilepath()
+	require.NoError(t, err)
+	got, err := withOptions.GetFilepath()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, dt.PathSegment(TestConfigSlug), withOptions.ConfigSlug())
+
+	// Options apply in order, and ConfigStoreArgs only sets its non-zero fields
+	mixed := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+		cfgstore.WithRelFilepath("other.json"),
+		cfgstore.ConfigStoreArgs{ConfigSlug: TestConfigSlug, DirsProvider: dp},
+	)
+	assert.Equal(t, "other.json", string(mixed.GetRelFilepath()))
+	mixed = cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+		cfgstore.ConfigStoreArgs{ConfigSlug: TestConfigSlug, RelFilepath: "config.json", DirsProvider: dp},
+		cfgstore.WithRelFilepath("other.json"),
+	)
+	assert.Equal(t, "other.json", string(mixed.GetRelFilepath()))
+}
+
+func TestNewConfigStore_WithFileMode(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported on Windows")
+	}
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+	cs := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+		cfgstore.WithSlug(TestConfigSlug),
+		cfgstore.WithRelFilepath("secrets.json"),
+		cfgstore.WithDirsProvider(dp),
+		cfgstore.WithFileMode(0600),
+	)
+	for _, save := range []func() error{
+		func() error { return cs.SaveJSON(&testData{Name: "Alice"}) },
+		func() error { return cs.Save([]byte(`{"Name":"Bob"}`)) },
+		func() error { return cfgstore.SaveJSONStream(cs, &testData{Name: "Carol"}) },
+		func() error { return cfgstore.SubStore(cs, "token.json").Save([]byte(`{}`)) },
+	} {
+		require.NoError(t, save())
+	}
+	for _, store := range []cfgstore.ConfigStore{cs, cfgstore.SubStore(cs, "token.json")} {
+		fp, err := store.GetFilepath()
+		require.NoError(t, err)
+		info, err := fp.Stat()
+		require.NoError(t, err)
+		assert.Equal(t, "-rw-------", info.Mode().Perm().String())
+	}
+}
+
+func TestNewConfigStore_WithCodec(t *testing.T) {
+	t.Parallel()
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+	cs := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+		cfgstore.WithSlug(TestConfigSlug),
+		cfgstore.WithRelFilepath("config.b64"),
+		cfgstore.WithDirsProvider(dp),
+		cfgstore.WithCodec(base64Codec{}),
+	)
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Alice", Age: 42}))
+	raw, err := cs.Load()
+	require.NoError(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(`{"Name":"Alice","Age":42}`)), string(raw))
+
+	var loaded, streamed, mapped testData
+	require.NoError(t, cs.LoadJSON(&loaded))
+	require.NoError(t, cfgstore.LoadJSONStream(cs, &streamed))
+	require.NoError(t, cfgstore.LoadJSONMapped(cs, &mapped))
+	for _, got := range []testData{loaded, streamed, mapped} {
+		assert.Equal(t, testData{Name: "Alice", Age: 42}, got)
+	}
+
+	require.NoError(t, cfgstore.UpdateJSON(cs, func(data *testData) error {
+		data.Age++
+		return nil
+	}))
+	require.NoError(t, cs.LoadJSON(&loaded))
+	assert.Equal(t, 43, loaded.Age)
+
+	// SubStores inherit the codec
+	sub := cfgstore.SubStore(cs, "other.b64")
+	require.NoError(t, cfgstore.SaveJSONStream(sub, &loaded))
+	raw, err = sub.Load()
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "{")
+}
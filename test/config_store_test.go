@@ -4,9 +4,9 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
-	"github.com/google/uuid"
 	"github.com/mikeschinkel/go-cfgstore"
 	"github.com/mikeschinkel/go-cfgstore/cstest"
 	"github.com/mikeschinkel/go-dt"
@@ -23,10 +23,7 @@ type testData struct {
 func TestConfigStore_SaveLoadExists(t *testing.T) {
 	var err error
 
-	testRoot := dt.DirPathJoin(os.TempDir(), "xmlui-cli-"+uuid.NewString())
-	cs, _ := getConfigStore("config/testdata.json", testRoot, cfgstore.DefaultConfigDirType)
-
-	t.Cleanup(cleanupFunc(t, cs))
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config/testdata.json")
 
 	data := testData{Name: "Alice", Age: 42}
 
@@ -45,9 +42,7 @@ func TestConfigStore_SaveLoadExists(t *testing.T) {
 func TestConfigStore_LoadNonexistent(t *testing.T) {
 	var err error
 
-	testRoot := dtx.TempTestDir(t)
-	cs, _ := getConfigStore("does-not-exist.json", testRoot, cfgstore.DefaultConfigDirType)
-	t.Cleanup(cleanupFunc(t, cs))
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "does-not-exist.json")
 
 	err = cs.LoadJSON(&testData{})
 	assert.Error(t, err)
@@ -57,15 +52,34 @@ func TestConfigStore_LoadNonexistent(t *testing.T) {
 func TestConfigStore_SaveInvalidJSON(t *testing.T) {
 	var err error
 
-	testRoot := dtx.TempTestDir(t)
-	cs, _ := getConfigStore("bad.json", testRoot, cfgstore.DefaultConfigDirType)
-	t.Cleanup(cleanupFunc(t, cs))
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "bad.json")
 
 	ch := make(chan int) // non-serializable
 	err = cs.SaveJSON(ch)
 	assert.Error(t, err)
 }
 
+func TestConfigStore_SaveThroughSymlink(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	target := cfgstore.SubStore(cs, "dotfiles/config.json")
+	require.NoError(t, target.Save([]byte(`{"name":"Alice"}`)))
+	targetFp, err := target.GetFilepath()
+	require.NoError(t, err)
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+	require.NoError(t, cfgstore.Symlink(cs.FileSystem(), targetFp, fp))
+
+	require.NoError(t, cs.Save([]byte(`{"name":"Bob"}`)))
+
+	info, err := os.Lstat(string(fp))
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink, "the link is kept")
+	data, err := target.Load()
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"Bob"}`, string(data), "the file linked to is written")
+}
+
 func TestConfigStore_ConfigDir(t *testing.T) {
 	testRoot := dtx.TempTestDir(t)
 	cs, args := getConfigStore("", testRoot, cfgstore.DefaultConfigDirType)
@@ -78,6 +92,30 @@ func TestConfigStore_ConfigDir(t *testing.T) {
 	assert.Equal(t, rel, args.RelConfigDir())
 }
 
+// TestConfigStore_SharedByGoroutines resolves a fresh store's config dir,
+// filepath and file system from several goroutines at once, for go test -race.
+func TestConfigStore_SharedByGoroutines(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+	want, err := cs.(cfgstore.StoreCopier).WithRelFilepath("config.json").GetFilepath()
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			_, err := cs.ConfigDir()
+			assert.NoError(t, err)
+			fp, err := cs.GetFilepath()
+			assert.NoError(t, err)
+			assert.Equal(t, want, fp)
+			_, err = cs.Load()
+			assert.ErrorIs(t, err, cfgstore.ErrFileDoesNotExist)
+			_ = cfgstore.SubStore(cs, "other.json").Exists()
+		})
+	}
+	wg.Wait()
+}
+
 func TestConfigStores_CLIAndProjectStores(t *testing.T) {
 	testRoot := dtx.TempTestDir(t)
 	defer cfgstore.LogOnError(testRoot.RemoveAll())
@@ -87,6 +125,7 @@ func TestConfigStores_CLIAndProjectStores(t *testing.T) {
 		ProjectDir: "myproject",
 		ConfigSlug: "myapp",
 		TestRoot:   testRoot,
+		GOOS:       "linux",
 	}
 
 	// Create both CLI and project stores
@@ -105,18 +144,14 @@ func TestConfigStores_CLIAndProjectStores(t *testing.T) {
 	assert.NotNil(t, cliStore)
 	assert.NotNil(t, projectStore)
 
-	// Get their directories - should be different
-	cliDir, err := cliStore.ConfigDir()
-	require.NoError(t, err)
-
-	projectDir, err := projectStore.ConfigDir()
-	require.NoError(t, err)
-
-	assert.NotEqual(t, cliDir, projectDir)
-
-	// Both should be under testRoot
-	assert.Contains(t, string(cliDir), string(testRoot))
-	assert.Contains(t, string(projectDir), string(testRoot))
+	// Both should be under testRoot, in different directories
+	cstest.AssertLayout(t, stores, cstest.Layout{
+		Root: testRoot,
+		Stores: map[cfgstore.DirType]cstest.StoreLayout{
+			cfgstore.CLIConfigDirType:     {RelDir: "home/testuser/.config/myapp"},
+			cfgstore.ProjectConfigDirType: {RelDir: "myproject/.myapp"},
+		},
+	})
 }
 
 func TestConfigStore_LoadFromPreCreatedFixture(t *testing.T) {
@@ -136,10 +171,11 @@ func TestConfigStore_LoadFromPreCreatedFixture(t *testing.T) {
 		ProjectDir: "testproject",
 		ConfigSlug: configSlug,
 		TestRoot:   testRoot,
+		GOOS:       "darwin",
 	}
 
 	// Manually create the fixture directory structure that TestDirsProvider would use
-	// For CLI config: <testRoot>/Users/<username>/.config/<configSlug>/
+	// For CLI config on macOS: <testRoot>/Users/<username>/.config/<configSlug>/
 	configDirPath := filepath.Join(string(testRoot), "Users", string(username), ".config", string(configSlug))
 	err = os.MkdirAll(configDirPath, 0755)
 	require.NoError(t, err, "Failed to create config directory")
@@ -183,3 +219,48 @@ func cleanupFunc(t *testing.T, cs cfgstore.ConfigStore) func() {
 		RemoveAll(t, cs)
 	}
 }
+
+func TestConfigStore_Stat(t *testing.T) {
+	t.Parallel()
+
+	t.Run("file", func(t *testing.T) {
+		t.Parallel()
+		cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, cs.Save([]byte(`{"name":"Alice"}`)))
+		fp, err := cs.GetFilepath()
+		require.NoError(t, err)
+
+		info, err := cfgstore.Stat(cs)
+		require.NoError(t, err)
+		assert.Equal(t, fp, info.Filepath)
+		assert.Equal(t, int64(len(`{"name":"Alice"}`)), info.Size)
+		assert.True(t, info.Mode.IsRegular())
+		assert.False(t, info.ModTime.IsZero())
+		assert.False(t, info.IsSymlink)
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		t.Parallel()
+		cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+		target := cfgstore.SubStore(cs, "real.json")
+		require.NoError(t, target.Save([]byte(`{}`)))
+		targetFp, err := target.GetFilepath()
+		require.NoError(t, err)
+		fp, err := cs.GetFilepath()
+		require.NoError(t, err)
+		require.NoError(t, cfgstore.Symlink(cs.FileSystem(), targetFp, fp))
+
+		info, err := cfgstore.Stat(cs)
+		require.NoError(t, err)
+		assert.True(t, info.IsSymlink)
+		assert.Equal(t, int64(2), info.Size, "the size is the target's")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+		cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+		_, err := cfgstore.Stat(cs)
+		assert.ErrorIs(t, err, cfgstore.ErrFileDoesNotExist)
+		assert.Equal(t, cfgstore.NotFoundErrorKind, cfgstore.ErrorKindOf(err))
+	})
+}
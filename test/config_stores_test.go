@@ -0,0 +1,120 @@
+package test
+
+import (
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowFileSystem delays every file opened through its DirFS, as a network home
+// directory would, and records how many were open at once.
+type slowFileSystem struct {
+	cfgstore.FileSystem
+	mutex     sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (s *slowFileSystem) DirFS(dp dt.DirPath) fs.FS {
+	return slowFS{FS: s.FileSystem.DirFS(dp), slow: s}
+}
+
+type slowFS struct {
+	fs.FS
+	slow *slowFileSystem
+}
+
+func (f slowFS) Open(name string) (fs.File, error) {
+	s := f.slow
+	s.mutex.Lock()
+	s.active++
+	s.maxActive = max(s.maxActive, s.active)
+	s.mutex.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	s.mutex.Lock()
+	s.active--
+	s.mutex.Unlock()
+	return f.FS.Open(name)
+}
+
+func TestLoadConfigStores_ParallelLayers(t *testing.T) {
+	t.Parallel()
+	dirTypes := []cfgstore.DirType{
+		cfgstore.AppConfigDirType,
+		cfgstore.CLIConfigDirType,
+		cfgstore.ProjectConfigDirType,
+	}
+	fix := cstest.NewFixture(t).
+		WithAppConfig(`{"name":"app","theme":"light"}`).
+		WithCLIConfig(`{"theme":"dark"}`).
+		WithProjectConfig(`{"name":"project"}`)
+	fix.Stores()
+
+	tests := []struct {
+		name     string
+		maxLoads int
+		wantMax  func(t *testing.T, maxActive int)
+	}{
+		{
+			name: "default",
+			wantMax: func(t *testing.T, maxActive int) {
+				assert.Greater(t, maxActive, 1)
+			},
+		},
+		{
+			name:     "sequential",
+			maxLoads: 1,
+			wantMax: func(t *testing.T, maxActive int) {
+				assert.Equal(t, 1, maxActive)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := *fix.DirsProvider()
+			slow := &slowFileSystem{FileSystem: dp.FileSystem}
+			dp.FileSystem = slow
+			stores := cfgstore.NewConfigStores(cfgstore.ConfigStoresArgs{
+				DirTypes:     dirTypes,
+				DirsProvider: &dp,
+				ConfigStoreArgs: cfgstore.ConfigStoreArgs{
+					ConfigSlug:   fix.Args().ConfigSlug,
+					RelFilepath:  cstest.DefaultFixtureConfigFile,
+					DirsProvider: &dp,
+				},
+			})
+			rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, cfgstore.RootConfigArgs{
+				DirTypes:         dirTypes,
+				DirsProvider:     &dp,
+				MaxParallelLoads: tt.maxLoads,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, &testRootConfig{Name: "project", Theme: "dark"}, rc)
+			tt.wantMax(t, slow.maxActive)
+		})
+	}
+}
+
+func TestLoadConfigStores_ParallelErrorsInOrder(t *testing.T) {
+	t.Parallel()
+	stores := cstest.NewFixture(t).
+		WithCLIConfig(`{"name":`).
+		WithProjectConfig(`not json`).
+		Stores()
+	_, err := cfgstore.LoadConfigStores[testRootConfig](stores, cfgstore.RootConfigArgs{})
+	require.Error(t, err)
+	cliFP, _ := stores.CLIConfigStore().GetFilepath()
+	projectFP, _ := stores.ProjectConfigStore().GetFilepath()
+	msg := err.Error()
+	require.Contains(t, msg, string(cliFP))
+	require.Contains(t, msg, string(projectFP))
+	assert.Less(t, strings.Index(msg, string(cliFP)), strings.Index(msg, string(projectFP)))
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigStores_ReturnsMergedConfig(t *testing.T) {
+	stores, args := newLayeredStores(t)
+
+	rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+	require.NoError(t, err)
+	assert.Equal(t, &testRootConfig{Name: "project", Theme: "dark"}, rc)
+}
+
+func TestConfigStores_WatchRemergesOnChange(t *testing.T) {
+	stores, args := newLayeredStores(t)
+
+	_, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan cfgstore.StoresChange, 10)
+	result := make(chan error, 1)
+	go func() {
+		result <- stores.Watch(ctx, func(change cfgstore.StoresChange) {
+			changes <- change
+		}, cfgstore.WatcherArgs{Interval: 10 * time.Millisecond, Debounce: -1})
+	}()
+	// Give the watchers time to capture the current content
+	time.Sleep(50 * time.Millisecond)
+
+	err = stores.ProjectConfigStore().SaveJSON(&testRootConfig{Theme: "light"})
+	require.NoError(t, err)
+
+	select {
+	case change := <-changes:
+		require.NoError(t, change.Err)
+		assert.Equal(t, cfgstore.ProjectConfigDirType, change.DirType)
+		assert.Equal(t, &testRootConfig{Name: "cli", Theme: "light"}, change.RootConfig)
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for re-merged config")
+	}
+
+	cancel()
+	select {
+	case err = <-result:
+		assert.NoError(t, err)
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for Watch to return")
+	}
+}
+
+func TestConfigStores_WatchReportsDeletedLayer(t *testing.T) {
+	stores, args := newLayeredStores(t)
+
+	_, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+	require.NoError(t, err)
+	fp, err := stores.CLIConfigStore().GetFilepath()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan cfgstore.StoresChange, 10)
+	go func() {
+		_ = stores.Watch(ctx, func(change cfgstore.StoresChange) {
+			changes <- change
+		}, cfgstore.WatcherArgs{Interval: 10 * time.Millisecond, Debounce: -1})
+	}()
+	// Give the watchers time to capture the current content
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.Remove(string(fp)))
+
+	select {
+	case change := <-changes:
+		require.NoError(t, change.Err)
+		assert.Equal(t, cfgstore.CLIConfigDirType, change.DirType)
+		assert.True(t, change.Missing)
+		assert.Equal(t, &testRootConfig{Name: "project"}, change.RootConfig)
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for re-merged config")
+	}
+	assert.False(t, stores.CLIConfigStore().Exists(), "the deleted layer is not recreated")
+}
+
+func TestConfigStores_WatchRequiresLoad(t *testing.T) {
+	stores, _ := newLayeredStores(t)
+
+	err := stores.Watch(context.Background(), func(cfgstore.StoresChange) {})
+	assert.ErrorIs(t, err, cfgstore.ErrConfigStoresNotLoaded)
+}
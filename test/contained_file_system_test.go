@@ -0,0 +1,45 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardWrites(t *testing.T) {
+	args := cstest.NewTestDirsProviderArgs(t)
+	outside := dt.DirPath(t.TempDir())
+
+	// Simulate a misconfigured provider that ignores the test root
+	ft := &testing.T{}
+	dp := cstest.NewTestDirsProvider(args)
+	dp.CLIConfigDirFunc = func() (dt.DirPath, error) {
+		return outside, nil
+	}
+	cs := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType, cfgstore.ConfigStoreArgs{
+		ConfigSlug:   args.ConfigSlug,
+		RelFilepath:  "config.json",
+		DirsProvider: cstest.GuardWrites(ft, dp, args),
+	})
+
+	err := cs.SaveJSON(&testData{Name: "Road Runner"})
+	assert.ErrorIs(t, err, cstest.ErrWriteOutsideTestRoot)
+	assert.True(t, ft.Failed())
+	assert.False(t, cs.Exists(), "the write should not have been performed")
+
+	contained := cstest.NewContainedFileSystem(t, args.TestRoot, nil)
+	assert.True(t, contained.Contains(string(args.TestRoot)))
+	assert.True(t, contained.Contains(string(dt.DirPathJoin(args.TestRoot, "home/coyote"))))
+	assert.False(t, contained.Contains(string(args.TestRoot)+"-other"))
+	assert.False(t, contained.Contains(string(outside)))
+}
+
+func TestNewTestStore_GuardsWrites(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Wile"}))
+	assert.IsType(t, &cstest.ContainedFileSystem{}, cs.FileSystem())
+}
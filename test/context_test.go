@@ -0,0 +1,152 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+// contextFileSystem is a cfgstore.ContextFileSystem, as a remote backend would
+// be, that records the context of each read and write.
+type contextFileSystem struct {
+	cfgstore.FileSystem
+	mutex sync.Mutex
+	ctxs  []context.Context
+}
+
+func (c *contextFileSystem) ReadFileContext(ctx context.Context, fp dt.Filepath) ([]byte, error) {
+	c.record(ctx)
+	return c.ReadFile(fp)
+}
+
+func (c *contextFileSystem) WriteFileContext(ctx context.Context, fp dt.Filepath, data []byte) error {
+	c.record(ctx)
+	return c.WriteFile(fp, data)
+}
+
+func (c *contextFileSystem) LockContext(ctx context.Context, fp dt.Filepath, timeout time.Duration) (func(), error) {
+	c.record(ctx)
+	return c.Lock(fp, timeout)
+}
+
+func (c *contextFileSystem) record(ctx context.Context) {
+	c.mutex.Lock()
+	c.ctxs = append(c.ctxs, ctx)
+	c.mutex.Unlock()
+}
+
+func TestConfigStore_ContextCanceled(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Alice"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cfgstore.LoadContext(ctx, cs)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, err, cfgstore.ErrContextDone)
+	err = cfgstore.LoadJSONContext(ctx, cs, &testData{})
+	assert.ErrorIs(t, err, context.Canceled)
+	err = cfgstore.SaveContext(ctx, cs, []byte(`{"Name":"Bob"}`))
+	assert.ErrorIs(t, err, context.Canceled)
+	err = cfgstore.SaveJSONContext(ctx, cs, &testData{Name: "Bob"})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	var loaded testData
+	require.NoError(t, cs.LoadJSON(&loaded))
+	assert.Equal(t, "Alice", loaded.Name, "canceled saves should not write")
+}
+
+func TestConfigStore_ContextPassedThrough(t *testing.T) {
+	t.Parallel()
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+	cfs := &contextFileSystem{FileSystem: cfgstore.OSFileSystem()}
+	dp.FileSystem = cfs
+	cs := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType, cfgstore.ConfigStoreArgs{
+		ConfigSlug:   TestConfigSlug,
+		RelFilepath:  "config.json",
+		DirsProvider: dp,
+	})
+
+	var hookCtxs []context.Context
+	require.NoError(t, cfgstore.AddPreSaveHook(cs, func(args *cfgstore.SaveHookArgs) error {
+		hookCtxs = append(hookCtxs, args.Context)
+		return nil
+	}))
+	require.NoError(t, cfgstore.AddPostLoadHook(cs, func(args *cfgstore.LoadHookArgs) error {
+		hookCtxs = append(hookCtxs, args.Context)
+		return nil
+	}))
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	require.NoError(t, cfgstore.SaveJSONContext(ctx, cs, &testData{Name: "Alice"}))
+	var loaded testData
+	require.NoError(t, cfgstore.LoadJSONContext(ctx, cs, &loaded))
+	assert.Equal(t, "Alice", loaded.Name)
+	require.NoError(t, cfgstore.UpdateJSONContext(ctx, cs, func(data *testData) error {
+		data.Age = 42
+		return nil
+	}))
+
+	require.Len(t, cfs.ctxs, 5, "write, read, then lock, read and write for the update")
+	for _, got := range append(cfs.ctxs, hookCtxs...) {
+		assert.Equal(t, "request", got.Value(ctxKey{}))
+	}
+
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Bob"}))
+	assert.Equal(t, context.Background(), hookCtxs[len(hookCtxs)-1])
+}
+
+func TestUpdateJSONContext_LockDeadline(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+	unlock, err := cs.FileSystem().Lock(fp, time.Second)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = cfgstore.UpdateJSONContext(ctx, cs, func(*testData) error {
+		t.Error("fn should not be called without the lock")
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToLockFile)
+	assert.Less(t, time.Since(start), cfgstore.DefaultLockTimeout)
+
+	// The abandoned wait must not keep the lock once it is released
+	unlock()
+	require.NoError(t, cfgstore.UpdateJSON(cs, func(data *testData) error {
+		data.Name = "Alice"
+		return nil
+	}, cfgstore.UpdateOptions{LockTimeout: time.Second}))
+}
+
+func TestLoadConfigStoresContext(t *testing.T) {
+	t.Parallel()
+	stores := cstest.NewFixture(t).
+		WithCLIConfig(`{"name":"cli","theme":"dark"}`).
+		WithProjectConfig(`{"name":"project"}`).
+		Stores()
+
+	rc, err := cfgstore.LoadConfigStoresContext[testRootConfig](context.Background(), stores, cfgstore.RootConfigArgs{})
+	require.NoError(t, err)
+	assert.Equal(t, &testRootConfig{Name: "project", Theme: "dark"}, rc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cfgstore.LoadConfigStoresContext[testRootConfig](ctx, stores, cfgstore.RootConfigArgs{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
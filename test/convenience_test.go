@@ -0,0 +1,46 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAppConfig(t *testing.T) {
+	t.Parallel()
+	fix := cstest.NewFixture(t).WithAppConfig(&testRootConfig{Name: "app", Theme: "dark"})
+	fix.Stores()
+
+	rc, err := cfgstore.LoadAppConfig[testRootConfig](cfgstore.LoadConfigArgs{
+		ConfigSlug:   TestConfigSlug,
+		ConfigFile:   cstest.DefaultFixtureConfigFile,
+		DirsProvider: fix.DirsProvider(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &testRootConfig{Name: "app", Theme: "dark"}, rc)
+
+	dir, err := cfgstore.AppConfigDir(TestConfigSlug, fix.DirsProvider())
+	require.NoError(t, err)
+	appDir, err := fix.Stores().AppConfigStore().ConfigDir()
+	require.NoError(t, err)
+	assert.Equal(t, dir, appDir)
+}
+
+func TestLoadAppDefaultConfig(t *testing.T) {
+	t.Parallel()
+	fix := cstest.NewFixture(t).
+		WithAppConfig(&testRootConfig{Name: "app", Theme: "dark"}).
+		WithProjectConfig(&testRootConfig{Name: "project"})
+	fix.Stores()
+
+	rc, err := cfgstore.LoadAppDefaultConfig[testRootConfig](cfgstore.LoadConfigArgs{
+		ConfigSlug:   TestConfigSlug,
+		ConfigFile:   cstest.DefaultFixtureConfigFile,
+		DirsProvider: fix.DirsProvider(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &testRootConfig{Name: "project", Theme: "dark"}, rc)
+}
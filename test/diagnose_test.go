@@ -0,0 +1,85 @@
+package test
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	t.Parallel()
+
+	t.Run("layers", func(t *testing.T) {
+		t.Parallel()
+		stores := cstest.NewFixture(t).
+			WithCLIConfig(`{"name":"cli"}`).
+			WithProjectConfig(`{"name":`).
+			Stores()
+
+		d := cfgstore.Diagnose(stores, cfgstore.DiagnoseOptions{
+			LookupEnv: func(string) (string, bool) { return "", false },
+		})
+		require.Len(t, d.Layers, 2)
+
+		cli, project := d.Layers[0], d.Layers[1]
+		assert.Equal(t, cfgstore.CLIConfigDirType, cli.DirType)
+		assert.Equal(t, cfgstore.ProjectConfigDirType, project.DirType)
+		assert.True(t, cli.Exists)
+		assert.Equal(t, int64(len(`{"name":"cli"}`)), cli.Size)
+		assert.NotZero(t, cli.ModTime)
+		assert.True(t, cli.Writable)
+		assert.Empty(t, cli.Findings)
+		fp, err := stores.CLIConfigStore().GetFilepath()
+		require.NoError(t, err)
+		assert.Equal(t, fp, cli.Filepath)
+
+		require.Len(t, project.Findings, 1)
+		assert.ErrorIs(t, project.Findings[0], cfgstore.ErrFailedToUnmarshalConfigFile)
+		assert.Equal(t, cfgstore.CorruptErrorKind, cfgstore.ErrorKindOf(project.Findings[0]))
+
+		err = d.Err()
+		assert.ErrorIs(t, err, cfgstore.ErrFailedToUnmarshalConfigFile)
+		assert.Equal(t, runtime.GOOS, d.Environment.GOOS)
+		assert.Empty(t, d.Environment.Vars)
+	})
+
+	t.Run("world-writable file", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("file modes are not enforced on Windows")
+		}
+		stores := cstest.NewFixture(t).WithCLIConfig(`{}`).WithDirTypes().Stores()
+		fp, err := stores.CLIConfigStore().GetFilepath()
+		require.NoError(t, err)
+		require.NoError(t, os.Chmod(string(fp), 0666))
+
+		d := cfgstore.Diagnose(stores)
+		assert.ErrorIs(t, d.Err(), cfgstore.ErrConfigFileWorldWritable)
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		t.Parallel()
+		env := map[string]string{
+			"XDG_CONFIG_HOME": "/xdg/config",
+			"FLATPAK_ID":      "org.example.App",
+		}
+		d := cfgstore.Diagnose(cstest.NewFixture(t).InMemory().Stores(), cfgstore.DiagnoseOptions{
+			LookupEnv: func(name string) (value string, ok bool) {
+				value, ok = env[name]
+				return value, ok
+			},
+		})
+		assert.Equal(t, []cfgstore.EnvVar{{Name: "XDG_CONFIG_HOME", Value: "/xdg/config"}}, d.Environment.Vars)
+		assert.Equal(t, "flatpak", d.Environment.Sandbox)
+		assert.NoError(t, d.Err())
+		for _, layer := range d.Layers {
+			assert.False(t, layer.Exists)
+			assert.True(t, layer.Writable)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("layers", func(t *testing.T) {
+		t.Parallel()
+		stores, _ := newLayeredStores(t)
+
+		changes, err := cfgstore.Diff(stores.CLIConfigStore(), stores.ProjectConfigStore())
+		require.NoError(t, err)
+		assert.Equal(t, []cfgstore.Change{
+			{Path: "name", Kind: cfgstore.ChangedChangeKind, Old: "cli", New: "project"},
+			{Path: "theme", Kind: cfgstore.RemovedChangeKind, Old: "dark"},
+		}, changes)
+	})
+
+	t.Run("missing file is empty", func(t *testing.T) {
+		t.Parallel()
+		stores, _ := newLayeredStores(t)
+		missing := cfgstore.SubStore(stores.ProjectConfigStore(), "missing.json")
+
+		changes, err := cfgstore.Diff(missing, stores.ProjectConfigStore())
+		require.NoError(t, err)
+		assert.Equal(t, []cfgstore.Change{
+			{Path: "name", Kind: cfgstore.AddedChangeKind, New: "project"},
+		}, changes)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		changes, err := cfgstore.DiffJSON(
+			[]byte(`{"port":1.0,"tags":["a"],"server":{"host":"a"}}`),
+			[]byte(`{"port":1,"tags":["a","b"],"server":{}}`),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []cfgstore.Change{
+			{Path: "tags.1", Kind: cfgstore.AddedChangeKind, New: "b"},
+			{Path: "server", Kind: cfgstore.AddedChangeKind, New: map[string]any{}},
+			{Path: "server.host", Kind: cfgstore.RemovedChangeKind, Old: "a"},
+		}, changes)
+
+		_, err = cfgstore.DiffJSON([]byte(`{`), nil)
+		assert.ErrorIs(t, err, cfgstore.ErrFailedToDiffConfig)
+	})
+}
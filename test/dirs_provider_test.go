@@ -1,7 +1,10 @@
 package test
 
 import (
+	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/mikeschinkel/go-cfgstore"
@@ -265,3 +268,141 @@ func TestNewTestDirsProvider_EmptyProjectDir(t *testing.T) {
 	_, err := provider.ProjectDirFunc()
 	assert.Error(t, err, "Empty project directory should cause error")
 }
+
+func TestNewTestRoot(t *testing.T) {
+	t.Run("sub/test name", func(t *testing.T) {
+		t.Parallel()
+		a := cstest.NewTestRoot(t)
+		b := cstest.NewTestRoot(t)
+		assert.NotEqual(t, a, b)
+		assert.Equal(t, "TestNewTestRoot_sub_test_name", filepath.Base(string(a)))
+	})
+}
+
+func TestTestDirsProviderArgs_ConcurrentWithoutTestRoot(t *testing.T) {
+	var wg sync.WaitGroup
+
+	args := cstest.NewTestDirsProviderArgs(t)
+	provider := cstest.NewTestDirsProvider(args)
+	for range 8 {
+		wg.Go(func() {
+			_, err := provider.CLIConfigDirFunc()
+			assert.NoError(t, err)
+			dir, err := args.WithoutTestRoot(provider.CLIConfigDirFunc)
+			assert.NoError(t, err)
+			assert.NotContains(t, string(dir), string(args.TestRoot))
+		})
+	}
+	wg.Wait()
+}
+
+func TestNewTestDirsProvider_UserCacheDir(t *testing.T) {
+	args := cstest.NewTestDirsProviderArgs(t)
+	provider := cstest.NewTestDirsProvider(args)
+
+	cacheDir, err := provider.UserCacheDirFunc()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(cacheDir), string(args.TestRoot)))
+	switch runtime.GOOS {
+	case "windows":
+		assert.Contains(t, string(cacheDir), `AppData\Local`)
+	case "darwin":
+		assert.Contains(t, string(cacheDir), "Library/Caches")
+	default:
+		assert.Contains(t, string(cacheDir), ".cache")
+	}
+
+	appDir, err := cfgstore.GetAppCacheDir("acme", "cli", cfgstore.CacheOptions{
+		DirsProvider: provider,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, dt.DirPathJoin3(cacheDir, "acme", "cli"), appDir)
+}
+
+func TestGetProjectCacheDir(t *testing.T) {
+	args := cstest.NewTestDirsProviderArgs(t)
+	provider := cstest.NewTestDirsProvider(args)
+	projectConfigDir, err := cfgstore.ProjectConfigDir("acme", provider)
+	require.NoError(t, err)
+
+	dir, err := cfgstore.GetProjectCacheDir("acme", cfgstore.CacheOptions{DirsProvider: provider})
+	require.NoError(t, err)
+	assert.Equal(t, dt.DirPathJoin(projectConfigDir, "cache"), dir)
+	assert.Equal(t, dir, cstest.NewTemplateData(args, provider).ProjectCacheDir)
+
+	dir, err = cfgstore.GetProjectCacheDir("acme", cfgstore.CacheOptions{
+		DirsProvider:       provider,
+		ProjectCacheSubdir: "build-cache",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, dt.DirPathJoin(projectConfigDir, "build-cache"), dir)
+
+	store := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{
+		Slug:         "acme",
+		Project:      true,
+		RelFilepath:  "objects/abc",
+		DirsProvider: provider,
+	})
+	fp, err := store.GetFilepath()
+	require.NoError(t, err)
+	assert.Equal(t, dt.FilepathJoin3(projectConfigDir, "cache", "objects/abc"), fp)
+
+	_, err = cfgstore.GetProjectCacheDir("acme", cfgstore.CacheOptions{
+		DirsProvider: cstest.NewFailingDirsProvider(provider).FailProjectDir(nil).Build(),
+	})
+	assert.ErrorIs(t, err, cstest.ErrInjected)
+}
+
+func TestNewTestDirsProvider_SimulatedGOOS(t *testing.T) {
+	tests := []struct {
+		goos      string
+		configDir dt.DirPath
+		cacheDir  dt.DirPath
+		relDir    string
+	}{
+		{
+			goos:      "windows",
+			configDir: `C:\Users\coyote\AppData\Roaming`,
+			cacheDir:  `C:\Users\coyote\AppData\Local`,
+			relDir:    "Users/coyote/AppData/Roaming",
+		},
+		{
+			goos:      "darwin",
+			configDir: "/Users/coyote/Library/Application Support",
+			cacheDir:  "/Users/coyote/Library/Caches",
+			relDir:    "Users/coyote/Library/Application Support",
+		},
+		{
+			goos:      "linux",
+			configDir: "/home/coyote/.config",
+			cacheDir:  "/home/coyote/.cache",
+			relDir:    "home/coyote/.config",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			args := cstest.NewTestDirsProviderArgs(t)
+			args.GOOS = tt.goos
+			provider := cstest.NewTestDirsProvider(args)
+
+			configDir, err := args.WithoutTestRoot(provider.UserConfigDirFunc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.configDir, configDir)
+			cacheDir, err := args.WithoutTestRoot(provider.UserCacheDirFunc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.cacheDir, cacheDir)
+
+			// Within the test root the same layout uses the host's separators
+			configDir, err = provider.UserConfigDirFunc()
+			require.NoError(t, err)
+			assert.Equal(t, dt.DirPathJoin(args.TestRoot, filepath.FromSlash(tt.relDir)), configDir)
+
+			cs := cfgstore.NewConfigStore(cfgstore.AppConfigDirType, cfgstore.ConfigStoreArgs{
+				ConfigSlug:   args.ConfigSlug,
+				RelFilepath:  "config.json",
+				DirsProvider: provider,
+			})
+			require.NoError(t, cs.SaveJSON(&testData{Name: "Wile"}))
+		})
+	}
+}
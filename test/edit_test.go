@@ -0,0 +1,121 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// editorScript writes a shell script that replaces the file it is given with
+// the next of contents each time it runs, and returns its path.
+func editorScript(t *testing.T, contents ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\nn=$(cat \"" + dir + "/n\" 2>/dev/null || echo 0)\necho $((n+1)) > \"" + dir + "/n\"\ncase $n in\n"
+	for i, content := range contents {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, string(rune('0'+i))), []byte(content), 0644))
+		script += "  " + string(rune('0'+i)) + ") cp \"" + dir + "/" + string(rune('0'+i)) + "\" \"$1\" ;;\n"
+	}
+	script += "esac\n"
+	fp := filepath.Join(dir, "editor.sh")
+	require.NoError(t, os.WriteFile(fp, []byte(script), 0755))
+	return fp
+}
+
+func TestEditInteractive(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("editor scripts need a POSIX shell")
+	}
+
+	t.Run("saves valid edits", func(t *testing.T) {
+		t.Parallel()
+		cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+		changed, err := cfgstore.EditInteractive(cs, cfgstore.ValidateJSONAs[testData](), cfgstore.EditOptions{
+			Editor: editorScript(t, `{"Name":"Alice","Age":30}`),
+		})
+		require.NoError(t, err)
+		assert.True(t, changed)
+
+		var data testData
+		require.NoError(t, cs.LoadJSON(&data))
+		assert.Equal(t, testData{Name: "Alice", Age: 30}, data)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		t.Parallel()
+		cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+		changed, err := cfgstore.EditInteractive(cs, nil, cfgstore.EditOptions{Editor: "true"})
+		require.NoError(t, err)
+		assert.False(t, changed)
+		assert.False(t, cs.Exists())
+	})
+
+	t.Run("invalid edits are not saved", func(t *testing.T) {
+		t.Parallel()
+		cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, cs.SaveJSON(&testData{Name: "Alice"}))
+		before, err := cs.Load()
+		require.NoError(t, err)
+
+		for _, content := range []string{`{"Name":`, `{"Name":"Bob","Extra":1}`} {
+			changed, editErr := cfgstore.EditInteractive(cs, cfgstore.ValidateJSONAs[testData](), cfgstore.EditOptions{
+				Editor: editorScript(t, content),
+			})
+			require.ErrorIs(t, editErr, cfgstore.ErrInvalidEdit)
+			assert.False(t, changed)
+
+			after, err := cs.Load()
+			require.NoError(t, err)
+			assert.Equal(t, before, after)
+
+			// The edits are kept for the user to recover
+			edited, ok := cfgstore.ErrValue[string](editErr, "edited_file")
+			require.True(t, ok)
+			data, err := os.ReadFile(edited)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(data))
+			require.NoError(t, os.Remove(edited))
+		}
+	})
+
+	t.Run("retry reopens the editor", func(t *testing.T) {
+		t.Parallel()
+		cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+		var retries []error
+		changed, err := cfgstore.EditInteractive(cs, nil, cfgstore.EditOptions{
+			Editor: editorScript(t, `{"Name":`, `{"Name":"Carol"}`),
+			Retry: func(err error) bool {
+				retries = append(retries, err)
+				return true
+			},
+		})
+		require.NoError(t, err)
+		assert.True(t, changed)
+		require.Len(t, retries, 1)
+		assert.ErrorIs(t, retries[0], cfgstore.ErrInvalidEdit)
+
+		name, _, err := cfgstore.GetString(cs, "Name")
+		require.NoError(t, err)
+		assert.Equal(t, "Carol", name)
+	})
+
+	t.Run("no editor", func(t *testing.T) {
+		t.Parallel()
+		cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+		_, err := cfgstore.EditInteractive(cs, nil, cfgstore.EditOptions{
+			LookupEnv: func(string) (string, bool) { return "", false },
+		})
+		assert.ErrorIs(t, err, cfgstore.ErrNoEditor)
+
+		_, err = cfgstore.EditInteractive(cs, nil, cfgstore.EditOptions{Editor: "false"})
+		assert.True(t, errors.Is(err, cfgstore.ErrEditorFailed))
+	})
+}
@@ -0,0 +1,52 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("creates then loads", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+
+		rc, created, err := cfgstore.EnsureConfig[testRootConfig](store, nil)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.NotNil(t, rc)
+		assert.True(t, store.Exists())
+
+		require.NoError(t, store.Save([]byte(`{"name":"saved"}`)))
+		rc, created, err = cfgstore.EnsureConfig[testRootConfig](store, nil)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, "saved", rc.Name)
+	})
+
+	t.Run("other stores", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewRecordingStore(cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json"))
+
+		_, created, err := cfgstore.EnsureConfig[testRootConfig](store, nil)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.True(t, store.Exists())
+	})
+
+	t.Run("corrupt file", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, store.Save([]byte(`{`)))
+
+		_, created, err := cfgstore.EnsureConfig[testRootConfig](store, nil)
+		assert.ErrorIs(t, err, cfgstore.ErrFailedToEnsureConfig)
+		assert.Equal(t, cfgstore.CorruptErrorKind, cfgstore.ErrorKindOf(err))
+		assert.False(t, created)
+	})
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnvHook(t *testing.T) {
+	t.Setenv("CFGSTORE_TEST_HOME", "/home/coyote")
+	t.Setenv("CFGSTORE_TEST_EMPTY", "")
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "Variable", value: "${CFGSTORE_TEST_HOME}/cache", want: "/home/coyote/cache"},
+		{name: "Default for unset", value: "${CFGSTORE_TEST_UNSET:-http://localhost}", want: "http://localhost"},
+		{name: "Default for empty", value: "${CFGSTORE_TEST_EMPTY:-x}", want: "x"},
+		{name: "Unset without default", value: "a${CFGSTORE_TEST_UNSET}b", want: "ab"},
+		{name: "Escaped", value: "$${CFGSTORE_TEST_HOME}", want: "${CFGSTORE_TEST_HOME}"},
+		{name: "Without braces", value: "$CFGSTORE_TEST_HOME", want: "$CFGSTORE_TEST_HOME"},
+		{name: "Not a name", value: "${{ matrix.os }}", want: "${{ matrix.os }}"},
+		{name: "Unterminated", value: "${CFGSTORE_TEST_HOME", want: "${CFGSTORE_TEST_HOME"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := newValuesStore(t, "")
+			require.NoError(t, cfgstore.SetValue(cs, "value", tt.value))
+			require.NoError(t, cfgstore.SetValue(cs, "${CFGSTORE_TEST_HOME}", 1))
+			require.NoError(t, cfgstore.AddPreLoadHook(cs, cfgstore.ExpandEnvHook()))
+
+			cfg := map[string]any{}
+			require.NoError(t, cs.LoadJSON(&cfg))
+			assert.Equal(t, tt.want, cfg["value"])
+			// Keys are never expanded
+			assert.Contains(t, cfg, "${CFGSTORE_TEST_HOME}")
+		})
+	}
+}
+
+func TestExpandEnvHook_Strict(t *testing.T) {
+	cs := newValuesStore(t, `{"url":"${CFGSTORE_TEST_UNSET}"}`)
+	require.NoError(t, cfgstore.AddPreLoadHook(cs, cfgstore.ExpandEnvHook(cfgstore.ExpandEnvOptions{
+		Strict: true,
+	})))
+
+	_, err := cs.Load()
+	assert.ErrorIs(t, err, cfgstore.ErrPreLoadHookFailed)
+	assert.ErrorIs(t, err, cfgstore.ErrEnvVarNotSet)
+}
+
+func TestExpandEnvHook_EditsKeepReferences(t *testing.T) {
+	t.Setenv("CFGSTORE_TEST_TOKEN", "s3cret")
+	cs := newValuesStore(t, `{"token":"${CFGSTORE_TEST_TOKEN}"}`)
+	require.NoError(t, cfgstore.AddPreLoadHook(cs, cfgstore.ExpandEnvHook()))
+
+	require.NoError(t, cfgstore.SetValue(cs, "theme", "dark"))
+	require.NoError(t, cfgstore.UpdateJSON(cs, func(cfg *map[string]any) error {
+		(*cfg)["debug"] = true
+		return nil
+	}))
+
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+	data, err := cs.FileSystem().ReadFile(fp)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"token":"${CFGSTORE_TEST_TOKEN}","theme":"dark","debug":true}`, string(data))
+
+	token, _, err := cfgstore.GetString(cs, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", token, "reads should still be expanded")
+}
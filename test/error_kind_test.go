@@ -0,0 +1,146 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errnoFileSystem fails every write with errno, as the OS would.
+type errnoFileSystem struct {
+	cfgstore.FileSystem
+	errno syscall.Errno
+}
+
+func (e errnoFileSystem) WriteFile(fp dt.Filepath, _ []byte) error {
+	return &fs.PathError{Op: "write", Path: string(fp), Err: e.errno}
+}
+
+func TestErrorKind(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		want cfgstore.ErrorKind
+		is   func(error) bool
+		err  func(t *testing.T, cs cfgstore.ConfigStore) error
+	}{
+		{
+			name: "not found",
+			want: cfgstore.NotFoundErrorKind,
+			is:   cfgstore.IsNotExist,
+			err: func(t *testing.T, cs cfgstore.ConfigStore) error {
+				return cs.LoadJSON(&testData{})
+			},
+		},
+		{
+			name: "corrupt",
+			want: cfgstore.CorruptErrorKind,
+			is:   cfgstore.IsCorrupt,
+			err: func(t *testing.T, cs cfgstore.ConfigStore) error {
+				require.NoError(t, cs.Save([]byte(`{"Name":`)))
+				return cs.LoadJSON(&testData{})
+			},
+		},
+		{
+			name: "conflict",
+			want: cfgstore.ConflictErrorKind,
+			is:   cfgstore.IsConflict,
+			err: func(t *testing.T, cs cfgstore.ConfigStore) error {
+				fp, err := cs.GetFilepath()
+				require.NoError(t, err)
+				unlock, err := cs.FileSystem().Lock(fp, time.Second)
+				require.NoError(t, err)
+				defer unlock()
+				return cfgstore.UpdateJSON(cs, func(*testData) error {
+					return nil
+				}, cfgstore.UpdateOptions{LockTimeout: 20 * time.Millisecond})
+			},
+		},
+		{
+			name: "permission denied",
+			want: cfgstore.PermissionDeniedErrorKind,
+			is:   cfgstore.IsPermission,
+			err:  saveWithErrno(syscall.EACCES),
+		},
+		{
+			name: "read-only",
+			want: cfgstore.ReadOnlyErrorKind,
+			is:   cfgstore.IsReadOnly,
+			err:  saveWithErrno(syscall.EROFS),
+		},
+		{
+			name: "remote unavailable",
+			want: cfgstore.RemoteUnavailableErrorKind,
+			is:   cfgstore.IsRemoteUnavailable,
+			err:  saveWithErrno(syscall.ECONNREFUSED),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+			err := tt.err(t, cs)
+			require.Error(t, err)
+
+			var kind cfgstore.ErrorKind
+			require.True(t, errors.As(err, &kind), "errors.As should find the kind")
+			assert.Equal(t, tt.want, kind)
+			assert.ErrorIs(t, err, tt.want)
+			assert.Equal(t, tt.want, cfgstore.ErrorKindOf(err))
+			assert.True(t, tt.is(err))
+		})
+	}
+}
+
+// saveWithErrno returns a test case that saves through a FileSystem failing
+// with errno.
+func saveWithErrno(errno syscall.Errno) func(*testing.T, cfgstore.ConfigStore) error {
+	return func(t *testing.T, cs cfgstore.ConfigStore) error {
+		dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+		dp.FileSystem = errnoFileSystem{FileSystem: cfgstore.OSFileSystem(), errno: errno}
+		cs = cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+			cfgstore.WithSlug(TestConfigSlug),
+			cfgstore.WithRelFilepath("config.json"),
+			cfgstore.WithDirsProvider(dp),
+		)
+		return cs.SaveJSON(&testData{Name: "Alice"})
+	}
+}
+
+func TestErrorKindOf(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, cfgstore.UnknownErrorKind, cfgstore.ErrorKindOf(nil))
+	assert.Equal(t, cfgstore.UnknownErrorKind, cfgstore.ErrorKindOf(errors.New("boom")))
+	assert.Equal(t, cfgstore.NotFoundErrorKind, cfgstore.ErrorKindOf(fs.ErrNotExist))
+
+	// A kind carried in the chain wins over the one its causes indicate
+	err := cfgstore.WithErr(fs.ErrNotExist, cfgstore.RemoteUnavailableErrorKind, "backend", "s3")
+	assert.Equal(t, cfgstore.RemoteUnavailableErrorKind, cfgstore.ErrorKindOf(err))
+
+	assert.Equal(t, "not_found", cfgstore.NotFoundErrorKind.Slug())
+	assert.Equal(t, "remote unavailable", cfgstore.RemoteUnavailableErrorKind.String())
+	assert.Equal(t, "invalid", cfgstore.ErrorKind(99).Slug())
+}
+
+func TestIsAlreadyExists(t *testing.T) {
+	t.Parallel()
+	store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, store.Save([]byte(`{}`)))
+	var buf bytes.Buffer
+	require.NoError(t, cfgstore.ExportArchive(store, &buf))
+
+	_, err := cfgstore.ImportArchive(store, &buf)
+	assert.True(t, cfgstore.IsAlreadyExists(err))
+	assert.True(t, cfgstore.IsConflict(err))
+	assert.False(t, cfgstore.IsNotExist(err))
+	assert.False(t, cfgstore.IsAlreadyExists(nil))
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt/dtx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventRecorder collects Events for the test that registered it.
+type eventRecorder struct {
+	mutex  sync.Mutex
+	events []cfgstore.Event
+}
+
+func recordEvents(t *testing.T) *eventRecorder {
+	r := &eventRecorder{}
+	remove := cfgstore.AddEventListener(func(ev cfgstore.Event) {
+		r.mutex.Lock()
+		r.events = append(r.events, ev)
+		r.mutex.Unlock()
+	})
+	t.Cleanup(remove)
+	return r
+}
+
+func (r *eventRecorder) kinds() (kinds []cfgstore.EventKind) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, ev := range r.events {
+		kinds = append(kinds, ev.Kind)
+	}
+	return kinds
+}
+
+func TestEvents_SaveAndLoad(t *testing.T) {
+	testRoot := dtx.TempTestDir(t)
+	cs, _ := getConfigStore("config.json", testRoot, cfgstore.DefaultConfigDirType)
+	t.Cleanup(cleanupFunc(t, cs))
+
+	rec := recordEvents(t)
+
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Dave"}))
+	require.NoError(t, cs.LoadJSON(&testData{}))
+
+	other, _ := getConfigStore("missing.json", testRoot, cfgstore.DefaultConfigDirType)
+	assert.Error(t, other.LoadJSON(&testData{}))
+
+	assert.Equal(t, []cfgstore.EventKind{
+		cfgstore.SaveEventKind,
+		cfgstore.LoadEventKind,
+		cfgstore.LoadEventKind,
+	}, rec.kinds())
+
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+	assert.Equal(t, fp, rec.events[0].Filepath)
+	assert.Equal(t, cfgstore.DefaultConfigDirType, rec.events[0].DirType)
+	assert.NoError(t, rec.events[1].Err)
+	assert.ErrorIs(t, rec.events[2].Err, cfgstore.ErrFileDoesNotExist)
+}
+
+func TestEvents_CreateAndMerge(t *testing.T) {
+	testRoot := dtx.TempTestDir(t)
+	dp := cstest.NewTestDirsProvider(newTestDirsProviderArgs(testRoot))
+
+	rec := recordEvents(t)
+
+	_, err := cfgstore.LoadCLIConfig[testRootConfig](cfgstore.LoadConfigArgs{
+		ConfigSlug:   TestConfigSlug,
+		ConfigFile:   "config.json",
+		DirsProvider: dp,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []cfgstore.EventKind{
+		cfgstore.SaveEventKind,
+		cfgstore.CreateEventKind,
+		cfgstore.MergeEventKind,
+	}, rec.kinds())
+	assert.Equal(t, []cfgstore.DirType{cfgstore.CLIConfigDirType}, rec.events[2].DirTypes)
+
+	remove := cfgstore.AddEventListener(func(cfgstore.Event) {
+		t.Error("removed listener should not be called")
+	})
+	remove()
+	_, err = cfgstore.LoadCLIConfig[testRootConfig](cfgstore.LoadConfigArgs{
+		ConfigSlug:   TestConfigSlug,
+		ConfigFile:   "config.json",
+		DirsProvider: dp,
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exampleServer struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+// exampleRootConfig is a RootConfig whose Normalize fills in defaults.
+type exampleRootConfig struct {
+	Theme  string        `json:"theme"`
+	Server exampleServer `json:"server"`
+}
+
+func (c *exampleRootConfig) RootConfig() {}
+
+func (c *exampleRootConfig) Normalize(cfgstore.NormalizeArgs) error {
+	if c.Theme == "" {
+		c.Theme = "light"
+	}
+	if c.Server.Port == 0 {
+		c.Server.Port = 8080
+	}
+	return nil
+}
+
+func (c *exampleRootConfig) Merge(rc cfgstore.RootConfig) cfgstore.RootConfig {
+	return c
+}
+
+func TestWriteExample(t *testing.T) {
+	t.Parallel()
+	store := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+		cfgstore.WithSlug(TestConfigSlug),
+		cfgstore.WithRelFilepath("config.json"),
+		cfgstore.WithDirsProvider(cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))),
+		cfgstore.WithExample(map[string]string{
+			"server.port":  "Port to listen on",
+			"server.proxy": "Not in the defaults, so ignored",
+		}),
+	)
+	fp, err := store.GetFilepath()
+	require.NoError(t, err)
+	example := cfgstore.SubStore(store, "config.example.json")
+	exampleFp, err := example.GetFilepath()
+	require.NoError(t, err)
+	assert.Equal(t, exampleFp, cfgstore.ExampleFilepath(fp))
+
+	_, created, err := cfgstore.EnsureConfig[exampleRootConfig](store, nil)
+	require.NoError(t, err)
+	assert.True(t, created)
+	data, err := example.Load()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"_comment": "Example acme settings with their defaults. Copy the ones to change into config.json; this file is rewritten and never read.",
+		"theme": "light",
+		"server": {"host": "", "_comment_port": "Port to listen on", "port": 8080}
+	}`, string(data))
+
+	// The user's settings never reach the example, which is rewritten if it
+	// was changed
+	require.NoError(t, store.Save([]byte(`{"theme":"dark"}`)))
+	require.NoError(t, example.Save([]byte(`{}`)))
+	cfg, created, err := cfgstore.EnsureConfig[exampleRootConfig](store, nil)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "dark", cfg.Theme)
+	updated, err := example.Load()
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(updated))
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailingDirsProvider(t *testing.T) {
+	errCustom := errors.New("custom")
+	base := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+
+	tests := []struct {
+		name    string
+		build   func(*cstest.FailingDirsProvider) *cstest.FailingDirsProvider
+		dirType cfgstore.DirType
+		wantErr []error
+	}{
+		{
+			name:    "User config dir",
+			build:   func(b *cstest.FailingDirsProvider) *cstest.FailingDirsProvider { return b.FailUserConfigDir(nil) },
+			dirType: cfgstore.AppConfigDirType,
+			wantErr: []error{cfgstore.ErrFailedGettingUserConfigDir, cstest.ErrInjected},
+		},
+		{
+			name:    "Project dir",
+			build:   func(b *cstest.FailingDirsProvider) *cstest.FailingDirsProvider { return b.FailProjectDir(errCustom) },
+			dirType: cfgstore.ProjectConfigDirType,
+			wantErr: []error{cfgstore.ErrFailedGettingWorkingDir, errCustom},
+		},
+		{
+			name:    "CLI config dir",
+			build:   func(b *cstest.FailingDirsProvider) *cstest.FailingDirsProvider { return b.FailCLIConfigDir(nil) },
+			dirType: cfgstore.CLIConfigDirType,
+			wantErr: []error{cstest.ErrInjected},
+		},
+		{
+			name:    "Other funcs are unaffected",
+			build:   func(b *cstest.FailingDirsProvider) *cstest.FailingDirsProvider { return b.FailUserCacheDir(nil) },
+			dirType: cfgstore.CLIConfigDirType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := tt.build(cstest.NewFailingDirsProvider(base)).Build()
+			_, err := cfgstore.ConfigDir(tt.dirType, TestConfigSlug, dp)
+			if len(tt.wantErr) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			for _, want := range tt.wantErr {
+				assert.ErrorIs(t, err, want)
+			}
+		})
+	}
+
+	_, err := cfgstore.GetSharedCacheDir("acme", cfgstore.CacheOptions{
+		DirsProvider: cstest.NewFailingDirsProvider(base).FailUserCacheDir(nil).Build(),
+	})
+	assert.ErrorIs(t, err, cfgstore.ErrFailedGettingUserCacheDir)
+}
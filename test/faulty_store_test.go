@@ -0,0 +1,72 @@
+package test
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFaultyStore(t *testing.T) *cstest.FaultyStore {
+	stores := cstest.NewFixture(t).InMemory().WithCLIConfig(`{"name":"cli"}`).Stores()
+	return cstest.NewFaultyStore(stores.CLIConfigStore())
+}
+
+func TestFaultyStore_FailNthSave(t *testing.T) {
+	t.Parallel()
+	store := newFaultyStore(t).FailNthSave(2, nil)
+
+	require.NoError(t, store.SaveJSON(&testRootConfig{Name: "first"}))
+	err := store.SaveJSON(&testRootConfig{Name: "second"})
+	assert.True(t, errors.Is(err, syscall.ENOSPC))
+	assert.Equal(t, 2, store.Saves())
+
+	var rc testRootConfig
+	require.NoError(t, store.LoadJSON(&rc))
+	assert.Equal(t, "first", rc.Name, "a failed save should not write")
+}
+
+func TestFaultyStore_FailNthSaveMidWrite(t *testing.T) {
+	t.Parallel()
+	store := newFaultyStore(t).FailNthSaveMidWrite(1)
+
+	err := store.Save([]byte(`{"name":"truncated"}`))
+	assert.True(t, errors.Is(err, syscall.ENOSPC))
+
+	var rc testRootConfig
+	err = store.LoadJSON(&rc)
+	assert.True(t, errors.Is(err, cfgstore.ErrFailedToUnmarshalConfigFile))
+}
+
+func TestFaultyStore_FailLoads(t *testing.T) {
+	t.Parallel()
+	store := newFaultyStore(t).FailLoads(nil)
+
+	_, err := store.Load()
+	assert.True(t, errors.Is(err, fs.ErrPermission))
+	var rc testRootConfig
+	assert.True(t, errors.Is(store.LoadJSON(&rc), fs.ErrPermission))
+
+	store.ClearFaults()
+	require.NoError(t, store.LoadJSON(&rc))
+	assert.Equal(t, "cli", rc.Name)
+	assert.Equal(t, 3, store.Loads())
+}
+
+func TestFaultyStore_CorruptLoads(t *testing.T) {
+	t.Parallel()
+	store := newFaultyStore(t).CorruptLoads(nil)
+
+	data, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, `{"name"`, string(data))
+
+	var rc testRootConfig
+	err = store.LoadJSON(&rc)
+	assert.True(t, errors.Is(err, cfgstore.ErrFailedToUnmarshalConfigFile))
+}
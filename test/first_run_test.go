@@ -0,0 +1,78 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnFirstRun(t *testing.T) {
+	t.Parallel()
+
+	load := func(fix *cstest.Fixture, fn cfgstore.FirstRunFunc) error {
+		_, err := cfgstore.LoadConfig[testRootConfig](cfgstore.LoadConfigArgs{
+			ConfigSlug:   TestConfigSlug,
+			ConfigFile:   cstest.DefaultFixtureConfigFile,
+			DirsProvider: fix.DirsProvider(),
+			OnFirstRun:   fn,
+		})
+		return err
+	}
+
+	t.Run("called once", func(t *testing.T) {
+		t.Parallel()
+		fix := cstest.NewFixture(t)
+		var calls int
+		var args cfgstore.FirstRunArgs
+		fn := func(a cfgstore.FirstRunArgs) error {
+			calls++
+			args = a
+			return nil
+		}
+
+		require.NoError(t, load(fix, fn))
+		require.NoError(t, load(fix, fn))
+		assert.Equal(t, 1, calls)
+		assert.NotNil(t, args.RootConfig)
+		assert.FileExists(t, string(args.MarkerFilepath))
+	})
+
+	t.Run("existing config", func(t *testing.T) {
+		t.Parallel()
+		fix := cstest.NewFixture(t).WithCLIConfig(&testRootConfig{Name: "cli"})
+		fix.Stores()
+		var calls int
+		fn := func(cfgstore.FirstRunArgs) error {
+			calls++
+			return nil
+		}
+
+		require.NoError(t, load(fix, fn))
+		assert.Equal(t, 0, calls, "users from before OnFirstRun are not onboarded")
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		t.Parallel()
+		fix := cstest.NewFixture(t)
+		var calls int
+		fn := func(cfgstore.FirstRunArgs) error {
+			calls++
+			if calls == 1 {
+				return errors.New("interrupted")
+			}
+			return nil
+		}
+
+		err := load(fix, fn)
+		assert.ErrorIs(t, err, cfgstore.ErrFirstRunFuncFailed)
+		// The config was created by the failed load, but the first run was only
+		// recorded as pending so it is retried
+		require.NoError(t, load(fix, fn))
+		require.NoError(t, load(fix, fn))
+		assert.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,48 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixture_Stores(t *testing.T) {
+	fix := cstest.NewFixture(t).
+		WithCLIConfig(`{"name":"cli","theme":"dark"}`).
+		WithProjectConfig(&testRootConfig{Name: "project"}).
+		WithTokenFile("alice.json", map[string]string{"token": "abc"})
+	stores := fix.Stores()
+
+	assert.Equal(t, []cfgstore.DirType{cfgstore.CLIConfigDirType, cfgstore.ProjectConfigDirType}, stores.DirTypes)
+	assert.Same(t, stores, fix.Stores(), "Stores() should build the fixture only once")
+
+	rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, fix.RootConfigArgs())
+	require.NoError(t, err)
+	assert.Equal(t, &testRootConfig{Name: "project", Theme: "dark"}, rc)
+
+	token, found, err := cfgstore.GetString(cfgstore.SubStore(stores.CLIConfigStore(), "tokens/alice.json"), "token")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "abc", token)
+}
+
+func TestFixture_InMemory(t *testing.T) {
+	t.Parallel()
+	fix := cstest.NewFixture(t).
+		InMemory().
+		WithProjectConfig(`{"name":"project"}`).
+		WithCLIConfig(&testRootConfig{Name: "cli", Theme: "light"})
+	stores := fix.Stores()
+
+	assert.Equal(t, []cfgstore.DirType{cfgstore.CLIConfigDirType, cfgstore.ProjectConfigDirType}, stores.DirTypes)
+	mfs, ok := fix.DirsProvider().FileSystem.(*cstest.MemFileSystem)
+	require.True(t, ok)
+	assert.Len(t, mfs.Files(), 2)
+
+	rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, fix.RootConfigArgs())
+	require.NoError(t, err)
+	assert.Equal(t, &testRootConfig{Name: "project", Theme: "light"}, rc)
+}
@@ -1,61 +1,69 @@
 package test
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
 	"github.com/mikeschinkel/go-dt"
 )
 
+func newCLIConfigStore(slug dt.PathSegment) cfgstore.ConfigStore {
+	return cfgstore.NewCLIConfigStore(slug, "")
+}
+
+func newProjectConfigStore(slug dt.PathSegment) cfgstore.ConfigStore {
+	return cfgstore.NewProjectConfigStore(slug, "")
+}
+
 // FuzzNewCLIConfigStore tests creating CLI config stores with various slugs
 func FuzzNewCLIConfigStore(f *testing.F) {
-	// Seed with valid and edge-case slugs
-	f.Add("myapp")
-	f.Add("my-app")
-	f.Add("my_app")
-	f.Add("app123")
-	f.Add("a")
-	f.Add("very-long-application-name-with-many-parts")
-	f.Add("")
-	f.Add(" ")
-	f.Add("app with spaces")
-	f.Add("app/with/slashes")
-	f.Add("app\\with\\backslashes")
-	f.Add("app\nwith\nnewlines")
-	f.Add("../../../etc/passwd")
-	f.Add("~/.ssh")
-
-	f.Fuzz(func(t *testing.T, slug string) {
-		defer func() {
-			if r := recover(); r != nil {
-				t.Errorf("NewCLIConfigStore panicked with slug %q: %v", slug, r)
-			}
-		}()
-
-		// Convert string to PathSegment and create store
-		// Just verify it doesn't panic - some slugs may be invalid and that's OK
-		_ = cfgstore.NewCLIConfigStore(dt.PathSegment(slug), "")
-	})
+	cstest.FuzzSlugs(f, newCLIConfigStore)
 }
 
 // FuzzNewProjectConfigStore tests creating project config stores with various slugs
 func FuzzNewProjectConfigStore(f *testing.F) {
-	f.Add("myapp")
-	f.Add("my-app")
-	f.Add("project123")
-	f.Add("")
-	f.Add(" ")
-	f.Add("project with spaces")
-	f.Add("../parent")
-	f.Add("/absolute/path")
-
-	f.Fuzz(func(t *testing.T, slug string) {
-		defer func() {
-			if r := recover(); r != nil {
-				t.Errorf("NewProjectConfigStore panicked with slug %q: %v", slug, r)
-			}
-		}()
-
-		_ = cfgstore.NewProjectConfigStore(dt.PathSegment(slug), "")
+	cstest.FuzzSlugs(f, newProjectConfigStore)
+}
+
+// FuzzSubStore tests creating sub-stores with various relative filepaths
+func FuzzSubStore(f *testing.F) {
+	cs := cstest.NewTestStore(f, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+	cstest.FuzzRelFilepaths(f, func(t *testing.T, relFilepath dt.RelFilepath) {
+		_, _ = cfgstore.SubStore(cs, relFilepath).GetFilepath()
+	})
+}
+
+// FuzzNewConfigStoreE tests that the stores NewConfigStoreE accepts keep their
+// files within their config dir
+func FuzzNewConfigStoreE(f *testing.F) {
+	for _, slug := range cstest.SlugSeeds {
+		for _, rf := range cstest.RelFilepathSeeds {
+			f.Add(slug, rf)
+		}
+	}
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(f))
+	f.Fuzz(func(t *testing.T, slug, rf string) {
+		cs, err := cfgstore.NewConfigStoreE(cfgstore.CLIConfigDirType,
+			cfgstore.WithSlug(dt.PathSegment(slug)),
+			cfgstore.WithRelFilepath(dt.RelFilepath(rf)),
+			cfgstore.WithDirsProvider(dp),
+		)
+		if err != nil {
+			return
+		}
+		dir, err := cs.ConfigDir()
+		if err != nil {
+			return
+		}
+		fp, err := cs.GetFilepath()
+		if err != nil {
+			return
+		}
+		rel, err := filepath.Rel(string(dir), string(fp))
+		if err != nil || !filepath.IsLocal(rel) {
+			t.Errorf("slug %q and RelFilepath %q lead outside %s to %s", slug, rf, dir, fp)
+		}
 	})
 }
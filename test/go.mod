@@ -3,21 +3,42 @@ module github.com/mikeschinkel/go-cfgstore/test
 go 1.25.3
 
 require (
-	github.com/google/uuid v1.6.0
 	github.com/mikeschinkel/go-cfgstore v0.4.0
+	github.com/mikeschinkel/go-cfgstore/cfgotel v0.0.0
+	github.com/mikeschinkel/go-cfgstore/cfgprom v0.0.0
+	github.com/mikeschinkel/go-cliutil v0.3.0
 	github.com/mikeschinkel/go-dt v0.3.3
 	github.com/mikeschinkel/go-dt/appinfo v0.2.1
 	github.com/mikeschinkel/go-dt/dtx v0.2.1
+	github.com/mikeschinkel/go-logutil v0.2.1
 	github.com/mikeschinkel/go-testutil v0.2.1
-	github.com/stretchr/testify v1.11.1
+	github.com/prometheus/client_golang v1.24.1
+	github.com/stretchr/testify v1.12.1
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/sdk v1.46.0
 )
 
 require (
-	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/mikeschinkel/go-cliutil v0.3.0 // indirect
-	github.com/mikeschinkel/go-logutil v0.2.1 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.2 // indirect
+	github.com/prometheus/common v0.70.1 // indirect
+	github.com/prometheus/procfs v0.21.1 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.46.0 // indirect
+	go.opentelemetry.io/otel/trace v1.46.0 // indirect
+	go.yaml.in/yaml/v3 v3.0.5 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
 )
 
 replace github.com/mikeschinkel/go-cfgstore => ..
+
+replace github.com/mikeschinkel/go-cfgstore/cfgotel => ../cfgotel
+
+replace github.com/mikeschinkel/go-cfgstore/cfgprom => ../cfgprom
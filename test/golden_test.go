@@ -0,0 +1,29 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertStoreMatchesGolden(t *testing.T) {
+	stores := cstest.NewFixture(t).
+		InMemory().
+		WithCLIConfig(`{"name":"cli",  "theme":"dark"}`).
+		Stores()
+	cstest.AssertStoreMatchesGolden(t, stores.CLIConfigStore(), "testdata/golden/cli_config.json")
+}
+
+func TestAssertJSONMatchesGolden_Mismatch(t *testing.T) {
+	ft := &testing.T{}
+	cstest.AssertJSONMatchesGolden(ft, []byte(`{"theme":"dark","name":"cli"}`), "testdata/golden/cli_config.json")
+	assert.True(t, ft.Failed(), "member order differences should fail")
+}
+
+func TestNormalizeJSON(t *testing.T) {
+	got, err := cstest.NormalizeJSON([]byte(`{"b":1,"a":[true, null]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"b\": 1,\n  \"a\": [\n    true,\n    null\n  ]\n}\n", string(got))
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGOOSVariantHook(t *testing.T) {
+	t.Parallel()
+	hook := cfgstore.GOOSVariantHook(cfgstore.GOOSVariantOptions{GOOS: "windows"})
+
+	t.Run("overlaid", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, store.Save([]byte(`{"name":"base","theme":"dark","paths":{"cache":"/tmp","data":"/var"}}`)))
+		require.NoError(t, cfgstore.SubStore(store, "config_windows.json").Save([]byte(`{"theme":null,"paths":{"cache":"C:\\Temp"}}`)))
+		require.NoError(t, cfgstore.AddPreLoadHook(store, hook))
+
+		data, err := store.Load()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"base","paths":{"cache":"C:\\Temp","data":"/var"}}`, string(data))
+	})
+
+	t.Run("not copied into the base file by edits", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, store.Save([]byte(`{"name":"base","theme":"dark"}`)))
+		require.NoError(t, cfgstore.SubStore(store, "config_windows.json").Save([]byte(`{"theme":"light","shell":"pwsh"}`)))
+		require.NoError(t, cfgstore.AddPreLoadHook(store, hook))
+
+		require.NoError(t, cfgstore.SetValue(store, "name", "edited"))
+		require.NoError(t, cfgstore.UpdateJSON(store, func(cfg *map[string]any) error {
+			(*cfg)["version"] = "2"
+			return nil
+		}))
+
+		fp, err := store.GetFilepath()
+		require.NoError(t, err)
+		data, err := store.FileSystem().ReadFile(fp)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"edited","theme":"dark","version":"2"}`, string(data))
+
+		data, err = store.Load()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"edited","theme":"light","shell":"pwsh","version":"2"}`, string(data))
+	})
+
+	t.Run("no variant", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, store.Save([]byte(`{"name":"base"}`)))
+		require.NoError(t, cfgstore.SubStore(store, "config_darwin.json").Save([]byte(`{"name":"darwin"}`)))
+		require.NoError(t, cfgstore.AddPreLoadHook(store, hook))
+
+		var rc testRootConfig
+		require.NoError(t, store.LoadJSON(&rc))
+		assert.Equal(t, "base", rc.Name)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, store.Save([]byte(`{"name":"base"}`)))
+		require.NoError(t, cfgstore.SubStore(store, "config_windows.json").Save([]byte(`["windows"]`)))
+		require.NoError(t, cfgstore.AddPreLoadHook(store, hook))
+
+		_, err := store.Load()
+		assert.ErrorIs(t, err, cfgstore.ErrGOOSVariantNotObject)
+	})
+}
+
+func TestGOOSVariantFilepath(t *testing.T) {
+	assert.Equal(t, dt.Filepath("/etc/acme/config_linux.json"), cfgstore.GOOSVariantFilepath("/etc/acme/config.json", "linux"))
+	assert.Equal(t, dt.Filepath("/etc/acme/acmerc_darwin"), cfgstore.GOOSVariantFilepath("/etc/acme/acmerc", "darwin"))
+}
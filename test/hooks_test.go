@@ -0,0 +1,152 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-dt/dtx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stampedConfig struct {
+	Name       string    `json:"name"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+func TestConfigStore_PreSaveHookMutatesValue(t *testing.T) {
+	var err error
+
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	stamp := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, cfgstore.AddPreSaveHook(cs, func(args *cfgstore.SaveHookArgs) error {
+		cfg, ok := args.Value.(*stampedConfig)
+		if ok {
+			cfg.ModifiedAt = stamp
+		}
+		return nil
+	}))
+
+	var saved []dt.Filepath
+	require.NoError(t, cfgstore.AddPostSaveHook(cs, func(args *cfgstore.SaveHookArgs) error {
+		saved = append(saved, args.Filepath)
+		assert.NotEmpty(t, args.Data)
+		return nil
+	}))
+
+	err = cs.SaveJSON(&stampedConfig{Name: "alice"})
+	require.NoError(t, err)
+
+	var loaded stampedConfig
+	require.NoError(t, cs.LoadJSON(&loaded))
+	assert.Equal(t, stamp, loaded.ModifiedAt)
+
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+	assert.Equal(t, []dt.Filepath{fp}, saved)
+}
+
+func TestConfigStore_PreSaveHookErrorAbortsSave(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	errInvalid := errors.New("name is required")
+	require.NoError(t, cfgstore.AddPreSaveHook(cs, func(args *cfgstore.SaveHookArgs) error {
+		return errInvalid
+	}))
+	require.NoError(t, cfgstore.AddPostSaveHook(cs, func(args *cfgstore.SaveHookArgs) error {
+		t.Error("post-save hook should not run when pre-save fails")
+		return nil
+	}))
+
+	err := cs.SaveJSON(&stampedConfig{})
+	assert.ErrorIs(t, err, cfgstore.ErrPreSaveHookFailed)
+	assert.ErrorIs(t, err, errInvalid)
+	assert.False(t, cs.Exists())
+}
+
+func TestConfigStores_AddPostSaveHookAppliesToAllStores(t *testing.T) {
+	testRoot := dtx.TempTestDir(t)
+	stores := cfgstore.NewConfigStores(cfgstore.ConfigStoresArgs{
+		ConfigStoreArgs: cfgstore.ConfigStoreArgs{
+			ConfigSlug:  "myapp",
+			RelFilepath: "config.json",
+			DirsProvider: cstest.NewTestDirsProvider(&cstest.TestDirsProviderArgs{
+				Username:   "testuser",
+				ProjectDir: "myproject",
+				ConfigSlug: "myapp",
+				TestRoot:   testRoot,
+			}),
+		},
+	})
+
+	var dirTypes []cfgstore.DirType
+	require.NoError(t, stores.AddPostSaveHook(func(args *cfgstore.SaveHookArgs) error {
+		dirTypes = append(dirTypes, args.Store.DirType())
+		return nil
+	}))
+
+	require.NoError(t, stores.CLIConfigStore().Save([]byte(`{}`)))
+	require.NoError(t, stores.ProjectConfigStore().Save([]byte(`{}`)))
+	assert.Equal(t, []cfgstore.DirType{
+		cfgstore.CLIConfigDirType,
+		cfgstore.ProjectConfigDirType,
+	}, dirTypes)
+}
+
+func TestConfigStore_LoadHooks(t *testing.T) {
+	var err error
+
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	// Store the file "encrypted" by reversing its bytes
+	require.NoError(t, cs.Save(reverseBytes([]byte(`{"Name":"Carol","Age":7}`))))
+
+	require.NoError(t, cfgstore.AddPreLoadHook(cs, func(args *cfgstore.LoadHookArgs) error {
+		assert.Nil(t, args.Value)
+		args.Data = reverseBytes(args.Data)
+		return nil
+	}))
+	var values []any
+	require.NoError(t, cfgstore.AddPostLoadHook(cs, func(args *cfgstore.LoadHookArgs) error {
+		values = append(values, args.Value)
+		return nil
+	}))
+
+	var loaded testData
+	err = cs.LoadJSON(&loaded)
+	require.NoError(t, err)
+	assert.Equal(t, testData{Name: "Carol", Age: 7}, loaded)
+
+	data, err := cs.Load()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Name":"Carol","Age":7}`, string(data))
+
+	assert.Equal(t, []any{&loaded, nil}, values)
+}
+
+func TestConfigStore_PreLoadHookErrorFailsLoad(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cs.Save([]byte(`{}`)))
+
+	errDecrypt := errors.New("cannot decrypt")
+	require.NoError(t, cfgstore.AddPreLoadHook(cs, func(args *cfgstore.LoadHookArgs) error {
+		return errDecrypt
+	}))
+
+	err := cs.LoadJSON(&testData{})
+	assert.ErrorIs(t, err, cfgstore.ErrPreLoadHookFailed)
+	assert.ErrorIs(t, err, errDecrypt)
+}
+
+func reverseBytes(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i, c := range b {
+		out[len(b)-1-i] = c
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pathsConfig struct {
+	Paths struct {
+		Base    string `json:"base"`
+		DataDir string `json:"data_dir"`
+	} `json:"paths"`
+	LogFile string   `json:"log_file"`
+	Port    int      `json:"port"`
+	URL     string   `json:"url"`
+	Notes   []string `json:"notes"`
+}
+
+func TestInterpolate(t *testing.T) {
+	cfg := pathsConfig{}
+	cfg.Paths.Base = "/var/lib"
+	cfg.Paths.DataDir = "${paths.base}/app"
+	cfg.LogFile = "${paths.data_dir}/app.log"
+	cfg.Port = 8080
+	cfg.URL = "http://localhost:${/port}"
+	cfg.Notes = []string{"$${paths.base}", "${HOME}", "${paths.missing}"}
+
+	require.NoError(t, cfgstore.Interpolate(&cfg))
+
+	assert.Equal(t, "/var/lib/app", cfg.Paths.DataDir)
+	assert.Equal(t, "/var/lib/app/app.log", cfg.LogFile)
+	assert.Equal(t, "http://localhost:8080", cfg.URL)
+	assert.Equal(t, []string{"${paths.base}", "${HOME}", "${paths.missing}"}, cfg.Notes)
+}
+
+func TestInterpolate_Errors(t *testing.T) {
+	cfg := pathsConfig{}
+	cfg.Paths.Base = "${log_file}"
+	cfg.LogFile = "${paths.data_dir}"
+	cfg.Paths.DataDir = "${paths.base}"
+
+	err := cfgstore.Interpolate(&cfg)
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToInterpolate)
+	assert.ErrorIs(t, err, cfgstore.ErrInvalidInterpolation)
+
+	cfg = pathsConfig{LogFile: "${paths}"}
+	err = cfgstore.Interpolate(&cfg)
+	assert.ErrorIs(t, err, cfgstore.ErrInvalidInterpolation)
+}
+
+func TestLoadConfigStores_Interpolate(t *testing.T) {
+	stores, args := newLayeredStores(t)
+	require.NoError(t, stores.ProjectConfigStore().SaveJSON(&testRootConfig{Theme: "${name}-dark"}))
+	args.Interpolate = true
+
+	rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+	require.NoError(t, err)
+	assert.Equal(t, &testRootConfig{Name: "cli", Theme: "cli-dark"}, rc)
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"encoding/json/jsontext"
+	"errors"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type generatedConfig struct {
+	Sections []map[string]any `json:"sections"`
+}
+
+func TestConfigStore_JSONStream(t *testing.T) {
+	t.Parallel()
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		data := cstest.GenerateConfig(cstest.LargeConfigSize)
+		cs := fix.WithCLIConfig(data).Stores().CLIConfigStore()
+
+		var streamed, loaded generatedConfig
+		require.NoError(t, cfgstore.LoadJSONStream(cs, &streamed))
+		require.NoError(t, cs.LoadJSON(&loaded))
+		assert.Equal(t, loaded, streamed)
+		assert.Len(t, streamed.Sections, 1000)
+
+		streamed.Sections = streamed.Sections[:2]
+		require.NoError(t, cfgstore.SaveJSONStream(cs, &streamed))
+		saved, err := cs.Load()
+		require.NoError(t, err)
+		want, err := jsontext.AppendFormat(nil, saved, jsontext.WithIndent("  "))
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(saved), "saved with SaveJSON's indentation")
+
+		require.NoError(t, cfgstore.SaveJSONStream(cs, &streamed, jsontext.Multiline(false)))
+		saved, err = cs.Load()
+		require.NoError(t, err)
+		assert.NotContains(t, string(saved), "\n")
+
+		err = cfgstore.LoadJSONStream(cfgstore.SubStore(cs, "missing.json"), &streamed)
+		assert.ErrorIs(t, err, cfgstore.ErrFileDoesNotExist)
+	})
+}
+
+func TestConfigStore_JSONStreamHooks(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	var saveArgs *cfgstore.SaveHookArgs
+	require.NoError(t, cfgstore.AddPreSaveHook(cs, func(args *cfgstore.SaveHookArgs) error {
+		args.Value.(*testData).Age = 42
+		return nil
+	}))
+	require.NoError(t, cfgstore.AddPostSaveHook(cs, func(args *cfgstore.SaveHookArgs) error {
+		saveArgs = args
+		return nil
+	}))
+	require.NoError(t, cfgstore.SaveJSONStream(cs, &testData{Name: "Alice"}))
+	require.NotNil(t, saveArgs)
+	assert.Nil(t, saveArgs.Data)
+
+	var postLoad *cfgstore.LoadHookArgs
+	require.NoError(t, cfgstore.AddPostLoadHook(cs, func(args *cfgstore.LoadHookArgs) error {
+		postLoad = args
+		return nil
+	}))
+	got := testData{}
+	require.NoError(t, cfgstore.LoadJSONStream(cs, &got))
+	assert.Equal(t, testData{Name: "Alice", Age: 42}, got)
+	require.NotNil(t, postLoad)
+	assert.Same(t, &got, postLoad.Value)
+
+	// A pre-load hook needs the whole content, so LoadJSON is used instead
+	errHook := errors.New("hook ran")
+	require.NoError(t, cfgstore.AddPreLoadHook(cs, func(*cfgstore.LoadHookArgs) error {
+		return errHook
+	}))
+	assert.ErrorIs(t, cfgstore.LoadJSONStream(cs, &got), errHook)
+}
+
+func TestConfigStore_LoadJSONMapped(t *testing.T) {
+	t.Parallel()
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		data := cstest.GenerateConfig(cstest.LargeConfigSize)
+		cs := fix.WithCLIConfig(data).Stores().CLIConfigStore()
+
+		var mapped, loaded generatedConfig
+		require.NoError(t, cfgstore.LoadJSONMapped(cs, &mapped))
+		require.NoError(t, cs.LoadJSON(&loaded))
+		assert.Equal(t, loaded, mapped)
+
+		// Values must not refer to the mapping once it has been released
+		raw := struct {
+			Sections jsontext.Value `json:"sections"`
+		}{}
+		require.NoError(t, cfgstore.LoadJSONMapped(cs, &raw))
+		require.NoError(t, cs.Save([]byte(`{"sections":[]}`)))
+		assert.True(t, raw.Sections.IsValid())
+		assert.Greater(t, len(raw.Sections), 1000)
+
+		err := cfgstore.LoadJSONMapped(cfgstore.SubStore(cs, "missing.json"), &mapped)
+		assert.ErrorIs(t, err, cfgstore.ErrFileDoesNotExist)
+
+		require.NoError(t, cs.Save(nil))
+		err = cfgstore.LoadJSONMapped(cs, &mapped)
+		assert.ErrorIs(t, err, cfgstore.ErrFailedToUnmarshalConfigFile)
+	})
+}
+
+func TestMapFile(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cs.Save([]byte(`{"name":"Alice"}`)))
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+
+	data, unmap, err := cfgstore.MapFile(cfgstore.OSFileSystem(), fp)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"Alice"}`, string(data))
+
+	// Saves rename over the file, so the mapping keeps the old content
+	require.NoError(t, cs.Save([]byte(`{"name":"Bob"}`)))
+	assert.Equal(t, `{"name":"Alice"}`, string(data))
+	require.NoError(t, unmap())
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertLayout(t *testing.T) {
+	t.Parallel()
+	fix := cstest.NewFixture(t).
+		WithArgs(&cstest.TestDirsProviderArgs{
+			Username:   cstest.DefaultFixtureUsername,
+			ProjectDir: cstest.DefaultFixtureProjectDir,
+			ConfigSlug: cstest.DefaultFixtureConfigSlug,
+			GOOS:       "linux",
+		}).
+		WithCLIConfig(`{"name":"cli"}`).
+		WithTokenFile("alice.json", `{"token":"abc"}`).
+		WithDirTypes(cfgstore.ProjectConfigDirType)
+	stores := fix.Stores()
+
+	assert.True(t, cstest.AssertLayout(t, stores, cstest.Layout{
+		Root: fix.Args().TestRoot,
+		Stores: map[cfgstore.DirType]cstest.StoreLayout{
+			cfgstore.CLIConfigDirType: {
+				RelDir:     "home/coyote/.config/acme",
+				FileExists: true,
+				Files:      []dt.RelFilepath{"tokens/alice.json"},
+			},
+			cfgstore.ProjectConfigDirType: {RelDir: "billboard/.acme"},
+		},
+	}))
+
+	tests := []struct {
+		name   string
+		layout cstest.Layout
+	}{
+		{
+			name: "missing layer",
+			layout: cstest.Layout{Stores: map[cfgstore.DirType]cstest.StoreLayout{
+				cfgstore.CLIConfigDirType: {FileExists: true},
+			}},
+		},
+		{
+			name: "wrong dir",
+			layout: cstest.Layout{Root: fix.Args().TestRoot, Stores: map[cfgstore.DirType]cstest.StoreLayout{
+				cfgstore.CLIConfigDirType:     {RelDir: "home/coyote/.acme", FileExists: true},
+				cfgstore.ProjectConfigDirType: {},
+			}},
+		},
+		{
+			name: "file absent",
+			layout: cstest.Layout{Stores: map[cfgstore.DirType]cstest.StoreLayout{
+				cfgstore.CLIConfigDirType:     {FileExists: true},
+				cfgstore.ProjectConfigDirType: {FileExists: true},
+			}},
+		},
+		{
+			name: "file present",
+			layout: cstest.Layout{Stores: map[cfgstore.DirType]cstest.StoreLayout{
+				cfgstore.CLIConfigDirType:     {},
+				cfgstore.ProjectConfigDirType: {},
+			}},
+		},
+		{
+			name: "missing file",
+			layout: cstest.Layout{Stores: map[cfgstore.DirType]cstest.StoreLayout{
+				cfgstore.CLIConfigDirType:     {FileExists: true, Files: []dt.RelFilepath{"tokens/bob.json"}},
+				cfgstore.ProjectConfigDirType: {},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := &testing.T{}
+			assert.False(t, cstest.AssertLayout(ft, stores, tt.layout))
+			assert.True(t, ft.Failed())
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dataset struct {
+	Name string `json:"name"`
+	Rows []int  `json:"rows"`
+}
+
+type lazyConfig struct {
+	Name     string                         `json:"name"`
+	Datasets cfgstore.Lazy[[]dataset]       `json:"datasets,omitzero"`
+	Index    cfgstore.Lazy[map[string]bool] `json:"index,omitzero"`
+}
+
+func TestLazy_DecodesOnGet(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cs.Save([]byte(`{"name":"app","datasets":[{"name":"a","rows":[1,2]}],"index":{"x":"not a bool"}}`)))
+
+	cfg := lazyConfig{}
+	require.NoError(t, cs.LoadJSON(&cfg), "invalid sections do not fail the load")
+	assert.Equal(t, "app", cfg.Name)
+	assert.JSONEq(t, `[{"name":"a","rows":[1,2]}]`, string(cfg.Datasets.Raw()))
+
+	datasets, err := cfg.Datasets.Get()
+	require.NoError(t, err)
+	assert.Equal(t, []dataset{{Name: "a", Rows: []int{1, 2}}}, datasets)
+
+	_, err = cfg.Index.Get()
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToUnmarshalLazyValue)
+}
+
+func TestLazy_RoundTrip(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	// Undecoded sections are saved as loaded
+	require.NoError(t, cs.Save([]byte(`{"name":"app","index":{"x":true}}`)))
+	cfg := lazyConfig{}
+	require.NoError(t, cs.LoadJSON(&cfg))
+	cfg.Datasets = cfgstore.NewLazy([]dataset{{Name: "b"}})
+	require.NoError(t, cs.SaveJSON(&cfg))
+
+	data, err := cs.Load()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"app","datasets":[{"name":"b","rows":[]}],"index":{"x":true}}`, string(data))
+
+	// Decoded sections are saved with any changes made to them
+	cfg = lazyConfig{}
+	require.NoError(t, cs.LoadJSON(&cfg))
+	index, err := cfg.Index.Get()
+	require.NoError(t, err)
+	index["y"] = false
+	require.NoError(t, cs.SaveJSON(&cfg))
+	data, err = cs.Load()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"app","datasets":[{"name":"b","rows":[]}],"index":{"x":true,"y":false}}`, string(data))
+
+	var zero lazyConfig
+	value, err := zero.Datasets.Get()
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+}
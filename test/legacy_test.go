@@ -0,0 +1,110 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLegacyFilepaths(t *testing.T) {
+	t.Parallel()
+
+	newStores := func(t *testing.T) (store, legacy cfgstore.ConfigStore) {
+		t.Helper()
+		args := cstest.NewTestDirsProviderArgs(t)
+		dp := cstest.NewTestDirsProvider(args)
+		home, err := dp.UserHomeDirFunc()
+		require.NoError(t, err)
+		legacy = cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+			cfgstore.WithSlug(TestConfigSlug),
+			cfgstore.WithRelFilepath(".acmerc"),
+			cfgstore.WithDirsProvider(dp),
+		)
+		legacy.SetConfigDir(home)
+		store = cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+			cfgstore.WithSlug(TestConfigSlug),
+			cfgstore.WithRelFilepath("config.json"),
+			cfgstore.WithDirsProvider(dp),
+			cfgstore.WithLegacyFilepaths("~/.acme/config", "~/.acmerc"),
+		)
+		return store, legacy
+	}
+
+	t.Run("read when missing", func(t *testing.T) {
+		t.Parallel()
+		store, legacy := newStores(t)
+		require.NoError(t, legacy.Save([]byte(`{"name":"legacy"}`)))
+
+		var rc testRootConfig
+		require.NoError(t, store.LoadJSON(&rc))
+		assert.Equal(t, "legacy", rc.Name)
+		assert.False(t, store.Exists(), "legacy files are not copied")
+
+		require.NoError(t, store.Save([]byte(`{"name":"new"}`)))
+		rc = testRootConfig{}
+		require.NoError(t, store.LoadJSON(&rc))
+		assert.Equal(t, "new", rc.Name)
+		data, err := legacy.Load()
+		require.NoError(t, err)
+		assert.Equal(t, `{"name":"legacy"}`, string(data), "legacy files are never written")
+	})
+
+	t.Run("used by ensure", func(t *testing.T) {
+		t.Parallel()
+		store, legacy := newStores(t)
+		require.NoError(t, legacy.Save([]byte(`{"name":"legacy"}`)))
+
+		rc, created, err := cfgstore.EnsureConfig[testRootConfig](store, nil)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, "legacy", rc.Name)
+	})
+
+	t.Run("counted as existing by loads", func(t *testing.T) {
+		t.Parallel()
+		dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+		home, err := dp.UserHomeDirFunc()
+		require.NoError(t, err)
+		legacy := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+			cfgstore.WithRelFilepath(".acmerc"),
+			cfgstore.WithDirsProvider(dp),
+		)
+		legacy.SetConfigDir(home)
+		require.NoError(t, legacy.Save([]byte(`{"name":"legacy"}`)))
+		legacyFp, err := legacy.GetFilepath()
+		require.NoError(t, err)
+		stores := cfgstore.NewConfigStores(cfgstore.ConfigStoresArgs{
+			ConfigStoreArgs: cfgstore.ConfigStoreArgs{
+				ConfigSlug:      TestConfigSlug,
+				RelFilepath:     "config.json",
+				DirsProvider:    dp,
+				LegacyFilepaths: []dt.Filepath{"~/.acmerc"},
+			},
+			DirTypes: []cfgstore.DirType{cfgstore.CLIConfigDirType},
+		})
+
+		var results cfgstore.LoadResults
+		rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, cfgstore.RootConfigArgs{
+			RequireExists: true,
+			Results:       &results,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "legacy", rc.Name)
+		cli, ok := results.Layer(cfgstore.CLIConfigDirType)
+		require.True(t, ok)
+		assert.True(t, cli.Existed)
+		assert.Equal(t, legacyFp, cli.Filepath)
+	})
+
+	t.Run("none exist", func(t *testing.T) {
+		t.Parallel()
+		store, _ := newStores(t)
+		_, err := store.Load()
+		assert.ErrorIs(t, err, cfgstore.ErrFileDoesNotExist)
+		assert.Equal(t, dt.RelFilepath("config.json"), store.GetRelFilepath())
+	})
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLive_AttachSwapsInReloadedConfig(t *testing.T) {
+	var err error
+
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	cfg := serverConfig{}
+	cfg.Server.Port = 8080
+	err = cs.SaveJSON(&cfg)
+	require.NoError(t, err)
+
+	var initial serverConfig
+	require.NoError(t, cs.LoadJSON(&initial))
+	live := cfgstore.NewLive(&initial)
+
+	w := cfgstore.NewWatcher(cs, cfgstore.WatcherArgs{
+		Interval: 10 * time.Millisecond,
+		Debounce: -1,
+	})
+	live.Attach(w, cfgstore.LiveArgs{})
+	require.NoError(t, w.Start())
+	t.Cleanup(w.Stop)
+
+	// Readers running concurrently with the reload must always see a complete config
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for range 4 {
+		wg.Go(func() {
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					port := live.Get().Server.Port
+					if port != 8080 && port != 9090 {
+						t.Errorf("unexpected port %d", port)
+						return
+					}
+				}
+			}
+		})
+	}
+
+	cfg.Server.Port = 9090
+	err = cs.SaveJSON(&cfg)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return live.Get().Server.Port == 9090
+	}, watchTimeout, 10*time.Millisecond)
+	close(stop)
+	wg.Wait()
+}
+
+func TestLive_Codec(t *testing.T) {
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+	cs := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+		cfgstore.WithSlug(TestConfigSlug),
+		cfgstore.WithRelFilepath("config.b64"),
+		cfgstore.WithDirsProvider(dp),
+		cfgstore.WithCodec(base64Codec{}),
+	)
+	cfg := serverConfig{}
+	cfg.Server.Port = 8080
+	require.NoError(t, cs.SaveJSON(&cfg))
+
+	live := cfgstore.NewLive(&cfg)
+	changes := make(chan cfgstore.ValueChange, 10)
+	errs := make(chan error, 10)
+	w := cfgstore.NewWatcher(cs, cfgstore.WatcherArgs{
+		Interval: 10 * time.Millisecond,
+		Debounce: -1,
+	})
+	w.Subscribe("server.port", func(vc cfgstore.ValueChange) {
+		changes <- vc
+	})
+	live.Attach(w, cfgstore.LiveArgs{OnError: func(err error) { errs <- err }})
+	require.NoError(t, w.Start())
+	t.Cleanup(w.Stop)
+
+	next := cfg
+	next.Server.Port = 9090
+	require.NoError(t, cs.SaveJSON(&next))
+
+	select {
+	case vc := <-changes:
+		assert.Equal(t, float64(8080), vc.OldValue)
+		assert.Equal(t, float64(9090), vc.NewValue)
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for subscription notification")
+	}
+	assert.Eventually(t, func() bool {
+		return live.Get().Server.Port == 9090
+	}, watchTimeout, 10*time.Millisecond)
+}
+
+func TestLive_InvalidChangeKeepsCurrentConfig(t *testing.T) {
+	var err error
+
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	cfg := serverConfig{}
+	cfg.Server.Port = 8080
+	require.NoError(t, cs.SaveJSON(&cfg))
+
+	errs := make(chan error, 1)
+	live := cfgstore.NewLive(&cfg)
+	w := cfgstore.NewWatcher(cs, cfgstore.WatcherArgs{
+		Interval: 10 * time.Millisecond,
+		Debounce: -1,
+	})
+	live.Attach(w, cfgstore.LiveArgs{
+		OnError: func(err error) {
+			errs <- err
+		},
+	})
+	require.NoError(t, w.Start())
+	t.Cleanup(w.Stop)
+
+	err = cs.Save([]byte(`{"server": {`))
+	require.NoError(t, err)
+
+	select {
+	case err = <-errs:
+		assert.ErrorIs(t, err, cfgstore.ErrFailedToUnmarshalConfigFile)
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for reload error")
+	}
+	assert.Equal(t, 8080, live.Get().Server.Port)
+}
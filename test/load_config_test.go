@@ -0,0 +1,168 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_RequireExists(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no config", func(t *testing.T) {
+		t.Parallel()
+		fix := cstest.NewFixture(t)
+		stores := fix.Stores()
+
+		_, err := cfgstore.LoadConfig[testRootConfig](cfgstore.LoadConfigArgs{
+			ConfigSlug:    TestConfigSlug,
+			ConfigFile:    cstest.DefaultFixtureConfigFile,
+			DirsProvider:  fix.DirsProvider(),
+			RequireExists: true,
+		})
+		require.ErrorIs(t, err, cfgstore.ErrNoConfigFound)
+		assert.Equal(t, cfgstore.NotFoundErrorKind, cfgstore.ErrorKindOf(err))
+		cliFp, fpErr := stores.CLIConfigStore().GetFilepath()
+		require.NoError(t, fpErr)
+		assert.Contains(t, err.Error(), string(cliFp))
+		assert.False(t, stores.CLIConfigStore().Exists(), "no config is created")
+	})
+
+	t.Run("missing layers are skipped", func(t *testing.T) {
+		t.Parallel()
+		fix := cstest.NewFixture(t).WithProjectConfig(&testRootConfig{Name: "project"})
+		fix.Stores()
+
+		var results cfgstore.LoadResults
+		rc, err := cfgstore.LoadConfig[testRootConfig](cfgstore.LoadConfigArgs{
+			ConfigSlug:    TestConfigSlug,
+			ConfigFile:    cstest.DefaultFixtureConfigFile,
+			DirsProvider:  fix.DirsProvider(),
+			RequireExists: true,
+			Results:       &results,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "project", rc.Name)
+		cli, ok := results.Layer(cfgstore.CLIConfigDirType)
+		require.True(t, ok)
+		assert.False(t, cli.Existed)
+		assert.False(t, cli.Created, "no config is created")
+		assert.NoFileExists(t, string(cli.Filepath))
+	})
+}
+
+func TestLoadConfigStores_LoadPolicies(t *testing.T) {
+	t.Parallel()
+	dirTypes := []cfgstore.DirType{
+		cfgstore.AppConfigDirType,
+		cfgstore.CLIConfigDirType,
+		cfgstore.ProjectConfigDirType,
+	}
+
+	t.Run("skip and create", func(t *testing.T) {
+		t.Parallel()
+		fix := cstest.NewFixture(t)
+
+		var results cfgstore.LoadResults
+		_, err := cfgstore.LoadConfig[testRootConfig](cfgstore.LoadConfigArgs{
+			ConfigSlug:   TestConfigSlug,
+			ConfigFile:   "other.json",
+			DirTypes:     dirTypes,
+			DirsProvider: fix.DirsProvider(),
+			LoadPolicies: map[cfgstore.DirType]cfgstore.LoadPolicy{
+				cfgstore.AppConfigDirType:     cfgstore.SkipIfMissingLoadPolicy,
+				cfgstore.ProjectConfigDirType: cfgstore.CreateLoadPolicy,
+			},
+			Results: &results,
+		})
+		require.NoError(t, err)
+		created := map[cfgstore.DirType]bool{}
+		for _, layer := range results.Layers {
+			created[layer.DirType] = layer.Created
+		}
+		assert.Equal(t, map[cfgstore.DirType]bool{
+			cfgstore.AppConfigDirType:     false,
+			cfgstore.CLIConfigDirType:     true,
+			cfgstore.ProjectConfigDirType: true,
+		}, created)
+	})
+
+	t.Run("require exists", func(t *testing.T) {
+		t.Parallel()
+		fix := cstest.NewFixture(t).WithCLIConfig(&testRootConfig{Name: "cli"})
+		fix.Stores()
+
+		_, err := cfgstore.LoadConfig[testRootConfig](cfgstore.LoadConfigArgs{
+			ConfigSlug:   TestConfigSlug,
+			ConfigFile:   cstest.DefaultFixtureConfigFile,
+			DirsProvider: fix.DirsProvider(),
+			LoadPolicies: map[cfgstore.DirType]cfgstore.LoadPolicy{
+				cfgstore.ProjectConfigDirType: cfgstore.RequireExistsLoadPolicy,
+			},
+		})
+		assert.ErrorIs(t, err, cfgstore.ErrNoConfigFound)
+		assert.Equal(t, cfgstore.NotFoundErrorKind, cfgstore.ErrorKindOf(err))
+	})
+}
+
+func TestLoadConfig_ProjectDir(t *testing.T) {
+	t.Parallel()
+	fix := cstest.NewFixture(t).
+		WithCLIConfig(&testRootConfig{Name: "cli", Theme: "dark"}).
+		WithProjectConfig(&testRootConfig{Name: "project"})
+	project, err := fix.Stores().ProjectConfigStore().ConfigDir()
+	require.NoError(t, err)
+	dp := fix.DirsProvider()
+	dp.ProjectDirFunc = func() (dt.DirPath, error) {
+		return "", assert.AnError
+	}
+
+	rc, err := cfgstore.LoadConfig[testRootConfig](cfgstore.LoadConfigArgs{
+		ConfigSlug:   TestConfigSlug,
+		ConfigFile:   cstest.DefaultFixtureConfigFile,
+		DirsProvider: dp,
+		ProjectDir:   project.Dir(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &testRootConfig{Name: "project", Theme: "dark"}, rc)
+}
+
+func TestLoadConfig_LockLayers(t *testing.T) {
+	t.Parallel()
+	fix := cstest.NewFixture(t).
+		WithCLIConfig(`{"name":"cli","theme":"dark"}`).
+		WithProjectConfig(`{"name":"project"}`)
+	stores := fix.Stores()
+	fp, err := stores.ProjectConfigStore().GetFilepath()
+	require.NoError(t, err)
+	fSys := stores.ProjectConfigStore().FileSystem()
+	args := cfgstore.LoadConfigArgs{
+		ConfigSlug:   TestConfigSlug,
+		ConfigFile:   cstest.DefaultFixtureConfigFile,
+		DirsProvider: fix.DirsProvider(),
+		LockLayers:   true,
+		LockTimeout:  50 * time.Millisecond,
+	}
+
+	// A writer holding the lock blocks the load
+	unlock, err := fSys.Lock(fp, time.Second)
+	require.NoError(t, err)
+	_, err = cfgstore.LoadConfig[testRootConfig](args)
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToLockFile)
+	assert.ErrorIs(t, err, cfgstore.ErrLockTimeout)
+	unlock()
+
+	// Readers do not block each other
+	unlock, err = cfgstore.RLock(fSys, fp, time.Second)
+	require.NoError(t, err)
+	defer unlock()
+	rc, err := cfgstore.LoadConfig[testRootConfig](args)
+	require.NoError(t, err)
+	assert.Equal(t, "project", rc.Name)
+	assert.Equal(t, "dark", rc.Theme)
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigStore_ConcurrentLoadsShareOneRead(t *testing.T) {
+	t.Parallel()
+	const callers = 20
+
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Alice", Age: 30}))
+
+	var reads atomic.Int32
+	release := make(chan struct{})
+	require.NoError(t, cfgstore.AddPreLoadHook(cs, func(args *cfgstore.LoadHookArgs) error {
+		reads.Add(1)
+		<-release
+		return nil
+	}))
+
+	results := make([]*testData, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Go(func() {
+			results[i] = &testData{}
+			assert.NoError(t, cs.LoadJSON(results[i]))
+		})
+	}
+	// Give every caller time to join the first one's read
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), reads.Load())
+	for _, result := range results {
+		assert.Equal(t, &testData{Name: "Alice", Age: 30}, result)
+	}
+
+	// Later loads read the file again
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Bob", Age: 40}))
+	got := testData{}
+	require.NoError(t, cs.LoadJSON(&got))
+	assert.Equal(t, "Bob", got.Name)
+	assert.Equal(t, int32(2), reads.Load())
+}
+
+func TestConfigStore_ConcurrentLoadsSharedPerStore(t *testing.T) {
+	t.Parallel()
+
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Alice", Age: 30}))
+	other := cs.(cfgstore.StoreCopier).WithRelFilepath("config.json")
+
+	var reads atomic.Int32
+	release := make(chan struct{})
+	hook := func(args *cfgstore.LoadHookArgs) error {
+		reads.Add(1)
+		<-release
+		return nil
+	}
+	require.NoError(t, cfgstore.AddPreLoadHook(cs, hook))
+	require.NoError(t, cfgstore.AddPreLoadHook(other, hook))
+
+	var wg sync.WaitGroup
+	for _, store := range []cfgstore.ConfigStore{cs, cs, other, other} {
+		wg.Go(func() {
+			got := testData{}
+			assert.NoError(t, store.LoadJSON(&got))
+			assert.Equal(t, "Alice", got.Name)
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// One read per store value, not per file
+	assert.Equal(t, int32(2), reads.Load())
+}
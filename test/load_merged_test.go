@@ -0,0 +1,21 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMerged(t *testing.T) {
+	t.Parallel()
+	rc := cstest.LoadMerged[testRootConfig](t, `{"name":"cli","theme":"dark"}`, &testRootConfig{Name: "project"})
+	assert.Equal(t, &testRootConfig{Name: "project", Theme: "dark"}, rc)
+}
+
+func TestLoadMerged_NoProjectFile(t *testing.T) {
+	rc := cstest.LoadMerged[testRootConfig](t, &testRootConfig{Name: "cli"}, nil, cstest.LoadMergedOptions{
+		OnDisk: true,
+	})
+	assert.Equal(t, &testRootConfig{Name: "cli"}, rc)
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOrInit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("init then load", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		defaults := &testRootConfig{Name: "default", Theme: "light"}
+
+		cfg, created, err := cfgstore.LoadOrInit(store, defaults, nil)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, &testRootConfig{Name: "default", Theme: "light"}, cfg)
+		assert.NotSame(t, defaults, cfg, "defaults is copied")
+		assert.True(t, store.Exists())
+
+		require.NoError(t, store.Save([]byte(`{"name":"user"}`)))
+		cfg, created, err = cfgstore.LoadOrInit(store, defaults, nil)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, &testRootConfig{Name: "user"}, cfg, "defaults only apply to new files")
+	})
+
+	t.Run("empty file is loaded", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, store.Save([]byte(`{}`)))
+
+		cfg, created, err := cfgstore.LoadOrInit(store, &testRootConfig{Name: "default"}, nil)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Empty(t, cfg.Name)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+		var wg sync.WaitGroup
+		var creates atomic.Int32
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		for range 8 {
+			wg.Go(func() {
+				cfg, created, err := cfgstore.LoadOrInit(store.(cfgstore.StoreCopier).WithRelFilepath("config.json"), &testRootConfig{Name: "default"}, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, "default", cfg.Name)
+				if created {
+					creates.Add(1)
+				}
+			})
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), creates.Load(), "exactly one caller creates the config")
+	})
+}
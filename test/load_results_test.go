@@ -0,0 +1,84 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadResults(t *testing.T) {
+	t.Parallel()
+
+	t.Run("existing and missing layers", func(t *testing.T) {
+		t.Parallel()
+		fix := cstest.NewFixture(t).WithCLIConfig(&testRootConfig{Name: "cli"})
+		stores := fix.Stores()
+
+		var results cfgstore.LoadResults
+		_, err := cfgstore.LoadConfig[testRootConfig](cfgstore.LoadConfigArgs{
+			ConfigSlug:   TestConfigSlug,
+			ConfigFile:   cstest.DefaultFixtureConfigFile,
+			DirsProvider: fix.DirsProvider(),
+			Results:      &results,
+		})
+		require.NoError(t, err)
+		require.Len(t, results.Layers, 2)
+
+		cliFp, err := stores.CLIConfigStore().GetFilepath()
+		require.NoError(t, err)
+		cli, ok := results.Layer(cfgstore.CLIConfigDirType)
+		require.True(t, ok)
+		assert.Equal(t, cliFp, cli.Filepath)
+		assert.True(t, cli.Existed)
+		assert.False(t, cli.Created)
+		assert.Empty(t, cli.Warnings)
+
+		project, ok := results.Layer(cfgstore.ProjectConfigDirType)
+		require.True(t, ok)
+		assert.False(t, project.Existed)
+		assert.False(t, project.Created)
+
+		_, ok = results.Layer(cfgstore.AppConfigDirType)
+		assert.False(t, ok)
+		assert.Equal(t, "loaded cli config from "+string(cliFp)+", no project config found", results.String())
+	})
+
+	t.Run("created layer", func(t *testing.T) {
+		t.Parallel()
+		fix := cstest.NewFixture(t)
+
+		var results cfgstore.LoadResults
+		_, err := cfgstore.LoadCLIConfig[testRootConfig](cfgstore.LoadConfigArgs{
+			ConfigSlug:   TestConfigSlug,
+			ConfigFile:   cstest.DefaultFixtureConfigFile,
+			DirsProvider: fix.DirsProvider(),
+			Results:      &results,
+		})
+		require.NoError(t, err)
+		cli, ok := results.Layer(cfgstore.CLIConfigDirType)
+		require.True(t, ok)
+		assert.False(t, cli.Existed)
+		assert.True(t, cli.Created)
+		assert.Contains(t, results.String(), "created cli config at ")
+	})
+
+	t.Run("empty project config", func(t *testing.T) {
+		t.Parallel()
+		stores, args := newLayeredStores(t)
+		require.NoError(t, stores.ProjectConfigStore().Save([]byte(`{}`)))
+
+		var results cfgstore.LoadResults
+		args.Results = &results
+		rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+		require.NoError(t, err)
+		assert.Equal(t, "cli", rc.Name)
+		project, ok := results.Layer(cfgstore.ProjectConfigDirType)
+		require.True(t, ok)
+		assert.True(t, project.Existed)
+		require.Len(t, project.Warnings, 1)
+		assert.ErrorIs(t, project.Warnings[0], cfgstore.ErrConfigFileHasNoSettings)
+	})
+}
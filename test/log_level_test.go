@@ -0,0 +1,83 @@
+package test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveLogLevel(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    cfgstore.LogLevelArgs
+		want    slog.Level
+		wantErr bool
+	}{
+		{
+			name: "default",
+			want: slog.LevelInfo,
+		},
+		{
+			name: "explicit default",
+			args: cfgstore.LogLevelArgs{Default: slog.LevelWarn},
+			want: slog.LevelWarn,
+		},
+		{
+			name: "config",
+			args: cfgstore.LogLevelArgs{ConfigLevel: "debug"},
+			want: slog.LevelDebug,
+		},
+		{
+			name: "env overrides config",
+			env:  map[string]string{"MY_APP_LOG_LEVEL": "ERROR"},
+			args: cfgstore.LogLevelArgs{ConfigSlug: "my-app", ConfigLevel: "debug"},
+			want: slog.LevelError,
+		},
+		{
+			name: "empty env ignored",
+			env:  map[string]string{"MYAPP_LOG_LEVEL": ""},
+			args: cfgstore.LogLevelArgs{ConfigSlug: "myapp", ConfigLevel: "warn"},
+			want: slog.LevelWarn,
+		},
+		{
+			name: "custom env var with offset",
+			env:  map[string]string{"DEBUG_LEVEL": "info+2"},
+			args: cfgstore.LogLevelArgs{ConfigSlug: "myapp", EnvVar: "DEBUG_LEVEL"},
+			want: slog.LevelInfo + 2,
+		},
+		{
+			name:    "invalid",
+			env:     map[string]string{"MYAPP_LOG_LEVEL": "loud"},
+			args:    cfgstore.LogLevelArgs{ConfigSlug: "myapp", Default: slog.LevelWarn},
+			want:    slog.LevelWarn,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.args.LookupEnv = func(name string) (value string, ok bool) {
+				value, ok = tt.env[name]
+				return value, ok
+			}
+			level, err := cfgstore.ResolveLogLevel(tt.args)
+			if tt.wantErr {
+				require.ErrorIs(t, err, cfgstore.ErrInvalidLogLevel)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, level)
+		})
+	}
+}
+
+func TestLogLevelEnvVar(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "MYAPP_LOG_LEVEL", cfgstore.LogLevelEnvVar("myapp"))
+	assert.Equal(t, "MY_APP_2_LOG_LEVEL", cfgstore.LogLevelEnvVar("my-app.2"))
+}
@@ -0,0 +1,193 @@
+package test
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	logRecord  = `{"time":"2025-01-02T15:04:05Z","msg":"hello"}` + "\n"
+	textRecord = "time=2025-01-02T15:04:05.000Z level=INFO msg=hello\n"
+)
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	t.Parallel()
+	fp := dt.Filepath(filepath.Join(t.TempDir(), "app.log"))
+	rf, err := cfgstore.OpenRotatingFile(fp, cfgstore.LogRotation{
+		MaxSize: int64(len(logRecord) * 2),
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, rf.Close()) }()
+
+	for range 5 {
+		_, err = rf.Write([]byte(logRecord))
+		require.NoError(t, err)
+	}
+
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 2)
+	for _, backup := range backups {
+		assert.True(t, strings.HasPrefix(filepath.Base(string(backup)), "app-"))
+		assert.Equal(t, ".log", filepath.Ext(string(backup)))
+		data, err := os.ReadFile(string(backup))
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat(logRecord, 2), string(data))
+	}
+	data, err := os.ReadFile(string(fp))
+	require.NoError(t, err)
+	assert.Equal(t, logRecord, string(data))
+}
+
+func TestRotatingFile_RotatesByAgeOnOpen(t *testing.T) {
+	t.Parallel()
+	fp := dt.Filepath(filepath.Join(t.TempDir(), "app.log"))
+	require.NoError(t, os.WriteFile(string(fp), []byte(logRecord), 0644))
+
+	rf, err := cfgstore.OpenRotatingFile(fp, cfgstore.LogRotation{MaxAge: time.Hour})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, rf.Close()) }()
+
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+	info, err := os.Stat(string(fp))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+func TestRotatingFile_RotatesTextLogByAge(t *testing.T) {
+	t.Parallel()
+	fp := dt.Filepath(filepath.Join(t.TempDir(), "app.log"))
+	require.NoError(t, os.WriteFile(string(fp), []byte(textRecord), 0644))
+
+	rf, err := cfgstore.OpenRotatingFile(fp, cfgstore.LogRotation{MaxAge: time.Hour})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, rf.Close()) }()
+
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 1, "the first record is older than MaxAge though the file was just written")
+}
+
+func TestRotatingFile_RotatesUnparsedLogByAge(t *testing.T) {
+	t.Parallel()
+	const maxAge = 50 * time.Millisecond
+	fp := dt.Filepath(filepath.Join(t.TempDir(), "app.log"))
+	rf, err := cfgstore.OpenRotatingFile(fp, cfgstore.LogRotation{MaxAge: maxAge})
+	require.NoError(t, err)
+	_, err = rf.Write([]byte("[INFO] first run\n"))
+	require.NoError(t, err)
+	require.NoError(t, rf.Close())
+
+	time.Sleep(2 * maxAge)
+	// A later run appends, so the file's ModTime is recent
+	f, err := os.OpenFile(string(fp), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString("[INFO] second run\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	rf, err = cfgstore.OpenRotatingFile(fp, cfgstore.LogRotation{MaxAge: maxAge})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, rf.Close()) }()
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 1, "the first record is older than MaxAge")
+}
+
+func TestRotatingFile_RecordsStartOnlyForUnparsedLogs(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name    string
+		record  string
+		sidecar bool
+	}{
+		{name: "json.log", record: logRecord},
+		{name: "text.log", record: textRecord},
+		{name: "custom.log", record: "[INFO] hello\n", sidecar: true},
+	} {
+		rf, err := cfgstore.OpenRotatingFile(dt.Filepath(filepath.Join(dir, tc.name)), cfgstore.LogRotation{MaxAge: time.Hour})
+		require.NoError(t, err)
+		_, err = rf.Write([]byte(tc.record))
+		require.NoError(t, err)
+		require.NoError(t, rf.Close())
+		_, err = os.Stat(filepath.Join(dir, "."+tc.name+".started"))
+		assert.Equal(t, tc.sidecar, err == nil, tc.name)
+	}
+}
+
+func TestRotatingFile_Retention(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{
+		"app-20000101T000000.000.log",
+		"app-20000102T000000.000.log",
+		"other-20000101T000000.000.log",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(logRecord), 0644))
+	}
+	fp := dt.Filepath(filepath.Join(dir, "app.log"))
+	rf, err := cfgstore.OpenRotatingFile(fp, cfgstore.LogRotation{
+		MaxSize:      1,
+		MaxBackups:   2,
+		MaxBackupAge: 24 * time.Hour,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, rf.Close()) }()
+
+	for range 3 {
+		_, err = rf.Write([]byte(logRecord))
+		require.NoError(t, err)
+	}
+
+	// The two old backups exceed MaxBackupAge and only the newest 2 are kept.
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 2)
+	for _, backup := range backups {
+		assert.NotContains(t, string(backup), "app-2000")
+	}
+	_, err = os.Stat(filepath.Join(dir, "other-20000101T000000.000.log"))
+	assert.NoError(t, err)
+}
+
+func TestRotatingFile_Compress(t *testing.T) {
+	t.Parallel()
+	fp := dt.Filepath(filepath.Join(t.TempDir(), "app.log"))
+	rf, err := cfgstore.OpenRotatingFile(fp, cfgstore.LogRotation{
+		MaxSize:  1,
+		Compress: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, rf.Close()) }()
+
+	_, err = rf.Write([]byte(logRecord))
+	require.NoError(t, err)
+	require.NoError(t, rf.Rotate())
+
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.True(t, strings.HasSuffix(string(backups[0]), ".log.gz"))
+
+	f, err := os.Open(string(backups[0]))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, f.Close()) }()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, logRecord, string(data))
+}
@@ -0,0 +1,25 @@
+package test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLogger_DefaultsToSlogDefault is not parallel because it unsets the
+// package's logger.
+func TestLogger_DefaultsToSlogDefault(t *testing.T) {
+	saved := cfgstore.Logger()
+	defer cfgstore.SetLogger(saved)
+
+	cfgstore.SetLogger(nil)
+	assert.NotPanics(t, func() {
+		assert.Same(t, slog.Default(), cfgstore.EnsureLogger())
+		cfgstore.LogOnError(nil)
+	})
+
+	cfgstore.SetLogger(saved)
+	assert.Same(t, saved, cfgstore.Logger())
+}
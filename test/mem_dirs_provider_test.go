@@ -0,0 +1,89 @@
+package test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemDirsProvider(t *testing.T) {
+	t.Parallel()
+
+	dp := cstest.NewMemDirsProvider(&cstest.TestDirsProviderArgs{
+		Username:   "coyote",
+		ProjectDir: "billboard",
+		ConfigSlug: TestConfigSlug,
+	})
+	stores := cfgstore.NewConfigStores(cfgstore.ConfigStoresArgs{
+		DirTypes: []cfgstore.DirType{cfgstore.CLIConfigDirType, cfgstore.ProjectConfigDirType},
+		ConfigStoreArgs: cfgstore.ConfigStoreArgs{
+			ConfigSlug:   TestConfigSlug,
+			RelFilepath:  "config.json",
+			DirsProvider: dp,
+		},
+	})
+	require.NoError(t, stores.ProjectConfigStore().SaveJSON(&testRootConfig{Theme: "dark"}))
+
+	rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, cfgstore.RootConfigArgs{
+		DirTypes: stores.DirTypes,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &testRootConfig{Theme: "dark"}, rc)
+
+	cs := stores.CLIConfigStore()
+	require.NoError(t, cfgstore.SetValue(cs, "name", "wile"))
+	require.NoError(t, cfgstore.SubStore(cs, "tokens/a.json").SaveJSON(&testData{}))
+	value, _, err := cfgstore.GetValue(cs, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "wile", value)
+
+	files, err := cfgstore.ListFiles(cs, "tokens/*")
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	require.NoError(t, cfgstore.UpdateJSON(cs, func(rc *testRootConfig) error {
+		rc.Theme = "light"
+		return nil
+	}))
+
+	mfs := dp.FileSystem.(*cstest.MemFileSystem)
+	assert.Len(t, mfs.Files(), 3)
+
+	// Nothing was written to disk
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+	_, err = os.Stat(string(fp))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRunOnFileSystems(t *testing.T) {
+	var ran []string
+
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		ran = append(ran, t.Name())
+		cs := fix.WithCLIConfig(`{"name":"cli","tags":["a"]}`).Stores().CLIConfigStore()
+
+		require.NoError(t, cfgstore.AppendValue(cs, "tags", "b"))
+		require.NoError(t, cfgstore.SubStore(cs, "tokens/a.json").Save([]byte(`{}`)))
+		files, err := cfgstore.ListFiles(cs, "tokens/*.json")
+		require.NoError(t, err)
+		assert.Equal(t, []dt.RelFilepath{"tokens/a.json"}, files)
+
+		var rc struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		}
+		require.NoError(t, cs.LoadJSON(&rc))
+		assert.Equal(t, []string{"a", "b"}, rc.Tags)
+
+		_, isMem := fix.DirsProvider().FileSystem.(*cstest.MemFileSystem)
+		assert.Equal(t, strings.HasSuffix(t.Name(), "/memory"), isMem)
+	})
+	assert.Equal(t, []string{"TestRunOnFileSystems/disk", "TestRunOnFileSystems/memory"}, ran)
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metricsRecorder collects the measurements reported while it is set.
+type metricsRecorder struct {
+	mutex   sync.Mutex
+	ops     []cfgstore.OperationMetric
+	lookups []bool
+}
+
+func recordMetrics(t *testing.T) *metricsRecorder {
+	r := &metricsRecorder{}
+	t.Cleanup(cfgstore.SetMetrics(r))
+	return r
+}
+
+func (r *metricsRecorder) ObserveOperation(op cfgstore.OperationMetric) {
+	r.mutex.Lock()
+	r.ops = append(r.ops, op)
+	r.mutex.Unlock()
+}
+
+func (r *metricsRecorder) ObserveCacheLookup(hit bool) {
+	r.mutex.Lock()
+	r.lookups = append(r.lookups, hit)
+	r.mutex.Unlock()
+}
+
+func TestMetrics(t *testing.T) {
+	store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+	missing := cfgstore.SubStore(store, "missing.json")
+	rec := recordMetrics(t)
+
+	require.NoError(t, store.Save([]byte(`{"name":"Alice"}`)))
+	require.NoError(t, store.LoadJSON(&testData{}))
+	_, err := missing.Load()
+	require.Error(t, err)
+
+	require.Len(t, rec.ops, 3)
+	assert.Equal(t, cfgstore.SaveEventKind, rec.ops[0].Kind)
+	assert.Equal(t, cfgstore.CLIConfigDirType, rec.ops[0].DirType)
+	assert.Equal(t, int64(len(`{"name":"Alice"}`)), rec.ops[0].Size)
+	assert.Equal(t, cfgstore.LoadEventKind, rec.ops[1].Kind)
+	assert.Equal(t, int64(len(`{"name":"Alice"}`)), rec.ops[1].Size)
+	assert.Positive(t, rec.ops[1].Duration)
+	assert.Equal(t, cfgstore.NotFoundErrorKind, cfgstore.ErrorKindOf(rec.ops[2].Err))
+	assert.Zero(t, rec.ops[2].Size)
+
+	cc := cfgstore.NewCachedConfig[testData](store)
+	for range 2 {
+		_, err = cc.Load()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []bool{false, true}, rec.lookups)
+
+	cfgstore.SetMetrics(nil)
+	require.NoError(t, store.Save([]byte(`{}`)))
+	assert.Len(t, rec.ops, 4, "the load by the cache miss and not the save after SetMetrics(nil)")
+}
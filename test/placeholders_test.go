@@ -0,0 +1,129 @@
+package test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-dt/appinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPlaceholderStore(t *testing.T, rf dt.RelFilepath, placeholders map[string]string) cfgstore.ConfigStore {
+	t.Helper()
+	args := cstest.NewTestDirsProviderArgs(t)
+	return cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+		cfgstore.WithSlug(TestConfigSlug),
+		cfgstore.WithRelFilepath(rf),
+		cfgstore.WithDirsProvider(cstest.NewTestDirsProvider(args)),
+		cfgstore.WithPlaceholders(placeholders),
+	)
+}
+
+// profileOptions supplies the {profile} placeholder, as a --profile flag would.
+type profileOptions struct {
+	profile string
+}
+
+func (profileOptions) Options() {}
+
+func (o profileOptions) Placeholders() map[string]string {
+	return map[string]string{"profile": o.profile}
+}
+
+func TestPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	t.Run("expanded", func(t *testing.T) {
+		t.Parallel()
+		store := newPlaceholderStore(t, "{version}/config-{profile}_{goos}.json", map[string]string{
+			"version": "v2",
+			"profile": "work",
+		})
+		want := dt.RelFilepath("v2/config-work_" + runtime.GOOS + ".json")
+		assert.Equal(t, want, store.GetRelFilepath())
+		fp, err := store.GetFilepath()
+		require.NoError(t, err)
+		dir, err := store.ConfigDir()
+		require.NoError(t, err)
+		assert.Equal(t, dt.FilepathJoin(dir, want), fp)
+
+		require.NoError(t, store.Save([]byte(`{}`)))
+		assert.True(t, store.Exists())
+	})
+
+	t.Run("sub stores", func(t *testing.T) {
+		t.Parallel()
+		store := newPlaceholderStore(t, "config.json", map[string]string{"profile": "work"})
+		assert.Equal(t, dt.RelFilepath("profiles/work.json"), cfgstore.SubStore(store, "profiles/{profile}.json").GetRelFilepath())
+		assert.Equal(t, dt.RelFilepath("work.json"), store.(cfgstore.StoreCopier).WithRelFilepath("{profile}.json").GetRelFilepath())
+	})
+
+	t.Run("app info", func(t *testing.T) {
+		t.Parallel()
+		info := appinfo.New(appinfo.Args{
+			Name:       "Acme",
+			Version:    "v1.4.0",
+			AppSlug:    "acme",
+			ConfigSlug: TestConfigSlug,
+		})
+		store := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+			cfgstore.WithSlug(TestConfigSlug),
+			cfgstore.WithRelFilepath("{version}/{app}.json"),
+			cfgstore.WithDirsProvider(cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))),
+			cfgstore.WithAppInfo(info),
+		)
+		assert.Equal(t, dt.RelFilepath("v1.4.0/acme.json"), store.GetRelFilepath())
+
+		store = cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+			cfgstore.WithRelFilepath("{version}/{app}.json"),
+			cfgstore.WithAppInfo(info),
+			cfgstore.WithPlaceholders(map[string]string{"version": "v2"}),
+		)
+		assert.Equal(t, dt.RelFilepath("v2/acme.json"), store.GetRelFilepath(), "Placeholders override AppInfo")
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+		fix := cstest.NewFixture(t)
+		stores := cfgstore.NewConfigStores(cfgstore.ConfigStoresArgs{
+			DirTypes: []cfgstore.DirType{cfgstore.CLIConfigDirType},
+			ConfigStoreArgs: cfgstore.ConfigStoreArgs{
+				ConfigSlug:   TestConfigSlug,
+				RelFilepath:  "config-{profile}.json",
+				DirsProvider: fix.DirsProvider(),
+			},
+		})
+		_, err := stores.CLIConfigStore().GetFilepath()
+		assert.ErrorIs(t, err, cfgstore.ErrUnknownPlaceholder, "profile is not known until the Options are")
+
+		require.NoError(t, stores.CLIConfigStore().(cfgstore.StoreCopier).WithRelFilepath("config-work.json").Save([]byte(`{"name":"work"}`)))
+		rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, cfgstore.RootConfigArgs{
+			DirTypes: []cfgstore.DirType{cfgstore.CLIConfigDirType},
+			Options:  profileOptions{profile: "work"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "work", rc.Name)
+		assert.Equal(t, dt.RelFilepath("config-work.json"), stores.CLIConfigStore().GetRelFilepath())
+	})
+
+	t.Run("unknown placeholder", func(t *testing.T) {
+		t.Parallel()
+		store := newPlaceholderStore(t, "config-{profile}.json", nil)
+		_, err := store.GetFilepath()
+		assert.ErrorIs(t, err, cfgstore.ErrUnknownPlaceholder)
+		assert.ErrorIs(t, store.Save([]byte(`{}`)), cfgstore.ErrUnknownPlaceholder)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		t.Parallel()
+		for _, value := range []string{"", "..", "a/b", `a\b`} {
+			store := newPlaceholderStore(t, "config-{profile}.json", map[string]string{"profile": value})
+			_, err := store.GetFilepath()
+			assert.ErrorIs(t, err, cfgstore.ErrInvalidPlaceholderValue, "value %q", value)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type promptConfig struct {
+	Name   string `json:"name"`
+	Server struct {
+		Port  int  `json:"port"`
+		Debug bool `json:"debug"`
+	} `json:"server"`
+}
+
+func (c *promptConfig) RootConfig() {}
+
+func (c *promptConfig) Normalize(cfgstore.NormalizeArgs) error {
+	return nil
+}
+
+func (c *promptConfig) Merge(rc cfgstore.RootConfig) cfgstore.RootConfig {
+	return c
+}
+
+// scriptedPrompter answers each prompt with the next of answers and records
+// the args it was called with.
+func scriptedPrompter(answers ...string) (cfgstore.Prompter, *[]cfgstore.PromptArgs) {
+	var calls []cfgstore.PromptArgs
+	return func(args cfgstore.PromptArgs) (answer string, err error) {
+		if len(calls) >= len(answers) {
+			return "", errors.New("no more answers")
+		}
+		answer = answers[len(calls)]
+		calls = append(calls, args)
+		return answer, nil
+	}, &calls
+}
+
+var promptFields = []cfgstore.PromptField{
+	{Path: "name", Label: "Project name", Validate: func(answer string) error {
+		if answer == "" {
+			return errors.New("name is required")
+		}
+		return nil
+	}},
+	{Path: "server.port", Default: "8080"},
+	{Path: "server.debug", Default: "false"},
+}
+
+func TestPromptConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("answers and defaults", func(t *testing.T) {
+		t.Parallel()
+		var cfg promptConfig
+		prompter, calls := scriptedPrompter("acme", "", "true")
+		require.NoError(t, cfgstore.PromptConfig(&cfg, promptFields, prompter))
+		assert.Equal(t, "acme", cfg.Name)
+		assert.Equal(t, 8080, cfg.Server.Port)
+		assert.True(t, cfg.Server.Debug)
+		assert.Equal(t, "Project name", (*calls)[0].Field.Label)
+		assert.Equal(t, "server.port", (*calls)[1].Field.Label)
+	})
+
+	t.Run("reprompts invalid answers", func(t *testing.T) {
+		t.Parallel()
+		var cfg promptConfig
+		prompter, calls := scriptedPrompter("", "acme", "eighty", "80", "yes", "false")
+		require.NoError(t, cfgstore.PromptConfig(&cfg, promptFields, prompter))
+		assert.Equal(t, "acme", cfg.Name)
+		assert.Equal(t, 80, cfg.Server.Port)
+		assert.False(t, cfg.Server.Debug)
+
+		require.Len(t, *calls, 6)
+		assert.NoError(t, (*calls)[0].Err)
+		assert.EqualError(t, (*calls)[1].Err, "name is required")
+		assert.ErrorIs(t, (*calls)[3].Err, cfgstore.ErrAnswerNotOfFieldType)
+		assert.ErrorIs(t, (*calls)[5].Err, cfgstore.ErrAnswerNotOfFieldType)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		t.Parallel()
+		var cfg promptConfig
+		prompter, calls := scriptedPrompter("", "", "")
+		err := cfgstore.PromptConfig(&cfg, promptFields[:1], prompter, cfgstore.PromptOptions{MaxAttempts: 2})
+		assert.ErrorIs(t, err, cfgstore.ErrInvalidPromptAnswer)
+		assert.Len(t, *calls, 2)
+	})
+
+	t.Run("nil prompter uses defaults", func(t *testing.T) {
+		t.Parallel()
+		var cfg promptConfig
+		require.NoError(t, cfgstore.PromptConfig(&cfg, promptFields[1:], nil))
+		assert.Equal(t, 8080, cfg.Server.Port)
+
+		err := cfgstore.PromptConfig(&cfg, promptFields[:1], nil)
+		assert.ErrorIs(t, err, cfgstore.ErrInvalidPromptAnswer)
+	})
+
+	t.Run("prompter error", func(t *testing.T) {
+		t.Parallel()
+		var cfg promptConfig
+		err := cfgstore.PromptConfig(&cfg, promptFields, func(cfgstore.PromptArgs) (string, error) {
+			return "", fmt.Errorf("stdin closed")
+		})
+		assert.ErrorContains(t, err, "stdin closed")
+	})
+}
+
+func TestInitProjectConfig_Prompts(t *testing.T) {
+	t.Chdir(t.TempDir())
+	prompter, _ := scriptedPrompter("acme", "9000", "")
+	opts := cfgstore.InitConfigOptions{Fields: promptFields, Prompter: prompter}
+
+	cfg, err := cfgstore.InitProjectConfig[promptConfig](TestConfigSlug, "config.json", nil, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", cfg.Name)
+	assert.Equal(t, 9000, cfg.Server.Port)
+
+	var saved promptConfig
+	store := cfgstore.NewProjectConfigStore(TestConfigSlug, "config.json")
+	require.NoError(t, store.LoadJSON(&saved))
+	assert.Equal(t, *cfg, saved)
+
+	// Existing configs fail before the user is asked anything
+	_, err = cfgstore.InitProjectConfig[promptConfig](TestConfigSlug, "config.json", nil, cfgstore.InitConfigOptions{
+		Fields: promptFields,
+		Prompter: func(cfgstore.PromptArgs) (string, error) {
+			t.Fatal("prompted for an existing config")
+			return "", nil
+		},
+	})
+	assert.ErrorIs(t, err, cfgstore.ErrConfigAlreadyExists)
+}
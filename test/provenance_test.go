@@ -0,0 +1,28 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertValueFrom(t *testing.T) {
+	t.Parallel()
+	stores := cstest.NewFixture(t).InMemory().
+		WithCLIConfig(`{"name":"cli","theme":"dark"}`).
+		WithProjectConfig(`{"name":"project"}`).
+		Stores()
+
+	assert.True(t, cstest.AssertValueFrom(t, stores, "name", cfgstore.ProjectConfigDirType))
+	assert.True(t, cstest.AssertValueFrom(t, stores, "theme", cfgstore.CLIConfigDirType))
+	assert.True(t, cstest.AssertValueSources(t, stores, "name", cfgstore.CLIConfigDirType, cfgstore.ProjectConfigDirType))
+	assert.True(t, cstest.AssertValueSources(t, stores, "missing"))
+
+	ft := &testing.T{}
+	assert.False(t, cstest.AssertValueFrom(ft, stores, "theme", cfgstore.ProjectConfigDirType))
+	assert.False(t, cstest.AssertValueFrom(ft, stores, "missing", cfgstore.CLIConfigDirType))
+	assert.False(t, cstest.AssertValueSources(ft, stores, "theme"))
+	assert.True(t, ft.Failed())
+}
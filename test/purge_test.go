@@ -0,0 +1,59 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurge(t *testing.T) {
+	t.Parallel()
+
+	t.Run("removes the config dir", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, store.Save([]byte(`{"name":"cli"}`)))
+		require.NoError(t, cfgstore.SubStore(store, "tokens/alice.json").Save([]byte(`{}`)))
+		require.NoError(t, withTenant(store, "work").Save([]byte(`{}`)))
+		dir, err := store.ConfigDir()
+		require.NoError(t, err)
+
+		require.NoError(t, cfgstore.Purge(store, TestConfigSlug))
+		assert.NoDirExists(t, string(dir))
+		assert.False(t, store.Exists())
+		assert.DirExists(t, string(dir.Dir()), "the parent is kept")
+	})
+
+	t.Run("missing dir", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.ProjectConfigDirType, TestConfigSlug, "config.json")
+		assert.NoError(t, cfgstore.Purge(store, TestConfigSlug))
+	})
+
+	t.Run("slug mismatch", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		require.NoError(t, store.Save([]byte(`{}`)))
+
+		err := cfgstore.Purge(store, "other")
+		assert.ErrorIs(t, err, cfgstore.ErrPurgeNotConfirmed)
+		assert.True(t, store.Exists())
+	})
+
+	t.Run("overridden config dir", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		dir, err := store.ConfigDir()
+		require.NoError(t, err)
+		store.SetConfigDir(dt.DirPathJoin(dt.DirPathJoin(dir.Dir(), "elsewhere"), TestConfigSlug))
+		require.NoError(t, store.Save([]byte(`{}`)))
+
+		err = cfgstore.Purge(store, TestConfigSlug)
+		assert.ErrorIs(t, err, cfgstore.ErrUnsafePurgePath)
+		assert.True(t, store.Exists())
+	})
+}
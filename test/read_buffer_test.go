@@ -0,0 +1,58 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfigStore_PooledBuffersAreNotShared saves and loads files of different
+// sizes concurrently so that a pooled buffer reused too early would corrupt
+// another store's content.
+func TestConfigStore_PooledBuffersAreNotShared(t *testing.T) {
+	t.Parallel()
+	const stores = 8
+	const rounds = 20
+
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		cs := fix.WithCLIConfig(`{}`).Stores().CLIConfigStore()
+		var wg sync.WaitGroup
+		for i := range stores {
+			wg.Go(func() {
+				store := cfgstore.SubStore(cs, dt.RelFilepath(fmt.Sprintf("store-%d.json", i)))
+				for round := range rounds {
+					want := testData{
+						Name: strings.Repeat(string(rune('a'+i)), 10+i*100+round),
+						Age:  round,
+					}
+					if !assert.NoError(t, store.SaveJSON(&want)) {
+						return
+					}
+					got := testData{}
+					if !assert.NoError(t, store.LoadJSON(&got)) {
+						return
+					}
+					assert.Equal(t, want, got)
+				}
+			})
+		}
+		wg.Wait()
+
+		// Post-save hooks get content that stays valid after the save
+		var saved []byte
+		require.NoError(t, cfgstore.AddPostSaveHook(cs, func(args *cfgstore.SaveHookArgs) error {
+			saved = args.Data
+			return nil
+		}))
+		assert.NoError(t, cs.SaveJSON(&testData{Name: "kept"}))
+		assert.NoError(t, cfgstore.SubStore(cs, "other.json").SaveJSON(&testData{Name: "other"}))
+		assert.JSONEq(t, `{"Name":"kept","Age":0}`, string(saved))
+	})
+}
@@ -0,0 +1,52 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingStore(t *testing.T) {
+	t.Parallel()
+	stores := cstest.NewFixture(t).InMemory().WithCLIConfig(`{"name":"cli"}`).Stores()
+	store := cstest.NewRecordingStore(stores.CLIConfigStore())
+
+	var rc testRootConfig
+	require.NoError(t, store.LoadJSON(&rc))
+	require.NoError(t, cfgstore.SetValue(store, "theme", "dark"))
+	require.NoError(t, store.SubStore("tokens/alice.json").SaveJSON(map[string]string{"token": "abc"}))
+
+	store.AssertLoaded(t, "config.json", 2)
+	store.AssertSaved(t, "config.json", 1)
+	store.AssertSaved(t, "tokens/alice.json", 1)
+
+	ops := store.Operations()
+	require.Len(t, ops, 4)
+	assert.Equal(t, "LoadContext", ops[1].Method)
+	assert.Equal(t, "Save", ops[2].Method)
+	assert.NotEqual(t, ops[1].Hash, ops[2].Hash, "the hash should change when the file does")
+
+	ft := &testing.T{}
+	assert.False(t, store.AssertSaved(ft, "config.json", 2))
+	assert.True(t, ft.Failed())
+}
+
+func TestRecordingStore_Replay(t *testing.T) {
+	t.Parallel()
+	src := cstest.NewRecordingStore(cstest.NewFixture(t).InMemory().Stores().CLIConfigStore())
+	require.NoError(t, src.SaveJSON(&testRootConfig{Name: "first"}))
+	require.NoError(t, src.SubStore("tokens/bob.json").Save([]byte(`{"token":"xyz"}`)))
+
+	dst := cstest.NewFixture(t).InMemory().Stores().CLIConfigStore()
+	require.NoError(t, src.Replay(dst))
+
+	name, _, err := cfgstore.GetString(dst, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "first", name)
+	token, _, err := cfgstore.GetString(cfgstore.SubStore(dst, "tokens/bob.json"), "token")
+	require.NoError(t, err)
+	assert.Equal(t, "xyz", token)
+}
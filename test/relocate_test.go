@@ -0,0 +1,146 @@
+package test
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRelocateStores returns a store for a legacy file holding legacy and one
+// for the new location, in the same test root.
+func newRelocateStores(t *testing.T, legacy string) (oldStore, newStore cfgstore.ConfigStore) {
+	t.Helper()
+	newStore = cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config/config.json")
+	oldStore = cfgstore.SubStore(newStore, "legacy.json")
+	if legacy != "" {
+		require.NoError(t, oldStore.Save([]byte(legacy)))
+	}
+	return oldStore, newStore
+}
+
+func TestRelocate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("moves and is idempotent", func(t *testing.T) {
+		t.Parallel()
+		oldStore, newStore := newRelocateStores(t, `{"name":"legacy"}`)
+
+		result, err := cfgstore.Relocate(oldStore, newStore)
+		require.NoError(t, err)
+		assert.Equal(t, cfgstore.Relocated, result)
+		assert.False(t, oldStore.Exists())
+		data, err := newStore.Load()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"legacy"}`, string(data))
+
+		result, err = cfgstore.Relocate(oldStore, newStore)
+		require.NoError(t, err)
+		assert.Equal(t, cfgstore.AlreadyRelocated, result)
+	})
+
+	t.Run("nothing to relocate", func(t *testing.T) {
+		t.Parallel()
+		oldStore, newStore := newRelocateStores(t, "")
+
+		result, err := cfgstore.Relocate(oldStore, newStore)
+		require.NoError(t, err)
+		assert.Equal(t, cfgstore.NothingToRelocate, result)
+		assert.False(t, newStore.Exists())
+	})
+
+	t.Run("stub", func(t *testing.T) {
+		t.Parallel()
+		oldStore, newStore := newRelocateStores(t, `{"name":"legacy"}`)
+		opts := cfgstore.RelocateOptions{Remnant: cfgstore.StubRelocateRemnant}
+
+		result, err := cfgstore.Relocate(oldStore, newStore, opts)
+		require.NoError(t, err)
+		assert.Equal(t, cfgstore.Relocated, result)
+		newFp, err := newStore.GetFilepath()
+		require.NoError(t, err)
+		var stub map[string]string
+		require.NoError(t, oldStore.LoadJSON(&stub))
+		assert.Equal(t, string(newFp), stub["moved_to"])
+
+		result, err = cfgstore.Relocate(oldStore, newStore, opts)
+		require.NoError(t, err)
+		assert.Equal(t, cfgstore.AlreadyRelocated, result)
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("creating symlinks requires privileges on Windows")
+		}
+		oldStore, newStore := newRelocateStores(t, `{"name":"legacy"}`)
+		opts := cfgstore.RelocateOptions{Remnant: cfgstore.SymlinkRelocateRemnant}
+
+		result, err := cfgstore.Relocate(oldStore, newStore, opts)
+		require.NoError(t, err)
+		assert.Equal(t, cfgstore.Relocated, result)
+		oldFp, err := oldStore.GetFilepath()
+		require.NoError(t, err)
+		newFp, err := newStore.GetFilepath()
+		require.NoError(t, err)
+		target, err := os.Readlink(string(oldFp))
+		require.NoError(t, err)
+		assert.Equal(t, string(newFp), target)
+
+		result, err = cfgstore.Relocate(oldStore, newStore, opts)
+		require.NoError(t, err)
+		assert.Equal(t, cfgstore.AlreadyRelocated, result)
+	})
+
+	t.Run("symlink not supported", func(t *testing.T) {
+		t.Parallel()
+		dp := cstest.NewMemDirsProvider(cstest.NewTestDirsProviderArgs(t))
+		newStore := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType,
+			cfgstore.WithSlug(TestConfigSlug),
+			cfgstore.WithRelFilepath("config.json"),
+			cfgstore.WithDirsProvider(dp),
+		)
+		oldStore := cfgstore.SubStore(newStore, "legacy.json")
+		require.NoError(t, oldStore.Save([]byte(`{}`)))
+
+		_, err := cfgstore.Relocate(oldStore, newStore, cfgstore.RelocateOptions{
+			Remnant: cfgstore.SymlinkRelocateRemnant,
+		})
+		assert.ErrorIs(t, err, cfgstore.ErrSymlinksNotSupported)
+		assert.True(t, oldStore.Exists())
+		assert.False(t, newStore.Exists())
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		t.Parallel()
+		oldStore, newStore := newRelocateStores(t, `{"name":"legacy"}`)
+		require.NoError(t, newStore.Save([]byte(`{"name":"new"}`)))
+
+		_, err := cfgstore.Relocate(oldStore, newStore)
+		assert.ErrorIs(t, err, cfgstore.ErrRelocateConflict)
+		assert.Equal(t, cfgstore.ConflictErrorKind, cfgstore.ErrorKindOf(err))
+		assert.True(t, oldStore.Exists())
+
+		result, err := cfgstore.Relocate(oldStore, newStore, cfgstore.RelocateOptions{Overwrite: true})
+		require.NoError(t, err)
+		assert.Equal(t, cfgstore.Relocated, result)
+		data, err := newStore.Load()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"legacy"}`, string(data))
+	})
+
+	t.Run("same content is not a conflict", func(t *testing.T) {
+		t.Parallel()
+		oldStore, newStore := newRelocateStores(t, `{"name":"legacy"}`)
+		require.NoError(t, newStore.Save([]byte(`{"name":"legacy"}`)))
+
+		result, err := cfgstore.Relocate(oldStore, newStore)
+		require.NoError(t, err)
+		assert.Equal(t, cfgstore.Relocated, result)
+		assert.False(t, oldStore.Exists())
+	})
+}
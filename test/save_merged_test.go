@@ -0,0 +1,75 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveMergedConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("saves whole config to last layer by default", func(t *testing.T) {
+		t.Parallel()
+		stores, args := newLayeredStores(t)
+		rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+		require.NoError(t, err)
+
+		rc.Name = "changed"
+		require.NoError(t, cfgstore.SaveMergedConfig(stores, rc))
+
+		data, err := stores.ProjectConfigStore().Load()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"changed","theme":"dark"}`, string(data))
+	})
+
+	t.Run("only diffs", func(t *testing.T) {
+		t.Parallel()
+		stores, args := newLayeredStores(t)
+		rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+		require.NoError(t, err)
+
+		// theme is inherited from the CLI layer, so only name is saved
+		require.NoError(t, cfgstore.SaveMergedConfig(stores, rc, cfgstore.SaveMergedConfigOptions{
+			DirType:   cfgstore.ProjectConfigDirType,
+			OnlyDiffs: true,
+		}))
+		data, err := stores.ProjectConfigStore().Load()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"project"}`, string(data))
+
+		rc.Theme = "light"
+		require.NoError(t, cfgstore.SaveMergedConfig(stores, rc, cfgstore.SaveMergedConfigOptions{
+			OnlyDiffs: true,
+		}))
+		data, err = stores.ProjectConfigStore().Load()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"project","theme":"light"}`, string(data))
+	})
+
+	t.Run("only diffs to lowest layer saves everything", func(t *testing.T) {
+		t.Parallel()
+		stores, args := newLayeredStores(t)
+		rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+		require.NoError(t, err)
+
+		require.NoError(t, cfgstore.SaveMergedConfig(stores, rc, cfgstore.SaveMergedConfigOptions{
+			DirType:   cfgstore.CLIConfigDirType,
+			OnlyDiffs: true,
+		}))
+		data, err := stores.CLIConfigStore().Load()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"project","theme":"dark"}`, string(data))
+	})
+
+	t.Run("unknown dir type", func(t *testing.T) {
+		t.Parallel()
+		stores, _ := newLayeredStores(t)
+		err := cfgstore.SaveMergedConfig(stores, &testRootConfig{}, cfgstore.SaveMergedConfigOptions{
+			DirType: cfgstore.AppConfigDirType,
+		})
+		assert.ErrorIs(t, err, cfgstore.ErrDirTypeNotInStores)
+	})
+}
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"os"
+	"testing"
+	"text/template"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newScaffoldStores returns CLI-only stores that create a missing config with
+// the template in src.
+func newScaffoldStores(t *testing.T, src string) (stores *cfgstore.ConfigStores, args cfgstore.RootConfigArgs) {
+	t.Helper()
+	tmpl, err := template.New("config.json.tmpl").Funcs(cfgstore.ScaffoldFuncs).Parse(src)
+	require.NoError(t, err)
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+	args = cfgstore.RootConfigArgs{
+		DirTypes:     []cfgstore.DirType{cfgstore.CLIConfigDirType},
+		DirsProvider: dp,
+	}
+	stores = cfgstore.NewConfigStores(cfgstore.ConfigStoresArgs{
+		DirTypes:     args.DirTypes,
+		DirsProvider: dp,
+		ConfigStoreArgs: cfgstore.ConfigStoreArgs{
+			ConfigSlug:   TestConfigSlug,
+			RelFilepath:  "config.json",
+			DirsProvider: dp,
+			Scaffold:     tmpl,
+		},
+	})
+	return stores, args
+}
+
+func TestScaffold(t *testing.T) {
+	t.Parallel()
+
+	t.Run("creates file from template", func(t *testing.T) {
+		t.Parallel()
+		src, err := os.ReadFile("testdata/scaffolds/config.json.tmpl")
+		require.NoError(t, err)
+		stores, args := newScaffoldStores(t, string(src))
+
+		rc, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+		require.NoError(t, err)
+		assert.Equal(t, &testRootConfig{Theme: "light"}, rc)
+
+		data, err := stores.CLIConfigStore().Load()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"_comment": "Settings for acme (cli)"`)
+
+		// The scaffold is only rendered for a config that does not exist yet
+		require.NoError(t, stores.CLIConfigStore().Save([]byte(`{"name":"saved"}`)))
+		rc, err = cfgstore.LoadConfigStores[testRootConfig](stores, args)
+		require.NoError(t, err)
+		assert.Equal(t, &testRootConfig{Name: "saved"}, rc)
+	})
+
+	t.Run("invalid output is not saved", func(t *testing.T) {
+		t.Parallel()
+		stores, args := newScaffoldStores(t, `{"name": {{ .Config.Name }}}`)
+
+		_, err := cfgstore.LoadConfigStores[testRootConfig](stores, args)
+		assert.ErrorIs(t, err, cfgstore.ErrFailedToRenderScaffold)
+		assert.ErrorIs(t, err, cfgstore.ErrFailedToUnmarshalConfigFile)
+		assert.False(t, stores.CLIConfigStore().Exists())
+	})
+}
@@ -0,0 +1,42 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotTree_DiffSnapshots(t *testing.T) {
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		cs := fix.WithCLIConfig(`{"name":"cli"}`).
+			WithTokenFile("alice.json", `{}`).
+			Stores().CLIConfigStore()
+		root, err := cs.ConfigDir()
+		require.NoError(t, err)
+		opts := cstest.SnapshotOptions{FileSystem: fix.DirsProvider().FileSystem}
+
+		before := cstest.SnapshotTree(t, root, opts)
+		assert.Equal(t, []byte(`{"name":"cli"}`), before.Entries["config.json"].Data)
+
+		// Not SetValue, whose lock sidecar would show up on disk
+		require.NoError(t, cs.Save([]byte(`{"name":"cli","theme":"dark"}`)))
+		require.NoError(t, cfgstore.SubStore(cs, "tokens/bob.json").Save([]byte(`{}`)))
+		require.NoError(t, cs.FileSystem().Remove(dt.FilepathJoin(root, "tokens/alice.json")))
+
+		diff := cstest.DiffSnapshots(before, cstest.SnapshotTree(t, root, opts))
+		assert.Equal(t, []string{"tokens/bob.json"}, diff.Created)
+		assert.Equal(t, []string{"config.json"}, diff.Modified)
+		assert.Equal(t, []string{"tokens/alice.json"}, diff.Deleted)
+		assert.Equal(t, "+ tokens/bob.json\n~ config.json\n- tokens/alice.json\n", diff.String())
+	})
+}
+
+func TestSnapshotTree_MissingRoot(t *testing.T) {
+	snap := cstest.SnapshotTree(t, cstest.NewTestRoot(t))
+	assert.Empty(t, snap.Entries)
+	assert.True(t, cstest.DiffSnapshots(snap, snap).IsEmpty())
+}
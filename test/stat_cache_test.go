@@ -0,0 +1,65 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStatCacheStore(t *testing.T, cacheStat bool) cfgstore.ConfigStore {
+	t.Helper()
+	return cfgstore.NewConfigStore(cfgstore.CLIConfigDirType, cfgstore.ConfigStoreArgs{
+		ConfigSlug:   TestConfigSlug,
+		RelFilepath:  "config.json",
+		DirsProvider: cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t)),
+		CacheStat:    cacheStat,
+	})
+}
+
+func TestConfigStore_CacheStat(t *testing.T) {
+	cs := newStatCacheStore(t, true)
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+
+	assert.False(t, cs.Exists())
+	require.NoError(t, os.MkdirAll(string(fp.Dir()), 0755))
+	require.NoError(t, os.WriteFile(string(fp), []byte(`{}`), 0644))
+	assert.False(t, cs.Exists(), "Exists should be cached until Invalidate")
+
+	cfgstore.Invalidate(cs)
+	assert.True(t, cs.Exists())
+
+	require.NoError(t, os.Remove(string(fp)))
+	assert.True(t, cs.Exists(), "Exists should be cached until Invalidate")
+	cfgstore.Invalidate(cs)
+	assert.False(t, cs.Exists())
+
+	require.NoError(t, cs.SaveJSON(&testData{Name: "Alice"}))
+	assert.True(t, cs.Exists(), "Save should update the cached result")
+}
+
+func TestConfigStore_CacheStat_SubStore(t *testing.T) {
+	cs := newStatCacheStore(t, true)
+	require.NoError(t, cs.Save([]byte(`{}`)))
+	assert.True(t, cs.Exists())
+
+	sub := cfgstore.SubStore(cs, "other.json")
+	assert.False(t, sub.Exists(), "SubStore should not share the cached result")
+	require.NoError(t, sub.Save([]byte(`{}`)))
+	assert.True(t, sub.Exists())
+}
+
+func TestConfigStore_CacheStat_Disabled(t *testing.T) {
+	cs := newStatCacheStore(t, false)
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+
+	assert.False(t, cs.Exists())
+	require.NoError(t, os.MkdirAll(string(fp.Dir()), 0755))
+	require.NoError(t, os.WriteFile(string(fp), []byte(`{}`), 0644))
+	assert.True(t, cs.Exists())
+}
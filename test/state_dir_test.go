@@ -0,0 +1,42 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStateDir_IsNotWithinOtherDirs checks, for each OS's layout, that the
+// state dir is never within a dir that Purge, ExportArchive, PruneCache or
+// RemoveStaleCacheVersions remove or read wholesale.
+func TestStateDir_IsNotWithinOtherDirs(t *testing.T) {
+	t.Parallel()
+	for _, goos := range []string{"linux", "darwin", "windows"} {
+		t.Run(goos, func(t *testing.T) {
+			t.Parallel()
+			args := cstest.NewTestDirsProviderArgs(t)
+			args.GOOS = goos
+			data := cstest.NewTemplateData(args, cstest.NewTestDirsProvider(args))
+			require.NotEmpty(t, data.StateDir)
+			for name, dir := range map[string]dt.DirPath{
+				"app config dir": data.AppConfigDir,
+				"CLI config dir": data.CLIConfigDir,
+				"cache dir":      data.CacheDir,
+			} {
+				require.NotEmpty(t, dir, name)
+				assert.False(t, isWithinDir(data.StateDir, dir), "%s %s holds state dir %s", name, dir, data.StateDir)
+			}
+		})
+	}
+}
+
+// isWithinDir reports whether dir is parent or one of its descendants.
+func isWithinDir(dir, parent dt.DirPath) bool {
+	rel, err := filepath.Rel(string(parent), string(dir))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
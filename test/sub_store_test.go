@@ -0,0 +1,89 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubStore(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.AppConfigDirType, TestConfigSlug, "config.json")
+
+	sub := cfgstore.SubStore(cs, "tokens/alice.json")
+	assert.Equal(t, cfgstore.AppConfigDirType, sub.DirType())
+	assert.Equal(t, dt.RelFilepath("tokens/alice.json"), sub.GetRelFilepath())
+
+	require.NoError(t, sub.SaveJSON(&testData{Name: "alice"}))
+
+	dir, err := cs.ConfigDir()
+	require.NoError(t, err)
+	fp, err := sub.GetFilepath()
+	require.NoError(t, err)
+	assert.Equal(t, dt.FilepathJoin(dir, "tokens/alice.json"), fp)
+
+	// The original store still refers to its own file
+	assert.False(t, cs.Exists())
+	assert.True(t, sub.Exists())
+}
+
+func TestListFiles(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.AppConfigDirType, TestConfigSlug, "config.json")
+
+	files, err := cfgstore.ListFiles(cs, "tokens/*.json")
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	for _, name := range []dt.RelFilepath{"tokens/bob.json", "tokens/alice.json", "tokens/notes.txt"} {
+		require.NoError(t, cfgstore.SubStore(cs, name).SaveJSON(&testData{}))
+	}
+	require.NoError(t, cfgstore.SubStore(cs, "tokens/old/carol.json").SaveJSON(&testData{}))
+	// UpdateJSON leaves a lock sidecar behind
+	require.NoError(t, cfgstore.UpdateJSON(cfgstore.SubStore(cs, "tokens/bob.json"), func(*testData) error { return nil }))
+
+	files, err = cfgstore.ListFiles(cs, "tokens/*.json")
+	require.NoError(t, err)
+	assert.Equal(t, []dt.RelFilepath{"tokens/alice.json", "tokens/bob.json"}, files)
+
+	files, err = cfgstore.ListFiles(cs, "tokens/*")
+	require.NoError(t, err)
+	assert.Equal(t, []dt.RelFilepath{"tokens/alice.json", "tokens/bob.json", "tokens/notes.txt"}, files)
+
+	_, err = cfgstore.ListFiles(cs, "tokens/[")
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToListFiles)
+}
+
+func TestWithRelFilepath(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+
+	other := cs.(cfgstore.StoreCopier).WithRelFilepath("other.json")
+	assert.Equal(t, dt.RelFilepath("other.json"), other.GetRelFilepath())
+	assert.Equal(t, dt.RelFilepath("config.json"), cs.GetRelFilepath(), "the original store is unchanged")
+
+	require.NoError(t, other.SaveJSON(&testData{Name: "other"}))
+	assert.True(t, other.Exists())
+	assert.False(t, cs.Exists())
+	dir, err := cs.ConfigDir()
+	require.NoError(t, err)
+	otherDir, err := other.ConfigDir()
+	require.NoError(t, err)
+	assert.Equal(t, dir, otherDir)
+}
+
+func TestWithConfigSlug(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+
+	other := cs.(cfgstore.StoreCopier).WithConfigSlug("roadrunner")
+	assert.Equal(t, dt.PathSegment("roadrunner"), other.ConfigSlug())
+	assert.Equal(t, dt.PathSegment(TestConfigSlug), cs.ConfigSlug(), "the original store is unchanged")
+
+	dir, err := cs.ConfigDir()
+	require.NoError(t, err)
+	otherDir, err := other.ConfigDir()
+	require.NoError(t, err)
+	assert.Equal(t, dt.PathSegment("roadrunner"), otherDir.Base())
+	assert.Equal(t, dir.Dir(), otherDir.Dir())
+}
@@ -0,0 +1,32 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixture_WithTemplateFile(t *testing.T) {
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		cs := fix.WithTemplateFile(cfgstore.CLIConfigDirType, "", "testdata/templates/config.json.tmpl").
+			Stores().CLIConfigStore()
+
+		var cfg struct {
+			Name       string     `json:"name"`
+			CacheDir   dt.DirPath `json:"cache_dir"`
+			ProjectDir dt.DirPath `json:"project_dir"`
+		}
+		require.NoError(t, cs.LoadJSON(&cfg))
+		data := fix.TemplateData()
+		assert.Equal(t, "coyote", cfg.Name)
+		assert.Equal(t, data.CLIConfigDir, cfg.CacheDir)
+		assert.Equal(t, data.ProjectConfigDir, cfg.ProjectDir)
+		dir, err := cs.ConfigDir()
+		require.NoError(t, err)
+		assert.Equal(t, dir, cfg.CacheDir)
+	})
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenants(t *testing.T) {
+	t.Parallel()
+
+	t.Run("namespaces config dir", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		base, err := store.ConfigDir()
+		require.NoError(t, err)
+
+		acme := withTenant(store, "acme-corp")
+		assert.Equal(t, dt.PathSegment("acme-corp"), tenantOf(acme))
+		dir, err := acme.ConfigDir()
+		require.NoError(t, err)
+		assert.Equal(t, dt.DirPathJoin(base, "acme-corp"), dir)
+
+		other := withTenant(acme, "globex")
+		dir, err = other.ConfigDir()
+		require.NoError(t, err)
+		assert.Equal(t, dt.DirPathJoin(base, "globex"), dir)
+
+		require.NoError(t, acme.SaveJSON(&testRootConfig{Name: "acme"}))
+		require.NoError(t, other.SaveJSON(&testRootConfig{Name: "globex"}))
+		require.NoError(t, cfgstore.SubStore(store, "profiles/empty/other.json").Save([]byte(`{}`)))
+
+		tenants, err := cfgstore.Tenants(store)
+		require.NoError(t, err)
+		assert.Equal(t, []dt.PathSegment{"acme-corp", "globex"}, tenants)
+		tenants, err = cfgstore.Tenants(acme)
+		require.NoError(t, err)
+		assert.Equal(t, []dt.PathSegment{"acme-corp", "globex"}, tenants)
+
+		var rc testRootConfig
+		require.NoError(t, withTenant(withTenant(store, ""), "globex").LoadJSON(&rc))
+		assert.Equal(t, "globex", rc.Name)
+	})
+
+	t.Run("switch", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		tenant, err := cfgstore.CurrentTenant(store)
+		require.NoError(t, err)
+		assert.Empty(t, tenant)
+
+		require.NoError(t, cfgstore.SwitchTenant(store, "acme-corp"))
+		assert.Equal(t, dt.PathSegment("acme-corp"), tenantOf(store))
+		require.NoError(t, store.SaveJSON(&testRootConfig{Name: "acme"}))
+
+		fresh := withTenant(store, "")
+		tenant, err = cfgstore.CurrentTenant(fresh)
+		require.NoError(t, err)
+		assert.Equal(t, dt.PathSegment("acme-corp"), tenant)
+		var rc testRootConfig
+		require.NoError(t, withTenant(fresh, tenant).LoadJSON(&rc))
+		assert.Equal(t, "acme", rc.Name)
+
+		require.NoError(t, cfgstore.SwitchTenant(store, ""))
+		tenant, err = cfgstore.CurrentTenant(store)
+		require.NoError(t, err)
+		assert.Empty(t, tenant)
+		assert.False(t, store.Exists())
+	})
+
+	t.Run("invalid tenant", func(t *testing.T) {
+		t.Parallel()
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		for _, tenant := range []dt.PathSegment{"..", "a/b", `a\b`, ".hidden"} {
+			_, err := withTenant(store, tenant).GetFilepath()
+			assert.ErrorIs(t, err, cfgstore.ErrInvalidTenant, tenant)
+			assert.ErrorIs(t, cfgstore.SwitchTenant(store, tenant), cfgstore.ErrInvalidTenant, tenant)
+		}
+		assert.Empty(t, tenantOf(store))
+	})
+}
+
+// withTenant returns cs's copy for tenant's namespace.
+func withTenant(cs cfgstore.ConfigStore, tenant dt.PathSegment) cfgstore.ConfigStore {
+	return cs.(cfgstore.TenantConfigStore).WithTenant(tenant)
+}
+
+// tenantOf returns the namespace cs's config dir is in.
+func tenantOf(cs cfgstore.ConfigStore) dt.PathSegment {
+	return cs.(cfgstore.TenantConfigStore).Tenant()
+}
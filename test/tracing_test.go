@@ -0,0 +1,137 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type spanKey struct{}
+
+// recordedSpan is a span started by a spanRecorder.
+type recordedSpan struct {
+	cfgstore.SpanArgs
+	parent string
+	ended  bool
+	err    error
+	rec    *spanRecorder
+}
+
+func (s *recordedSpan) End(err error) {
+	s.rec.mutex.Lock()
+	s.ended, s.err = true, err
+	s.rec.mutex.Unlock()
+}
+
+// spanRecorder is a cfgstore.Tracer that records the spans started while it is
+// set, with the name of their parent.
+type spanRecorder struct {
+	mutex sync.Mutex
+	spans []*recordedSpan
+}
+
+func recordSpans(t *testing.T) *spanRecorder {
+	r := &spanRecorder{}
+	t.Cleanup(cfgstore.SetTracer(r))
+	return r
+}
+
+func (r *spanRecorder) Start(ctx context.Context, args cfgstore.SpanArgs) (context.Context, cfgstore.Span) {
+	span := &recordedSpan{SpanArgs: args, rec: r}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		span.parent = parent.Name
+	}
+	r.mutex.Lock()
+	r.spans = append(r.spans, span)
+	r.mutex.Unlock()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// named returns the spans named name.
+func (r *spanRecorder) named(name string) (spans []*recordedSpan) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, span := range r.spans {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestTracing(t *testing.T) {
+	t.Run("layered load", func(t *testing.T) {
+		stores := cstest.NewFixture(t).
+			WithCLIConfig(`{"name":"cli"}`).
+			WithProjectConfig(`{"theme":"dark"}`).
+			Stores()
+		rec := recordSpans(t)
+		_, err := cfgstore.LoadConfigStores[testRootConfig](stores, cfgstore.RootConfigArgs{})
+		require.NoError(t, err)
+
+		roots := rec.named(cfgstore.LoadConfigSpanName)
+		require.Len(t, roots, 1)
+		assert.Empty(t, roots[0].parent)
+		assert.True(t, roots[0].ended)
+		assert.Equal(t, []cfgstore.DirType{cfgstore.CLIConfigDirType, cfgstore.ProjectConfigDirType}, roots[0].DirTypes)
+
+		loads := rec.named(cfgstore.LoadSpanName)
+		require.Len(t, loads, 2)
+		for _, span := range loads {
+			assert.Equal(t, cfgstore.LoadConfigSpanName, span.parent)
+			assert.NotEmpty(t, span.Filepath)
+			assert.True(t, span.ended)
+			assert.NoError(t, span.err)
+		}
+		merges := rec.named(cfgstore.MergeSpanName)
+		require.Len(t, merges, 1)
+		assert.Equal(t, cfgstore.LoadConfigSpanName, merges[0].parent)
+	})
+
+	t.Run("remote calls", func(t *testing.T) {
+		dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+		dp.FileSystem = &contextFileSystem{FileSystem: cfgstore.OSFileSystem()}
+		cs := cfgstore.NewConfigStore(cfgstore.CLIConfigDirType, cfgstore.ConfigStoreArgs{
+			ConfigSlug:   TestConfigSlug,
+			RelFilepath:  "config.json",
+			DirsProvider: dp,
+		})
+		rec := recordSpans(t)
+		require.NoError(t, cs.Save([]byte(`{"name":"Alice"}`)))
+		_, err := cs.Load()
+		require.NoError(t, err)
+		_, err = cfgstore.SubStore(cs, "missing.json").Load()
+		require.Error(t, err)
+
+		saves := rec.named(cfgstore.SaveSpanName)
+		require.Len(t, saves, 1)
+		assert.Equal(t, cfgstore.CLIConfigDirType, saves[0].DirType)
+		writes := rec.named(cfgstore.WriteFileSpanName)
+		require.Len(t, writes, 1)
+		assert.Equal(t, cfgstore.SaveSpanName, writes[0].parent)
+		assert.Equal(t, saves[0].Filepath, writes[0].Filepath)
+
+		reads := rec.named(cfgstore.ReadFileSpanName)
+		require.Len(t, reads, 2)
+		for _, span := range reads {
+			assert.Equal(t, cfgstore.LoadSpanName, span.parent)
+		}
+		loads := rec.named(cfgstore.LoadSpanName)
+		require.Len(t, loads, 2)
+		assert.NoError(t, loads[0].err)
+		assert.ErrorIs(t, loads[1].err, cfgstore.ErrFileDoesNotExist)
+	})
+
+	t.Run("no tracer", func(t *testing.T) {
+		store := cstest.NewTestStore(t, cfgstore.CLIConfigDirType, TestConfigSlug, "config.json")
+		rec := recordSpans(t)
+		cfgstore.SetTracer(nil)
+		require.NoError(t, store.Save([]byte(`{}`)))
+		assert.Empty(t, rec.named(cfgstore.SaveSpanName))
+	})
+}
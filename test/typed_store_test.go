@@ -0,0 +1,38 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedStore(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	ts := cfgstore.Typed[testData](cs)
+	assert.Same(t, cs, ts.Store())
+
+	_, err := ts.Load()
+	require.ErrorIs(t, err, cfgstore.ErrFileDoesNotExist)
+
+	require.NoError(t, ts.Save(testData{Name: "Alice", Age: 30}))
+	got, err := ts.Load()
+	require.NoError(t, err)
+	assert.Equal(t, testData{Name: "Alice", Age: 30}, got)
+
+	require.NoError(t, ts.Update(func(d *testData) error {
+		d.Age++
+		return nil
+	}))
+	got, err = ts.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 31, got.Age)
+
+	require.NoError(t, cs.Save([]byte(`{"Name":`)))
+	got, err = ts.Load()
+	require.Error(t, err)
+	assert.Zero(t, got)
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const typedValuesContent = `{
+  "name": "acme",
+  "port": 8080,
+  "port_str": " 9090 ",
+  "big": 8.08e3,
+  "ratio": 1.5,
+  "debug": true,
+  "verbose": "yes",
+  "quiet": "0",
+  "timeout": "1m30s",
+  "retry": 5,
+  "huge": 9.223372036854775808e18,
+  "neg_huge": -9.3e18,
+  "ages": 1e10,
+  "neg_ages": -1e10,
+  "none": null,
+  "server": {"host": "a"}
+}`
+
+func TestGetString(t *testing.T) {
+	cs := newValuesStore(t, typedValuesContent)
+
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{path: "name", want: "acme", ok: true},
+		{path: "ratio", want: "1.5", ok: true},
+		{path: "debug", want: "true", ok: true},
+		{path: "none", ok: false},
+		{path: "missing", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value, ok, err := cfgstore.GetString(cs, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+
+	_, ok, err := cfgstore.GetString(cs, "server")
+	assert.False(t, ok)
+	assert.ErrorIs(t, err, cfgstore.ErrValueTypeMismatch)
+}
+
+func TestGetInt(t *testing.T) {
+	cs := newValuesStore(t, typedValuesContent)
+
+	tests := []struct {
+		path    string
+		want    int
+		ok      bool
+		wantErr bool
+	}{
+		{path: "port", want: 8080, ok: true},
+		{path: "port_str", want: 9090, ok: true},
+		{path: "big", want: 8080, ok: true},
+		{path: "huge", wantErr: true},
+		{path: "neg_huge", wantErr: true},
+		{path: "ratio", wantErr: true},
+		{path: "debug", wantErr: true},
+		{path: "name", wantErr: true},
+		{path: "missing", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value, ok, err := cfgstore.GetInt(cs, tt.path)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, cfgstore.ErrValueTypeMismatch)
+				assert.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
+
+func TestGetBool(t *testing.T) {
+	cs := newValuesStore(t, typedValuesContent)
+
+	tests := []struct {
+		path    string
+		want    bool
+		ok      bool
+		wantErr bool
+	}{
+		{path: "debug", want: true, ok: true},
+		{path: "quiet", want: false, ok: true},
+		{path: "verbose", wantErr: true},
+		{path: "port", wantErr: true},
+		{path: "missing", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value, ok, err := cfgstore.GetBool(cs, tt.path)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, cfgstore.ErrValueTypeMismatch)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
+
+func TestGetDuration(t *testing.T) {
+	cs := newValuesStore(t, typedValuesContent)
+
+	tests := []struct {
+		path    string
+		want    time.Duration
+		ok      bool
+		wantErr bool
+	}{
+		{path: "timeout", want: 90 * time.Second, ok: true},
+		{path: "retry", want: 5 * time.Second, ok: true},
+		{path: "ratio", want: 1500 * time.Millisecond, ok: true},
+		{path: "ages", wantErr: true},
+		{path: "neg_ages", wantErr: true},
+		{path: "name", wantErr: true},
+		{path: "debug", wantErr: true},
+		{path: "missing", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value, ok, err := cfgstore.GetDuration(cs, tt.path)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, cfgstore.ErrValueTypeMismatch)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
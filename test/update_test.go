@@ -0,0 +1,129 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt/dtx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type counterConfig struct {
+	Count int `json:"count"`
+}
+
+func TestUpdateJSON_ConcurrentUpdatesAreNotLost(t *testing.T) {
+	const updates = 20
+
+	testRoot := dtx.TempTestDir(t)
+	cs, _ := getConfigStore("config.json", testRoot, cfgstore.DefaultConfigDirType)
+	t.Cleanup(cleanupFunc(t, cs))
+
+	var wg sync.WaitGroup
+	for range updates {
+		wg.Go(func() {
+			// Use a separate store per goroutine as separate processes would
+			store, _ := getConfigStore("config.json", testRoot, cfgstore.DefaultConfigDirType)
+			err := cfgstore.UpdateJSON(store, func(cfg *counterConfig) error {
+				cfg.Count++
+				return nil
+			})
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	cfg := counterConfig{}
+	require.NoError(t, cs.LoadJSON(&cfg))
+	assert.Equal(t, updates, cfg.Count)
+}
+
+func TestUpdateJSON_ErrorAbortsSave(t *testing.T) {
+	errNope := errors.New("nope")
+
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cs.SaveJSON(&counterConfig{Count: 1}))
+
+	err := cfgstore.UpdateJSON(cs, func(cfg *counterConfig) error {
+		cfg.Count = 100
+		return errNope
+	})
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToUpdateConfig)
+	assert.ErrorIs(t, err, errNope)
+
+	cfg := counterConfig{}
+	require.NoError(t, cs.LoadJSON(&cfg))
+	assert.Equal(t, 1, cfg.Count)
+}
+
+type partialConfig struct {
+	Name   string `json:"name"`
+	Server struct {
+		Port int `json:"port"`
+	} `json:"server"`
+	Note string `json:"note,omitempty"`
+}
+
+func TestUpdateJSON_PreservesUnknownKeys(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cs.Save([]byte(`{
+  "plugin": {"enabled": true},
+  "name": "old",
+  "server": {"port": 80, "host": "a"},
+  "note": "remove me"
+}`)))
+
+	err := cfgstore.UpdateJSON(cs, func(cfg *partialConfig) error {
+		cfg.Name = "new"
+		cfg.Server.Port = 8080
+		cfg.Note = ""
+		return nil
+	})
+	require.NoError(t, err)
+
+	data, err := cs.Load()
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "plugin": {
+    "enabled": true
+  },
+  "name": "new",
+  "server": {
+    "port": 8080,
+    "host": "a"
+  }
+}
+`, string(data))
+}
+
+func TestUpdateJSON_ReadsFileOnce(t *testing.T) {
+	t.Parallel()
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cs.Save([]byte(`{}`)))
+	var reads int
+	// Each read sees different content, as if another writer changed the file
+	require.NoError(t, cfgstore.AddPreLoadHook(cs, func(args *cfgstore.LoadHookArgs) error {
+		reads++
+		args.Data = fmt.Appendf(nil, `{"name":"read %d","extra":%d}`, reads, reads)
+		return nil
+	}))
+
+	err := cfgstore.UpdateJSON(cs, func(cfg *partialConfig) error {
+		cfg.Name += "!"
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, reads)
+
+	fp, err := cs.GetFilepath()
+	require.NoError(t, err)
+	data, err := os.ReadFile(string(fp))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"read 1!","extra":1,"server":{"port":0}}`, string(data))
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigStore_Usage(t *testing.T) {
+	t.Parallel()
+	cstest.RunOnFileSystems(t, func(t *testing.T, fix *cstest.Fixture) {
+		stores := fix.
+			WithDirTypes(cfgstore.ProjectConfigDirType).
+			WithCLIConfig(`{"name":"cli"}`).
+			WithTokenFile("alice.json", `{"token":"a-much-longer-token-value"}`).
+			WithTokenFile("bob.json", `{"token":"b"}`).
+			Stores()
+		cs := stores.StoreMap[cfgstore.CLIConfigDirType]
+
+		usage, err := cfgstore.StoreUsage(cs, cfgstore.UsageOptions{Largest: 2})
+		require.NoError(t, err)
+		dir, err := cs.ConfigDir()
+		require.NoError(t, err)
+		assert.Equal(t, dir, usage.Dir)
+		assert.Equal(t, 3, usage.FileCount)
+		assert.Equal(t, int64(len(`{"name":"cli"}`)+len(`{"token":"a-much-longer-token-value"}`)+len(`{"token":"b"}`)), usage.TotalBytes)
+		require.Len(t, usage.Largest, 2)
+		assert.Equal(t, dt.RelFilepath("tokens/alice.json"), usage.Largest[0].RelFilepath)
+		assert.Equal(t, int64(len(`{"token":"a-much-longer-token-value"}`)), usage.Largest[0].Size)
+		assert.Equal(t, dt.RelFilepath("config.json"), usage.Largest[1].RelFilepath)
+
+		usage, err = cfgstore.StoreUsage(cs, cfgstore.UsageOptions{Largest: -1})
+		require.NoError(t, err)
+		assert.Empty(t, usage.Largest)
+		assert.Equal(t, 3, usage.FileCount)
+
+		empty, err := cfgstore.StoreUsage(stores.StoreMap[cfgstore.ProjectConfigDirType])
+		require.NoError(t, err, "a missing config dir is empty")
+		assert.Zero(t, empty.FileCount)
+		assert.Zero(t, empty.TotalBytes)
+	})
+}
+
+func TestCacheStore_Usage(t *testing.T) {
+	t.Parallel()
+	dp := cstest.NewTestDirsProvider(cstest.NewTestDirsProviderArgs(t))
+	shared := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{Slug: "acme", RelFilepath: "index.json", DirsProvider: dp})
+	app := cfgstore.NewCacheStore(cfgstore.CacheStoreArgs{Slug: "acme", AppName: "cli", RelFilepath: "a.bin", DirsProvider: dp})
+	require.NoError(t, shared.Save(make([]byte, 10)))
+	require.NoError(t, app.Save(make([]byte, 100)))
+	require.NoError(t, app.SubStore("b.bin").Save(make([]byte, 50)))
+	unlock, err := app.Lock(0)
+	require.NoError(t, err)
+	defer unlock()
+
+	usage, err := app.Usage()
+	require.NoError(t, err)
+	assert.Equal(t, 2, usage.FileCount, "lock files are not counted")
+	assert.Equal(t, int64(150), usage.TotalBytes)
+
+	usage, err = shared.Usage()
+	require.NoError(t, err)
+	assert.Equal(t, 3, usage.FileCount)
+	assert.Equal(t, int64(160), usage.TotalBytes)
+	require.Len(t, usage.Largest, 3)
+	assert.Equal(t, []dt.RelFilepath{"cli/a.bin", "cli/b.bin", "index.json"}, []dt.RelFilepath{
+		usage.Largest[0].RelFilepath,
+		usage.Largest[1].RelFilepath,
+		usage.Largest[2].RelFilepath,
+	})
+}
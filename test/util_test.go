@@ -6,21 +6,12 @@ import (
 	"github.com/mikeschinkel/go-cfgstore"
 	"github.com/mikeschinkel/go-cfgstore/cstest"
 	"github.com/mikeschinkel/go-dt"
-	"github.com/mikeschinkel/go-dt/appinfo"
 )
 
 const (
 	TestConfigSlug = "acme"
 )
 
-type ConfigDirFixturesArgs struct {
-	appinfo.AppInfo
-	DirTypes    []cfgstore.DirType
-	TestDataDir dt.DirPath
-	UserFile    dt.Filepath
-	ProjectFile dt.Filepath
-}
-
 func getConfigStore(fp dt.RelFilepath, testRoot dt.DirPath, dirType cfgstore.DirType) (cfgstore.ConfigStore, *cstest.TestDirsProviderArgs) {
 	args := &cstest.TestDirsProviderArgs{
 		Username:   "coyote",
@@ -50,55 +41,43 @@ end:
 	return
 }
 
-//// SetupConfigDirFixtures sets up a root fixture with two dir fixtures, one to
-//// emulate the user's ~/.config/<path> config directory and the other to emulate
-//// the project's ./.<path> config directory. The userFile and projectFile should
-//// be filenames containing hte respective config files for each.
-//func SetupConfigDirFixtures(t *testing.T, testDataDir dt.DirPath, userFile, projectFile dt.Filepath, args ConfigDirFixturesArgs) (rootFix *fsfix.RootFixture, css *cfgstore.ConfigStores) {
-//
-//	configFile := args.ConfigFile()
-//	rootFix = fsfix.NewRootFixture("cfgstore")
-//	testArgs := &cstest.TestDirsProviderArgs{
-//		Username:   "coyote",
-//		ProjectDir: "billboard",
-//		ConfigSlug: "acme",
-//		TestRootFunc: func() dt.DirPath {
-//			return rootFix.Dir()
-//		},
-//	}
-//	css = cfgstore.NewConfigStores(cfgstore.ConfigStoresArgs{
-//		ConfigStoreArgs: cfgstore.ConfigStoreArgs{
-//			ConfigSlug:   "acme",
-//			RelFilepath:  configFile,
-//			DirsProvider: cstest.NewTestDirsProvider(testArgs),
-//		},
-//	})
-//	cliStore := css.CLIConfigStore()
-//	cliDir, err := cstest.GetRelConfigDir(cliStore, testArgs)
-//	if err != nil {
-//		t.Fatal(err)
-//	}
-//
-//	cliFix := rootFix.AddDirFixture(t, cliDir, nil)
-//	cliFix.AddFileFixture(t, configFile, &fsfix.FileFixtureArgs{
-//		Content: string(testutil.LoadFile(t, userFile, true)),
-//	})
-//
-//	projectStore := css.CLIConfigStore()
-//	projectDir, err := cstest.GetRelConfigDir(projectStore, testArgs)
-//	if err != nil {
-//		t.Fatal(err)
-//	}
-//
-//	projectFix := rootFix.AddDirFixture(t, projectDir, nil)
-//	projectFix.AddFileFixture(t, configFile, &fsfix.FileFixtureArgs{
-//		Content: string(testutil.LoadFile(t, projectFile, true)),
-//	})
-//
-//	rootFix.Create(t)
-//
-//	css.CLIConfigStore().SetConfigDir(cliFix.Dir())
-//	css.ProjectConfigStore().SetConfigDir(projectFix.Dir())
-//
-//	return rootFix, css
-//}
+// testRootConfig is a minimal RootConfig whose receiver's non-empty values take
+// precedence when merged.
+type testRootConfig struct {
+	Name  string `json:"name,omitempty"`
+	Theme string `json:"theme,omitempty"`
+}
+
+func (c *testRootConfig) RootConfig() {}
+
+func (c *testRootConfig) Normalize(cfgstore.NormalizeArgs) error {
+	return nil
+}
+
+func (c *testRootConfig) Merge(rc cfgstore.RootConfig) cfgstore.RootConfig {
+	other := rc.(*testRootConfig)
+	result := *c
+	if result.Name == "" {
+		result.Name = other.Name
+	}
+	if result.Theme == "" {
+		result.Theme = other.Theme
+	}
+	return &result
+}
+
+func newTestDirsProviderArgs(testRoot dt.DirPath) *cstest.TestDirsProviderArgs {
+	return &cstest.TestDirsProviderArgs{
+		Username:   "coyote",
+		ProjectDir: "billboard",
+		ConfigSlug: TestConfigSlug,
+		TestRoot:   testRoot,
+	}
+}
+
+func newLayeredStores(t *testing.T) (stores *cfgstore.ConfigStores, args cfgstore.RootConfigArgs) {
+	fix := cstest.NewFixture(t).
+		WithCLIConfig(&testRootConfig{Name: "cli", Theme: "dark"}).
+		WithProjectConfig(&testRootConfig{Name: "project"})
+	return fix.Stores(), fix.RootConfigArgs()
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfigStoreE(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		dirType cfgstore.DirType
+		slug    dt.PathSegment
+		rf      dt.RelFilepath
+		tenant  dt.PathSegment
+		errs    []error
+	}{
+		{name: "valid", dirType: cfgstore.CLIConfigDirType, slug: "myapp", rf: "profiles/work.json"},
+		{name: "unspecified dir type", dirType: cfgstore.UnspecifiedConfigDirType, slug: "myapp", rf: "config.json",
+			errs: []error{cfgstore.ErrInvalidConfigDirType}},
+		{name: "invalid dir type", dirType: cfgstore.DirType(99), slug: "myapp", rf: "config.json",
+			errs: []error{cfgstore.ErrInvalidConfigDirType}},
+		{name: "empty slug", dirType: cfgstore.CLIConfigDirType, rf: "config.json",
+			errs: []error{cfgstore.ErrInvalidConfigSlug}},
+		{name: "traversal slug", dirType: cfgstore.CLIConfigDirType, slug: "../../etc", rf: "config.json",
+			errs: []error{cfgstore.ErrInvalidConfigSlug}},
+		{name: "empty rel filepath", dirType: cfgstore.CLIConfigDirType, slug: "myapp",
+			errs: []error{cfgstore.ErrInvalidConfigFilepath}},
+		{name: "absolute rel filepath", dirType: cfgstore.CLIConfigDirType, slug: "myapp", rf: "/etc/passwd",
+			errs: []error{cfgstore.ErrInvalidConfigFilepath}},
+		{name: "traversal rel filepath", dirType: cfgstore.CLIConfigDirType, slug: "myapp", rf: "../escape.json",
+			errs: []error{cfgstore.ErrInvalidConfigFilepath}},
+		{name: "unknown placeholder", dirType: cfgstore.CLIConfigDirType, slug: "myapp", rf: "config-{profile}.json",
+			errs: []error{cfgstore.ErrUnknownPlaceholder}},
+		{name: "valid tenant", dirType: cfgstore.CLIConfigDirType, slug: "myapp", rf: "config.json", tenant: "acme"},
+		{name: "traversal tenant", dirType: cfgstore.CLIConfigDirType, slug: "myapp", rf: "config.json", tenant: "../x",
+			errs: []error{cfgstore.ErrInvalidTenant}},
+		{name: "hidden tenant", dirType: cfgstore.CLIConfigDirType, slug: "myapp", rf: "config.json", tenant: ".hidden",
+			errs: []error{cfgstore.ErrInvalidTenant}},
+		{name: "all reported", dirType: cfgstore.UnspecifiedConfigDirType, slug: "..", rf: "../escape.json", tenant: "a/b",
+			errs: []error{cfgstore.ErrInvalidConfigDirType, cfgstore.ErrInvalidConfigSlug, cfgstore.ErrInvalidTenant, cfgstore.ErrInvalidConfigFilepath}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			store, err := cfgstore.NewConfigStoreE(tt.dirType,
+				cfgstore.WithSlug(tt.slug),
+				cfgstore.WithRelFilepath(tt.rf),
+				cfgstore.WithTenant(tt.tenant),
+			)
+			if len(tt.errs) == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, tt.rf, store.GetRelFilepath())
+				return
+			}
+			assert.Nil(t, store)
+			for _, want := range tt.errs {
+				assert.ErrorIs(t, err, want)
+			}
+		})
+	}
+}
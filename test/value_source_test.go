@@ -0,0 +1,37 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigStores_ValueSource(t *testing.T) {
+	stores, _ := newLayeredStores(t)
+
+	src, found, err := stores.ValueSource("name")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, cfgstore.ProjectConfigDirType, src.DirType)
+	assert.Equal(t, "project", src.Value)
+	fp, err := stores.ProjectConfigStore().GetFilepath()
+	require.NoError(t, err)
+	assert.Equal(t, fp, src.Filepath)
+	assert.False(t, src.ModTime.IsZero())
+
+	src, found, err = stores.ValueSource("theme")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, cfgstore.CLIConfigDirType, src.DirType)
+
+	sources, err := stores.ValueSources("name")
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+	assert.Equal(t, "cli", sources[0].Value)
+
+	_, found, err = stores.ValueSource("missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
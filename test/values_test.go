@@ -0,0 +1,318 @@
+package test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-dt/dtx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newValuesStore(t *testing.T, content string) cfgstore.ConfigStore {
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	if content != "" {
+		require.NoError(t, cs.Save([]byte(content)))
+	}
+	return cs
+}
+
+func loadString(t *testing.T, cs cfgstore.ConfigStore) string {
+	data, err := cs.Load()
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestGetValue(t *testing.T) {
+	cs := newValuesStore(t, `{"server":{"host":"localhost","port":8080},"tags":["a","b"]}`)
+
+	tests := []struct {
+		name  string
+		path  string
+		value any
+		found bool
+	}{
+		{name: "Nested number", path: "server.port", value: float64(8080), found: true},
+		{name: "Object", path: "server", value: map[string]any{"host": "localhost", "port": float64(8080)}, found: true},
+		{name: "Array element", path: "tags.1", value: "b", found: true},
+		{name: "Missing key", path: "server.user", found: false},
+		{name: "Array index out of range", path: "tags.2", found: false},
+		{name: "Through a scalar", path: "server.port.x", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, found, err := cfgstore.GetValue(cs, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.value, value)
+		})
+	}
+}
+
+func TestGetValue_MissingFile(t *testing.T) {
+	cs := newValuesStore(t, "")
+
+	value, found, err := cfgstore.GetValue(cs, "server.port")
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Nil(t, value)
+}
+
+func TestSetValue_PreservesOrderAndFormatting(t *testing.T) {
+	cs := newValuesStore(t, `{"zeta":1.50,"alpha":{"port":80},"mid":true}`)
+
+	require.NoError(t, cfgstore.SetValue(cs, "alpha.port", 8080))
+	require.NoError(t, cfgstore.SetValue(cs, "alpha.tls.enabled", true))
+
+	assert.Equal(t, `{
+  "zeta": 1.50,
+  "alpha": {
+    "port": 8080,
+    "tls": {
+      "enabled": true
+    }
+  },
+  "mid": true
+}
+`, loadString(t, cs))
+}
+
+func TestSetValue_CreatesFile(t *testing.T) {
+	cs := newValuesStore(t, "")
+
+	require.NoError(t, cfgstore.SetValue(cs, "servers", []map[string]any{{"host": "a"}}))
+
+	// An index equal to the length of an array appends to it
+	require.NoError(t, cfgstore.SetValue(cs, "servers.1.host", "b"))
+
+	value, found, err := cfgstore.GetValue(cs, "servers")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []any{
+		map[string]any{"host": "a"},
+		map[string]any{"host": "b"},
+	}, value)
+}
+
+func TestSetValue_ConcurrentEditsAreNotLost(t *testing.T) {
+	const edits = 20
+
+	testRoot := dtx.TempTestDir(t)
+	cs, _ := getConfigStore("config.json", testRoot, cfgstore.DefaultConfigDirType)
+	t.Cleanup(cleanupFunc(t, cs))
+
+	var wg sync.WaitGroup
+	for i := range edits {
+		wg.Go(func() {
+			// Use a separate store per goroutine as separate processes would
+			store, _ := getConfigStore("config.json", testRoot, cfgstore.DefaultConfigDirType)
+			assert.NoError(t, cfgstore.SetValue(store, fmt.Sprintf("key%d", i), i))
+		})
+	}
+	wg.Wait()
+
+	keys, err := cfgstore.Keys(cs, "")
+	require.NoError(t, err)
+	assert.Len(t, keys, edits)
+}
+
+func TestSetValue_Errors(t *testing.T) {
+	cs := newValuesStore(t, `{"port":8080,"tags":["a"]}`)
+
+	err := cfgstore.SetValue(cs, "port.number", 1)
+	assert.ErrorIs(t, err, cfgstore.ErrValuePathNotContainer)
+
+	err = cfgstore.SetValue(cs, "tags.5", "x")
+	assert.ErrorIs(t, err, cfgstore.ErrInvalidValuePath)
+
+	err = cfgstore.SetValue(cs, "port", func() {})
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToMarshalValue)
+
+	// Failed sets must leave the file untouched
+	assert.Equal(t, `{"port":8080,"tags":["a"]}`, loadString(t, cs))
+}
+
+func TestGetValue_InvalidJSON(t *testing.T) {
+	cs := newValuesStore(t, `{"port":`)
+
+	_, _, err := cfgstore.GetValue(cs, "port")
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToUnmarshalConfigFile)
+}
+
+func TestUnsetValue(t *testing.T) {
+	const content = `{"server":{"tls":{"cert":"a.pem"},"port":80},"tags":["a","b","c"]}`
+
+	tests := []struct {
+		name    string
+		path    string
+		opts    []cfgstore.UnsetValueOptions
+		found   bool
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "Leaves empty parent",
+			path:  "server.tls.cert",
+			found: true,
+			want:  `{"server":{"tls":{},"port":80},"tags":["a","b","c"]}`,
+		},
+		{
+			name:  "Prunes empty parent",
+			path:  "server.tls.cert",
+			opts:  []cfgstore.UnsetValueOptions{{PruneEmpty: true}},
+			found: true,
+			want:  `{"server":{"port":80},"tags":["a","b","c"]}`,
+		},
+		{
+			name:  "Array element",
+			path:  "tags.1",
+			found: true,
+			want:  `{"server":{"tls":{"cert":"a.pem"},"port":80},"tags":["a","c"]}`,
+		},
+		{
+			name: "Missing key",
+			path: "server.user",
+			want: content,
+		},
+		{
+			name:    "Entire document",
+			path:    "",
+			want:    content,
+			wantErr: cfgstore.ErrInvalidValuePath,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := newValuesStore(t, content)
+
+			found, err := cfgstore.UnsetValue(cs, tt.path, tt.opts...)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.found, found)
+			assert.JSONEq(t, tt.want, loadString(t, cs))
+		})
+	}
+}
+
+func TestValues_JSONPointer(t *testing.T) {
+	cs := newValuesStore(t, `{"a/b":{"m~n":1},"servers":[{"host":"a"}],"":"empty"}`)
+
+	tests := []struct {
+		name  string
+		path  string
+		value any
+		found bool
+	}{
+		{name: "Escaped segments", path: "/a~1b/m~0n", value: float64(1), found: true},
+		{name: "Array element", path: "/servers/0/host", value: "a", found: true},
+		{name: "Empty key", path: "/", value: "empty", found: true},
+		{name: "Past the end", path: "/servers/-", found: false},
+		{name: "Signed index", path: "/servers/+0/host", found: false},
+		{name: "Leading zero", path: "/servers/00/host", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, found, err := cfgstore.GetValue(cs, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.value, value)
+		})
+	}
+
+	_, _, err := cfgstore.GetValue(cs, "/a~2b")
+	assert.ErrorIs(t, err, cfgstore.ErrInvalidValuePath)
+
+	err = cfgstore.SetValue(cs, "/servers/01", map[string]any{"host": "b"})
+	assert.ErrorIs(t, err, cfgstore.ErrInvalidValuePath)
+
+	require.NoError(t, cfgstore.SetValue(cs, "/servers/-", map[string]any{"host": "b"}))
+	value, _, err := cfgstore.GetValue(cs, "/servers/1/host")
+	require.NoError(t, err)
+	assert.Equal(t, "b", value)
+}
+
+func TestKeys(t *testing.T) {
+	cs := newValuesStore(t, `{"server":{"port":80,"host":"a","tls":{}},"tags":["x","y"],"sort":true}`)
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{name: "All", prefix: "", want: []string{"server.port", "server.host", "server.tls", "tags.0", "tags.1", "sort"}},
+		{name: "Partial key", prefix: "so", want: []string{"sort"}},
+		{name: "Nested", prefix: "server.", want: []string{"server.port", "server.host", "server.tls"}},
+		{name: "No match", prefix: "client", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := cfgstore.Keys(cs, tt.prefix)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, keys)
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	cs := newValuesStore(t, `{"server":{"port":80},"tags":["x"]}`)
+
+	var paths []string
+	err := cfgstore.Walk(cs, func(path string, value any) error {
+		paths = append(paths, path)
+		if path == "tags" {
+			assert.Equal(t, []any{"x"}, value)
+			return cfgstore.SkipChildren
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"server", "server.port", "tags"}, paths)
+}
+
+func TestMergeJSON(t *testing.T) {
+	cs := newValuesStore(t, `{"name":"acme","server":{"host":"a","port":80},"tags":["x"],"old":1}`)
+
+	err := cfgstore.MergeJSON(cs, []byte(`{"server":{"port":8080,"tls":{"on":true,"skip":null}},"tags":["y"],"old":null,"new":"n"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, `{
+  "name": "acme",
+  "server": {
+    "host": "a",
+    "port": 8080,
+    "tls": {
+      "on": true
+    }
+  },
+  "tags": [
+    "y"
+  ],
+  "new": "n"
+}
+`, loadString(t, cs))
+}
+
+func TestMergeJSON_CreatesFile(t *testing.T) {
+	cs := newValuesStore(t, "")
+
+	require.NoError(t, cfgstore.MergeJSON(cs, []byte(`{"telemetry":{"enabled":false}}`)))
+	assert.JSONEq(t, `{"telemetry":{"enabled":false}}`, loadString(t, cs))
+}
+
+func TestMergeJSON_RequiresObject(t *testing.T) {
+	cs := newValuesStore(t, `{"name":"acme"}`)
+
+	err := cfgstore.MergeJSON(cs, []byte(`["x"]`))
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToMergeJSON)
+	assert.ErrorIs(t, err, cfgstore.ErrValueTypeMismatch)
+
+	err = cfgstore.MergeJSON(cs, []byte(`{"name":`))
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToUnmarshalConfigFile)
+	assert.Equal(t, `{"name":"acme"}`, loadString(t, cs))
+}
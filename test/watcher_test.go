@@ -0,0 +1,225 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const watchTimeout = 2 * time.Second
+
+type serverConfig struct {
+	Server struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	} `json:"server"`
+}
+
+func TestWatcher_SubscribeOnlyNotifiesOnValueChange(t *testing.T) {
+	var err error
+
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	cfg := serverConfig{}
+	cfg.Server.Host = "localhost"
+	cfg.Server.Port = 8080
+	err = cs.SaveJSON(&cfg)
+	require.NoError(t, err)
+
+	changes := make(chan cfgstore.ValueChange, 10)
+	events := make(chan cfgstore.WatchEvent, 10)
+
+	w := cfgstore.NewWatcher(cs, cfgstore.WatcherArgs{Interval: 10 * time.Millisecond})
+	w.Subscribe("server.port", func(vc cfgstore.ValueChange) {
+		changes <- vc
+	})
+	w.OnChange(func(ev cfgstore.WatchEvent) {
+		events <- ev
+	})
+	require.NoError(t, w.Start())
+	t.Cleanup(w.Stop)
+
+	// Changing an unrelated key fires OnChange but not the subscription
+	cfg.Server.Host = "example.com"
+	err = cs.SaveJSON(&cfg)
+	require.NoError(t, err)
+
+	select {
+	case ev := <-events:
+		assert.NoError(t, ev.Err)
+		assert.True(t, ev.Exists)
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for change event")
+	}
+	select {
+	case vc := <-changes:
+		t.Fatalf("unexpected change notification for %s", vc.Path)
+	default:
+	}
+
+	cfg.Server.Port = 9090
+	err = cs.SaveJSON(&cfg)
+	require.NoError(t, err)
+
+	select {
+	case vc := <-changes:
+		assert.Equal(t, "server.port", vc.Path)
+		assert.Equal(t, float64(8080), vc.OldValue)
+		assert.Equal(t, float64(9090), vc.NewValue)
+		assert.True(t, vc.Existed)
+		assert.True(t, vc.Exists)
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for subscription notification")
+	}
+}
+
+func TestWatcher_TransientLoadError(t *testing.T) {
+	var failing atomic.Bool
+
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+	require.NoError(t, cfgstore.AddPreLoadHook(cs, func(*cfgstore.LoadHookArgs) error {
+		if failing.Load() {
+			return errors.New("network home dir unavailable")
+		}
+		return nil
+	}))
+	cfg := serverConfig{}
+	cfg.Server.Host = "a"
+	cfg.Server.Port = 8080
+	require.NoError(t, cs.SaveJSON(&cfg))
+
+	changes := make(chan cfgstore.ValueChange, 10)
+	events := make(chan cfgstore.WatchEvent, 10)
+	w := cfgstore.NewWatcher(cs, cfgstore.WatcherArgs{Interval: 10 * time.Millisecond})
+	w.Subscribe("server.port", func(vc cfgstore.ValueChange) {
+		changes <- vc
+	})
+	w.OnChange(func(ev cfgstore.WatchEvent) {
+		events <- ev
+	})
+	require.NoError(t, w.Start())
+	t.Cleanup(w.Stop)
+
+	waitForEvent := func(wantErr bool) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			assert.Equal(t, wantErr, ev.Err != nil)
+		case <-time.After(watchTimeout):
+			t.Fatal("timed out waiting for change event")
+		}
+	}
+	failing.Store(true)
+	cfg.Server.Host = "bb"
+	require.NoError(t, cs.SaveJSON(&cfg))
+	waitForEvent(true)
+
+	failing.Store(false)
+	cfg.Server.Host = "ccc"
+	require.NoError(t, cs.SaveJSON(&cfg))
+	waitForEvent(false)
+
+	select {
+	case vc := <-changes:
+		t.Fatalf("unexpected change notification for %s, existed=%v", vc.Path, vc.Existed)
+	default:
+	}
+}
+
+func TestWatcher_StartTwice(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	w := cfgstore.NewWatcher(cs, cfgstore.WatcherArgs{Interval: 10 * time.Millisecond})
+	require.NoError(t, w.Start())
+	t.Cleanup(w.Stop)
+
+	err := w.Start()
+	assert.ErrorIs(t, err, cfgstore.ErrWatcherAlreadyStarted)
+}
+
+func TestWatcher_DebounceCoalescesBursts(t *testing.T) {
+	var err error
+
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	cfg := serverConfig{}
+	cfg.Server.Port = 1000
+	err = cs.SaveJSON(&cfg)
+	require.NoError(t, err)
+
+	changes := make(chan cfgstore.ValueChange, 10)
+	w := cfgstore.NewWatcher(cs, cfgstore.WatcherArgs{
+		Interval: 10 * time.Millisecond,
+		Debounce: 300 * time.Millisecond,
+	})
+	w.Subscribe("server.port", func(vc cfgstore.ValueChange) {
+		changes <- vc
+	})
+	require.NoError(t, w.Start())
+	t.Cleanup(w.Stop)
+
+	// Simulate an editor writing several times in quick succession
+	for port := 1001; port <= 1005; port++ {
+		cfg.Server.Port = port
+		err = cs.SaveJSON(&cfg)
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	select {
+	case vc := <-changes:
+		assert.Equal(t, float64(1000), vc.OldValue)
+		assert.Equal(t, float64(1005), vc.NewValue)
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for subscription notification")
+	}
+	select {
+	case vc := <-changes:
+		t.Fatalf("expected a single coalesced notification, also got %v", vc.NewValue)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestWatcher_RunStopsOnCancel(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := cfgstore.NewWatcher(cs, cfgstore.WatcherArgs{Interval: 10 * time.Millisecond})
+
+	result := make(chan error, 1)
+	go func() {
+		result <- w.Run(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-result:
+		assert.NoError(t, err)
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for Run to return")
+	}
+
+	// A canceled context must not prevent the watcher from being restarted
+	require.NoError(t, w.Start())
+	w.Stop()
+}
+
+func TestWatcher_StartContextCanceled(t *testing.T) {
+	cs := cstest.NewTestStore(t, cfgstore.DefaultConfigDirType, TestConfigSlug, "config.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w := cfgstore.NewWatcher(cs, cfgstore.WatcherArgs{})
+	err := w.StartContext(ctx)
+	assert.ErrorIs(t, err, cfgstore.ErrFailedToStartWatcher)
+	assert.ErrorIs(t, err, context.Canceled)
+}
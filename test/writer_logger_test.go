@@ -0,0 +1,240 @@
+package test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-cfgstore/cstest"
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-logutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateWriterLogger_Formats is not parallel because CreateWriterLogger
+// replaces the package's logger.
+func TestCreateWriterLogger_Formats(t *testing.T) {
+	saved := cfgstore.Logger()
+	defer cfgstore.SetLogger(saved)
+
+	tests := []struct {
+		name    string
+		format  cfgstore.LogFormat
+		console bool
+		want    string
+	}{
+		{
+			name:   "json",
+			format: cfgstore.JSONLogFormat,
+			want:   `"msg":"hello","user":"coyote"`,
+		},
+		{
+			name:   "text",
+			format: cfgstore.TextLogFormat,
+			want:   `msg=hello user=coyote`,
+		},
+		{
+			name:    "json and console",
+			format:  cfgstore.JSONLogFormat,
+			console: true,
+			want:    `"msg":"hello","user":"coyote"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var console bytes.Buffer
+
+			args := cstest.NewTestDirsProviderArgs(t)
+			wlArgs := &cfgstore.WriterLoggerArgs{
+				ConfigSlug:   args.ConfigSlug,
+				LogFile:      "app.log",
+				Verbosity:    cliutil.LowVerbosity,
+				Format:       tt.format,
+				DirsProvider: cstest.NewTestDirsProvider(args),
+			}
+			if tt.console {
+				wlArgs.Console = &console
+			}
+			wl, err := cfgstore.CreateWriterLogger(wlArgs)
+			require.NoError(t, err)
+
+			wl.Logger.Info("hello", "user", "coyote")
+
+			dir, err := cfgstore.LogDir(wlArgs)
+			require.NoError(t, err)
+			data, err := os.ReadFile(string(dt.FilepathJoin(dir, "app.log")))
+			require.NoError(t, err)
+			assert.Contains(t, string(data), tt.want)
+			if tt.console {
+				assert.Contains(t, console.String(), "msg=hello user=coyote")
+			}
+		})
+	}
+}
+
+// TestCreateWriterLogger_CustomHandler is not parallel because
+// CreateWriterLogger replaces the package's logger.
+func TestCreateWriterLogger_CustomHandler(t *testing.T) {
+	saved := cfgstore.Logger()
+	defer cfgstore.SetLogger(saved)
+
+	t.Run("handler", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		args := cstest.NewTestDirsProviderArgs(t)
+		wlArgs := &cfgstore.WriterLoggerArgs{
+			ConfigSlug:   args.ConfigSlug,
+			LogFile:      "app.log",
+			Verbosity:    cliutil.LowVerbosity,
+			Handler:      slog.NewTextHandler(&buf, nil),
+			DirsProvider: cstest.NewTestDirsProvider(args),
+		}
+		wl, err := cfgstore.CreateWriterLogger(wlArgs)
+		require.NoError(t, err)
+
+		wl.Logger.Info("hello", "user", "coyote")
+		assert.Contains(t, buf.String(), "msg=hello user=coyote")
+		assert.Same(t, wl.Logger, cfgstore.Logger())
+
+		dir, err := cfgstore.LogDir(wlArgs)
+		require.NoError(t, err)
+		assert.NoDirExists(t, string(dir), "no log file is created")
+	})
+
+	t.Run("handler func", func(t *testing.T) {
+		var gotOpts *slog.HandlerOptions
+
+		args := cstest.NewTestDirsProviderArgs(t)
+		wlArgs := &cfgstore.WriterLoggerArgs{
+			ConfigSlug: args.ConfigSlug,
+			LogFile:    "app.log",
+			Verbosity:  cliutil.LowVerbosity,
+			Level:      slog.LevelDebug,
+			HandlerFunc: func(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+				gotOpts = opts
+				return slog.NewTextHandler(w, &slog.HandlerOptions{
+					Level: opts.Level,
+					ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+						if a.Key == slog.MessageKey {
+							a.Key = "message"
+						}
+						return a
+					},
+				})
+			},
+			DirsProvider: cstest.NewTestDirsProvider(args),
+		}
+		wl, err := cfgstore.CreateWriterLogger(wlArgs)
+		require.NoError(t, err)
+		require.NotNil(t, gotOpts)
+		assert.Equal(t, slog.LevelDebug, gotOpts.Level)
+
+		wl.Logger.Debug("hello")
+
+		dir, err := cfgstore.LogDir(wlArgs)
+		require.NoError(t, err)
+		fp := dt.FilepathJoin(dir, "app.log")
+		data, err := os.ReadFile(string(fp))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "level=DEBUG message=hello")
+		assert.Equal(t, fp, logutil.GetJSONFilepath(wl.Logger))
+	})
+}
+
+func TestLogDir(t *testing.T) {
+	t.Parallel()
+	args := cstest.NewTestDirsProviderArgs(t)
+	args.GOOS = "linux"
+	wlArgs := &cfgstore.WriterLoggerArgs{
+		ConfigSlug:   args.ConfigSlug,
+		DirsProvider: cstest.NewTestDirsProvider(args),
+	}
+
+	dir, err := cfgstore.LogDir(wlArgs)
+	require.NoError(t, err)
+	assert.Equal(t, dt.DirPathJoin(args.TestRoot, "home/coyote/.local/state/acme/logs"), dir)
+
+	wlArgs.LegacyLogDir = true
+	dir, err = cfgstore.LogDir(wlArgs)
+	require.NoError(t, err)
+	assert.Equal(t, dt.DirPathJoin(args.TestRoot, "home/coyote/.config/acme/logs"), dir)
+
+	wlArgs.DirsProvider = cstest.NewFailingDirsProvider(wlArgs.DirsProvider).FailUserStateDir(nil).Build()
+	wlArgs.LegacyLogDir = false
+	_, err = cfgstore.LogDir(wlArgs)
+	assert.ErrorIs(t, err, cfgstore.ErrFailedGettingUserStateDir)
+	assert.ErrorIs(t, err, cstest.ErrInjected)
+}
+
+func TestMigrateLogs(t *testing.T) {
+	t.Parallel()
+	args := cstest.NewTestDirsProviderArgs(t)
+	wlArgs := &cfgstore.WriterLoggerArgs{
+		ConfigSlug:   args.ConfigSlug,
+		DirsProvider: cstest.NewTestDirsProvider(args),
+		LegacyLogDir: true,
+	}
+	oldDir, err := cfgstore.LogDir(wlArgs)
+	require.NoError(t, err)
+	require.NoError(t, oldDir.MkdirAll(0755))
+	for _, name := range []string{"app.log", "app-20250102T150405.000.log"} {
+		require.NoError(t, os.WriteFile(string(dt.FilepathJoin(oldDir, name)), []byte(name), 0644))
+	}
+
+	moved, err := cfgstore.MigrateLogs(wlArgs)
+	require.NoError(t, err)
+
+	wlArgs.LegacyLogDir = false
+	newDir, err := cfgstore.LogDir(wlArgs)
+	require.NoError(t, err)
+	assert.Equal(t, []dt.Filepath{
+		dt.FilepathJoin(newDir, "app-20250102T150405.000.log"),
+		dt.FilepathJoin(newDir, "app.log"),
+	}, moved)
+	data, err := os.ReadFile(string(dt.FilepathJoin(newDir, "app.log")))
+	require.NoError(t, err)
+	assert.Equal(t, "app.log", string(data))
+	_, err = os.Stat(string(oldDir))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Nothing left to migrate
+	moved, err = cfgstore.MigrateLogs(wlArgs)
+	require.NoError(t, err)
+	assert.Empty(t, moved)
+}
+
+func TestMigrateLogs_FileSystem(t *testing.T) {
+	t.Parallel()
+	args := cstest.NewTestDirsProviderArgs(t)
+	dp := cstest.NewTestDirsProvider(args)
+	mfs := cstest.NewMemFileSystem()
+	dp.FileSystem = mfs
+	wlArgs := &cfgstore.WriterLoggerArgs{
+		ConfigSlug:   args.ConfigSlug,
+		DirsProvider: dp,
+		LegacyLogDir: true,
+	}
+	oldDir, err := cfgstore.LogDir(wlArgs)
+	require.NoError(t, err)
+	require.NoError(t, mfs.WriteFile(dt.FilepathJoin(oldDir, "app.log"), []byte("app.log")))
+
+	moved, err := cfgstore.MigrateLogs(wlArgs)
+	require.NoError(t, err)
+
+	wlArgs.LegacyLogDir = false
+	newDir, err := cfgstore.LogDir(wlArgs)
+	require.NoError(t, err)
+	assert.Equal(t, []dt.Filepath{dt.FilepathJoin(newDir, "app.log")}, moved)
+	data, err := mfs.ReadFile(dt.FilepathJoin(newDir, "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "app.log", string(data))
+	_, err = mfs.ReadFile(dt.FilepathJoin(oldDir, "app.log"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(string(newDir))
+	assert.ErrorIs(t, err, os.ErrNotExist, "nothing is written to disk")
+}
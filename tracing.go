@@ -0,0 +1,98 @@
+package cfgstore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// Names of the spans started with the Tracer set with SetTracer.
+const (
+	LoadConfigSpanName = "cfgstore.load_config" // LoadConfigStores loading and merging every layer
+	LoadSpanName       = "cfgstore.load"        // A store's file being loaded
+	SaveSpanName       = "cfgstore.save"        // A store's file being saved
+	CreateSpanName     = "cfgstore.create"      // A default config being created for a store
+	MergeSpanName      = "cfgstore.merge"       // The layers' configs being merged
+	ReadFileSpanName   = "cfgstore.read_file"   // A ContextFileSystem reading a file
+	WriteFileSpanName  = "cfgstore.write_file"  // A ContextFileSystem writing a file
+	LockSpanName       = "cfgstore.lock"        // A ContextFileSystem acquiring a lock
+)
+
+// Tracer starts spans for the config IO performed by this package, e.g. to find
+// the network home dir or remote backend behind a slow startup; see the cfgotel
+// module for an OpenTelemetry adapter. Spans are started with the context of
+// the operation, e.g. the one passed to LoadContext or LoadConfigStoresContext,
+// and the context returned is passed on, so the spans of a store's file reads
+// are children of its load's span. Only ContextFileSystem calls, which may be
+// remote, get spans of their own.
+type Tracer interface {
+	Start(ctx context.Context, args SpanArgs) (context.Context, Span)
+}
+
+// SpanArgs describes a span started by a Tracer.
+type SpanArgs struct {
+	// Name is one of the *SpanName constants, e.g. LoadSpanName.
+	Name     string
+	DirType  DirType
+	Filepath dt.Filepath
+
+	// DirTypes lists the layers, in order, of LoadConfigSpanName and
+	// MergeSpanName spans.
+	DirTypes []DirType
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// End ends the span, recording err as the reason it failed if it is not
+	// nil.
+	End(err error)
+}
+
+var (
+	tracerMutex   sync.RWMutex
+	currentTracer Tracer
+)
+
+// SetTracer makes t start the spans of every store, replacing the Tracer set
+// before, if any. A nil t stops tracing. Call the returned func to restore the
+// previous Tracer, e.g. at the end of a test.
+func SetTracer(t Tracer) (restore func()) {
+	tracerMutex.Lock()
+	prev := currentTracer
+	currentTracer = t
+	tracerMutex.Unlock()
+
+	return func() {
+		tracerMutex.Lock()
+		currentTracer = prev
+		tracerMutex.Unlock()
+	}
+}
+
+// startSpan starts a span with the Tracer set with SetTracer, or returns ctx and
+// a Span that does nothing if there is none.
+func startSpan(ctx context.Context, args SpanArgs) (context.Context, Span) {
+	tracerMutex.RLock()
+	t := currentTracer
+	tracerMutex.RUnlock()
+
+	if t == nil {
+		return ctx, noopSpan{}
+	}
+	return t.Start(ctx, args)
+}
+
+// startSpan starts a span named name for an operation on cs.
+func (cs *configStore) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	fp, _ := cs.GetFilepath()
+	return startSpan(ctx, SpanArgs{
+		Name:     name,
+		DirType:  cs.dirType,
+		Filepath: fp,
+	})
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(error) {}
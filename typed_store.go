@@ -0,0 +1,48 @@
+package cfgstore
+
+// TypedStore wraps a ConfigStore whose file holds a T, so application code can
+// load and save a T directly instead of passing an any to LoadJSON and SaveJSON
+// or threading the [RC, PRC] type parameters of the layered loaders:
+//
+//	settings := cfgstore.Typed[Settings](store)
+//	s, err := settings.Load()
+//	err = settings.Update(func(s *Settings) error {
+//		s.Theme = "dark"
+//		return nil
+//	})
+type TypedStore[T any] struct {
+	store ConfigStore
+}
+
+// Typed returns a TypedStore for cs.
+func Typed[T any](cs ConfigStore) *TypedStore[T] {
+	return &TypedStore[T]{store: cs}
+}
+
+// Store returns the wrapped ConfigStore.
+func (ts *TypedStore[T]) Store() ConfigStore {
+	return ts.store
+}
+
+// Load returns the store's file unmarshaled into a T, or the zero T and an
+// error. A file that does not exist fails with an error matching
+// ErrFileDoesNotExist.
+func (ts *TypedStore[T]) Load() (v T, err error) {
+	err = ts.store.LoadJSON(&v)
+	if err != nil {
+		var zero T
+		v = zero
+	}
+	return v, err
+}
+
+// Save replaces the store's file with v.
+func (ts *TypedStore[T]) Save(v T) error {
+	return ts.store.SaveJSON(&v)
+}
+
+// Update loads the store's file into a T, calls fn to modify it and saves it
+// while holding the file's lock, as UpdateJSON does.
+func (ts *TypedStore[T]) Update(fn func(*T) error, opts ...UpdateOptions) error {
+	return UpdateJSON(ts.store, fn, opts...)
+}
@@ -0,0 +1,188 @@
+package cfgstore
+
+import (
+	"encoding/json/jsontext"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetString returns the value at path as a string. Numbers and booleans are
+// returned as they are written in the file. ok is false if the path does not
+// exist or its value is null.
+func GetString(store ConfigStore, path string) (value string, ok bool, err error) {
+	var raw jsontext.Value
+
+	raw, ok, err = getScalar(store, path)
+	if !ok || err != nil {
+		goto end
+	}
+	switch raw.Kind() {
+	case '"':
+		value, err = unquoteJSON(raw)
+	default:
+		value = string(raw)
+	}
+end:
+	if err != nil {
+		ok = false
+		err = WithErr(err, "path", path)
+	}
+	return value, ok, err
+}
+
+// GetInt returns the value at path as an int. Numbers without a fractional part,
+// e.g. 8080 or 8.08e3, and strings containing one are accepted. ok is false if
+// the path does not exist or its value is null.
+func GetInt(store ConfigStore, path string) (value int, ok bool, err error) {
+	var raw jsontext.Value
+	var s string
+	var f float64
+
+	raw, ok, err = getScalar(store, path)
+	if !ok || err != nil {
+		goto end
+	}
+	s, err = scalarText(raw, '0')
+	if err != nil {
+		goto end
+	}
+	value, err = strconv.Atoi(s)
+	if err == nil {
+		goto end
+	}
+	// Accept integral values written with an exponent or a fraction. float64
+	// rounds math.MaxInt up to -math.MinInt, which is itself out of range.
+	f, err = strconv.ParseFloat(s, 64)
+	if err != nil || f != math.Trunc(f) || f >= -float64(math.MinInt) || f < float64(math.MinInt) {
+		err = NewErr(ErrValueTypeMismatch, "expected", "int", "value", s)
+		goto end
+	}
+	value = int(f)
+end:
+	if err != nil {
+		ok = false
+		err = WithErr(err, "path", path)
+	}
+	return value, ok, err
+}
+
+// GetBool returns the value at path as a bool. Strings accepted by
+// strconv.ParseBool, such as "true", "1" or "F", are accepted. ok is false if
+// the path does not exist or its value is null.
+func GetBool(store ConfigStore, path string) (value bool, ok bool, err error) {
+	var raw jsontext.Value
+	var s string
+
+	raw, ok, err = getScalar(store, path)
+	if !ok || err != nil {
+		goto end
+	}
+	s, err = scalarText(raw, 't', 'f')
+	if err != nil {
+		goto end
+	}
+	value, err = strconv.ParseBool(s)
+	if err != nil {
+		err = NewErr(ErrValueTypeMismatch, "expected", "bool", "value", s)
+		goto end
+	}
+end:
+	if err != nil {
+		ok = false
+		err = WithErr(err, "path", path)
+	}
+	return value, ok, err
+}
+
+// GetDuration returns the value at path as a time.Duration. Strings are parsed
+// with time.ParseDuration, e.g. "1m30s", while numbers, and strings containing
+// only a number, are taken to be seconds. ok is false if the path does not exist
+// or its value is null.
+func GetDuration(store ConfigStore, path string) (value time.Duration, ok bool, err error) {
+	var raw jsontext.Value
+	var s string
+	var f float64
+
+	raw, ok, err = getScalar(store, path)
+	if !ok || err != nil {
+		goto end
+	}
+	s, err = scalarText(raw, '0')
+	if err != nil {
+		goto end
+	}
+	f, err = strconv.ParseFloat(s, 64)
+	if err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		f *= float64(time.Second)
+		if f >= -float64(math.MinInt64) || f < float64(math.MinInt64) {
+			err = NewErr(ErrValueTypeMismatch, "expected", "duration", "value", s)
+			goto end
+		}
+		value = time.Duration(f)
+		goto end
+	}
+	value, err = time.ParseDuration(s)
+	if err != nil {
+		err = NewErr(ErrValueTypeMismatch, "expected", "duration", "value", s)
+		goto end
+	}
+end:
+	if err != nil {
+		ok = false
+		err = WithErr(err, "path", path)
+	}
+	return value, ok, err
+}
+
+// getScalar returns the raw scalar at path. ok is false if the path does not
+// exist or is null, and err is set if it is an object or an array.
+func getScalar(store ConfigStore, path string) (raw jsontext.Value, ok bool, err error) {
+	var doc, node any
+	var segments []string
+
+	segments, err = splitValuePath(path)
+	if err != nil {
+		goto end
+	}
+	doc, err = loadDocument(store)
+	if err != nil {
+		goto end
+	}
+	node, ok = lookupNode(doc, segments)
+	if !ok {
+		goto end
+	}
+	raw, ok = node.(jsontext.Value)
+	if !ok {
+		err = NewErr(ErrValueTypeMismatch, "expected", "scalar", "value", "object or array")
+		goto end
+	}
+	ok = raw.Kind() != 'n'
+end:
+	return raw, ok, err
+}
+
+// scalarText returns the text of raw, unquoted and trimmed if it is a string.
+// raw must be a string or of one of the other kinds the caller accepts.
+func scalarText(raw jsontext.Value, kinds ...jsontext.Kind) (s string, err error) {
+	switch {
+	case raw.Kind() == '"':
+		s, err = unquoteJSON(raw)
+		s = strings.TrimSpace(s)
+	case slices.Contains(kinds, raw.Kind()):
+		s = string(raw)
+	default:
+		err = NewErr(ErrValueTypeMismatch, "value", string(raw))
+	}
+	return s, err
+}
+
+func unquoteJSON(raw jsontext.Value) (s string, err error) {
+	var b []byte
+
+	b, err = jsontext.AppendUnquote(nil, raw)
+	return string(b), err
+}
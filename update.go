@@ -0,0 +1,126 @@
+package cfgstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+type UpdateOptions struct {
+	// LockTimeout is how long to wait for another process's update of the same
+	// file to finish. Defaults to DefaultLockTimeout.
+	LockTimeout time.Duration
+}
+
+// UpdateJSON loads the store's file into a new RC, calls fn to modify it and
+// then saves it, all while holding an exclusive lock on the file so concurrent
+// updates from other goroutines or processes cannot overwrite each other. If the
+// file does not exist fn receives a zero RC. If fn returns an error nothing is
+// saved and the error is returned. The file is loaded as it is on disk, without
+// what pre-load hooks derive only for reading, see LoadHookArgs.ForUpdate.
+//
+// Keys in the file that RC does not model are preserved: the updated RC is
+// layered over the file's original content rather than replacing it. This needs
+// the file to be JSON, so a store with a Codec replaces the file's content.
+func UpdateJSON[RC any](store ConfigStore, fn func(rc *RC) error, opts ...UpdateOptions) (err error) {
+	return UpdateJSONContext(context.Background(), store, fn, opts...)
+}
+
+// UpdateJSONContext is UpdateJSON with a context that also bounds the wait for
+// the lock, see LockContext, and is passed to the load and save.
+func UpdateJSONContext[RC any](ctx context.Context, store ConfigStore, fn func(rc *RC) error, opts ...UpdateOptions) (err error) {
+	var fp dt.Filepath
+	var unlock func()
+	var rc *RC
+	var cs *configStore
+	var doc, before any
+	var preserve bool
+	var data []byte
+
+	if len(opts) == 0 {
+		opts = []UpdateOptions{{}}
+	}
+	if opts[0].LockTimeout <= 0 {
+		opts[0].LockTimeout = DefaultLockTimeout
+	}
+	fp, err = store.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	unlock, err = LockContext(ctx, store.FileSystem(), fp, opts[0].LockTimeout)
+	if err != nil {
+		goto end
+	}
+	defer unlock()
+
+	rc = new(RC)
+	// Preserving unknown keys needs the raw JSON document, which only configStore
+	// exposes. It is parsed from the content rc is loaded from so the file is
+	// read once and cannot change in between. Either way it is loaded for update,
+	// without what pre-load hooks such as ExpandEnvHook derive only for reading,
+	// so that is not saved back.
+	cs, preserve = store.(*configStore)
+	preserve = preserve && cs.codec == nil
+	if preserve {
+		data, err = cs.loadJSON(forUpdate(ctx), rc, true)
+	} else {
+		err = LoadJSONContext(forUpdate(ctx), store, rc)
+	}
+	if errors.Is(err, ErrFileDoesNotExist) {
+		err = nil
+	}
+	if err != nil {
+		goto end
+	}
+	if preserve {
+		doc, err = parseDocument(data)
+		if err != nil {
+			goto end
+		}
+		before, err = valueToNode(rc)
+		if err != nil {
+			goto end
+		}
+	}
+	err = fn(rc)
+	if err != nil {
+		err = NewErr(ErrUpdateFuncFailed, err)
+		goto end
+	}
+	if !preserve {
+		err = SaveJSONContext(ctx, store, rc)
+		goto end
+	}
+	err = cs.save(ctx, &SaveHookArgs{Value: rc}, func(value any) ([]byte, error) {
+		return overlayJSON(doc, before, value)
+	})
+end:
+	if err != nil {
+		err = withErrorKind(WithErr(err, ErrFailedToUpdateConfig, "filepath", fp))
+	}
+	return err
+}
+
+// overlayJSON marshals value and layers it over doc, the document value was
+// loaded from, keeping the keys of doc that value's type does not model. before
+// is value as it was marshaled when loaded.
+func overlayJSON(doc, before, value any) (data []byte, err error) {
+	var after any
+
+	after, err = valueToNode(value)
+	if err != nil {
+		goto end
+	}
+	if target, ok := doc.(*jsonObject); ok {
+		if afterObj, ok := after.(*jsonObject); ok {
+			beforeObj, _ := before.(*jsonObject)
+			overlayObject(target, beforeObj, afterObj)
+			after = target
+		}
+	}
+	data, err = encodeDocument(after)
+end:
+	return data, err
+}
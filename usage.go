@@ -0,0 +1,122 @@
+package cfgstore
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"slices"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// DefaultUsageLargest is how many of the largest files a Usage lists by default.
+const DefaultUsageLargest = 10
+
+// UsageEntry is a file counted by a Usage.
+type UsageEntry struct {
+	RelFilepath dt.RelFilepath
+	Size        int64
+	ModTime     time.Time
+}
+
+// Usage reports the disk usage of a config or cache directory, e.g. for a
+// `myapp cache info` command. Lock and temporary files are not counted.
+type Usage struct {
+	Dir        dt.DirPath
+	TotalBytes int64
+	FileCount  int
+
+	// Largest lists the largest files, largest first, with ties ordered by
+	// path.
+	Largest []UsageEntry
+}
+
+type UsageOptions struct {
+	// Largest is how many of the largest files to list. Defaults to
+	// DefaultUsageLargest; a negative value lists none.
+	Largest int
+}
+
+// StoreUsage returns the disk usage of the store's config directory, including
+// its subdirectories. A config directory that does not exist yet is empty.
+func StoreUsage(store ConfigStore, opts ...UsageOptions) (usage Usage, err error) {
+	var dir dt.DirPath
+
+	dir, err = store.ConfigDir()
+	if err != nil {
+		goto end
+	}
+	usage, err = DirUsage(store.FileSystem(), dir, opts...)
+end:
+	return usage, err
+}
+
+// Usage returns the disk usage of the store's cache directory, including its
+// subdirectories. For a store in the shared cache dir that includes every
+// app-specific cache dir. A cache directory that does not exist yet is empty.
+func (cs *cacheStore) Usage(opts ...UsageOptions) (usage Usage, err error) {
+	var dir dt.DirPath
+
+	dir, err = cs.CacheDir()
+	if err != nil {
+		goto end
+	}
+	usage, err = DirUsage(cs.FileSystem(), dir, opts...)
+end:
+	return usage, err
+}
+
+// DirUsage returns the disk usage of dir on fSys, including its subdirectories.
+// A directory that does not exist is empty.
+func DirUsage(fSys FileSystem, dir dt.DirPath, opts ...UsageOptions) (usage Usage, err error) {
+	var entries []UsageEntry
+
+	if len(opts) == 0 {
+		opts = []UsageOptions{{}}
+	}
+	largest := opts[0].Largest
+	if largest == 0 {
+		largest = DefaultUsageLargest
+	}
+	usage.Dir = dir
+	err = fs.WalkDir(fSys.DirFS(dir), ".", func(p string, d fs.DirEntry, err error) error {
+		var info fs.FileInfo
+
+		if p == "." && errors.Is(err, fs.ErrNotExist) {
+			return fs.SkipAll
+		}
+		if err != nil {
+			goto end
+		}
+		if d.IsDir() || isSidecarFile(p) {
+			goto end
+		}
+		info, err = d.Info()
+		if err != nil {
+			goto end
+		}
+		usage.TotalBytes += info.Size()
+		usage.FileCount++
+		entries = append(entries, UsageEntry{
+			RelFilepath: dt.RelFilepath(p),
+			Size:        info.Size(),
+			ModTime:     info.ModTime(),
+		})
+	end:
+		return err
+	})
+	if err != nil {
+		err = NewErr(ErrFailedToGetUsage, "dir", dir, err)
+		goto end
+	}
+	if largest < 0 {
+		goto end
+	}
+	slices.SortFunc(entries, func(a, b UsageEntry) int {
+		return cmp.Or(cmp.Compare(b.Size, a.Size), cmp.Compare(a.RelFilepath, b.RelFilepath))
+	})
+	usage.Largest = entries[:min(largest, len(entries))]
+end:
+	return usage, err
+}
@@ -60,8 +60,7 @@ func CloseOrLog(c io.Closer) {
 }
 
 func LogOnError(err error) {
-	EnsureLogger()
 	if err != nil {
-		logger.Warn("Operation failed", "error", err)
+		Logger().Warn("Operation failed", "error", err)
 	}
 }
@@ -0,0 +1,63 @@
+package cfgstore
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// NewConfigStoreE is NewConfigStore for configuration that comes from users or
+// other untrusted sources: rather than panicking on an unspecified DirType, or
+// returning a store whose paths may lead outside its config dir, it fails with
+// ErrInvalidConfigDirType for a DirType other than App, CLI or Project,
+// ErrInvalidConfigSlug for a slug that is not a single path segment, and
+// ErrInvalidConfigFilepath for a RelFilepath that is empty, absolute or leads
+// outside the config dir once its placeholders are expanded, and
+// ErrInvalidTenant for a Tenant that is not a valid tenant name. All of the
+// problems found are reported.
+func NewConfigStoreE(dirType DirType, opts ...ConfigStoreOption) (store ConfigStore, err error) {
+	var args ConfigStoreArgs
+	var errs []error
+	var rf dt.RelFilepath
+
+	for _, opt := range opts {
+		opt.applyToConfigStoreArgs(&args)
+	}
+	switch dirType {
+	case AppConfigDirType, CLIConfigDirType, ProjectConfigDirType:
+	default:
+		errs = append(errs, NewErr(ErrInvalidConfigDirType, "dir_type", dirType.Slug()))
+	}
+	if !isLocalSegment(string(args.ConfigSlug)) {
+		errs = append(errs, NewErr(ErrInvalidConfigSlug, "config_slug", args.ConfigSlug))
+	}
+	if args.Tenant != "" {
+		err = validateTenant(args.Tenant)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	rf, err = expandPlaceholders(args.RelFilepath, newPlaceholders(args.AppInfo, args.Placeholders))
+	switch {
+	case err != nil:
+		errs = append(errs, err)
+	case !isLocalRelFilepath(string(rf)):
+		errs = append(errs, NewErr(ErrInvalidConfigFilepath, "rel_filepath", rf))
+	}
+	err = CombineErrs(errs)
+	if err != nil {
+		goto end
+	}
+	store = NewConfigStore(dirType, args)
+end:
+	return store, err
+}
+
+// isLocalRelFilepath returns true if rf names a file within the directory it is
+// relative to.
+func isLocalRelFilepath(rf string) bool {
+	return rf != "" &&
+		fs.ValidPath(filepath.ToSlash(rf)) &&
+		filepath.IsLocal(rf)
+}
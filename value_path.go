@@ -0,0 +1,87 @@
+package cfgstore
+
+import (
+	"strings"
+)
+
+// splitValuePath splits a path into its segments. A path beginning with "/" is
+// an RFC 6901 JSON Pointer such as "/servers/0/host", in which "~1" and "~0"
+// escape "/" and "~". Any other path is dotted, such as "servers.0.host". An
+// empty path refers to the entire document.
+func splitValuePath(path string) (segments []string, err error) {
+	switch {
+	case path == "":
+	case strings.HasPrefix(path, "/"):
+		segments = strings.Split(path[1:], "/")
+		for i, seg := range segments {
+			segments[i], err = unescapePointerSegment(seg)
+			if err != nil {
+				err = WithErr(err, "path", path)
+				goto end
+			}
+		}
+	default:
+		segments = strings.Split(path, ".")
+	}
+end:
+	return segments, err
+}
+
+func unescapePointerSegment(seg string) (_ string, err error) {
+	var sb strings.Builder
+
+	for i := 0; i < len(seg); i++ {
+		if seg[i] != '~' {
+			sb.WriteByte(seg[i])
+			continue
+		}
+		i++
+		switch {
+		case i < len(seg) && seg[i] == '0':
+			sb.WriteByte('~')
+		case i < len(seg) && seg[i] == '1':
+			sb.WriteByte('/')
+		default:
+			err = NewErr(ErrInvalidValuePath, "segment", seg)
+			goto end
+		}
+	}
+end:
+	return sb.String(), err
+}
+
+// lookupValuePath returns the value found at path within doc, where doc is a
+// document decoded into map[string]any / []any values. Numeric segments index
+// into arrays.
+func lookupValuePath(doc any, path string) (value any, found bool) {
+	segments, err := splitValuePath(path)
+	if err != nil {
+		goto end
+	}
+	value = doc
+	for _, seg := range segments {
+		switch v := value.(type) {
+		case map[string]any:
+			value, found = v[seg]
+			if !found {
+				goto end
+			}
+		case []any:
+			idx, ok := arrayIndex(seg, len(v))
+			if !ok {
+				found = false
+				goto end
+			}
+			value = v[idx]
+		default:
+			found = false
+			goto end
+		}
+	}
+	found = true
+end:
+	if !found {
+		value = nil
+	}
+	return value, found
+}
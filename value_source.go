@@ -0,0 +1,82 @@
+package cfgstore
+
+import (
+	"os"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ValueSource describes a layer that sets the value at a path.
+type ValueSource struct {
+	DirType  DirType
+	Filepath dt.Filepath
+
+	// ModTime is the modification time of Filepath.
+	ModTime time.Time
+
+	// Value is the value at the path in this layer, as returned by GetValue.
+	Value any
+}
+
+// ValueSources returns a ValueSource for each store whose file sets the value at
+// path, in DirTypes order. Because later stores take precedence when merged, the
+// last ValueSource is the one that contributes the value in the merged config,
+// assuming RootConfig.Merge follows that convention for the path.
+func (stores *ConfigStores) ValueSources(path string) (sources []ValueSource, err error) {
+	var errs []error
+
+	for _, dirType := range stores.DirTypes {
+		src, found, srcErr := storeValueSource(stores.StoreMap[dirType], path)
+		if srcErr != nil {
+			errs = append(errs, WithErr(srcErr, "dir_type", dirType.Slug()))
+			continue
+		}
+		if found {
+			sources = append(sources, src)
+		}
+	}
+	err = CombineErrs(errs)
+	return sources, err
+}
+
+// ValueSource returns the ValueSource for the layer that contributes the value
+// at path to the merged config, e.g. for a `config get --show-origin` command or
+// to invalidate a cache when that file changes. found is false if no layer sets
+// the value.
+func (stores *ConfigStores) ValueSource(path string) (src ValueSource, found bool, err error) {
+	var sources []ValueSource
+
+	sources, err = stores.ValueSources(path)
+	if err != nil {
+		goto end
+	}
+	if len(sources) == 0 {
+		goto end
+	}
+	src = sources[len(sources)-1]
+	found = true
+end:
+	return src, found, err
+}
+
+func storeValueSource(store ConfigStore, path string) (src ValueSource, found bool, err error) {
+	var info os.FileInfo
+
+	src.Value, found, err = GetValue(store, path)
+	if !found || err != nil {
+		goto end
+	}
+	src.DirType = store.DirType()
+	src.Filepath, err = store.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	info, err = store.FileSystem().Stat(src.Filepath)
+	if err != nil {
+		goto end
+	}
+	src.ModTime = info.ModTime()
+end:
+	return src, found, err
+}
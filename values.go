@@ -0,0 +1,259 @@
+package cfgstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// GetValue returns the value found at path in the store's file without the
+// caller needing a struct to unmarshal into. path is either dotted, e.g.
+// "server.port" or "servers.0.host", or an RFC 6901 JSON Pointer, e.g.
+// "/servers/0/host". Objects are returned as map[string]any, arrays as []any
+// and numbers as float64. found is false if the path or the file does not exist.
+func GetValue(store ConfigStore, path string) (value any, found bool, err error) {
+	var doc, node any
+	var segments []string
+
+	segments, err = splitValuePath(path)
+	if err != nil {
+		goto end
+	}
+	doc, err = loadDocument(store)
+	if err != nil {
+		goto end
+	}
+	node, found = lookupNode(doc, segments)
+	if !found {
+		goto end
+	}
+	value, err = nodeToValue(node)
+end:
+	if err != nil {
+		err = WithErr(err, "path", path)
+	}
+	return value, found, err
+}
+
+// SetValue marshals value as JSON, stores it at path in the store's file,
+// creating the file and any missing intermediate objects, and saves the file.
+// The order of the file's other keys is preserved.
+func SetValue(store ConfigStore, path string, value any) (err error) {
+	var node any
+	var segments []string
+
+	segments, err = splitValuePath(path)
+	if err != nil {
+		goto end
+	}
+	node, err = valueToNode(value)
+	if err != nil {
+		goto end
+	}
+	err = updateDocument(store, func(doc any) (any, bool, error) {
+		doc, err := setNode(doc, segments, node)
+		return doc, err == nil, err
+	})
+end:
+	if err != nil {
+		err = WithErr(err, "path", path)
+	}
+	return err
+}
+
+type UnsetValueOptions struct {
+	// PruneEmpty removes objects and arrays left empty by the removal.
+	PruneEmpty bool
+}
+
+// UnsetValue removes the value at path from the store's file and saves the
+// file. Removing an array element shifts the elements after it. found is false,
+// and the file is left untouched, if the path does not exist.
+func UnsetValue(store ConfigStore, path string, opts ...UnsetValueOptions) (found bool, err error) {
+	var segments []string
+
+	if len(opts) == 0 {
+		opts = []UnsetValueOptions{{}}
+	}
+	segments, err = splitValuePath(path)
+	if err != nil {
+		goto end
+	}
+	if len(segments) == 0 {
+		err = NewErr(ErrInvalidValuePath)
+		goto end
+	}
+	err = updateDocument(store, func(doc any) (any, bool, error) {
+		found = unsetNode(doc, segments, opts[0].PruneEmpty)
+		return doc, found, nil
+	})
+end:
+	if err != nil {
+		err = WithErr(err, "path", path)
+	}
+	return found, err
+}
+
+// MergeJSON deep-merges fragment, which must be a JSON object, into the store's
+// file, creating the file if it does not exist, and saves it. Objects are merged
+// recursively, null removes a key and any other value replaces the existing one,
+// per RFC 7396 JSON Merge Patch. Keys not in fragment are left as they were.
+func MergeJSON(store ConfigStore, fragment []byte) (err error) {
+	var patch any
+	var patchObj *jsonObject
+	var ok bool
+
+	patch, err = parseDocument(fragment)
+	if err != nil {
+		goto end
+	}
+	patchObj, ok = patch.(*jsonObject)
+	if !ok {
+		err = NewErr(ErrValueTypeMismatch, "expected", "object")
+		goto end
+	}
+	err = updateDocument(store, func(doc any) (any, bool, error) {
+		if doc == nil {
+			doc = &jsonObject{}
+		}
+		target, ok := doc.(*jsonObject)
+		if !ok {
+			return nil, false, NewErr(ErrValueTypeMismatch, "expected", "object", "value", "file")
+		}
+		mergeObject(target, patchObj)
+		return target, true, nil
+	})
+end:
+	if err != nil {
+		err = WithErr(err, ErrFailedToMergeJSON)
+	}
+	return err
+}
+
+// SkipChildren is returned by a WalkFunc to skip the children of the object or
+// array it was called for.
+var SkipChildren = errors.New("skip children")
+
+// WalkFunc is called by Walk for each value in a store's file. path is dotted
+// and value is an object, array or scalar as returned by GetValue.
+type WalkFunc func(path string, value any) error
+
+// Walk calls fn for every value in the store's file, depth first and in the
+// order they appear in the file, with objects and arrays visited before their
+// children. If fn returns SkipChildren the children of that value are skipped;
+// any other error stops the walk and is returned.
+func Walk(store ConfigStore, fn WalkFunc) (err error) {
+	var doc any
+
+	doc, err = loadDocument(store)
+	if err != nil {
+		goto end
+	}
+	err = walkNode(doc, nil, func(segments []string, node any) (err error) {
+		var value any
+
+		value, err = nodeToValue(node)
+		if err != nil {
+			goto end
+		}
+		err = fn(strings.Join(segments, "."), value)
+	end:
+		return err
+	})
+end:
+	return err
+}
+
+// Keys returns the dotted path of every scalar, empty object and empty array in
+// the store's file that begins with prefix, in the order they appear in the
+// file, e.g. for `config list` output or shell completion of key names.
+func Keys(store ConfigStore, prefix string) (keys []string, err error) {
+	var doc any
+
+	doc, err = loadDocument(store)
+	if err != nil {
+		goto end
+	}
+	err = walkNode(doc, nil, func(segments []string, node any) error {
+		path := strings.Join(segments, ".")
+		switch {
+		case !strings.HasPrefix(path, prefix) && !strings.HasPrefix(prefix, path+"."):
+			// Neither this path nor any of its children can match
+			return SkipChildren
+		case strings.HasPrefix(path, prefix) && (isScalarNode(node) || isEmptyNode(node)):
+			keys = append(keys, path)
+		}
+		return nil
+	})
+end:
+	return keys, err
+}
+
+// loadDocument loads and parses the store's file. A file that does not exist
+// yet loads as a nil document.
+func loadDocument(store ConfigStore) (doc any, err error) {
+	return loadDocumentContext(store, context.Background())
+}
+
+// loadDocumentContext is loadDocument with a context, see LoadContext.
+func loadDocumentContext(store ConfigStore, ctx context.Context) (doc any, err error) {
+	var data []byte
+
+	data, err = LoadContext(ctx, store)
+	if errors.Is(err, ErrFileDoesNotExist) {
+		err = nil
+		goto end
+	}
+	if err != nil {
+		goto end
+	}
+	doc, err = parseDocument(data)
+end:
+	return doc, err
+}
+
+// updateDocument loads the store's file as a document, calls fn with it and
+// saves the document fn returns if fn also returns true, all while holding the
+// same exclusive lock on the file as UpdateJSON, so that processes editing
+// different keys at once do not lose each other's changes.
+func updateDocument(store ConfigStore, fn func(doc any) (any, bool, error)) (err error) {
+	var fp dt.Filepath
+	var unlock func()
+	var doc any
+	var save bool
+
+	fp, err = store.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	unlock, err = store.FileSystem().Lock(fp, DefaultLockTimeout)
+	if err != nil {
+		goto end
+	}
+	defer unlock()
+	doc, err = loadDocumentContext(store, forUpdate(context.Background()))
+	if err != nil {
+		goto end
+	}
+	doc, save, err = fn(doc)
+	if err != nil || !save {
+		goto end
+	}
+	err = saveDocument(store, doc)
+end:
+	return err
+}
+
+func saveDocument(store ConfigStore, doc any) (err error) {
+	var data []byte
+
+	data, err = encodeDocument(doc)
+	if err != nil {
+		goto end
+	}
+	err = store.Save(data)
+end:
+	return err
+}
@@ -0,0 +1,379 @@
+package cfgstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// DefaultWatchInterval is how often a Watcher polls its store's file when
+// WatcherArgs.Interval is not specified.
+const DefaultWatchInterval = time.Second
+
+// DefaultWatchDebounce is how long a file must go unchanged before a Watcher
+// reports a change when WatcherArgs.Debounce is not specified. Editors often
+// write a file several times per save so this coalesces those writes.
+const DefaultWatchDebounce = 250 * time.Millisecond
+
+// WatchEvent describes a change to a store's file observed by a Watcher.
+type WatchEvent struct {
+	Store    ConfigStore
+	Filepath dt.Filepath
+
+	// Data is the new content of the file, or nil if the file no longer exists.
+	Data   []byte
+	Exists bool
+
+	// Err is set when the changed file could not be read or parsed as JSON.
+	Err error
+}
+
+// WatchFunc is called by a Watcher each time its store's file changes.
+type WatchFunc func(WatchEvent)
+
+// ValueChange describes a change to the value found at a subscribed path.
+type ValueChange struct {
+	Path     string
+	OldValue any
+	NewValue any
+
+	// Existed and Exists report whether Path was present before and after the change.
+	Existed bool
+	Exists  bool
+}
+
+// SubscribeFunc is called by a Watcher when the value at a subscribed path changes.
+type SubscribeFunc func(ValueChange)
+
+type WatcherArgs struct {
+	// Interval is how often the store's file is checked for changes. Defaults to
+	// DefaultWatchInterval.
+	Interval time.Duration
+
+	// Debounce is how long the file must remain unchanged after a change is seen
+	// before callbacks are called, so that a burst of writes results in a single
+	// notification. Defaults to DefaultWatchDebounce; use a negative value to
+	// report every change as soon as it is seen.
+	Debounce time.Duration
+}
+
+// Watcher polls a ConfigStore's file and notifies callers when its content
+// changes. Callbacks registered with OnChange receive every change while those
+// registered with Subscribe are only called when the value at their dotted path
+// (e.g. "server.port") differs between the previous and the new content.
+type Watcher struct {
+	store         ConfigStore
+	interval      time.Duration
+	debounce      time.Duration
+	mutex         sync.Mutex
+	watchFuncs    []WatchFunc
+	subscriptions []subscription
+	snapshot      watchSnapshot // last snapshot reported to callbacks
+	pending       *watchSnapshot
+	pendingErr    error
+	pendingSince  time.Time
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+type subscription struct {
+	path string
+	fn   SubscribeFunc
+}
+
+// watchSnapshot is the state of the watched file as of the last check.
+type watchSnapshot struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	data    []byte
+	doc     any
+}
+
+// NewWatcher returns a Watcher for store. Call Start to begin watching.
+func NewWatcher(store ConfigStore, args WatcherArgs) *Watcher {
+	if args.Interval <= 0 {
+		args.Interval = DefaultWatchInterval
+	}
+	switch {
+	case args.Debounce == 0:
+		args.Debounce = DefaultWatchDebounce
+	case args.Debounce < 0:
+		args.Debounce = 0
+	}
+	return &Watcher{
+		store:    store,
+		interval: args.Interval,
+		debounce: args.Debounce,
+	}
+}
+
+// OnChange registers fn to be called whenever the store's file changes.
+func (w *Watcher) OnChange(fn WatchFunc) {
+	w.mutex.Lock()
+	w.watchFuncs = append(w.watchFuncs, fn)
+	w.mutex.Unlock()
+}
+
+// Subscribe registers fn to be called only when the value at the dotted path or
+// JSON Pointer changes, including when the path is added or removed.
+func (w *Watcher) Subscribe(path string, fn SubscribeFunc) {
+	w.mutex.Lock()
+	w.subscriptions = append(w.subscriptions, subscription{
+		path: path,
+		fn:   fn,
+	})
+	w.mutex.Unlock()
+}
+
+// Start captures the current content of the store's file and begins polling it
+// for changes in a background goroutine until Stop is called.
+func (w *Watcher) Start() error {
+	return w.StartContext(context.Background())
+}
+
+// StartContext is like Start but also stops polling when ctx is canceled. To
+// shut down cleanly, cancel ctx and then call Stop, which waits for any
+// in-progress callbacks to return.
+func (w *Watcher) StartContext(ctx context.Context) (err error) {
+	var snap watchSnapshot
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.running() {
+		err = NewErr(ErrWatcherAlreadyStarted)
+		goto end
+	}
+	err = ctx.Err()
+	if err != nil {
+		err = NewErr(ErrFailedToStartWatcher, err)
+		goto end
+	}
+	snap, _, err = w.check(watchSnapshot{})
+	if errors.Is(err, ErrFailedToUnmarshalConfigFile) {
+		// Keep watching so that a fix to the invalid file is seen
+		err = nil
+	}
+	if err != nil {
+		err = NewErr(ErrFailedToStartWatcher, err)
+		goto end
+	}
+	w.snapshot = snap
+	w.pending = nil
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+	go w.run(ctx, w.done)
+
+end:
+	return err
+}
+
+// Run starts watching and blocks until ctx is canceled, then stops and waits for
+// any in-progress callbacks to return. It returns nil after a clean shutdown,
+// which makes it suitable for use with errgroup.Group.Go:
+//
+//	g.Go(func() error { return w.Run(ctx) })
+func (w *Watcher) Run(ctx context.Context) (err error) {
+	err = w.StartContext(ctx)
+	if err != nil {
+		goto end
+	}
+	<-ctx.Done()
+	w.Stop()
+end:
+	return err
+}
+
+// Stop stops polling and waits for any in-progress callbacks to return. It is
+// safe to call more than once, and after the context passed to StartContext has
+// been canceled.
+func (w *Watcher) Stop() {
+	w.mutex.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// running reports whether the polling goroutine is still active. The caller
+// must hold w.mutex.
+func (w *Watcher) running() (running bool) {
+	if w.done == nil {
+		goto end
+	}
+	select {
+	case <-w.done:
+		// Stopped by cancellation of its context; release it so it can restart
+		w.cancel()
+		w.cancel, w.done = nil, nil
+	default:
+		running = true
+	}
+end:
+	return running
+}
+
+func (w *Watcher) run(ctx context.Context, done chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	defer close(done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+// poll checks for changes relative to the most recently seen content and
+// reports them once no further changes have been seen for the debounce window.
+func (w *Watcher) poll() {
+	var next, prev watchSnapshot
+	var changed bool
+	var err error
+
+	latest := w.snapshot
+	if w.pending != nil {
+		latest = *w.pending
+	}
+	next, changed, err = w.check(latest)
+	if changed {
+		// Coalesce into the pending change and restart the debounce window
+		w.pending = &next
+		w.pendingErr = err
+		w.pendingSince = time.Now()
+	}
+	if w.pending == nil {
+		goto end
+	}
+	if time.Since(w.pendingSince) < w.debounce {
+		goto end
+	}
+	next, err = *w.pending, w.pendingErr
+	w.pending, w.pendingErr = nil, nil
+	if err == nil && next.exists == w.snapshot.exists && bytes.Equal(next.data, w.snapshot.data) {
+		// The burst of writes left the content as it was last reported
+		w.snapshot = next
+		goto end
+	}
+	prev = w.snapshot
+	w.snapshot = next
+	w.notify(prev, next, err)
+end:
+	return
+}
+
+// check stats the store's file and, only if its size or modification time differ
+// from prev, reads and parses it. changed is true if the content differs from prev.
+func (w *Watcher) check(prev watchSnapshot) (next watchSnapshot, changed bool, err error) {
+	var fp dt.Filepath
+	var info os.FileInfo
+	var doc any
+
+	fp, err = w.store.GetFilepath()
+	if err != nil {
+		next = prev
+		goto end
+	}
+	info, err = w.store.FileSystem().Stat(fp)
+	switch {
+	case err == nil:
+		next.exists = true
+		next.modTime = info.ModTime()
+		next.size = info.Size()
+	case errors.Is(err, fs.ErrNotExist):
+		err = nil
+		changed = prev.exists
+		goto end
+	default:
+		// Treat other stat failures as transient and try again next time
+		next = prev
+		goto end
+	}
+	if prev.exists && next.modTime.Equal(prev.modTime) && next.size == prev.size {
+		next = prev
+		goto end
+	}
+	next.data, err = w.store.Load()
+	if err != nil {
+		// Keep the last good document so subscribers only see real changes once
+		// the file can be loaded again
+		next.doc = prev.doc
+		changed = true
+		goto end
+	}
+	if prev.exists && bytes.Equal(next.data, prev.data) {
+		// Touched but not modified; keep the new stat info but report no change
+		next.doc = prev.doc
+		goto end
+	}
+	changed = true
+	err = unmarshalStore(w.store, next.data, &doc)
+	if err != nil {
+		// Keep the last good document so subscribers only see real changes once fixed
+		next.doc = prev.doc
+		err = NewErr(ErrFailedToUnmarshalConfigFile, err)
+		goto end
+	}
+	next.doc = doc
+end:
+	if err != nil {
+		err = WithErr(err, "filepath", fp)
+	}
+	return next, changed, err
+}
+
+func (w *Watcher) notify(prev, next watchSnapshot, err error) {
+	var fp dt.Filepath
+
+	w.mutex.Lock()
+	watchFuncs := append([]WatchFunc(nil), w.watchFuncs...)
+	subscriptions := append([]subscription(nil), w.subscriptions...)
+	w.mutex.Unlock()
+
+	fp, _ = w.store.GetFilepath()
+	for _, fn := range watchFuncs {
+		fn(WatchEvent{
+			Store:    w.store,
+			Filepath: fp,
+			Data:     next.data,
+			Exists:   next.exists,
+			Err:      err,
+		})
+	}
+	if err != nil {
+		// Without a parsed document there are no values to compare
+		goto end
+	}
+	for _, sub := range subscriptions {
+		oldValue, existed := lookupValuePath(prev.doc, sub.path)
+		newValue, exists := lookupValuePath(next.doc, sub.path)
+		if existed == exists && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		sub.fn(ValueChange{
+			Path:     sub.path,
+			OldValue: oldValue,
+			NewValue: newValue,
+			Existed:  existed,
+			Exists:   exists,
+		})
+	}
+end:
+	return
+}
@@ -2,6 +2,8 @@ package cfgstore
 
 import (
 	"errors"
+	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 
@@ -18,10 +20,45 @@ type WriterLoggerArgs struct {
 	Verbosity  cliutil.Verbosity
 	ConfigSlug dt.PathSegment
 	LogFile    dt.Filename
+
+	// Level is the minimum level logged, e.g. from ResolveLogLevel. Defaults to
+	// slog.LevelInfo.
+	Level slog.Leveler
+
+	// Rotation rotates the log file by size and/or age and prunes rotated files.
+	// The zero value writes to a single ever-growing file.
+	Rotation LogRotation
+
+	// Format is the format of the log file. Defaults to JSONLogFormat.
+	Format LogFormat
+
+	// Console, if not nil, e.g. os.Stderr, also receives every record logged,
+	// formatted as text.
+	Console io.Writer
+
+	// Handler, if not nil, receives every record logged instead of a log file,
+	// so an app can route cfgstore's logs into its existing logging stack. No
+	// log file is created and Level, Rotation, Format and HandlerFunc are
+	// ignored.
+	Handler slog.Handler
+
+	// HandlerFunc, if not nil, creates the handler that writes records to the
+	// log file, in place of the one for Format.
+	HandlerFunc LogHandlerFunc
+
+	// LegacyLogDir writes logs to the logs directory of the CLI config dir, as
+	// earlier versions did, rather than of the state dir. See MigrateLogs.
+	LegacyLogDir bool
+
+	// DirsProvider locates the log directory. It is intended only to be used
+	// by test code. Defaults to DefaultDirsProvider().
+	DirsProvider *DirsProvider
 }
 
+// CreateWriterLogger returns a WriterLogger whose logger writes to LogFile in
+// the logs directory of the state dir for ConfigSlug, e.g.
+// ~/.local/state/myapp/logs on Linux. See LogDir.
 func CreateWriterLogger(args *WriterLoggerArgs) (wr cliutil.WriterLogger, err error) {
-	var configDir dt.DirPath
 	var logger *slog.Logger
 	var logDir dt.DirPath
 
@@ -30,16 +67,17 @@ func CreateWriterLogger(args *WriterLoggerArgs) (wr cliutil.WriterLogger, err er
 		Verbosity: args.Verbosity,
 	})
 
-	configDir, err = CLIConfigDir(args.ConfigSlug)
+	if args.Handler != nil {
+		logger = slog.New(args.consoleHandler(args.Handler))
+		SetLogger(logger)
+		wr = cliutil.NewWriterLogger(writer, logger)
+		goto end
+	}
+	logDir, err = LogDir(args)
 	if err != nil {
-		err = dt.NewErr(
-			ErrFailedWriterSetup,
-			ErrFailedGettingUserConfigDir,
-			err,
-		)
+		err = dt.WithErr(err, ErrFailedWriterSetup)
 		goto end
 	}
-	logDir = dt.DirPathJoin(configDir, "logs")
 	err = logDir.MkdirAll(0755)
 	if err != nil {
 		goto end
@@ -55,6 +93,113 @@ end:
 	return wr, err
 }
 
+// LogDir returns the directory CreateWriterLogger writes logs to, logs/ in the
+// state dir for args.ConfigSlug, or in its CLI config dir if args.LegacyLogDir.
+func LogDir(args *WriterLoggerArgs) (logDir dt.DirPath, err error) {
+	var dir dt.DirPath
+
+	if args.LegacyLogDir {
+		dir, err = legacyLogDir(args)
+		goto end
+	}
+	dir, err = StateDir(args.ConfigSlug, args.DirsProvider)
+	if err != nil {
+		goto end
+	}
+	dir = dt.DirPathJoin(dir, "logs")
+end:
+	return dir, err
+}
+
+func legacyLogDir(args *WriterLoggerArgs) (logDir dt.DirPath, err error) {
+	var configDir dt.DirPath
+
+	configDir, err = CLIConfigDir(args.ConfigSlug, args.DirsProvider)
+	if err != nil {
+		err = NewErr(ErrFailedGettingUserConfigDir, err)
+		goto end
+	}
+	logDir = dt.DirPathJoin(configDir, "logs")
+end:
+	return logDir, err
+}
+
+// MigrateLogs moves the log files, including rotated ones, that earlier versions
+// wrote to the logs directory of the CLI config dir into the state dir's, and
+// removes the old directory if it is then empty. It returns the new paths of the
+// files moved, emitting a MigrateEventKind Event for each. Files that already
+// exist in the state dir are left in place.
+func MigrateLogs(args *WriterLoggerArgs) (moved []dt.Filepath, err error) {
+	var oldDir, newDir dt.DirPath
+	var entries []fs.DirEntry
+	var errs []error
+
+	fSys := args.fileSystem()
+	stateArgs := *args
+	stateArgs.LegacyLogDir = false
+	oldDir, err = legacyLogDir(args)
+	if err != nil {
+		goto end
+	}
+	newDir, err = LogDir(&stateArgs)
+	if err != nil {
+		goto end
+	}
+	entries, err = fs.ReadDir(fSys.DirFS(oldDir), ".")
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+		goto end
+	}
+	if err != nil {
+		goto end
+	}
+	err = fSys.MkdirAll(newDir)
+	if err != nil {
+		goto end
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		from := dt.FilepathJoin(oldDir, entry.Name())
+		to := dt.FilepathJoin(newDir, entry.Name())
+		_, statErr := fSys.Stat(to)
+		if statErr == nil {
+			continue
+		}
+		renameErr := Rename(fSys, from, to)
+		if hasEventListeners() {
+			emitEvent(Event{
+				Kind:     MigrateEventKind,
+				Filepath: to,
+				Err:      renameErr,
+			})
+		}
+		if renameErr != nil {
+			errs = append(errs, renameErr)
+			continue
+		}
+		moved = append(moved, to)
+	}
+	// Remove fails, and is logged, if anything was left behind
+	LogOnError(fSys.Remove(dt.Filepath(oldDir)))
+	err = CombineErrs(errs)
+end:
+	if err != nil {
+		err = NewErr(ErrFailedToMigrateLogs, "old_log_dir", oldDir, "new_log_dir", newDir, err)
+	}
+	return moved, err
+}
+
+// fileSystem returns the FileSystem of args.DirsProvider, or OSFileSystem() if
+// it has none.
+func (args *WriterLoggerArgs) fileSystem() FileSystem {
+	if args.DirsProvider == nil || args.DirsProvider.FileSystem == nil {
+		return OSFileSystem()
+	}
+	return args.DirsProvider.FileSystem
+}
+
 func createLogger(logDir dt.DirPath, writer cliutil.Writer, args *WriterLoggerArgs) (logger *slog.Logger, err error) {
 	var tmpFile *os.File
 	var canWrite bool
@@ -80,7 +225,11 @@ func createLogger(logDir dt.DirPath, writer cliutil.Writer, args *WriterLoggerAr
 		goto end
 	}
 	fp = dt.FilepathJoin(logDir, args.LogFile)
-	logger, err = logutil.CreateJSONFileLogger(fp)
+	if args.isLogutilDefault() {
+		logger, err = logutil.CreateJSONFileLogger(fp)
+	} else {
+		logger, err = createFileLogger(fp, args)
+	}
 	if err != nil {
 		err = dt.NewErr(dt.ErrFailedtoCreateFile,
 			"log_file", fp,
@@ -95,3 +244,62 @@ end:
 	}
 	return logger, err
 }
+
+// isLogutilDefault returns true if args asks for nothing more than the JSON file
+// logger created by logutil.CreateJSONFileLogger.
+func (args *WriterLoggerArgs) isLogutilDefault() bool {
+	return args.Rotation.IsZero() &&
+		args.Level == nil &&
+		args.Format == JSONLogFormat &&
+		args.Console == nil &&
+		args.HandlerFunc == nil
+}
+
+// consoleHandler returns h, also logging to args.Console if set.
+func (args *WriterLoggerArgs) consoleHandler(h slog.Handler) slog.Handler {
+	if args.Console == nil {
+		return h
+	}
+	return multiHandler{h, slog.NewTextHandler(args.Console, &slog.HandlerOptions{
+		Level: args.Level,
+	})}
+}
+
+var _ dt.FilepathGetter = (*fileHandler)(nil)
+
+// fileHandler lets logutil.GetJSONFilepath find the file a logger writes to.
+type fileHandler struct {
+	slog.Handler
+	file *RotatingFile
+}
+
+func (h *fileHandler) Filepath() dt.Filepath {
+	return h.file.Filepath()
+}
+
+// createFileLogger creates a logger for fp in args.Format, or using
+// args.HandlerFunc, that logs at args.Level, and to args.Console if set. A zero
+// args.Rotation never rotates.
+func createFileLogger(fp dt.Filepath, args *WriterLoggerArgs) (logger *slog.Logger, err error) {
+	var rf *RotatingFile
+	var h slog.Handler
+
+	opts := &slog.HandlerOptions{
+		Level: args.Level,
+	}
+	rf, err = OpenRotatingFile(fp, args.Rotation)
+	if err != nil {
+		goto end
+	}
+	if args.HandlerFunc != nil {
+		h = args.HandlerFunc(rf, opts)
+	} else {
+		h = newLogHandler(rf, args.Format, opts)
+	}
+	logger = slog.New(args.consoleHandler(&fileHandler{
+		Handler: h,
+		file:    rf,
+	}))
+end:
+	return logger, err
+}